@@ -0,0 +1,171 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collcachescc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("collcachescc")
+
+const (
+	ccName = "collcachescc"
+
+	sizeFunc  = "size"
+	flushFunc = "flush"
+
+	// aclPrefix is the prefix for the policy resource names of the functions of this chaincode,
+	// for example "collcachescc/flush". The policies must be defined in the ACLs of the channel.
+	aclPrefix = ccName + "/"
+)
+
+type function func(shim.ChaincodeStubInterface, string, string) pb.Response
+
+type cacheManager interface {
+	CacheSize(channelID, ns, coll string) (int, error)
+	FlushCache(channelID, ns, coll string) (int, error)
+}
+
+type aclProvider interface {
+	CheckACL(resName string, channelID string, idinfo interface{}) error
+}
+
+// CacheInfo is returned by the size and flush functions
+type CacheInfo struct {
+	Namespace  string `json:"namespace"`
+	Collection string `json:"collection"`
+	// Size is the number of keys in the cache (for the size function) or the number
+	// of keys that were removed from the cache (for the flush function)
+	Size int `json:"size"`
+}
+
+// CollCacheSCC is an in-process system chaincode that allows an administrator to inspect the size
+// of a collection's data cache and to flush the cache of a collection without restarting the peer.
+type CollCacheSCC struct {
+	cacheManager     cacheManager
+	aclProvider      aclProvider
+	functionRegistry map[string]function
+}
+
+// New returns a new collection cache system chaincode
+func New(cacheManager cacheManager, aclProvider aclProvider) *CollCacheSCC {
+	logger.Info("Creating collection cache system chaincode")
+
+	cc := &CollCacheSCC{
+		cacheManager: cacheManager,
+		aclProvider:  aclProvider,
+	}
+
+	cc.functionRegistry = map[string]function{
+		sizeFunc:  cc.size,
+		flushFunc: cc.flush,
+	}
+
+	return cc
+}
+
+// Name returns the name of this chaincode
+func (cc *CollCacheSCC) Name() string { return ccName }
+
+// Chaincode returns the chaincode implementation
+func (cc *CollCacheSCC) Chaincode() shim.Chaincode { return cc }
+
+// Init will be deprecated in a future Fabric release
+func (cc *CollCacheSCC) Init(shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+// Invoke invokes the collection cache SCC. The arguments are:
+// args[0] - The function: "size" or "flush"
+// args[1] - The chaincode namespace
+// args[2] - The collection name
+func (cc *CollCacheSCC) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	args := stub.GetArgs()
+	if len(args) == 0 {
+		return shim.Error(fmt.Sprintf("Function not provided. Expecting one of [%s, %s]", sizeFunc, flushFunc))
+	}
+
+	functionName := string(args[0])
+	f, ok := cc.functionRegistry[functionName]
+	if !ok {
+		return shim.Error(fmt.Sprintf("Invalid function: [%s]. Expecting one of [%s, %s]", functionName, sizeFunc, flushFunc))
+	}
+
+	if len(args) < 3 {
+		return shim.Error("Expecting namespace and collection")
+	}
+
+	ns := string(args[1])
+	coll := string(args[2])
+
+	if ns == "" || coll == "" {
+		return shim.Error("Namespace and collection are required")
+	}
+
+	if err := cc.checkACL(stub, aclPrefix+functionName); err != nil {
+		return pb.Response{Status: http.StatusForbidden, Message: err.Error()}
+	}
+
+	logger.Debugf("[%s] Invoking function [%s] for collection [%s:%s]", stub.GetChannelID(), functionName, ns, coll)
+
+	return f(stub, ns, coll)
+}
+
+// size returns the number of keys of the given collection that are held in the cache
+func (cc *CollCacheSCC) size(stub shim.ChaincodeStubInterface, ns, coll string) pb.Response {
+	size, err := cc.cacheManager.CacheSize(stub.GetChannelID(), ns, coll)
+	if err != nil {
+		logger.Errorf("[%s] Error getting cache size for collection [%s:%s]: %s", stub.GetChannelID(), ns, coll, err)
+		return shim.Error(fmt.Sprintf("error getting cache size: %s", err))
+	}
+
+	return newResponse(ns, coll, size)
+}
+
+// flush removes the keys of the given collection from the cache
+func (cc *CollCacheSCC) flush(stub shim.ChaincodeStubInterface, ns, coll string) pb.Response {
+	flushed, err := cc.cacheManager.FlushCache(stub.GetChannelID(), ns, coll)
+	if err != nil {
+		logger.Errorf("[%s] Error flushing cache for collection [%s:%s]: %s", stub.GetChannelID(), ns, coll, err)
+		return shim.Error(fmt.Sprintf("error flushing cache: %s", err))
+	}
+
+	return newResponse(ns, coll, flushed)
+}
+
+func (cc *CollCacheSCC) checkACL(stub shim.ChaincodeStubInterface, resourceName string) error {
+	sp, err := stub.GetSignedProposal()
+	if err != nil {
+		return errors.WithMessage(err, "unable to get signed proposal")
+	}
+
+	if err := cc.aclProvider.CheckACL(resourceName, stub.GetChannelID(), sp); err != nil {
+		return errors.WithMessagef(err, "ACL check failed for resource %s", resourceName)
+	}
+
+	return nil
+}
+
+func newResponse(ns, coll string, size int) pb.Response {
+	payload, err := json.Marshal(&CacheInfo{
+		Namespace:  ns,
+		Collection: coll,
+		Size:       size,
+	})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("error marshalling response: %s", err))
+	}
+
+	return shim.Success(payload)
+}
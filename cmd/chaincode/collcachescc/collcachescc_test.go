@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package collcachescc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+)
+
+const (
+	channel1 = "channel1"
+	tx1      = "tx1"
+	ns1      = "ns1"
+	coll1    = "coll1"
+)
+
+func TestCollCacheSCC_New(t *testing.T) {
+	cc := New(&mockCacheManager{}, &mocks.ACLProvider{})
+	require.NotNil(t, cc)
+
+	require.Equal(t, ccName, cc.Name())
+	require.Equal(t, cc, cc.Chaincode())
+
+	r := shimtest.NewMockStub("mock_stub", cc.Chaincode()).MockInit(tx1, nil)
+	require.Equal(t, shim.OK, int(r.Status))
+}
+
+func TestCollCacheSCC_Invoke(t *testing.T) {
+	cm := &mockCacheManager{size: 10}
+	aclProvider := &mocks.ACLProvider{}
+
+	cc := New(cm, aclProvider)
+	require.NotNil(t, cc)
+
+	newStub := func() *shimtest.MockStub {
+		stub := shimtest.NewMockStub("mock_stub", cc.Chaincode())
+		stub.ChannelID = channel1
+		return stub
+	}
+
+	t.Run("Invalid args", func(t *testing.T) {
+		r := newStub().MockInvoke(tx1, nil)
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "Function not provided")
+
+		r = newStub().MockInvoke(tx1, [][]byte{[]byte("xxx")})
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "Invalid function")
+
+		r = newStub().MockInvoke(tx1, [][]byte{[]byte(sizeFunc), []byte(ns1)})
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "Expecting namespace and collection")
+
+		r = newStub().MockInvoke(tx1, [][]byte{[]byte(sizeFunc), []byte(ns1), []byte("")})
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "Namespace and collection are required")
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		r := newStub().MockInvoke(tx1, [][]byte{[]byte(sizeFunc), []byte(ns1), []byte(coll1)})
+		require.Equal(t, shim.OK, int(r.Status), r.Message)
+
+		info := &CacheInfo{}
+		require.NoError(t, json.Unmarshal(r.Payload, info))
+		require.Equal(t, ns1, info.Namespace)
+		require.Equal(t, coll1, info.Collection)
+		require.Equal(t, 10, info.Size)
+
+		resName, channelID, _ := aclProvider.CheckACLArgsForCall(aclProvider.CheckACLCallCount() - 1)
+		require.Equal(t, "collcachescc/size", resName)
+		require.Equal(t, channel1, channelID)
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		r := newStub().MockInvoke(tx1, [][]byte{[]byte(flushFunc), []byte(ns1), []byte(coll1)})
+		require.Equal(t, shim.OK, int(r.Status), r.Message)
+
+		info := &CacheInfo{}
+		require.NoError(t, json.Unmarshal(r.Payload, info))
+		require.Equal(t, 10, info.Size)
+		require.Equal(t, []string{channel1, ns1, coll1}, cm.flushed)
+
+		resName, _, _ := aclProvider.CheckACLArgsForCall(aclProvider.CheckACLCallCount() - 1)
+		require.Equal(t, "collcachescc/flush", resName)
+	})
+
+	t.Run("Cache manager error", func(t *testing.T) {
+		cm.err = fmt.Errorf("injected cache error")
+		defer func() { cm.err = nil }()
+
+		r := newStub().MockInvoke(tx1, [][]byte{[]byte(sizeFunc), []byte(ns1), []byte(coll1)})
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "injected cache error")
+
+		r = newStub().MockInvoke(tx1, [][]byte{[]byte(flushFunc), []byte(ns1), []byte(coll1)})
+		require.Equal(t, shim.ERROR, int(r.Status))
+		require.Contains(t, r.Message, "injected cache error")
+	})
+
+	t.Run("Access denied", func(t *testing.T) {
+		aclProvider.CheckACLReturns(fmt.Errorf("access denied"))
+		defer aclProvider.CheckACLReturns(nil)
+
+		r := newStub().MockInvoke(tx1, [][]byte{[]byte(flushFunc), []byte(ns1), []byte(coll1)})
+		require.Equal(t, http.StatusForbidden, int(r.Status))
+		require.Contains(t, r.Message, "access denied")
+	})
+}
+
+type mockCacheManager struct {
+	size    int
+	err     error
+	flushed []string
+}
+
+func (m *mockCacheManager) CacheSize(channelID, ns, coll string) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	return m.size, nil
+}
+
+func (m *mockCacheManager) FlushCache(channelID, ns, coll string) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	m.flushed = []string{channelID, ns, coll}
+
+	return m.size, nil
+}
@@ -78,3 +78,29 @@ func NewQueryKey(endorsedAtTxID string, ns string, coll string, query string) *Q
 func (k *QueryKey) String() string {
 	return fmt.Sprintf("%s:%s:[%s]-%s", k.Namespace, k.Collection, k.Query, k.EndorsedAtTxID)
 }
+
+// RangeKey holds the criteria for retrieving a range of collection data
+type RangeKey struct {
+	EndorsedAtTxID string
+	Namespace      string
+	Collection     string
+	StartKey       string
+	EndKey         string
+}
+
+// NewRangeKey returns a new collection data range-key. The start key is inclusive and the end key is exclusive.
+// An empty start or end key indicates an open-ended range.
+func NewRangeKey(endorsedAtTxID string, ns string, coll string, startKey, endKey string) *RangeKey {
+	return &RangeKey{
+		EndorsedAtTxID: endorsedAtTxID,
+		Namespace:      ns,
+		Collection:     coll,
+		StartKey:       startKey,
+		EndKey:         endKey,
+	}
+}
+
+// String returns the string representation of the key
+func (k *RangeKey) String() string {
+	return fmt.Sprintf("%s:%s:[%s-%s]-%s", k.Namespace, k.Collection, k.StartKey, k.EndKey, k.EndorsedAtTxID)
+}
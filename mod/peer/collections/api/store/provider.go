@@ -8,10 +8,12 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	proto "github.com/hyperledger/fabric-protos-go/transientstore"
+	"github.com/pkg/errors"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common"
 )
 
@@ -19,11 +21,43 @@ import (
 type ExpiringValue struct {
 	Value  []byte
 	Expiry time.Time
+	// WriteTime is the time at which the value was originally written. It is used to determine which of two
+	// values of a key, written by different transactions, is the most recent. The current time is used if
+	// the write time isn't set when the value is stored.
+	WriteTime time.Time
 }
 
 // ExpiringValues expiring values
 type ExpiringValues []*ExpiringValue
 
+// RevisionConflictError is returned from a conditional put if the revision of the locally
+// stored value doesn't match the expected revision
+type RevisionConflictError struct {
+	Key      *Key
+	Expected string
+	Actual   string
+}
+
+// Error returns the error message
+func (e *RevisionConflictError) Error() string {
+	return fmt.Sprintf("revision conflict for key [%s]: expected revision [%s] but the stored revision is [%s]", e.Key, e.Expected, e.Actual)
+}
+
+// IsRevisionConflict returns true if the given error (or its cause) is a RevisionConflictError
+func IsRevisionConflict(err error) bool {
+	_, ok := errors.Cause(err).(*RevisionConflictError)
+	return ok
+}
+
+// Tombstone records the deletion of a key from the local store
+type Tombstone struct {
+	// TxID is the ID of the transaction that deleted the key. The ID is empty if the key
+	// was deleted locally, for example by garbage collection.
+	TxID string
+	// WriteTime is the time at which the key was deleted
+	WriteTime time.Time
+}
+
 // QueryResult holds a single item from the query result set
 type QueryResult struct {
 	*Key
@@ -40,6 +74,14 @@ type ResultsIterator interface {
 	Close()
 }
 
+// QueryResponseMetadata holds the metadata returned by a paginated query
+type QueryResponseMetadata struct {
+	// FetchedRecordsCount is the number of records returned in the page
+	FetchedRecordsCount int32
+	// Bookmark is passed to a subsequent query in order to retrieve the next page of results
+	Bookmark string
+}
+
 // Store manages the storage of private data collections.
 type Store interface {
 	// Persist stores the private write set of a transaction.
@@ -58,12 +100,34 @@ type Store interface {
 	GetDataMultipleKeys(key *MultiKey) (ExpiringValues, error)
 
 	// Query executes the given query
-	// NOTE: This function is only supported on CouchDB
 	Query(key *QueryKey) (ResultsIterator, error)
 
+	// QueryWithPagination executes the given query and returns at most pageSize results starting
+	// at the given bookmark. An empty bookmark starts at the first result.
+	QueryWithPagination(key *QueryKey, bookmark string, pageSize int32) (ResultsIterator, *QueryResponseMetadata, error)
+
+	// GetDataByRange returns the data for the given range of keys, ordered by key
+	GetDataByRange(key *RangeKey) (ResultsIterator, error)
+
+	// GetDataWithRevision gets the value for the given item along with the revision of the stored value.
+	// An empty revision is returned if the item doesn't exist.
+	GetDataWithRevision(key *Key) (*ExpiringValue, string, error)
+
 	// PutData stores the key/value.
 	PutData(config *pb.StaticCollectionConfig, key *Key, value *ExpiringValue) error
 
+	// PutDataIfLocalRevision stores the key/value only if the revision of the value in the local store matches
+	// the given revision. An empty revision indicates that the key must not exist. A RevisionConflictError is
+	// returned if the revisions don't match. The revision is not checked when the value is persisted on other peers.
+	PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *Key, value *ExpiringValue, revision string) error
+
+	// DeleteData deletes the given keys.
+	DeleteData(config *pb.StaticCollectionConfig, key *MultiKey) error
+
+	// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted. The key
+	// is the stored key, as returned by GetDataByRange.
+	GetTombstone(key *Key) (*Tombstone, error)
+
 	// Close closes the store
 	Close()
 }
@@ -84,6 +148,13 @@ type Retriever interface {
 
 	// Query returns the results of the given query
 	Query(ctxt context.Context, key *QueryKey) (ResultsIterator, error)
+
+	// QueryWithPagination returns at most pageSize results of the given query starting at the given bookmark.
+	// An empty bookmark starts at the first result.
+	QueryWithPagination(ctxt context.Context, key *QueryKey, bookmark string, pageSize int32) (ResultsIterator, *QueryResponseMetadata, error)
+
+	// GetDataByRange returns the data for the given range of keys, ordered by key
+	GetDataByRange(ctxt context.Context, key *RangeKey) (ResultsIterator, error)
 }
 
 // Provider provides private data retrievers
@@ -78,6 +78,16 @@ func (m *DataStore) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key
 	return nil
 }
 
+// PutDataIfLocalRevision stores the key/value if the revision matches
+func (m *DataStore) PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error {
+	panic("not implemented")
+}
+
+// GetDataWithRevision gets the value and revision for the given item
+func (m *DataStore) GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error) {
+	panic("not implemented")
+}
+
 // GetData gets the value for the given DCAS item
 func (m *DataStore) GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error) {
 	return m.olData[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}], m.err
@@ -101,6 +111,26 @@ func (m *DataStore) Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, err
 	panic("not implemented")
 }
 
+// QueryWithPagination executes the given query and returns a page of results
+func (m *DataStore) QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	panic("not implemented")
+}
+
+// DeleteData deletes the given keys
+func (m *DataStore) DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error {
+	panic("not implemented")
+}
+
+// GetTombstone returns the tombstone of the given key
+func (m *DataStore) GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error) {
+	panic("not implemented")
+}
+
+// GetDataByRange returns the data for the given range of keys
+func (m *DataStore) GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	panic("not implemented")
+}
+
 // Close closes the store
 func (m *DataStore) Close() {
 }
@@ -55,3 +55,11 @@ func (m *dataRetriever) GetDataMultipleKeys(ctxt context.Context, key *storeapi.
 func (m *dataRetriever) Query(ctxt context.Context, key *storeapi.QueryKey) (storeapi.ResultsIterator, error) {
 	panic("not implemented")
 }
+
+func (m *dataRetriever) QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	panic("not implemented")
+}
+
+func (m *dataRetriever) GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	panic("not implemented")
+}
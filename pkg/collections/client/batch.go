@@ -0,0 +1,186 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+)
+
+// CollectionID identifies a collection within a namespace
+type CollectionID struct {
+	Namespace  string
+	Collection string
+}
+
+// String returns the string representation of the collection ID
+func (c CollectionID) String() string {
+	return fmt.Sprintf("%s:%s", c.Namespace, c.Collection)
+}
+
+// BatchError is returned from Batch.Commit if the operations for one or more collections failed. It contains
+// the error for each of the failed collections. None of the operations in the batch are committed if a
+// BatchError is returned.
+type BatchError struct {
+	Errors map[CollectionID]error
+}
+
+// Error returns the errors of all of the failed collections
+func (e *BatchError) Error() string {
+	var ids []CollectionID
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("[%s]: %s", id, e.Errors[id])
+	}
+
+	return fmt.Sprintf("batch failed for %d collection(s): %s", len(ids), strings.Join(msgs, "; "))
+}
+
+type batchOp struct {
+	CollectionID
+	key    string
+	value  []byte
+	delete bool
+}
+
+// Batch collects puts and deletes, which may span multiple namespaces and collections, so that they
+// are committed together in a single private write set. A Batch is not safe for concurrent use.
+type Batch struct {
+	client *Client
+	ops    []*batchOp
+}
+
+// NewBatch returns a new, empty batch
+func (d *Client) NewBatch() *Batch {
+	return &Batch{client: d}
+}
+
+// Put adds a put of the given key/value to the batch
+func (b *Batch) Put(ns, coll, key string, value []byte) *Batch {
+	b.ops = append(b.ops, &batchOp{
+		CollectionID: CollectionID{Namespace: ns, Collection: coll},
+		key:          key,
+		value:        value,
+	})
+
+	return b
+}
+
+// Delete adds a delete of the given key(s) to the batch
+func (b *Batch) Delete(ns, coll string, keys ...string) *Batch {
+	for _, key := range keys {
+		b.ops = append(b.ops, &batchOp{
+			CollectionID: CollectionID{Namespace: ns, Collection: coll},
+			key:          key,
+			delete:       true,
+		})
+	}
+
+	return b
+}
+
+// Commit simulates all of the operations in the batch in a single transaction and disseminates the resulting
+// private write set to the peers in the collections. Either all of the operations are committed or none of them
+// are. If the operations for any of the collections fail then a BatchError is returned which contains the
+// error for each failed collection.
+func (b *Batch) Commit() error {
+	if len(b.ops) == 0 {
+		logger.Debugf("[%s] Nothing to commit", b.client.channelID)
+
+		return nil
+	}
+
+	sim, txID, height, err := b.client.newTxSimulator()
+	if err != nil {
+		return err
+	}
+	defer sim.Done()
+
+	configs := make(map[string]*pb.CollectionConfigPackage)
+	loaded := make(map[CollectionID]struct{})
+	errs := make(map[CollectionID]error)
+
+	for _, op := range b.ops {
+		if _, failed := errs[op.CollectionID]; failed {
+			continue
+		}
+
+		if _, ok := loaded[op.CollectionID]; !ok {
+			if err := b.addCollectionConfig(op.CollectionID, configs); err != nil {
+				errs[op.CollectionID] = err
+
+				continue
+			}
+
+			loaded[op.CollectionID] = struct{}{}
+		}
+
+		if err := b.simulate(sim, op); err != nil {
+			errs[op.CollectionID] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		batchErr := &BatchError{Errors: errs}
+
+		logger.Warningf("[%s] Batch for transaction [%s] failed: %s", b.client.channelID, txID, batchErr)
+
+		return batchErr
+	}
+
+	logger.Debugf("[%s] Committing batch of %d operation(s) in %d collection(s) for transaction [%s]", b.client.channelID, len(b.ops), len(loaded), txID)
+
+	return b.client.distribute(sim, txID, height, configs)
+}
+
+func (b *Batch) simulate(sim ledger.TxSimulator, op *batchOp) error {
+	if op.delete {
+		if err := sim.DeletePrivateData(op.Namespace, op.Collection, op.key); err != nil {
+			return errors.WithMessagef(err, "error deleting key [%s]", op.key)
+		}
+
+		return nil
+	}
+
+	if err := sim.SetPrivateData(op.Namespace, op.Collection, op.key, op.value); err != nil {
+		return errors.WithMessagef(err, "error setting key [%s]", op.key)
+	}
+
+	return nil
+}
+
+func (b *Batch) addCollectionConfig(id CollectionID, configs map[string]*pb.CollectionConfigPackage) error {
+	collConfig, err := b.client.ConfigRetriever.Config(id.Namespace, id.Collection)
+	if err != nil {
+		return errors.WithMessage(err, "error getting collection config")
+	}
+
+	configPkg, ok := configs[id.Namespace]
+	if !ok {
+		configPkg = &pb.CollectionConfigPackage{}
+		configs[id.Namespace] = configPkg
+	}
+
+	configPkg.Config = append(configPkg.Config, &pb.CollectionConfig{
+		Payload: &pb.CollectionConfig_StaticCollectionConfig{
+			StaticCollectionConfig: collConfig,
+		},
+	})
+
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"testing"
+
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	coreledger "github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	clientmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/client/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+)
+
+func TestBatch_Commit(t *testing.T) {
+	const (
+		ns2   = "ns2"
+		coll2 = "coll2"
+		coll3 = "coll3"
+	)
+
+	txSimulator := &mocks.TxSimulator{}
+	txSimulator.GetTxSimulationResultsReturns(&coreledger.TxSimulationResults{}, nil)
+
+	ledger := &mocks.Ledger{
+		TxSimulator: txSimulator,
+		BlockchainInfo: &cb.BlockchainInfo{
+			Height: blockHeight,
+		},
+	}
+
+	distributor := &clientmocks.PvtDataDistributor{}
+	configRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionConfig(&pb.StaticCollectionConfig{Name: coll1}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{Name: coll2})
+
+	signingIdentity := &mocks.SigningIdentity{}
+	signingIdentity.SerializeReturns([]byte("creator"), nil)
+
+	identityProvider := &mocks.IdentityProvider{}
+	identityProvider.GetDefaultSigningIdentityReturns(signingIdentity, nil)
+
+	c := New(channelID, &ChannelProviders{
+		Ledger:           ledger,
+		Distributor:      distributor,
+		ConfigRetriever:  configRetriever,
+		IdentityProvider: identityProvider,
+	})
+
+	value1 := []byte("value1")
+	value2 := []byte("value2")
+
+	t.Run("Empty batch -> success", func(t *testing.T) {
+		require.NoError(t, c.NewBatch().Commit())
+		require.Equal(t, 0, distributor.DistributePrivateDataCallCount())
+	})
+
+	t.Run("Multiple namespaces and collections -> success", func(t *testing.T) {
+		setCount := txSimulator.SetPrivateDataCallCount()
+		deleteCount := txSimulator.DeletePrivateDataCallCount()
+
+		err := c.NewBatch().
+			Put(ns1, coll1, key1, value1).
+			Put(ns1, coll2, key1, value2).
+			Delete(ns1, coll1, key2).
+			Put(ns2, coll1, key2, value2).
+			Delete(ns2, coll2, key1, key2).
+			Commit()
+		require.NoError(t, err)
+
+		require.Equal(t, setCount+3, txSimulator.SetPrivateDataCallCount())
+		require.Equal(t, deleteCount+3, txSimulator.DeletePrivateDataCallCount())
+
+		ns, coll, key, value := txSimulator.SetPrivateDataArgsForCall(setCount + 1)
+		require.Equal(t, ns1, ns)
+		require.Equal(t, coll2, coll)
+		require.Equal(t, key1, key)
+		require.Equal(t, value2, value)
+
+		ns, coll, key = txSimulator.DeletePrivateDataArgsForCall(deleteCount)
+		require.Equal(t, ns1, ns)
+		require.Equal(t, coll1, coll)
+		require.Equal(t, key2, key)
+
+		// The write set is disseminated once
+		require.Equal(t, 1, distributor.DistributePrivateDataCallCount())
+
+		chID, txID, pvtData, height := distributor.DistributePrivateDataArgsForCall(0)
+		require.Equal(t, channelID, chID)
+		require.NotEmpty(t, txID)
+		require.Equal(t, blockHeight, height)
+		require.Equal(t, blockHeight, pvtData.EndorsedAt)
+		require.Len(t, pvtData.CollectionConfigs, 2)
+		require.Len(t, pvtData.CollectionConfigs[ns1].Config, 2)
+		require.Len(t, pvtData.CollectionConfigs[ns2].Config, 2)
+	})
+
+	t.Run("Collection errors -> nothing committed", func(t *testing.T) {
+		distributeCount := distributor.DistributePrivateDataCallCount()
+
+		errExpected := errors.New("injected simulator error")
+		txSimulator.DeletePrivateDataReturns(errExpected)
+		defer txSimulator.DeletePrivateDataReturns(nil)
+
+		err := c.NewBatch().
+			Put(ns1, coll1, key1, value1).
+			Delete(ns1, coll2, key1).
+			Put(ns2, coll3, key2, value2).
+			Commit()
+		require.Error(t, err)
+
+		batchErr, ok := err.(*BatchError)
+		require.True(t, ok)
+		require.Len(t, batchErr.Errors, 2)
+
+		collErr := batchErr.Errors[CollectionID{Namespace: ns1, Collection: coll2}]
+		require.Error(t, collErr)
+		require.Contains(t, collErr.Error(), errExpected.Error())
+
+		collErr = batchErr.Errors[CollectionID{Namespace: ns2, Collection: coll3}]
+		require.Error(t, collErr)
+		require.Contains(t, collErr.Error(), "error getting collection config")
+
+		require.Contains(t, err.Error(), "batch failed for 2 collection(s): [ns1:coll2]")
+
+		require.Equal(t, distributeCount, distributor.DistributePrivateDataCallCount())
+	})
+
+	t.Run("GetBlockchainInfo error", func(t *testing.T) {
+		ledger.BcInfoError = errors.New("mock ledger error")
+		defer func() { ledger.BcInfoError = nil }()
+
+		err := c.NewBatch().Put(ns1, coll1, key1, value1).Commit()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error getting blockchain info")
+	})
+
+	t.Run("Distributor error", func(t *testing.T) {
+		distributor.DistributePrivateDataReturns(errors.New("mock distributor error"))
+		defer distributor.DistributePrivateDataReturns(nil)
+
+		err := c.NewBatch().Put(ns1, coll1, key1, value1).Commit()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error distributing private data")
+	})
+}
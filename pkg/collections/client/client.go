@@ -19,6 +19,7 @@ import (
 	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/core/ledger"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	"github.com/hyperledger/fabric/extensions/collections/api/support"
 	"github.com/pkg/errors"
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
@@ -46,6 +47,12 @@ type PvtDataDistributor interface {
 	DistributePrivateData(chainID string, txID string, privateData *transientstore.TxPvtReadWriteSetWithConfigInfo, blkHt uint64) error
 }
 
+// RevisionStore defines the functions of the local off-ledger store that are required for conditional puts
+type RevisionStore interface {
+	GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error)
+	PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error
+}
+
 // KeyValue holds a key-value pair
 type KeyValue struct {
 	Key   string
@@ -58,6 +65,7 @@ type ChannelProviders struct {
 	Distributor      PvtDataDistributor
 	ConfigRetriever  support.CollectionConfigRetriever
 	IdentityProvider collcommon.IdentityProvider
+	Store            RevisionStore
 }
 
 // Client allows you to put and get Client from outside of a chaincode
@@ -83,24 +91,9 @@ func (d *Client) Put(ns, coll, key string, value []byte) error {
 
 // PutMultipleValues puts the given key/values
 func (d *Client) PutMultipleValues(ns, coll string, kvs []*KeyValue) error {
-	bcInfo, err := d.Ledger.GetBlockchainInfo()
+	sim, txID, height, err := d.newTxSimulator()
 	if err != nil {
-		logger.Warningf("[%s] Error getting blockchain info: %s", d.channelID, err)
-		return errors.WithMessagef(err, "error getting blockchain info in channel [%s]", d.channelID)
-	}
-
-	// Generate a new TxID. The TxID doesn't really matter since this transaction is never committed.
-	// It just has to be unique.
-	txID, err := d.newTxID()
-	if err != nil {
-		logger.Warningf("[%s] Error generating transaction ID: %s", d.channelID, err)
-		return errors.WithMessagef(err, "error generating transaction ID in channel [%s]", d.channelID)
-	}
-
-	sim, err := d.Ledger.NewTxSimulator(txID)
-	if err != nil {
-		logger.Warningf("[%s] Error getting TxSimulator for transaction [%s]: %s", d.channelID, txID, err)
-		return errors.WithMessagef(err, "error getting TxSimulator for transaction [%s] in channel [%s]", txID, d.channelID)
+		return err
 	}
 	defer sim.Done()
 
@@ -115,33 +108,57 @@ func (d *Client) PutMultipleValues(ns, coll string, kvs []*KeyValue) error {
 		return errors.WithMessagef(err, "error setting keys for transaction [%s] in channel [%s]", txID, d.channelID)
 	}
 
-	results, err := sim.GetTxSimulationResults()
+	configPkg, err := d.getCollectionConfigPackage(ns, coll)
 	if err != nil {
-		logger.Warningf("[%s] Error generating simulation results for transaction [%s]: %s", d.channelID, txID, err)
-		return errors.WithMessagef(err, "error generating simulation results for transaction [%s] in channel [%s]", txID, d.channelID)
+		logger.Warningf("[%s] Error getting collection config for [%s:%s]: %s", d.channelID, ns, coll, err)
+		return errors.WithMessagef(err, "error getting collection config for [%s:%s] in channel [%s]", ns, coll, d.channelID)
+	}
+
+	return d.distribute(sim, txID, height, map[string]*pb.CollectionConfigPackage{ns: configPkg})
+}
+
+// PutIfLocalRevision puts the value for the given key only if the revision of the value currently stored
+// on the local peer matches the given revision (an empty revision means that the key must not exist).
+// The conditional put is performed atomically against the local store and, if it succeeds, the value
+// is distributed to the other peers in the collection. A storeapi.RevisionConflictError is returned
+// if the revision doesn't match.
+//
+// This is a local compare-and-set: the other peers persist the distributed value unconditionally, so
+// conditional puts of the same key that are submitted to different peers may both succeed. All writers
+// of a key should therefore submit their conditional puts to the same peer.
+func (d *Client) PutIfLocalRevision(ns, coll, key string, value []byte, revision string) error {
+	if d.Store == nil {
+		return errors.Errorf("conditional put not supported in channel [%s] since no store was provided", d.channelID)
 	}
 
+	sim, txID, height, err := d.newTxSimulator()
+	if err != nil {
+		return err
+	}
+	defer sim.Done()
+
 	configPkg, err := d.getCollectionConfigPackage(ns, coll)
 	if err != nil {
 		logger.Warningf("[%s] Error getting collection config for [%s:%s]: %s", d.channelID, ns, coll, err)
 		return errors.WithMessagef(err, "error getting collection config for [%s:%s] in channel [%s]", ns, coll, d.channelID)
 	}
 
-	pvtData := &transientstore.TxPvtReadWriteSetWithConfigInfo{
-		EndorsedAt: bcInfo.Height,
-		PvtRwset:   results.PvtSimulationResults,
-		CollectionConfigs: map[string]*pb.CollectionConfigPackage{
-			ns: configPkg,
-		},
+	err = d.Store.PutDataIfLocalRevision(
+		configPkg.Config[0].GetStaticCollectionConfig(),
+		storeapi.NewKey(txID, ns, coll, key), &storeapi.ExpiringValue{Value: value}, revision,
+	)
+	if err != nil {
+		logger.Debugf("[%s] Conditional put of [%s:%s:%s] failed for transaction [%s]: %s", d.channelID, ns, coll, key, txID, err)
+		return err
 	}
 
-	err = d.Distributor.DistributePrivateData(d.channelID, txID, pvtData, bcInfo.Height)
+	err = sim.SetPrivateData(ns, coll, key, value)
 	if err != nil {
-		logger.Warningf("[%s] Failed to distribute private data: %s", d.channelID, err)
-		return errors.WithMessagef(err, "error distributing private data in channel [%s]", d.channelID)
+		logger.Warningf("[%s] Error setting value for transaction [%s]: %s", d.channelID, txID, err)
+		return errors.WithMessagef(err, "error setting key for transaction [%s] in channel [%s]", txID, d.channelID)
 	}
 
-	return nil
+	return d.distribute(sim, txID, height, map[string]*pb.CollectionConfigPackage{ns: configPkg})
 }
 
 // Delete deletes the given key(s)
@@ -165,6 +182,26 @@ func (d *Client) Get(ns, coll, key string) ([]byte, error) {
 	return qe.GetPrivateData(ns, coll, key)
 }
 
+// GetWithRevision retrieves the value for the given key from the local store along with its local revision.
+// The revision may be passed to PutIfLocalRevision in order to update the value only if it hasn't changed.
+func (d *Client) GetWithRevision(ns, coll, key string) ([]byte, string, error) {
+	if d.Store == nil {
+		return nil, "", errors.Errorf("revisions not supported in channel [%s] since no store was provided", d.channelID)
+	}
+
+	v, revision, err := d.Store.GetDataWithRevision(storeapi.NewKey("", ns, coll, key))
+	if err != nil {
+		logger.Warningf("[%s] Error getting value for [%s:%s:%s]: %s", d.channelID, ns, coll, key, err)
+		return nil, "", errors.WithMessagef(err, "error getting value for [%s:%s:%s] in channel [%s]", ns, coll, key, d.channelID)
+	}
+
+	if v == nil {
+		return nil, revision, nil
+	}
+
+	return v.Value, revision, nil
+}
+
 // GetMultipleKeys retrieves the values for the given keys
 func (d *Client) GetMultipleKeys(ns, coll string, keys ...string) ([][]byte, error) {
 	qe, err := d.Ledger.NewQueryExecutor()
@@ -192,6 +229,54 @@ func (d *Client) Query(ns, coll, query string) (commonledger.ResultsIterator, er
 	return qe.ExecuteQueryOnPrivateData(ns, coll, query)
 }
 
+// newTxSimulator returns a new transaction simulator along with the transaction ID and the current block height
+func (d *Client) newTxSimulator() (ledger.TxSimulator, string, uint64, error) {
+	bcInfo, err := d.Ledger.GetBlockchainInfo()
+	if err != nil {
+		logger.Warningf("[%s] Error getting blockchain info: %s", d.channelID, err)
+		return nil, "", 0, errors.WithMessagef(err, "error getting blockchain info in channel [%s]", d.channelID)
+	}
+
+	// Generate a new TxID. The TxID doesn't really matter since this transaction is never committed.
+	// It just has to be unique.
+	txID, err := d.newTxID()
+	if err != nil {
+		logger.Warningf("[%s] Error generating transaction ID: %s", d.channelID, err)
+		return nil, "", 0, errors.WithMessagef(err, "error generating transaction ID in channel [%s]", d.channelID)
+	}
+
+	sim, err := d.Ledger.NewTxSimulator(txID)
+	if err != nil {
+		logger.Warningf("[%s] Error getting TxSimulator for transaction [%s]: %s", d.channelID, txID, err)
+		return nil, "", 0, errors.WithMessagef(err, "error getting TxSimulator for transaction [%s] in channel [%s]", txID, d.channelID)
+	}
+
+	return sim, txID, bcInfo.Height, nil
+}
+
+// distribute distributes the private data simulation results of the given simulator to the peers in the collections
+func (d *Client) distribute(sim ledger.TxSimulator, txID string, height uint64, configs map[string]*pb.CollectionConfigPackage) error {
+	results, err := sim.GetTxSimulationResults()
+	if err != nil {
+		logger.Warningf("[%s] Error generating simulation results for transaction [%s]: %s", d.channelID, txID, err)
+		return errors.WithMessagef(err, "error generating simulation results for transaction [%s] in channel [%s]", txID, d.channelID)
+	}
+
+	pvtData := &transientstore.TxPvtReadWriteSetWithConfigInfo{
+		EndorsedAt:        height,
+		PvtRwset:          results.PvtSimulationResults,
+		CollectionConfigs: configs,
+	}
+
+	err = d.Distributor.DistributePrivateData(d.channelID, txID, pvtData, height)
+	if err != nil {
+		logger.Warningf("[%s] Failed to distribute private data: %s", d.channelID, err)
+		return errors.WithMessagef(err, "error distributing private data in channel [%s]", d.channelID)
+	}
+
+	return nil
+}
+
 func (d *Client) getCollectionConfigPackage(ns, coll string) (*pb.CollectionConfigPackage, error) {
 	collConfig, err := d.ConfigRetriever.Config(ns, coll)
 	if err != nil {
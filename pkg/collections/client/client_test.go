@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	coreledger "github.com/hyperledger/fabric/core/ledger"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -137,6 +138,115 @@ func TestClient_Put(t *testing.T) {
 	})
 }
 
+func TestClient_PutIfLocalRevision(t *testing.T) {
+	txSimulator := &mocks.TxSimulator{}
+	txSimulator.GetTxSimulationResultsReturns(&coreledger.TxSimulationResults{}, nil)
+
+	ledger := &mocks.Ledger{
+		TxSimulator: txSimulator,
+		BlockchainInfo: &cb.BlockchainInfo{
+			Height: blockHeight,
+		},
+	}
+
+	distributor := &clientmocks.PvtDataDistributor{}
+	configRetriever := mocks.NewCollectionConfigRetriever().WithCollectionConfig(&pb.StaticCollectionConfig{Name: coll1})
+
+	signingIdentity := &mocks.SigningIdentity{}
+	signingIdentity.SerializeReturns([]byte("creator"), nil)
+
+	identityProvider := &mocks.IdentityProvider{}
+	identityProvider.GetDefaultSigningIdentityReturns(signingIdentity, nil)
+
+	store := mocks.NewDataStore()
+
+	providers := &ChannelProviders{
+		Ledger:           ledger,
+		Distributor:      distributor,
+		ConfigRetriever:  configRetriever,
+		IdentityProvider: identityProvider,
+		Store:            store,
+	}
+	c := New(channelID, providers)
+	require.NotNil(t, c)
+
+	value1 := []byte("value1")
+	value2 := []byte("value2")
+
+	t.Run("No store -> error", func(t *testing.T) {
+		c := New(channelID, &ChannelProviders{Ledger: ledger})
+
+		err := c.PutIfLocalRevision(ns1, coll1, key1, value1, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "conditional put not supported")
+
+		_, _, err = c.GetWithRevision(ns1, coll1, key1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "revisions not supported")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		value, revision, err := c.GetWithRevision(ns1, coll1, key1)
+		require.NoError(t, err)
+		require.Nil(t, value)
+		require.Empty(t, revision)
+
+		require.NoError(t, c.PutIfLocalRevision(ns1, coll1, key1, value1, revision))
+		require.Equal(t, 1, txSimulator.SetPrivateDataCallCount())
+		require.Equal(t, 1, distributor.DistributePrivateDataCallCount())
+
+		value, revision, err = c.GetWithRevision(ns1, coll1, key1)
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+		require.NotEmpty(t, revision)
+
+		require.NoError(t, c.PutIfLocalRevision(ns1, coll1, key1, value2, revision))
+
+		value, _, err = c.GetWithRevision(ns1, coll1, key1)
+		require.NoError(t, err)
+		require.Equal(t, value2, value)
+	})
+
+	t.Run("Revision conflict", func(t *testing.T) {
+		distributeCount := distributor.DistributePrivateDataCallCount()
+
+		err := c.PutIfLocalRevision(ns1, coll1, key2, value1, "invalid-revision")
+		require.Error(t, err)
+		require.True(t, storeapi.IsRevisionConflict(err))
+		require.Equal(t, distributeCount, distributor.DistributePrivateDataCallCount(), "expecting no distribution on conflict")
+	})
+
+	t.Run("CollectionConfig error", func(t *testing.T) {
+		configRetriever.WithError(errors.New("mock config error"))
+		defer configRetriever.WithError(nil)
+
+		err := c.PutIfLocalRevision(ns1, coll1, key2, value1, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error getting collection config")
+	})
+
+	t.Run("TxSimulator error", func(t *testing.T) {
+		txSimulator.SetPrivateDataReturns(errors.New("mock TxSimulator error"))
+		defer txSimulator.SetPrivateDataReturns(nil)
+
+		err := c.PutIfLocalRevision(ns1, coll1, key2, value1, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error setting key")
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		store.Error(errors.New("mock store error"))
+		defer store.Error(nil)
+
+		err := c.PutIfLocalRevision(ns1, coll1, key2, value1, "")
+		require.EqualError(t, err, "mock store error")
+
+		_, _, err = c.GetWithRevision(ns1, coll1, key2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mock store error")
+	})
+}
+
 func TestClient_Get(t *testing.T) {
 	value1 := []byte("value1")
 	value2 := []byte("value2")
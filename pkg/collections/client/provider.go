@@ -21,12 +21,24 @@ type OffLedger interface {
 	// PutMultipleValues puts the given key/values
 	PutMultipleValues(ns, coll string, kvs []*KeyValue) error
 
+	// PutIfLocalRevision puts the value for the given key only if the revision of the value stored on the local
+	// peer matches the given revision. An empty revision means that the key must not exist. The revision is not
+	// checked on the other peers to which the value is distributed.
+	PutIfLocalRevision(ns, coll, key string, value []byte, revision string) error
+
 	// Delete deletes the given key(s)
 	Delete(ns, coll string, keys ...string) error
 
+	// NewBatch returns a new batch which collects puts and deletes across multiple namespaces and
+	// collections so that they are committed together
+	NewBatch() *Batch
+
 	// Get retrieves the value for the given key
 	Get(ns, coll, key string) ([]byte, error)
 
+	// GetWithRevision retrieves the value for the given key along with its revision
+	GetWithRevision(ns, coll, key string) ([]byte, string, error)
+
 	// GetMultipleKeys retrieves the values for the given keys
 	GetMultipleKeys(ns, coll string, keys ...string) ([][]byte, error)
 
@@ -47,6 +59,7 @@ type Providers struct {
 	GossipProvider   collcommon.GossipProvider
 	ConfigProvider   collcommon.CollectionConfigProvider
 	IdentityProvider collcommon.IdentityProvider
+	StoreProvider    collcommon.StoreProvider
 }
 
 // NewProvider returns a new client provider
@@ -81,12 +94,16 @@ func newClient(channelID string, p *Providers) (*Client, error) {
 		return nil, errors.Errorf("no ledger for channel [%s]", channelID)
 	}
 
-	return New(channelID,
-		&ChannelProviders{
-			Ledger:           l,
-			Distributor:      p.GossipProvider.GetGossipService(),
-			ConfigRetriever:  p.ConfigProvider.ForChannel(channelID),
-			IdentityProvider: p.IdentityProvider,
-		},
-	), nil
+	providers := &ChannelProviders{
+		Ledger:           l,
+		Distributor:      p.GossipProvider.GetGossipService(),
+		ConfigRetriever:  p.ConfigProvider.ForChannel(channelID),
+		IdentityProvider: p.IdentityProvider,
+	}
+
+	if p.StoreProvider != nil {
+		providers.Store = p.StoreProvider.StoreForChannel(channelID)
+	}
+
+	return New(channelID, providers), nil
 }
@@ -32,6 +32,7 @@ func TestProvider(t *testing.T) {
 		GossipProvider:   &mocks.GossipProvider{},
 		ConfigProvider:   &mocks.CollectionConfigProvider{},
 		IdentityProvider: &mocks.IdentityProvider{},
+		StoreProvider:    &mocks.StoreProvider{},
 	}
 	p := NewProvider(providers)
 	require.NotNil(t, p)
@@ -0,0 +1,204 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cachestats
+
+import (
+	"sync"
+
+	fabricmetrics "github.com/hyperledger/fabric/common/metrics"
+)
+
+const subsystem = "cache"
+
+var labelNames = []string{"channel", "namespace", "collection"}
+
+const statsdFormat = "%{#fqname}.%{channel}.%{namespace}.%{collection}"
+
+type cacheMetrics struct {
+	provider  fabricmetrics.Provider
+	hits      fabricmetrics.Counter
+	misses    fabricmetrics.Counter
+	evictions fabricmetrics.Counter
+	size      fabricmetrics.Gauge
+}
+
+var (
+	mutex              sync.Mutex
+	metricsByNamespace = make(map[string]*cacheMetrics)
+)
+
+// getMetrics returns the cache metrics for the given namespace. The metrics are created only once
+// per metrics provider since a provider (e.g. Prometheus) doesn't allow the same metric to be registered twice.
+func getMetrics(p fabricmetrics.Provider, namespace string) *cacheMetrics {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	m, ok := metricsByNamespace[namespace]
+	if ok && m.provider == p {
+		return m
+	}
+
+	m = &cacheMetrics{
+		provider: p,
+		hits: p.NewCounter(fabricmetrics.CounterOpts{
+			Namespace:    namespace,
+			Subsystem:    subsystem,
+			Name:         "hits",
+			Help:         "The number of cache lookups that found the key in the cache.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+		misses: p.NewCounter(fabricmetrics.CounterOpts{
+			Namespace:    namespace,
+			Subsystem:    subsystem,
+			Name:         "misses",
+			Help:         "The number of cache lookups that had to load the key from the database.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+		evictions: p.NewCounter(fabricmetrics.CounterOpts{
+			Namespace:    namespace,
+			Subsystem:    subsystem,
+			Name:         "evictions",
+			Help:         "The number of keys evicted from the cache, either because the cache is full or because the key expired.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+		size: p.NewGauge(fabricmetrics.GaugeOpts{
+			Namespace:    namespace,
+			Subsystem:    subsystem,
+			Name:         "size",
+			Help:         "The number of keys in the cache.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+	}
+
+	metricsByNamespace[namespace] = m
+
+	return m
+}
+
+type collKey struct {
+	namespace  string
+	collection string
+}
+
+// Stats maintains the statistics of a collection data cache for a channel. The keys held by the cache are
+// tracked per collection so that the size of a collection's cache may be reported and the cache may be
+// flushed for a single collection.
+type Stats struct {
+	channelID string
+	metrics   *cacheMetrics
+	mutex     sync.RWMutex
+	keys      map[collKey]map[string]struct{}
+}
+
+// New returns the statistics for a cache. The given namespace is the metrics namespace
+// (for example "offledger") under which the cache metrics are reported to the given provider.
+func New(metricsProvider fabricmetrics.Provider, namespace, channelID string) *Stats {
+	return &Stats{
+		channelID: channelID,
+		metrics:   getMetrics(metricsProvider, namespace),
+		keys:      make(map[collKey]map[string]struct{}),
+	}
+}
+
+// Hit records a cache lookup for which the key was found in the cache
+func (s *Stats) Hit(ns, coll string) {
+	s.metrics.hits.With(s.labels(ns, coll)...).Add(1)
+}
+
+// Miss records a cache lookup for which the key was not found in the cache
+func (s *Stats) Miss(ns, coll string) {
+	s.metrics.misses.With(s.labels(ns, coll)...).Add(1)
+}
+
+// Added records that the given key was added to (or updated in) the cache
+func (s *Stats) Added(ns, coll, key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := collKey{namespace: ns, collection: coll}
+
+	keys, ok := s.keys[k]
+	if !ok {
+		keys = make(map[string]struct{})
+		s.keys[k] = keys
+	}
+
+	keys[key] = struct{}{}
+
+	s.metrics.size.With(s.labels(ns, coll)...).Set(float64(len(keys)))
+}
+
+// Evicted records that the given key was removed from the cache. The eviction is only counted
+// if the key is being tracked, i.e. it wasn't explicitly removed with Remove.
+func (s *Stats) Evicted(ns, coll, key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := collKey{namespace: ns, collection: coll}
+
+	keys, ok := s.keys[k]
+	if !ok {
+		return
+	}
+
+	if _, ok := keys[key]; !ok {
+		return
+	}
+
+	delete(keys, key)
+
+	s.metrics.evictions.With(s.labels(ns, coll)...).Add(1)
+	s.metrics.size.With(s.labels(ns, coll)...).Set(float64(len(keys)))
+}
+
+// Size returns the number of keys in the cache for the given collection
+func (s *Stats) Size(ns, coll string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.keys[collKey{namespace: ns, collection: coll}])
+}
+
+// Remove stops tracking the keys of the given collection and returns the keys. The caller is
+// expected to remove the returned keys from the cache.
+func (s *Stats) Remove(ns, coll string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := collKey{namespace: ns, collection: coll}
+
+	var keys []string
+	for key := range s.keys[k] {
+		keys = append(keys, key)
+	}
+
+	delete(s.keys, k)
+
+	s.metrics.size.With(s.labels(ns, coll)...).Set(0)
+
+	return keys
+}
+
+// Clear stops tracking the keys of all collections. This function should be called when the cache is purged.
+func (s *Stats) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for k := range s.keys {
+		s.metrics.size.With(s.labels(k.namespace, k.collection)...).Set(0)
+	}
+
+	s.keys = make(map[collKey]map[string]struct{})
+}
+
+func (s *Stats) labels(ns, coll string) []string {
+	return []string{"channel", s.channelID, "namespace", ns, "collection", coll}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cachestats
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/metrics/metricsfakes"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	channel1 = "channel1"
+	ns1      = "ns1"
+	coll1    = "coll1"
+	coll2    = "coll2"
+	key1     = "key1"
+	key2     = "key2"
+)
+
+func TestStats(t *testing.T) {
+	hits := &metricsfakes.Counter{}
+	hits.WithReturns(hits)
+	misses := &metricsfakes.Counter{}
+	misses.WithReturns(misses)
+	evictions := &metricsfakes.Counter{}
+	evictions.WithReturns(evictions)
+	size := &metricsfakes.Gauge{}
+	size.WithReturns(size)
+
+	p := &metricsfakes.Provider{}
+	p.NewCounterReturnsOnCall(0, hits)
+	p.NewCounterReturnsOnCall(1, misses)
+	p.NewCounterReturnsOnCall(2, evictions)
+	p.NewGaugeReturns(size)
+
+	s := New(p, "test", channel1)
+	require.NotNil(t, s)
+
+	// The metrics should only be created once per namespace
+	require.NotNil(t, New(p, "test", "channel2"))
+	require.Equal(t, 3, p.NewCounterCallCount())
+	require.Equal(t, 1, p.NewGaugeCallCount())
+
+	t.Run("Hit and miss", func(t *testing.T) {
+		s.Hit(ns1, coll1)
+		require.Equal(t, 1, hits.AddCallCount())
+		require.Equal(t, []string{"channel", channel1, "namespace", ns1, "collection", coll1}, hits.WithArgsForCall(0))
+
+		s.Miss(ns1, coll1)
+		require.Equal(t, 1, misses.AddCallCount())
+	})
+
+	t.Run("Added and evicted", func(t *testing.T) {
+		s.Added(ns1, coll1, key1)
+		s.Added(ns1, coll1, key2)
+		s.Added(ns1, coll1, key2)
+		s.Added(ns1, coll2, key1)
+
+		require.Equal(t, 2, s.Size(ns1, coll1))
+		require.Equal(t, 1, s.Size(ns1, coll2))
+		require.Equal(t, float64(2), size.SetArgsForCall(size.SetCallCount()-2))
+
+		s.Evicted(ns1, coll1, key1)
+		require.Equal(t, 1, s.Size(ns1, coll1))
+		require.Equal(t, 1, evictions.AddCallCount())
+
+		// Keys which aren't tracked aren't counted as evictions
+		s.Evicted(ns1, coll1, key1)
+		s.Evicted(ns1, "coll3", key1)
+		require.Equal(t, 1, evictions.AddCallCount())
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		keys := s.Remove(ns1, coll1)
+		require.Equal(t, []string{key2}, keys)
+		require.Zero(t, s.Size(ns1, coll1))
+		require.Equal(t, 1, s.Size(ns1, coll2))
+
+		// The removed key is no longer tracked so it isn't counted as an eviction
+		s.Evicted(ns1, coll1, key2)
+		require.Equal(t, 1, evictions.AddCallCount())
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		s.Clear()
+		require.Zero(t, s.Size(ns1, coll2))
+		require.Zero(t, size.SetArgsForCall(size.SetCallCount()-1))
+	})
+}
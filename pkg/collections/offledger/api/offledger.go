@@ -22,16 +22,37 @@ type Store interface {
 	// PutData stores the key/value.
 	PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue) error
 
+	// PutDataIfLocalRevision stores the key/value only if the revision of the value in the local store matches
+	// the given revision. An empty revision indicates that the key must not exist. A RevisionConflictError is
+	// returned if the revisions don't match. The revision is not checked when the value is persisted on other peers.
+	PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error
+
+	// DeleteData deletes the given keys.
+	DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error
+
+	// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted. The key
+	// is the stored key, as returned by GetDataByRange.
+	GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error)
+
 	// GetData gets the value for the given item
 	GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error)
 
+	// GetDataWithRevision gets the value for the given item along with the revision of the stored value.
+	// An empty revision is returned if the item doesn't exist.
+	GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error)
+
 	// GetDataMultipleKeys gets the values for the multiple items in a single call
 	GetDataMultipleKeys(key *storeapi.MultiKey) (storeapi.ExpiringValues, error)
 
 	// Query executes the given query
-	// NOTE: This function is only supported on CouchDB
 	Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, error)
 
+	// QueryWithPagination executes the given query and returns at most pageSize results starting at the given bookmark
+	QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error)
+
+	// GetDataByRange returns the data for the given range of keys, ordered by key
+	GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error)
+
 	// Close closes the store
 	Close()
 }
@@ -54,8 +75,13 @@ type Retriever interface {
 	GetDataMultipleKeys(ctxt context.Context, key *storeapi.MultiKey) (storeapi.ExpiringValues, error)
 
 	// Query returns the results from the given query
-	// NOTE: This function is only supported on CouchDB
 	Query(ctxt context.Context, key *storeapi.QueryKey) (storeapi.ResultsIterator, error)
+
+	// QueryWithPagination returns at most pageSize results from the given query starting at the given bookmark
+	QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error)
+
+	// GetDataByRange returns the data for the given range of keys, ordered by key
+	GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error)
 }
 
 // Provider provides data retrievers
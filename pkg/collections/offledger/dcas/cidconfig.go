@@ -0,0 +1,245 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcas
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/bluele/gcache"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/service"
+)
+
+const (
+	// configMSP is the MSP ID under which channel-wide configuration is stored in the ledger config. Updates to this
+	// configuration are governed by the configdata/write/general policy of the channel.
+	configMSP = "general"
+
+	configApp        = "dcas"
+	configAppVersion = "1"
+
+	cidConfigComponent = "cid"
+	cidConfigVersion   = "1"
+)
+
+// cidConfigKey is the ledger config key of the DCAS CID configuration of a channel
+var cidConfigKey = config.NewComponentKey(configMSP, configApp, configAppVersion, cidConfigComponent, cidConfigVersion)
+
+// CIDConfig contains the content ID (CID) settings of the DCAS collections in a channel. The config is stored
+// in JSON format in the ledger config of the channel so that all peers validate CIDs using the same settings.
+type CIDConfig struct {
+	Collections []*CollectionCIDConfig
+}
+
+// CollectionCIDConfig contains the CID versions (0 or 1), codecs (dag-pb, dag-cbor or raw) and multihash
+// types (e.g. sha2-256, sha3-256 or blake2b-256) that are allowed in a DCAS collection. An empty list means
+// that any value is allowed.
+type CollectionCIDConfig struct {
+	Namespace  string
+	Collection string
+	Versions   []string `json:",omitempty"`
+	Codecs     []string `json:",omitempty"`
+	Hashes     []string `json:",omitempty"`
+}
+
+type configServiceProvider interface {
+	ForChannel(channelID string) config.Service
+}
+
+type configValidatorRegistry interface {
+	Register(v config.Validator)
+}
+
+// CIDSettingsProvider provides the CID settings of DCAS collections from the ledger config of the channel
+var CIDSettingsProvider = &cidSettingsProvider{}
+
+type cidSettingsProvider struct {
+	channels gcache.Cache
+}
+
+// Initialize initializes the CID settings provider with the ledger config service provider and registers
+// the validator for the DCAS CID config
+func (p *cidSettingsProvider) Initialize(configProvider configServiceProvider, validatorRegistry configValidatorRegistry) *cidSettingsProvider {
+	logger.Infof("Initializing DCAS CID settings provider")
+
+	validatorRegistry.Register(&cidConfigValidator{})
+
+	p.channels = gcache.New(0).LoaderFunc(func(channelID interface{}) (interface{}, error) {
+		return newChannelCIDSettings(channelID.(string), configProvider.ForChannel(channelID.(string))), nil
+	}).Build()
+
+	return p
+}
+
+// GetCIDSettings returns the CID settings of the given collection. Default settings, which allow any CID, are
+// returned if the collection isn't configured or if the provider isn't initialized (e.g. in a chaincode process).
+func (p *cidSettingsProvider) GetCIDSettings(channelID, ns, coll string) (*CIDSettings, error) {
+	if p.channels == nil || channelID == "" {
+		return &CIDSettings{}, nil
+	}
+
+	s, err := p.channels.Get(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.(*channelCIDSettings).get(ns, coll)
+}
+
+type collKey struct {
+	ns   string
+	coll string
+}
+
+// channelCIDSettings holds the parsed CID settings of a channel. The settings are parsed on first use and
+// again after the CID config is updated.
+type channelCIDSettings struct {
+	channelID     string
+	configService config.Service
+	mutex         sync.RWMutex
+	settings      map[collKey]*CIDSettings
+}
+
+func newChannelCIDSettings(channelID string, configService config.Service) *channelCIDSettings {
+	s := &channelCIDSettings{
+		channelID:     channelID,
+		configService: configService,
+	}
+
+	configService.AddUpdateHandler(s.handleConfigUpdate)
+
+	return s
+}
+
+func (s *channelCIDSettings) get(ns, coll string) (*CIDSettings, error) {
+	settings, err := s.getAll()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to load DCAS CID settings for collection [%s:%s]", ns, coll)
+	}
+
+	if cs, ok := settings[collKey{ns: ns, coll: coll}]; ok {
+		return cs, nil
+	}
+
+	return &CIDSettings{}, nil
+}
+
+func (s *channelCIDSettings) getAll() (map[collKey]*CIDSettings, error) {
+	s.mutex.RLock()
+	settings := s.settings
+	s.mutex.RUnlock()
+
+	if settings != nil {
+		return settings, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.settings != nil {
+		return s.settings, nil
+	}
+
+	settings, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.settings = settings
+
+	return settings, nil
+}
+
+func (s *channelCIDSettings) load() (map[collKey]*CIDSettings, error) {
+	value, err := s.configService.Get(cidConfigKey)
+	if err != nil {
+		if errors.Cause(err) == service.ErrConfigNotFound {
+			logger.Debugf("[%s] DCAS CID config not found. Any CID is allowed.", s.channelID)
+
+			return make(map[collKey]*CIDSettings), nil
+		}
+
+		return nil, err
+	}
+
+	logger.Debugf("[%s] Loaded DCAS CID config: %s", s.channelID, value.Config)
+
+	return parseCIDConfig(value.Config)
+}
+
+func (s *channelCIDSettings) handleConfigUpdate(kv *config.KeyValue) {
+	if *kv.Key != *cidConfigKey {
+		return
+	}
+
+	logger.Infof("[%s] DCAS CID config was updated", s.channelID)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The settings are reloaded on next use
+	s.settings = nil
+}
+
+func parseCIDConfig(cfg string) (map[collKey]*CIDSettings, error) {
+	cidConfig := &CIDConfig{}
+	if err := json.Unmarshal([]byte(cfg), cidConfig); err != nil {
+		return nil, errors.WithMessage(err, "error unmarshalling DCAS CID config")
+	}
+
+	settings := make(map[collKey]*CIDSettings)
+
+	for _, c := range cidConfig.Collections {
+		if c.Namespace == "" || c.Collection == "" {
+			return nil, errors.New("fields [Namespace] and [Collection] are required in DCAS CID config")
+		}
+
+		key := collKey{ns: c.Namespace, coll: c.Collection}
+		if _, ok := settings[key]; ok {
+			return nil, errors.Errorf("duplicate DCAS CID config for collection [%s:%s]", c.Namespace, c.Collection)
+		}
+
+		s, err := NewCIDSettings(c.Versions, c.Codecs, c.Hashes)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid DCAS CID settings for collection [%s:%s]", c.Namespace, c.Collection)
+		}
+
+		settings[key] = s
+	}
+
+	return settings, nil
+}
+
+// cidConfigValidator validates the DCAS CID config before it is saved to the ledger config
+type cidConfigValidator struct {
+}
+
+func (v *cidConfigValidator) Validate(kv *config.KeyValue) error {
+	if kv.AppName != configApp {
+		return nil
+	}
+
+	if kv.MspID != configMSP || kv.PeerID != "" {
+		return errors.Errorf("DCAS config must be stored under MSP [%s] and without a peer ID: %s", configMSP, kv.Key)
+	}
+
+	if kv.AppVersion != configAppVersion || kv.ComponentName != cidConfigComponent || kv.ComponentVersion != cidConfigVersion {
+		return errors.Errorf("unsupported DCAS config key %s", kv.Key)
+	}
+
+	if config.Format(strings.ToUpper(string(kv.Format))) != config.FormatJSON {
+		return errors.Errorf("expecting format [%s] but got [%s] for %s", config.FormatJSON, kv.Format, kv.Key)
+	}
+
+	_, err := parseCIDConfig(kv.Config)
+
+	return err
+}
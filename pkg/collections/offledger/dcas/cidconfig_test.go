@@ -0,0 +1,188 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcas
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/service"
+)
+
+//go:generate counterfeiter -o ./mocks/configserviceprovider.gen.go --fake-name ConfigServiceProvider . configServiceProvider
+//go:generate counterfeiter -o ./mocks/configservice.gen.go --fake-name ConfigService ../../../config/ledgerconfig/config Service
+//go:generate counterfeiter -o ./mocks/configvalidatorregistry.gen.go --fake-name ConfigValidatorRegistry . configValidatorRegistry
+
+const (
+	channel1 = "channel1"
+	coll2    = "coll2"
+
+	cidConfig1 = `{"Collections":[{"Namespace":"chaincode1","Collection":"coll1","Versions":["0"],"Codecs":["dag-pb"],"Hashes":["sha2-256"]}]}`
+	cidConfig2 = `{"Collections":[{"Namespace":"chaincode1","Collection":"coll1","Hashes":["sha3-256"]}]}`
+)
+
+func TestCIDSettingsProvider(t *testing.T) {
+	t.Run("Not initialized -> default settings", func(t *testing.T) {
+		s, err := (&cidSettingsProvider{}).GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.False(t, s.HasVersions())
+		require.Empty(t, s.Codecs)
+		require.Empty(t, s.MhTypes)
+	})
+
+	t.Run("Config not found -> default settings", func(t *testing.T) {
+		configService := &mocks.ConfigService{}
+		configService.GetReturns(nil, service.ErrConfigNotFound)
+
+		s, err := newTestCIDSettingsProvider(t, configService).GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.False(t, s.HasVersions())
+	})
+
+	t.Run("Configured collection", func(t *testing.T) {
+		configService := &mocks.ConfigService{}
+		configService.GetReturns(config.NewValue("tx1", cidConfig1, config.FormatJSON), nil)
+
+		p := newTestCIDSettingsProvider(t, configService)
+
+		s, err := p.GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, []CIDVersion{CIDV0}, s.Versions)
+		require.Equal(t, []uint64{cid.DagProtobuf}, s.Codecs)
+		require.Equal(t, []uint64{mh.SHA2_256}, s.MhTypes)
+
+		s, err = p.GetCIDSettings(channel1, ns1, coll2)
+		require.NoError(t, err)
+		require.False(t, s.HasVersions())
+		require.Empty(t, s.MhTypes)
+
+		// The config is parsed only once
+		require.Equal(t, 1, configService.GetCallCount())
+	})
+
+	t.Run("Config updated -> settings reloaded", func(t *testing.T) {
+		configService := &mocks.ConfigService{}
+		configService.GetReturns(config.NewValue("tx1", cidConfig1, config.FormatJSON), nil)
+
+		p := newTestCIDSettingsProvider(t, configService)
+
+		s, err := p.GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{mh.SHA2_256}, s.MhTypes)
+
+		require.Equal(t, 1, configService.AddUpdateHandlerCallCount())
+		handleUpdate := configService.AddUpdateHandlerArgsForCall(0)
+
+		configService.GetReturns(config.NewValue("tx2", cidConfig2, config.FormatJSON), nil)
+
+		// Updates to other config are ignored
+		handleUpdate(config.NewKeyValue(config.NewAppKey("org1MSP", "app1", "1"), config.NewValue("tx2", "{}", config.FormatJSON)))
+
+		s, err = p.GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{mh.SHA2_256}, s.MhTypes)
+
+		handleUpdate(config.NewKeyValue(cidConfigKey, config.NewValue("tx2", cidConfig2, config.FormatJSON)))
+
+		s, err = p.GetCIDSettings(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{mh.SHA3_256}, s.MhTypes)
+		require.Equal(t, 2, configService.GetCallCount())
+	})
+
+	t.Run("Config service error", func(t *testing.T) {
+		errExpected := fmt.Errorf("injected config service error")
+
+		configService := &mocks.ConfigService{}
+		configService.GetReturns(nil, errExpected)
+
+		_, err := newTestCIDSettingsProvider(t, configService).GetCIDSettings(channel1, ns1, coll1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
+func TestCIDConfigValidator(t *testing.T) {
+	v := &cidConfigValidator{}
+
+	t.Run("Success", func(t *testing.T) {
+		require.NoError(t, v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", cidConfig1, config.FormatJSON))))
+	})
+
+	t.Run("Other app -> ignored", func(t *testing.T) {
+		require.NoError(t, v.Validate(config.NewKeyValue(config.NewAppKey("org1MSP", "app1", "1"), config.NewValue("tx1", "invalid", config.FormatOther))))
+	})
+
+	t.Run("Org MSP -> error", func(t *testing.T) {
+		key := config.NewComponentKey("org1MSP", configApp, configAppVersion, cidConfigComponent, cidConfigVersion)
+
+		err := v.Validate(config.NewKeyValue(key, config.NewValue("tx1", cidConfig1, config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "DCAS config must be stored under MSP [general]")
+	})
+
+	t.Run("Unsupported component -> error", func(t *testing.T) {
+		key := config.NewComponentKey(configMSP, configApp, configAppVersion, "other", cidConfigVersion)
+
+		err := v.Validate(config.NewKeyValue(key, config.NewValue("tx1", cidConfig1, config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported DCAS config key")
+	})
+
+	t.Run("Invalid format -> error", func(t *testing.T) {
+		err := v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", cidConfig1, config.FormatYAML)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "expecting format [JSON]")
+	})
+
+	t.Run("Invalid JSON -> error", func(t *testing.T) {
+		err := v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", "{", config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling DCAS CID config")
+	})
+
+	t.Run("Missing collection -> error", func(t *testing.T) {
+		err := v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", `{"Collections":[{"Namespace":"chaincode1"}]}`, config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "fields [Namespace] and [Collection] are required")
+	})
+
+	t.Run("Duplicate collection -> error", func(t *testing.T) {
+		cfg := `{"Collections":[{"Namespace":"chaincode1","Collection":"coll1"},{"Namespace":"chaincode1","Collection":"coll1"}]}`
+
+		err := v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", cfg, config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "duplicate DCAS CID config for collection [chaincode1:coll1]")
+	})
+
+	t.Run("Invalid settings -> error", func(t *testing.T) {
+		cfg := `{"Collections":[{"Namespace":"chaincode1.v1","Collection":"coll1","Hashes":["invalid"]}]}`
+
+		err := v.Validate(config.NewKeyValue(cidConfigKey, config.NewValue("tx1", cfg, config.FormatJSON)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid DCAS CID settings for collection [chaincode1.v1:coll1]")
+	})
+}
+
+func newTestCIDSettingsProvider(t *testing.T, configService *mocks.ConfigService) *cidSettingsProvider {
+	configProvider := &mocks.ConfigServiceProvider{}
+	configProvider.ForChannelReturns(configService)
+
+	validatorRegistry := &mocks.ConfigValidatorRegistry{}
+
+	p := (&cidSettingsProvider{}).Initialize(configProvider, validatorRegistry)
+
+	require.Equal(t, 1, validatorRegistry.RegisterCallCount())
+
+	return p
+}
@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcas
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+)
+
+// codecs contains the codecs that may be used in a DCAS collection
+var codecs = map[string]uint64{
+	"dag-pb":   cid.DagProtobuf,
+	"dag-cbor": cid.DagCBOR,
+	"raw":      cid.Raw,
+}
+
+// mhTypes contains the multihash types that may be used in a DCAS collection
+var mhTypes = map[string]uint64{
+	"sha2-256":    mh.SHA2_256,
+	"sha2-512":    mh.SHA2_512,
+	"sha3-224":    mh.SHA3_224,
+	"sha3-256":    mh.SHA3_256,
+	"sha3-384":    mh.SHA3_384,
+	"sha3-512":    mh.SHA3_512,
+	"blake2b-256": mh.Names["blake2b-256"],
+	"blake2b-384": mh.Names["blake2b-384"],
+	"blake2b-512": mh.Names["blake2b-512"],
+}
+
+// CIDSettings contains the content ID (CID) versions, codecs and multihash types that are allowed in a
+// DCAS collection. An empty list means that any value is allowed. New content is stored using the first
+// version and the first multihash type.
+type CIDSettings struct {
+	Versions []CIDVersion
+	Codecs   []uint64
+	MhTypes  []uint64
+}
+
+// NewCIDSettings returns CID settings for the given versions (0 or 1), codecs (dag-pb, dag-cbor or raw) and
+// multihash types (e.g. sha2-256, sha3-256 or blake2b-256). An empty list means that any value is allowed.
+// An error is returned if any of the values is not supported or if the values don't result in a valid CID combination.
+func NewCIDSettings(versions, codecNames, mhNames []string) (*CIDSettings, error) {
+	s := &CIDSettings{}
+
+	for _, v := range versions {
+		version, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(v), "v"), 10, 64)
+		if err != nil || (version != CIDV0 && version != CIDV1) {
+			return nil, errors.Errorf("unsupported CID version [%s]", v)
+		}
+
+		s.Versions = append(s.Versions, version)
+	}
+
+	for _, name := range codecNames {
+		codec, ok := codecs[strings.ToLower(name)]
+		if !ok {
+			return nil, errors.Errorf("unsupported codec [%s]", name)
+		}
+
+		s.Codecs = append(s.Codecs, codec)
+	}
+
+	for _, name := range mhNames {
+		mhType, ok := mhTypes[strings.ToLower(name)]
+		if !ok {
+			return nil, errors.Errorf("unsupported multihash type [%s]", name)
+		}
+
+		s.MhTypes = append(s.MhTypes, mhType)
+	}
+
+	if s.Version() == CIDV0 && (!s.allows(s.Codecs, cid.DagProtobuf) || s.MhType() != mh.SHA2_256) {
+		return nil, errors.New("CID version 0 requires codec dag-pb and multihash type sha2-256")
+	}
+
+	return s, nil
+}
+
+// Version returns the CID version that is used for new content. Version 1 is returned if no versions are configured.
+func (s *CIDSettings) Version() CIDVersion {
+	if len(s.Versions) == 0 {
+		return CIDV1
+	}
+
+	return s.Versions[0]
+}
+
+// HasVersions returns true if the allowed CID versions are configured
+func (s *CIDSettings) HasVersions() bool {
+	return len(s.Versions) > 0
+}
+
+// MhType returns the default multihash type that is used for new content. sha2-256 is returned if no multihash
+// types are configured.
+func (s *CIDSettings) MhType() uint64 {
+	if len(s.MhTypes) == 0 {
+		return mh.SHA2_256
+	}
+
+	return s.MhTypes[0]
+}
+
+// Validate returns an error if the version, codec or multihash type of the given CID is not allowed
+func (s *CIDSettings) Validate(c cid.Cid) error {
+	prefix := c.Prefix()
+
+	if !s.allows(s.Versions, prefix.Version) {
+		return errors.Errorf("CID version [%d] of [%s] is not allowed", prefix.Version, c)
+	}
+
+	if !s.allows(s.Codecs, prefix.Codec) {
+		return errors.Errorf("codec [%d] of [%s] is not allowed", prefix.Codec, c)
+	}
+
+	if !s.allows(s.MhTypes, prefix.MhType) {
+		return errors.Errorf("multihash type [%d] of [%s] is not allowed", prefix.MhType, c)
+	}
+
+	return nil
+}
+
+// allows returns true if the given list of allowed values is empty (i.e. any value is allowed) or contains the value
+func (s *CIDSettings) allows(allowed []uint64, value uint64) bool {
+	return len(allowed) == 0 || containsUint(allowed, value)
+}
+
+func containsUint(values []uint64, value uint64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcas
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCIDSettings(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		s, err := NewCIDSettings([]string{"v1", "0"}, []string{"dag-pb", "DAG-CBOR", "raw"}, []string{"sha3-256", "blake2b-256"})
+		require.NoError(t, err)
+		require.Equal(t, []CIDVersion{CIDV1, CIDV0}, s.Versions)
+		require.Equal(t, []uint64{cid.DagProtobuf, cid.DagCBOR, cid.Raw}, s.Codecs)
+		require.Equal(t, []uint64{mh.SHA3_256, mh.Names["blake2b-256"]}, s.MhTypes)
+		require.Equal(t, CIDV1, s.Version())
+		require.Equal(t, uint64(mh.SHA3_256), s.MhType())
+	})
+
+	t.Run("Invalid version -> error", func(t *testing.T) {
+		_, err := NewCIDSettings([]string{"2"}, []string{"raw"}, []string{"sha2-256"})
+		require.EqualError(t, err, "unsupported CID version [2]")
+	})
+
+	t.Run("Invalid codec -> error", func(t *testing.T) {
+		_, err := NewCIDSettings([]string{"1"}, []string{"git-raw"}, []string{"sha2-256"})
+		require.EqualError(t, err, "unsupported codec [git-raw]")
+	})
+
+	t.Run("Invalid multihash type -> error", func(t *testing.T) {
+		_, err := NewCIDSettings([]string{"1"}, []string{"raw"}, []string{"md5"})
+		require.EqualError(t, err, "unsupported multihash type [md5]")
+	})
+
+	t.Run("Empty -> success", func(t *testing.T) {
+		s, err := NewCIDSettings(nil, nil, nil)
+		require.NoError(t, err)
+		require.False(t, s.HasVersions())
+		require.Equal(t, CIDV1, s.Version())
+		require.Equal(t, uint64(mh.SHA2_256), s.MhType())
+
+		s, err = NewCIDSettings([]string{"0"}, nil, nil)
+		require.NoError(t, err)
+		require.True(t, s.HasVersions())
+		require.Equal(t, CIDV0, s.Version())
+	})
+
+	t.Run("Version 0 without dag-pb -> error", func(t *testing.T) {
+		_, err := NewCIDSettings([]string{"0"}, []string{"raw"}, []string{"sha2-256"})
+		require.EqualError(t, err, "CID version 0 requires codec dag-pb and multihash type sha2-256")
+	})
+
+	t.Run("Version 0 without sha2-256 -> error", func(t *testing.T) {
+		_, err := NewCIDSettings([]string{"0"}, []string{"dag-pb"}, []string{"sha3-256"})
+		require.EqualError(t, err, "CID version 0 requires codec dag-pb and multihash type sha2-256")
+	})
+}
+
+func TestCIDSettings_Validate(t *testing.T) {
+	content := []byte("some content")
+
+	allVersions := []string{"1", "0"}
+	allCodecs := []string{"dag-pb", "dag-cbor", "raw"}
+	allHashes := []string{"sha2-256", "sha2-512", "sha3-224", "sha3-256", "sha3-384", "sha3-512", "blake2b-256", "blake2b-384", "blake2b-512"}
+
+	s, err := NewCIDSettings(allVersions, allCodecs, allHashes)
+	require.NoError(t, err)
+
+	t.Run("All allowed combinations -> success", func(t *testing.T) {
+		for _, codec := range s.Codecs {
+			for _, mhType := range s.MhTypes {
+				c := getTestCID(t, content, CIDV1, codec, mhType)
+				require.NoErrorf(t, s.Validate(c), "CID [%s] should be allowed", c)
+			}
+		}
+
+		require.NoError(t, s.Validate(getTestCID(t, content, CIDV0, cid.DagProtobuf, mh.SHA2_256)))
+	})
+
+	t.Run("Restricted settings -> only allowed combinations succeed", func(t *testing.T) {
+		restricted, err := NewCIDSettings([]string{"1"}, []string{"raw", "dag-pb"}, []string{"sha3-256", "blake2b-256"})
+		require.NoError(t, err)
+
+		for _, codec := range s.Codecs {
+			for _, mhType := range s.MhTypes {
+				c := getTestCID(t, content, CIDV1, codec, mhType)
+
+				if containsUint(restricted.Codecs, codec) && containsUint(restricted.MhTypes, mhType) {
+					require.NoErrorf(t, restricted.Validate(c), "CID [%s] should be allowed", c)
+				} else {
+					require.Errorf(t, restricted.Validate(c), "CID [%s] should not be allowed", c)
+				}
+			}
+		}
+
+		err = restricted.Validate(getTestCID(t, content, CIDV0, cid.DagProtobuf, mh.SHA2_256))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "CID version [0]")
+	})
+
+	t.Run("No settings -> any CID allowed", func(t *testing.T) {
+		unrestricted, err := NewCIDSettings(nil, nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, unrestricted.Validate(getTestCID(t, content, CIDV0, cid.DagProtobuf, mh.SHA2_256)))
+		require.NoError(t, unrestricted.Validate(getTestCID(t, content, CIDV1, cid.GitRaw, mh.SHA2_256)))
+		require.NoError(t, unrestricted.Validate(getTestCID(t, content, CIDV1, cid.Raw, mh.MD5)))
+	})
+
+	t.Run("Unsupported codec -> error", func(t *testing.T) {
+		err := s.Validate(getTestCID(t, content, CIDV1, cid.GitRaw, mh.SHA2_256))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "codec")
+	})
+
+	t.Run("Unsupported multihash type -> error", func(t *testing.T) {
+		err := s.Validate(getTestCID(t, content, CIDV1, cid.Raw, mh.MD5))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "multihash type")
+	})
+}
+
+func getTestCID(t *testing.T, content []byte, version CIDVersion, codec, mhType uint64) cid.Cid {
+	c, err := getCID(content, version, codec, mhType)
+	require.NoError(t, err)
+
+	return c
+}
@@ -0,0 +1,268 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+)
+
+const (
+	// carVersion is the version of the Content Addressable aRchive (CAR) format that is supported
+	carVersion = 1
+
+	// maxCARSectionSize is the maximum size of a header or block section that is read from a CAR stream
+	maxCARSectionSize = 32 << 20
+
+	blocksPrefix = "/blocks"
+)
+
+// carHeader is the header of a CARv1 stream (see https://ipld.io/specs/transport/car/carv1/)
+type carHeader struct {
+	Roots   []cid.Cid
+	Version uint64
+}
+
+func init() {
+	cbornode.RegisterCborType(carHeader{})
+}
+
+// ExportCAR writes the block of the given content ID along with all of its descendant blocks to the
+// given writer as a CARv1 stream. The given content ID is the single root of the CAR.
+func (d *DCASClient) ExportCAR(id string, w io.Writer) error {
+	root, err := cid.Decode(id)
+	if err != nil {
+		return err
+	}
+
+	// The blocks are written in the order in which they are visited so that the root block is written first
+	var cids []cid.Cid
+
+	visited := cid.NewSet()
+
+	err = dag.Walk(context.Background(), d.getAllLinks, root, func(c cid.Cid) bool {
+		if !visited.Visit(c) {
+			return false
+		}
+
+		cids = append(cids, c)
+
+		return true
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "error resolving blocks for CID [%s]", id)
+	}
+
+	headerBytes, err := cbornode.DumpObject(&carHeader{Roots: []cid.Cid{root}, Version: carVersion})
+	if err != nil {
+		return errors.WithMessage(err, "error marshalling CAR header")
+	}
+
+	if err := writeCARSection(w, headerBytes); err != nil {
+		return errors.WithMessage(err, "error writing CAR header")
+	}
+
+	for _, c := range cids {
+		blk, err := d.blockStore.Get(c)
+		if err != nil {
+			return errors.WithMessagef(err, "error loading block [%s]", c)
+		}
+
+		if err := writeCARSection(w, c.Bytes(), blk.RawData()); err != nil {
+			return errors.WithMessagef(err, "error writing block [%s]", c)
+		}
+	}
+
+	logger.Debugf("Exported %d blocks for CID [%s]", len(cids), id)
+
+	return nil
+}
+
+// ImportCAR reads a CARv1 stream from the given reader and stores all of its blocks. Each block is validated
+// against its content ID and the CID settings of the collection before it is stored. The content IDs of the roots of the CAR are returned.
+func (d *DCASClient) ImportCAR(r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+
+	header, err := readCARHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.tracker != nil {
+		// Ensure that a sweep doesn't remove blocks that are being imported
+		d.mutex.RLock()
+		defer d.mutex.RUnlock()
+	}
+
+	count := 0
+
+	for {
+		section, err := readCARSection(br)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.WithMessage(err, "error reading CAR block")
+		}
+
+		if err := d.importBlock(section); err != nil {
+			return nil, err
+		}
+
+		count++
+	}
+
+	roots := make([]string, len(header.Roots))
+	for i, root := range header.Roots {
+		roots[i] = root.String()
+
+		if err := d.trackRoot(root); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Debugf("Imported %d blocks for roots %s", count, roots)
+
+	return roots, nil
+}
+
+func (d *DCASClient) importBlock(section []byte) error {
+	n, c, err := cid.CidFromBytes(section)
+	if err != nil {
+		return errors.WithMessage(err, "invalid CID in CAR block")
+	}
+
+	data := section[n:]
+
+	if err := dcas.ValidateDatastoreKey(blocksPrefix+dshelp.NewKeyFromBinary(c.Bytes()).String(), data); err != nil {
+		return errors.WithMessagef(err, "invalid CAR block [%s]", c)
+	}
+
+	if err := d.cidSettings.Validate(c); err != nil {
+		return errors.WithMessagef(err, "invalid CAR block [%s]", c)
+	}
+
+	blk, err := blocks.NewBlockWithCid(data, c)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid CAR block [%s]", c)
+	}
+
+	if err := d.blockStore.Put(blk); err != nil {
+		return errors.WithMessagef(err, "error storing block [%s]", c)
+	}
+
+	return nil
+}
+
+// trackRoot adds the given root to the garbage collection tracker (if enabled) using the same
+// blocks that would have been recorded if the content had been put with this client
+func (d *DCASClient) trackRoot(root cid.Cid) error {
+	if d.tracker == nil {
+		return nil
+	}
+
+	var blockIDs []string
+
+	err := dag.Walk(context.Background(), d.getLinks, root, func(c cid.Cid) bool {
+		blockIDs = append(blockIDs, c.String())
+
+		return true
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "error resolving blocks for CID [%s]", root)
+	}
+
+	if err := d.tracker.AddRoot(root.String(), blockIDs); err != nil {
+		return errors.WithMessagef(err, "error tracking blocks for CID [%s]", root)
+	}
+
+	return nil
+}
+
+// getAllLinks returns all of the links of the node with the given CID. An error is returned if the node is not found.
+func (d *DCASClient) getAllLinks(ctx context.Context, c cid.Cid) ([]*ipld.Link, error) {
+	nd, err := d.dagService.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return nd.Links(), nil
+}
+
+func readCARHeader(r *bufio.Reader) (*carHeader, error) {
+	headerBytes, err := readCARSection(r)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error reading CAR header")
+	}
+
+	header := &carHeader{}
+	if err := cbornode.DecodeInto(headerBytes, header); err != nil {
+		return nil, errors.WithMessage(err, "invalid CAR header")
+	}
+
+	if header.Version != carVersion {
+		return nil, errors.Errorf("unsupported CAR version: %d", header.Version)
+	}
+
+	return header, nil
+}
+
+func readCARSection(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+
+		return nil, errors.WithMessage(err, "error reading section size")
+	}
+
+	if size == 0 || size > maxCARSectionSize {
+		return nil, errors.Errorf("invalid section size: %d", size)
+	}
+
+	section := make([]byte, size)
+	if _, err := io.ReadFull(r, section); err != nil {
+		return nil, errors.WithMessage(err, "error reading section")
+	}
+
+	return section, nil
+}
+
+func writeCARSection(w io.Writer, data ...[]byte) error {
+	size := 0
+	for _, d := range data {
+		size += len(d)
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(size))
+
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+
+	for _, d := range data {
+		if _, err := w.Write(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	mh "github.com/multiformats/go-multihash"
+	viper "github.com/spf13/viper2015"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/gc"
+	olmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/mocks"
+	extconfig "github.com/trustbloc/fabric-peer-ext/pkg/config"
+)
+
+func TestDCASClient_ExportImportCAR(t *testing.T) {
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+	cborValue1 := []byte(`{"field1":"value1","field2":"value2"}`)
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	ds1 := newMockDataStore()
+
+	c1, err := createClient(cfg, ds1)
+	require.NoError(t, err)
+
+	cidFile1, err := c1.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	numFileBlocks := len(ds1.data)
+	require.True(t, numFileBlocks > 1)
+
+	cidCbor1, err := c1.Put(bytes.NewReader(cborValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(CborFormat))
+	require.NoError(t, err)
+
+	t.Run("File -> success", func(t *testing.T) {
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, c1.ExportCAR(cidFile1, car))
+
+		ds2 := newMockDataStore()
+
+		c2, err := createClient(cfg, ds2)
+		require.NoError(t, err)
+
+		roots, err := c2.ImportCAR(car)
+		require.NoError(t, err)
+		require.Equal(t, []string{cidFile1}, roots)
+		require.Len(t, ds2.data, numFileBlocks)
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c2.Get(cidFile1, b))
+		require.Equal(t, fileData1, b.Bytes())
+	})
+
+	t.Run("Object -> success", func(t *testing.T) {
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, c1.ExportCAR(cidCbor1, car))
+
+		ds2 := newMockDataStore()
+
+		c2, err := createClient(cfg, ds2)
+		require.NoError(t, err)
+
+		roots, err := c2.ImportCAR(car)
+		require.NoError(t, err)
+		require.Equal(t, []string{cidCbor1}, roots)
+		require.Len(t, ds2.data, 1)
+
+		expected := bytes.NewBuffer(nil)
+		require.NoError(t, c1.Get(cidCbor1, expected))
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c2.Get(cidCbor1, b))
+		require.Equal(t, expected.Bytes(), b.Bytes())
+	})
+
+	t.Run("Export - invalid CID -> error", func(t *testing.T) {
+		require.Error(t, c1.ExportCAR("invalid", bytes.NewBuffer(nil)))
+	})
+
+	t.Run("Export - not found -> error", func(t *testing.T) {
+		cID, err := dcas.GetCID([]byte("value2"), dcas.CIDV1, cid.Raw, mh.SHA2_256)
+		require.NoError(t, err)
+
+		car := bytes.NewBuffer(nil)
+
+		err = c1.ExportCAR(cID, car)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error resolving blocks for CID")
+		require.Empty(t, car.Bytes())
+	})
+
+	t.Run("Import - invalid block -> error", func(t *testing.T) {
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, c1.ExportCAR(cidFile1, car))
+
+		// Tamper with the data of the last block
+		carBytes := car.Bytes()
+		carBytes[len(carBytes)-1]++
+
+		c2, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		roots, err := c2.ImportCAR(bytes.NewReader(carBytes))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid CAR block")
+		require.Empty(t, roots)
+	})
+
+	t.Run("Import - truncated block -> error", func(t *testing.T) {
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, c1.ExportCAR(cidFile1, car))
+
+		c2, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		_, err = c2.ImportCAR(bytes.NewReader(car.Bytes()[:car.Len()-1]))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading CAR block")
+	})
+
+	t.Run("Import - invalid header -> error", func(t *testing.T) {
+		_, err := c1.ImportCAR(bytes.NewReader([]byte("invalid header")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "CAR header")
+	})
+
+	t.Run("Import - unsupported version -> error", func(t *testing.T) {
+		headerBytes, err := cbornode.DumpObject(&carHeader{Version: 2})
+		require.NoError(t, err)
+
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, writeCARSection(car, headerBytes))
+
+		_, err = c1.ImportCAR(car)
+		require.EqualError(t, err, "unsupported CAR version: 2")
+	})
+}
+
+func TestDCASClient_ImportCARWithGC(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasclientcar")
+	require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath()))
+	defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+	gcManager, err := gc.NewManager(time.Hour)
+	require.NoError(t, err)
+	defer gcManager.Close()
+
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	c1, err := createClient(cfg, newMockDataStore())
+	require.NoError(t, err)
+
+	cidFile1, err := c1.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	car := bytes.NewBuffer(nil)
+	require.NoError(t, c1.ExportCAR(cidFile1, car))
+
+	ds2 := newMockDataStore()
+
+	c2, err := createClient(cfg, ds2)
+	require.NoError(t, err)
+
+	c2.tracker = gcManager.Tracker(channelID, ns1, coll1)
+	c2.localBlockStore = blockstore.NewBlockstore(ds2)
+
+	_, err = c2.ImportCAR(car)
+	require.NoError(t, err)
+	require.NotEmpty(t, ds2.data)
+
+	// The imported root is tracked so all of its blocks are deleted
+	require.NoError(t, c2.Delete(cidFile1))
+	require.Empty(t, ds2.data)
+}
@@ -6,10 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/ipfs/go-blockservice"
@@ -25,9 +28,12 @@ import (
 	"github.com/ipfs/go-unixfs/importer/balanced"
 	unixfshelpers "github.com/ipfs/go-unixfs/importer/helpers"
 	"github.com/ipfs/go-unixfs/importer/trickle"
+	uio "github.com/ipfs/go-unixfs/io"
 	mh "github.com/multiformats/go-multihash"
 	"github.com/pkg/errors"
 	olclient "github.com/trustbloc/fabric-peer-ext/pkg/collections/client"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/gc"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/ipfsdatastore"
 	"github.com/trustbloc/fabric-peer-ext/pkg/internal/github.com/ipfs/go-ipfs/core/coredag"
 )
@@ -42,6 +48,7 @@ type config interface {
 	IsDCASRawLeaves() bool
 	GetDCASMaxBlockSize() int64
 	GetDCASBlockLayout() string
+	GetDCASGCInterval() time.Duration
 }
 
 type layoutStrategy = func(db *unixfshelpers.DagBuilderHelper) (ipld.Node, error)
@@ -49,12 +56,29 @@ type layoutStrategy = func(db *unixfshelpers.DagBuilderHelper) (ipld.Node, error
 // DCASClient allows you to put and get DCASClient from outside of a chaincode
 type DCASClient struct {
 	config
-	dagService format.DAGService
-	layout     layoutStrategy
+	blockStore      blockstore.Blockstore
+	localBlockStore blockstore.Blockstore
+	dagService      format.DAGService
+	layout          layoutStrategy
+	tracker         *gc.Tracker
+	cidSettings     *dcas.CIDSettings
+	mutex           sync.RWMutex
 }
 
 func createOLWrappedClient(cfg config, channelID, ns, coll string, providers *olclient.ChannelProviders) (*DCASClient, error) {
-	return createClient(cfg, ipfsdatastore.NewOLClientWrapper(ns, coll, olclient.New(channelID, providers)))
+	cidSettings, err := dcas.CIDSettingsProvider.GetCIDSettings(channelID, ns, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := createClient(cfg, ipfsdatastore.NewOLClientWrapper(ns, coll, olclient.New(channelID, providers)))
+	if err != nil {
+		return nil, err
+	}
+
+	c.cidSettings = cidSettings
+
+	return c, nil
 }
 
 func createCCStubWrappedClient(cfg config, coll string, stub shim.ChaincodeStubInterface) (*DCASClient, error) {
@@ -67,45 +91,166 @@ func createClient(cfg config, ds datastore.Batching) (*DCASClient, error) {
 		return nil, err
 	}
 
+	bs := blockstore.NewBlockstore(ds)
+
 	return &DCASClient{
-		config: cfg,
-		layout: layout,
+		config:     cfg,
+		layout:     layout,
+		blockStore: bs,
+		// Use the default CID settings. The settings are overridden for clients of a specific collection.
+		cidSettings: &dcas.CIDSettings{},
 		dagService: dag.NewDAGService(
-			blockservice.NewWriteThrough(
-				blockstore.NewBlockstore(ds), nil,
-			),
+			blockservice.NewWriteThrough(bs, nil),
 		),
 	}, nil
 }
 
 // Put stores the given content and returns the content ID (CID) for the value
 func (d *DCASClient) Put(data io.Reader, opts ...Option) (string, error) {
-	options := getOptions(opts)
-	switch options.nodeType {
-	case FileNodeType:
-		return d.putFile(data, options)
-	case ObjectNodeType:
-		fallthrough
-	case "":
-		return d.putObject(context.Background(), data, options)
-	default:
-		return "", fmt.Errorf("unsupported node type [%s]", options.nodeType)
+	if d.tracker == nil {
+		cID, _, err := d.put(data, d.getOptions(opts))
+
+		return cID, err
 	}
+
+	// Ensure that a sweep doesn't remove blocks that are being put
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	cID, blocks, err := d.put(data, d.getOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.tracker.AddRoot(cID, blocks); err != nil {
+		return "", errors.WithMessagef(err, "error tracking blocks for CID [%s]", cID)
+	}
+
+	return cID, nil
 }
 
-// Delete deletes the values for the given content IDs.
+// Delete deletes the values for the given content IDs. If a content ID refers to a multi-block file then
+// the child blocks of the file that are no longer referenced by any other root are also deleted. A block
+// that is still referenced by another root (for example, a chunk that is shared with another file) is not
+// deleted. Content that is linked from an object has its own root and is therefore not deleted along
+// with the object.
+//
+// Reference tracking is required in order to determine which blocks may be deleted, so an error is returned
+// if the client doesn't track references (i.e. if the client wraps a chaincode stub).
 func (d *DCASClient) Delete(ids ...string) error {
-	cids := make([]cid.Cid, len(ids))
-	for i, key := range ids {
-		cID, err := cid.Decode(key)
+	if d.tracker == nil {
+		return errors.New("delete is not supported since references are not tracked by this client")
+	}
+
+	// Ensure that a root isn't added while the reference counts are being checked
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	ctx := context.Background()
+	blocks := cid.NewSet()
+
+	for _, id := range ids {
+		cID, err := cid.Decode(id)
 		if err != nil {
 			return err
 		}
 
-		cids[i] = cID
+		if err := d.resolveUnreferenced(ctx, cID, blocks); err != nil {
+			return errors.WithMessagef(err, "error resolving blocks for CID [%s]", id)
+		}
+	}
+
+	if blocks.Len() == 0 {
+		return nil
+	}
+
+	logger.Debugf("Deleting %d blocks for CIDs %s", blocks.Len(), ids)
+
+	if err := d.dagService.RemoveMany(ctx, blocks.Keys()); err != nil {
+		return err
+	}
+
+	// The blocks have already been removed so there's no need for the sweep to remove them
+	var removed []string
+	for _, c := range blocks.Keys() {
+		removed = append(removed, c.String())
+	}
+
+	return d.tracker.Purged(removed...)
+}
+
+// resolveUnreferenced releases the given root (if it is tracked) and adds the blocks of the root
+// (including the root itself) that are no longer referenced by any root to the given set
+func (d *DCASClient) resolveUnreferenced(ctx context.Context, root cid.Cid, blocks *cid.Set) error {
+	released, err := d.tracker.Release(root.String())
+	if err != nil {
+		return err
+	}
+
+	if released {
+		logger.Debugf("Released CID [%s]", root)
+	}
+
+	var errWalk error
+
+	err = dag.Walk(ctx, d.getLinks, root, func(c cid.Cid) bool {
+		referenced, err := d.tracker.IsReferenced(c.String())
+		if err != nil {
+			errWalk = err
+
+			return false
+		}
+
+		if referenced {
+			// The children of a referenced block are also referenced by the same root
+			logger.Debugf("Block [%s] is referenced by another root and will not be deleted", c)
+
+			return false
+		}
+
+		return blocks.Visit(c)
+	})
+	if err != nil {
+		return err
 	}
 
-	return d.dagService.RemoveMany(context.Background(), cids)
+	return errWalk
+}
+
+// Sweep removes all blocks that are no longer referenced by any root from the local store. Reference counts
+// are maintained by each peer for the content that was put by the peer, so blocks are never removed from the
+// stores of other peers by a sweep. Sweep does nothing if garbage collection is not enabled.
+func (d *DCASClient) Sweep() error {
+	if d.tracker == nil {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	ids, err := d.tracker.Unreferenced()
+	if err != nil {
+		return errors.WithMessage(err, "error retrieving unreferenced blocks")
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	logger.Debugf("Sweeping %d unreferenced blocks", len(ids))
+
+	for _, id := range ids {
+		cID, err := cid.Decode(id)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid CID [%s]", id)
+		}
+
+		if err := d.localBlockStore.DeleteBlock(cID); err != nil {
+			return errors.WithMessagef(err, "error removing unreferenced block [%s]", id)
+		}
+	}
+
+	return d.tracker.Purged(ids...)
 }
 
 // Get retrieves the value for the given content ID.
@@ -133,6 +278,54 @@ func (d *DCASClient) Get(id string, w io.Writer) error {
 	return nil
 }
 
+// GetRange writes length bytes of the content for the given content ID, starting at the given offset.
+// If length is zero then all content from the offset is written. If fewer than length bytes are
+// available from the offset then only the available bytes are written.
+func (d *DCASClient) GetRange(id string, offset, length int64, w io.Writer) error {
+	if offset < 0 || length < 0 {
+		return errors.Errorf("invalid range - offset: %d, length: %d", offset, length)
+	}
+
+	r, err := d.newReader(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	if r == nil {
+		return nil
+	}
+
+	defer closeReader(r)
+
+	if uint64(offset) > r.Size() {
+		return errors.Errorf("offset %d is out of range for content of size %d", offset, r.Size())
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return errors.WithMessagef(err, "error seeking to offset %d", offset)
+	}
+
+	var src io.Reader = r
+	if length > 0 {
+		src = io.LimitReader(r, length)
+	}
+
+	_, err = io.Copy(w, src)
+
+	return err
+}
+
+// GetReader returns a reader that may be used to read and seek the content for the given content ID.
+// Nil is returned if the content is not found.
+func (d *DCASClient) GetReader(id string) (io.ReadSeeker, error) {
+	r, err := d.newReader(context.Background(), id)
+	if err != nil || r == nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
 // GetNode retrieves the CAS Node for the given content ID. A node contains data and/or links to other nodes.
 func (d *DCASClient) GetNode(id string) (*Node, error) {
 	nd, err := d.getNode(context.Background(), id)
@@ -156,10 +349,43 @@ func (d *DCASClient) GetNode(id string) (*Node, error) {
 	return &Node{
 		Data:  nd.RawData(),
 		Links: links,
+		Size:  fileSize(nd),
 	}, nil
 }
 
-func (d *DCASClient) putObject(ctx context.Context, data io.Reader, options *options) (string, error) {
+// put stores the given content and returns the content ID (CID) along with the CIDs of all of the blocks that were stored
+func (d *DCASClient) put(data io.Reader, options *options) (string, []string, error) {
+	if d.cidSettings.Version() == dcas.CIDV0 && options.multihashType != mh.SHA2_256 {
+		return "", nil, errors.Errorf("multihash type [%d] is not supported by CID version 0", options.multihashType)
+	}
+
+	dagService := &recordingDAGService{
+		DAGService: d.dagService,
+		validate:   d.cidSettings.Validate,
+	}
+
+	var cID string
+	var err error
+
+	switch options.nodeType {
+	case FileNodeType:
+		cID, err = d.putFile(dagService, data, options)
+	case ObjectNodeType:
+		fallthrough
+	case "":
+		cID, err = d.putObject(context.Background(), dagService, data, options)
+	default:
+		err = fmt.Errorf("unsupported node type [%s]", options.nodeType)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return cID, dagService.cids, nil
+}
+
+func (d *DCASClient) putObject(ctx context.Context, dagService format.DAGService, data io.Reader, options *options) (string, error) {
 	nds, err := coredag.ParseInputs(
 		string(options.inputEncoding), string(options.format),
 		data, options.multihashType, -1,
@@ -175,7 +401,13 @@ func (d *DCASClient) putObject(ctx context.Context, data io.Reader, options *opt
 
 	nd := nds[0]
 
-	err = d.dagService.Add(ctx, nd)
+	if pbNode, ok := nd.(*dag.ProtoNode); ok && d.cidSettings.HasVersions() {
+		// Protobuf nodes use the configured CID version. If no version is configured then the CID version that
+		// was chosen by the parser is used. All other formats are only supported by CID version 1.
+		pbNode.SetCidBuilder(d.cidBuilder(options.multihashType))
+	}
+
+	err = dagService.Add(ctx, nd)
 	if err != nil {
 		return "", err
 	}
@@ -183,12 +415,13 @@ func (d *DCASClient) putObject(ctx context.Context, data io.Reader, options *opt
 	return nd.Cid().String(), nil
 }
 
-func (d *DCASClient) putFile(data io.Reader, options *options) (string, error) {
+func (d *DCASClient) putFile(dagService format.DAGService, data io.Reader, options *options) (string, error) {
 	dbp := unixfshelpers.DagBuilderParams{
-		Maxlinks:   d.GetDCASMaxLinksPerBlock(),
-		RawLeaves:  d.IsDCASRawLeaves(),
-		CidBuilder: cid.V1Builder{MhType: options.multihashType},
-		Dagserv:    d.dagService,
+		Maxlinks: d.GetDCASMaxLinksPerBlock(),
+		// Raw leaves are not supported by CID version 0
+		RawLeaves:  d.IsDCASRawLeaves() && d.cidSettings.Version() != dcas.CIDV0,
+		CidBuilder: d.cidBuilder(options.multihashType),
+		Dagserv:    dagService,
 		NoCopy:     false,
 	}
 
@@ -209,6 +442,16 @@ func (d *DCASClient) putFile(data io.Reader, options *options) (string, error) {
 	return root.Cid().String(), nil
 }
 
+// cidBuilder returns a CID builder for the configured CID version using the given multihash type.
+// The codec is set by the node that uses the builder.
+func (d *DCASClient) cidBuilder(mhType uint64) cid.Builder {
+	if d.cidSettings.Version() == dcas.CIDV0 {
+		return cid.V0Builder{}
+	}
+
+	return cid.V1Builder{MhType: mhType}
+}
+
 func (d *DCASClient) getNode(ctx context.Context, id string) (ipld.Node, error) {
 	logger.Debugf("Getting node for CID: %s", id)
 
@@ -233,6 +476,56 @@ func (d *DCASClient) getNode(ctx context.Context, id string) (ipld.Node, error)
 	return nd, nil
 }
 
+// getLinks returns the links to the child blocks of the node with the given CID. Only the links of unixfs
+// file nodes are returned since any other links refer to content that has its own root.
+// A node that is not found has no links.
+func (d *DCASClient) getLinks(ctx context.Context, c cid.Cid) ([]*ipld.Link, error) {
+	if c.Type() != cid.DagProtobuf {
+		return nil, nil
+	}
+
+	nd, err := d.dagService.Get(ctx, c)
+	if err != nil {
+		if err == format.ErrNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	pbNode, ok := nd.(*dag.ProtoNode)
+	if !ok {
+		return nil, nil
+	}
+
+	if _, err := unixfs.FSNodeFromBytes(pbNode.Data()); err != nil {
+		logger.Debugf("Node [%s] is not a unixfs node. Its links will not be followed.", c)
+
+		return nil, nil
+	}
+
+	return pbNode.Links(), nil
+}
+
+// newReader returns a reader for the content of the given content ID or nil if the content is not found
+func (d *DCASClient) newReader(ctx context.Context, id string) (contentReader, error) {
+	nd, err := d.getNode(ctx, id)
+	if err != nil || nd == nil {
+		return nil, err
+	}
+
+	if cborNode, ok := nd.(*cbornode.Node); ok {
+		j, err := cborNode.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		return &bytesReader{Reader: bytes.NewReader(j)}, nil
+	}
+
+	return uio.NewDagReader(ctx, nd, d.dagService)
+}
+
 func (d *DCASClient) getContent(ctx context.Context, nd ipld.Node, w io.Writer) error {
 	logger.Debugf("Getting content from CID [%s], Node Type: %s, CID Version: %d, Multi-hash type: %d, Codec: %d",
 		nd.String(), reflect.TypeOf(nd), nd.Cid().Prefix().Version, nd.Cid().Prefix().MhType, nd.Cid().Prefix().Codec)
@@ -300,9 +593,91 @@ func (d *DCASClient) getContentFromProtoNode(ctx context.Context, nd *dag.ProtoN
 	return nil
 }
 
-func getOptions(opts []Option) *options {
+// contentReader reads and seeks content of a known size
+type contentReader interface {
+	io.ReadSeeker
+	io.Closer
+	Size() uint64
+}
+
+// bytesReader is a contentReader for content that is held in memory
+type bytesReader struct {
+	*bytes.Reader
+}
+
+func (r *bytesReader) Size() uint64 {
+	return uint64(r.Reader.Size())
+}
+
+func (r *bytesReader) Close() error {
+	return nil
+}
+
+func closeReader(r io.Closer) {
+	if err := r.Close(); err != nil {
+		logger.Warnf("Error closing reader: %s", err)
+	}
+}
+
+// fileSize returns the total size of the file content for file nodes and raw nodes and zero for all other nodes
+func fileSize(nd ipld.Node) uint64 {
+	switch node := nd.(type) {
+	case *dag.RawNode:
+		return uint64(len(node.RawData()))
+	case *dag.ProtoNode:
+		fsNode, err := unixfs.FSNodeFromBytes(node.Data())
+		if err != nil {
+			return 0
+		}
+
+		return fsNode.FileSize()
+	default:
+		return 0
+	}
+}
+
+// recordingDAGService validates and records the CIDs of all of the nodes that are added
+type recordingDAGService struct {
+	format.DAGService
+	validate func(c cid.Cid) error
+	cids     []string
+}
+
+func (s *recordingDAGService) Add(ctx context.Context, nd format.Node) error {
+	if err := s.validate(nd.Cid()); err != nil {
+		return err
+	}
+
+	if err := s.DAGService.Add(ctx, nd); err != nil {
+		return err
+	}
+
+	s.cids = append(s.cids, nd.Cid().String())
+
+	return nil
+}
+
+func (s *recordingDAGService) AddMany(ctx context.Context, nds []format.Node) error {
+	for _, nd := range nds {
+		if err := s.validate(nd.Cid()); err != nil {
+			return err
+		}
+	}
+
+	if err := s.DAGService.AddMany(ctx, nds); err != nil {
+		return err
+	}
+
+	for _, nd := range nds {
+		s.cids = append(s.cids, nd.Cid().String())
+	}
+
+	return nil
+}
+
+func (d *DCASClient) getOptions(opts []Option) *options {
 	o := &options{
-		multihashType: mh.SHA2_256,
+		multihashType: d.cidSettings.MhType(),
 		nodeType:      ObjectNodeType,
 		inputEncoding: RawEncoding, // Only applies to object nodes
 		format:        RawFormat,   // Only applies to object nodes
@@ -8,17 +8,26 @@ package client
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	mh "github.com/multiformats/go-multihash"
+	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/require"
 
 	olclient "github.com/trustbloc/fabric-peer-ext/pkg/collections/client"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/gc"
 	olmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/mocks"
+	extconfig "github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
 const (
@@ -109,10 +118,13 @@ func TestDCASClient_Put(t *testing.T) {
 		require.Empty(t, cID)
 	})
 
-	t.Run("Delete", func(t *testing.T) {
+	t.Run("Delete - references not tracked -> error", func(t *testing.T) {
 		cID, err := dcas.GetCID(value1, dcas.CIDV1, cid.Raw, mh.SHA2_256)
 		require.NoError(t, err)
-		require.NoError(t, c.Delete(cID))
+
+		err = c.Delete(cID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "references are not tracked")
 	})
 }
 
@@ -235,6 +247,455 @@ func TestDCASClient_Get(t *testing.T) {
 	})
 }
 
+func TestDCASClient_GetRange(t *testing.T) {
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+	value1 := []byte("value1")
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	ds := newMockDataStore()
+
+	c, err := createClient(cfg, ds)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	cidFile1, err := c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	cidRaw1, err := c.Put(bytes.NewReader(value1))
+	require.NoError(t, err)
+
+	cborValue1 := []byte(`{"field1":"value1","field2":"value2"}`)
+	cidCbor1, err := c.Put(bytes.NewReader(cborValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(CborFormat))
+	require.NoError(t, err)
+
+	cidRaw2, err := dcas.GetCID([]byte("value2"), dcas.CIDV1, cid.Raw, mh.SHA2_256)
+	require.NoError(t, err)
+
+	t.Run("File - range across blocks -> success", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidFile1, 30, 40, b))
+		require.Equal(t, fileData1[30:70], b.Bytes())
+	})
+
+	t.Run("File - zero length -> rest of content", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidFile1, 100, 0, b))
+		require.Equal(t, fileData1[100:], b.Bytes())
+	})
+
+	t.Run("File - length past end -> available content", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidFile1, 120, 100, b))
+		require.Equal(t, fileData1[120:], b.Bytes())
+	})
+
+	t.Run("File - offset at end -> empty", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidFile1, int64(len(fileData1)), 10, b))
+		require.Empty(t, b.Bytes())
+	})
+
+	t.Run("Raw -> success", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidRaw1, 2, 3, b))
+		require.Equal(t, value1[2:5], b.Bytes())
+	})
+
+	t.Run("CBOR -> success", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidCbor1, 1, 8, b))
+		require.Equal(t, cborValue1[1:9], b.Bytes())
+	})
+
+	t.Run("Not found -> empty", func(t *testing.T) {
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.GetRange(cidRaw2, 0, 10, b))
+		require.Empty(t, b.Bytes())
+	})
+
+	t.Run("Offset out of range -> error", func(t *testing.T) {
+		err := c.GetRange(cidFile1, int64(len(fileData1)+1), 10, bytes.NewBuffer(nil))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "out of range")
+	})
+
+	t.Run("Invalid range -> error", func(t *testing.T) {
+		err := c.GetRange(cidFile1, -1, 10, bytes.NewBuffer(nil))
+		require.EqualError(t, err, "invalid range - offset: -1, length: 10")
+
+		err = c.GetRange(cidFile1, 0, -10, bytes.NewBuffer(nil))
+		require.EqualError(t, err, "invalid range - offset: 0, length: -10")
+	})
+
+	t.Run("Invalid CID -> error", func(t *testing.T) {
+		require.EqualError(t, c.GetRange("invalid", 0, 10, bytes.NewBuffer(nil)), "selected encoding not supported")
+	})
+
+	t.Run("GetReader -> success", func(t *testing.T) {
+		r, err := c.GetReader(cidFile1)
+		require.NoError(t, err)
+		require.NotNil(t, r)
+
+		pos, err := r.Seek(-10, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(fileData1)-10), pos)
+
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, fileData1[len(fileData1)-10:], data)
+
+		_, err = r.Seek(5, io.SeekStart)
+		require.NoError(t, err)
+
+		buf := make([]byte, 10)
+		_, err = io.ReadFull(r, buf)
+		require.NoError(t, err)
+		require.Equal(t, fileData1[5:15], buf)
+	})
+
+	t.Run("GetReader - not found -> nil", func(t *testing.T) {
+		r, err := c.GetReader(cidRaw2)
+		require.NoError(t, err)
+		require.Nil(t, r)
+	})
+
+	t.Run("GetNode - size", func(t *testing.T) {
+		nd, err := c.GetNode(cidFile1)
+		require.NoError(t, err)
+		require.NotNil(t, nd)
+		require.Equal(t, uint64(len(fileData1)), nd.Size)
+
+		nd, err = c.GetNode(cidRaw1)
+		require.NoError(t, err)
+		require.NotNil(t, nd)
+		require.Equal(t, uint64(len(value1)), nd.Size)
+
+		nd, err = c.GetNode(cidCbor1)
+		require.NoError(t, err)
+		require.NotNil(t, nd)
+		require.Zero(t, nd.Size)
+	})
+}
+
+func TestDCASClient_Delete(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasclientdelete")
+	require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath()))
+	defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+	gcManager, err := gc.NewManager(0)
+	require.NoError(t, err)
+	defer gcManager.Close()
+
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+	fileData2 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS and it shares chunks with the other file.")
+	fileData3 := []byte("Here is a small file.")
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	ds := newMockDataStore()
+
+	c, err := createClient(cfg, ds)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	c.tracker = gcManager.Tracker(channelID, ns1, coll1)
+
+	cidFile1, err := c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	cidFile2, err := c.Put(bytes.NewReader(fileData2), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	cidFile3, err := c.Put(bytes.NewReader(fileData3), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	cborValue := []byte(fmt.Sprintf(`{"field1":"value1","file":{"/":"%s"}}`, cidFile3))
+	cidObj, err := c.Put(bytes.NewReader(cborValue), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(CborFormat))
+	require.NoError(t, err)
+
+	// The first two chunks of each of the multi-block files are the same so the two files share two blocks.
+	// In addition, there is one block for file3 and one for the object.
+	require.Len(t, ds.data, 11)
+
+	t.Run("Invalid CID -> error", func(t *testing.T) {
+		require.EqualError(t, c.Delete("invalid"), "selected encoding not supported")
+		require.Len(t, ds.data, 11)
+	})
+
+	t.Run("Multi-block file -> unreferenced blocks deleted", func(t *testing.T) {
+		// The root and the three chunks that aren't shared with file2 are deleted
+		require.NoError(t, c.Delete(cidFile1))
+		require.Len(t, ds.data, 7)
+
+		nd, err := c.GetNode(cidFile1)
+		require.NoError(t, err)
+		require.Nil(t, nd)
+
+		// The shared chunks must still be available
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.Get(cidFile2, b))
+		require.Equal(t, fileData2, b.Bytes())
+	})
+
+	t.Run("Object with links -> linked content not deleted", func(t *testing.T) {
+		require.NoError(t, c.Delete(cidObj))
+		require.Len(t, ds.data, 6)
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.Get(cidFile3, b))
+		require.Equal(t, fileData3, b.Bytes())
+	})
+
+	t.Run("Not found -> success", func(t *testing.T) {
+		require.NoError(t, c.Delete(cidFile1, cidObj))
+		require.Len(t, ds.data, 6)
+	})
+
+	t.Run("Data store error -> error", func(t *testing.T) {
+		errExpected := fmt.Errorf("injected data store error")
+		ds.WithError(errExpected)
+		defer func() { ds.WithError(nil) }()
+
+		err = c.Delete(cidFile2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
+func TestDCASClient_GC(t *testing.T) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasclientgc")
+	require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath()))
+	defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+	gcManager, err := gc.NewManager(time.Hour)
+	require.NoError(t, err)
+	defer gcManager.Close()
+
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+	fileData2 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS and it shares chunks with the other file.")
+	fileData3 := []byte("Here is a small file.")
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	ds := newMockDataStore()
+
+	c, err := createClient(cfg, ds)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	c.tracker = gcManager.Tracker(channelID, ns1, coll1)
+	c.localBlockStore = blockstore.NewBlockstore(ds)
+
+	cidFile1, err := c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	cidFile2, err := c.Put(bytes.NewReader(fileData2), WithNodeType(FileNodeType))
+	require.NoError(t, err)
+
+	// The first two chunks of each file are the same so the two files share two blocks
+	require.Len(t, ds.data, 9)
+
+	t.Run("Delete shared block -> not deleted", func(t *testing.T) {
+		nd, err := c.GetNode(cidFile1)
+		require.NoError(t, err)
+		require.NotNil(t, nd)
+		require.NotEmpty(t, nd.Links)
+
+		// The first chunk is referenced by both files
+		require.NoError(t, c.Delete(nd.Links[0].Hash))
+		require.Len(t, ds.data, 9)
+	})
+
+	t.Run("Delete tracked root -> unreferenced blocks deleted", func(t *testing.T) {
+		require.NoError(t, c.Delete(cidFile1))
+		require.Len(t, ds.data, 5)
+
+		nd, err := c.GetNode(cidFile1)
+		require.NoError(t, err)
+		require.Nil(t, nd)
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.Get(cidFile2, b))
+		require.Equal(t, fileData2, b.Bytes())
+
+		// Nothing left to sweep
+		require.NoError(t, c.Sweep())
+		require.Len(t, ds.data, 5)
+	})
+
+	t.Run("Delete untracked root -> deleted immediately", func(t *testing.T) {
+		cID, err := dcas.GetCID(fileData3, dcas.CIDV1, cid.Raw, mh.SHA2_256)
+		require.NoError(t, err)
+
+		dsKey, err := dcas.GetCASKey(fileData3, dcas.CIDV1, cid.Raw, mh.SHA2_256)
+		require.NoError(t, err)
+
+		ds.WithData(dsKey, fileData3)
+		require.Len(t, ds.data, 6)
+
+		require.NoError(t, c.Delete(cID))
+		require.Len(t, ds.data, 5)
+	})
+
+	t.Run("Sweep - data store error -> error", func(t *testing.T) {
+		// Release the root without deleting its blocks so that the blocks are left for the sweep
+		released, err := c.tracker.Release(cidFile2)
+		require.NoError(t, err)
+		require.True(t, released)
+		require.Len(t, ds.data, 5)
+
+		errExpected := fmt.Errorf("injected data store error")
+		ds.WithError(errExpected)
+
+		err = c.Sweep()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		ds.WithError(nil)
+
+		require.NoError(t, c.Sweep())
+		require.Empty(t, ds.data)
+	})
+}
+
+func TestDCASClient_CIDSettings(t *testing.T) {
+	fileData1 := []byte("Here is some data which is longer than the maximum size for a file node in DCAS so it will have to be split into multiple chunks.")
+	value1 := []byte("value1")
+	cborValue1 := []byte(`{"field1":"value1","field2":"value2"}`)
+	pbValue1 := []byte(`{"data":"","links":[]}`)
+
+	cfg := &olmocks.DCASConfig{}
+	cfg.GetDCASMaxBlockSizeReturns(32)
+	cfg.GetDCASMaxLinksPerBlockReturns(5)
+	cfg.IsDCASRawLeavesReturns(true)
+
+	t.Run("Default settings", func(t *testing.T) {
+		c, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		// Files are stored using CID version 1
+		cID, err := c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.DagProtobuf, mh.SHA2_256)
+
+		// Protobuf objects use the CID version chosen by the parser, i.e. version 0 for sha2-256
+		cID, err = c.Put(bytes.NewReader(pbValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(ProtobufFormat))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(cID, "Qm"))
+
+		cID, err = c.Put(bytes.NewReader(pbValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(ProtobufFormat), WithMultihash(mh.SHA3_256))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.DagProtobuf, mh.SHA3_256)
+
+		cID, err = c.Put(bytes.NewReader(cborValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(CborFormat), WithMultihash(mh.SHA2_512))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.DagCBOR, mh.SHA2_512)
+	})
+
+	t.Run("CID version 0", func(t *testing.T) {
+		c, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		c.cidSettings, err = dcas.NewCIDSettings([]string{"0", "1"}, []string{"dag-pb", "raw"}, []string{"sha2-256"})
+		require.NoError(t, err)
+
+		cID, err := c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(cID, "Qm"))
+
+		nd, err := c.GetNode(cID)
+		require.NoError(t, err)
+		require.NotEmpty(t, nd.Links)
+
+		for _, l := range nd.Links {
+			require.True(t, strings.HasPrefix(l.Hash, "Qm"))
+		}
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.Get(cID, b))
+		require.Equal(t, fileData1, b.Bytes())
+
+		cID, err = c.Put(bytes.NewReader(pbValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(ProtobufFormat))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(cID, "Qm"))
+
+		// Raw objects are only supported by CID version 1
+		cID, err = c.Put(bytes.NewReader(value1))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.Raw, mh.SHA2_256)
+
+		_, err = c.Put(bytes.NewReader(value1), WithMultihash(mh.SHA3_256))
+		require.EqualError(t, err, "multihash type [22] is not supported by CID version 0")
+	})
+
+	t.Run("CID version 1", func(t *testing.T) {
+		c, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		c.cidSettings, err = dcas.NewCIDSettings([]string{"1"}, []string{"dag-pb", "raw"}, []string{"sha3-256", "blake2b-256"})
+		require.NoError(t, err)
+
+		cID, err := c.Put(bytes.NewReader(value1))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.Raw, mh.SHA3_256)
+
+		cID, err = c.Put(bytes.NewReader(value1), WithMultihash(mh.Names["blake2b-256"]))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.Raw, mh.Names["blake2b-256"])
+
+		cID, err = c.Put(bytes.NewReader(pbValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(ProtobufFormat))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.DagProtobuf, mh.SHA3_256)
+
+		cID, err = c.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+		require.NoError(t, err)
+		requireCIDPrefix(t, cID, dcas.CIDV1, cid.DagProtobuf, mh.SHA3_256)
+
+		b := bytes.NewBuffer(nil)
+		require.NoError(t, c.Get(cID, b))
+		require.Equal(t, fileData1, b.Bytes())
+
+		_, err = c.Put(bytes.NewReader(value1), WithMultihash(mh.SHA2_256))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "multihash type [18]")
+
+		_, err = c.Put(bytes.NewReader(cborValue1), WithNodeType(ObjectNodeType), WithInputEncoding(JSONEncoding), WithFormat(CborFormat))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "codec [113]")
+	})
+
+	t.Run("Import CAR with CID that is not allowed -> error", func(t *testing.T) {
+		c1, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		cID, err := c1.Put(bytes.NewReader(fileData1), WithNodeType(FileNodeType))
+		require.NoError(t, err)
+
+		car := bytes.NewBuffer(nil)
+		require.NoError(t, c1.ExportCAR(cID, car))
+
+		c2, err := createClient(cfg, newMockDataStore())
+		require.NoError(t, err)
+
+		c2.cidSettings, err = dcas.NewCIDSettings([]string{"1"}, []string{"dag-pb", "raw"}, []string{"sha3-256"})
+		require.NoError(t, err)
+
+		_, err = c2.ImportCAR(car)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid CAR block")
+	})
+}
+
 func TestCreateClient(t *testing.T) {
 	providers := &olclient.ChannelProviders{}
 
@@ -345,3 +806,13 @@ func (m *mockDataStore) Batch() (datastore.Batch, error) {
 	// Not supported
 	return nil, datastore.ErrBatchUnsupported
 }
+
+func requireCIDPrefix(t *testing.T, id string, version dcas.CIDVersion, codec, mhType uint64) {
+	c, err := cid.Decode(id)
+	require.NoError(t, err)
+
+	prefix := c.Prefix()
+	require.Equal(t, version, prefix.Version)
+	require.Equal(t, codec, prefix.Codec)
+	require.Equal(t, mhType, prefix.MhType)
+}
@@ -12,9 +12,13 @@ import (
 	"github.com/bluele/gcache"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric/common/flogging"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	"github.com/pkg/errors"
 
 	olclient "github.com/trustbloc/fabric-peer-ext/pkg/collections/client"
+	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/gc"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/ipfsdatastore"
 )
 
 var logger = flogging.MustGetLogger("ext_offledger")
@@ -35,6 +39,9 @@ type Node struct {
 	Data []byte `json:"data,omitempty"`
 	// Links contains zero or more links to other nodes
 	Links []Link `json:"links,omitempty"`
+	// Size contains the total size of the file content (including the content of all linked blocks)
+	// if the node is a file node or a raw node. Size is zero for other types of nodes.
+	Size uint64 `json:"size,omitempty"`
 }
 
 // NodeType specifies the type of node to be stored (object or file)
@@ -127,25 +134,62 @@ type DCAS interface {
 	// Get retrieves the value for the given content ID (CID).
 	Get(cid string, w io.Writer) error
 
+	// GetRange writes length bytes of the content for the given content ID (CID), starting at the given offset.
+	// If length is zero then all content from the offset is written.
+	GetRange(cid string, offset, length int64, w io.Writer) error
+
+	// GetReader returns a reader that may be used to read and seek the content for the given content ID (CID).
+	// Nil is returned if the content is not found.
+	GetReader(cid string) (io.ReadSeeker, error)
+
 	// GetNode retrieves the CAS Node for the given content ID (CID). A node contains data and/or links to other nodes.
 	GetNode(cid string) (*Node, error)
+
+	// ExportCAR writes the given content ID (CID) along with all of its descendant blocks to the given writer
+	// as a CARv1 (Content Addressable aRchive) stream.
+	ExportCAR(cid string, w io.Writer) error
+
+	// ImportCAR stores all of the blocks in the given CARv1 (Content Addressable aRchive) stream and returns
+	// the content IDs (CIDs) of the roots.
+	ImportCAR(r io.Reader) ([]string, error)
 }
 
 // Provider manages multiple clients - one per channel
 type Provider struct {
 	config
-	cache gcache.Cache
+	cache         gcache.Cache
+	gcManager     *gc.Manager
+	storeProvider collcommon.StoreProvider
 }
 
 // NewProvider returns a new client provider
-func NewProvider(providers *olclient.Providers, cfg config) *Provider {
+func NewProvider(providers *olclient.Providers, storeProvider collcommon.StoreProvider, cfg config) *Provider {
 	logger.Infof("Creating DCAS client provider.")
-	return &Provider{
-		config: cfg,
-		cache: gcache.New(0).LoaderFunc(func(key interface{}) (i interface{}, e error) {
-			return newClient(key.(cacheKey), providers, cfg)
-		}).Build(),
+
+	p := &Provider{
+		config:        cfg,
+		storeProvider: storeProvider,
 	}
+
+	// References are always tracked since they're needed in order to delete content. Unreferenced blocks
+	// are only swept periodically if an interval is configured.
+	interval := cfg.GetDCASGCInterval()
+	if interval > 0 {
+		logger.Infof("DCAS garbage collection is enabled with interval [%s]", interval)
+	}
+
+	gcManager, err := gc.NewManager(interval)
+	if err != nil {
+		panic(err)
+	}
+
+	p.gcManager = gcManager
+
+	p.cache = gcache.New(0).LoaderFunc(func(key interface{}) (i interface{}, e error) {
+		return p.newClient(key.(cacheKey), providers)
+	}).Build()
+
+	return p
 }
 
 // GetDCASClient returns the client for the given channel
@@ -173,22 +217,41 @@ func (p *Provider) CreateDCASClientStubWrapper(coll string, stub shim.ChaincodeS
 	return createCCStubWrappedClient(p.config, coll, stub)
 }
 
-func newClient(key cacheKey, p *olclient.Providers, cfg config) (*DCASClient, error) {
+// Close stops garbage collection. Close is invoked by the resource manager when the peer shuts down.
+func (p *Provider) Close() {
+	p.gcManager.Close()
+}
+
+func (p *Provider) newClient(key cacheKey, providers *olclient.Providers) (*DCASClient, error) {
 	logger.Debugf("Creating client for [%s]", key)
 
-	l := p.LedgerProvider.GetLedger(key.channelID)
+	l := providers.LedgerProvider.GetLedger(key.channelID)
 	if l == nil {
 		return nil, errors.Errorf("no ledger for channel [%s]", key.channelID)
 	}
 
-	return createOLWrappedClient(cfg, key.channelID, key.namespace, key.collection,
+	configRetriever := providers.ConfigProvider.ForChannel(key.channelID)
+
+	c, err := createOLWrappedClient(p.config, key.channelID, key.namespace, key.collection,
 		&olclient.ChannelProviders{
 			Ledger:           l,
-			Distributor:      p.GossipProvider.GetGossipService(),
-			ConfigRetriever:  p.ConfigProvider.ForChannel(key.channelID),
-			IdentityProvider: p.IdentityProvider,
+			Distributor:      providers.GossipProvider.GetGossipService(),
+			ConfigRetriever:  configRetriever,
+			IdentityProvider: providers.IdentityProvider,
 		},
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reference counts are only known to the local peer so unreferenced blocks are only swept from the local store
+	c.localBlockStore = blockstore.NewBlockstore(
+		ipfsdatastore.NewLocalStoreWrapper(key.namespace, key.collection, p.storeProvider.StoreForChannel(key.channelID), configRetriever),
+	)
+	c.tracker = p.gcManager.Tracker(key.channelID, key.namespace, key.collection)
+	p.gcManager.Register(key.channelID, key.namespace, key.collection, c)
+
+	return c, nil
 }
 
 type cacheKey struct {
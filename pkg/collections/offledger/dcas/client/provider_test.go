@@ -8,16 +8,21 @@ package client
 
 import (
 	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/require"
 
 	olclient "github.com/trustbloc/fabric-peer-ext/pkg/collections/client"
 	olmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/mocks"
+	extconfig "github.com/trustbloc/fabric-peer-ext/pkg/config"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/resource"
 )
 
 //go:generate counterfeiter -o ../../mocks/dcasconfig.gen.go --fake-name DCASConfig . config
@@ -41,8 +46,13 @@ func TestProvider(t *testing.T) {
 	}
 
 	t.Run("GetDCASClient", func(t *testing.T) {
-		p := NewProvider(providers, &olmocks.DCASConfig{})
+		viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasprovider")
+		defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+		p := NewProvider(providers, &mocks.StoreProvider{}, &olmocks.DCASConfig{})
 		require.NotNil(t, p)
+		require.NotNil(t, p.gcManager, "references should be tracked even if periodic garbage collection is disabled")
+		defer p.Close()
 
 		client1_1, err := p.GetDCASClient(channel1, ns1, coll1)
 		require.NoError(t, err)
@@ -67,9 +77,60 @@ func TestProvider(t *testing.T) {
 		require.Nil(t, client4)
 	})
 
+	t.Run("GetDCASClient with GC", func(t *testing.T) {
+		viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasprovidergc")
+		defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+		lp.GetLedgerReturns(l)
+
+		cfg := &olmocks.DCASConfig{}
+		cfg.GetDCASGCIntervalReturns(time.Hour)
+
+		p := NewProvider(providers, &mocks.StoreProvider{}, cfg)
+		require.NotNil(t, p)
+		require.NotNil(t, p.gcManager)
+		defer p.Close()
+
+		c, err := p.GetDCASClient(channel1, ns1, coll1)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.NotNil(t, c.(*DCASClient).tracker)
+	})
+
+	t.Run("Closed at peer shutdown", func(t *testing.T) {
+		viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasproviderclose")
+		defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+		cfg := &olmocks.DCASConfig{}
+		cfg.GetDCASGCIntervalReturns(time.Hour)
+
+		m := resource.NewManager()
+		m.Register(NewProvider)
+		require.NoError(t, m.Initialize(lp, providers.GossipProvider, providers.ConfigProvider, providers.IdentityProvider, &mocks.StoreProvider{}, cfg))
+		require.Len(t, m.Resources(), 7)
+
+		p, ok := m.Resources()[6].(*Provider)
+		require.True(t, ok)
+
+		c, err := p.GetDCASClient(channel1, ns1, coll1)
+		require.NoError(t, err)
+
+		_, err = c.(*DCASClient).tracker.IsReferenced("cid1")
+		require.NoError(t, err)
+
+		m.Close()
+
+		_, err = c.(*DCASClient).tracker.IsReferenced("cid1")
+		require.Error(t, err, "expecting the tracker database to be closed")
+	})
+
 	t.Run("CreateDCASClientStubWrapper", func(t *testing.T) {
-		p := NewProvider(providers, &olmocks.DCASConfig{})
+		viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasproviderstub")
+		defer func() { require.NoError(t, os.RemoveAll(extconfig.GetDCASGCLevelDBPath())) }()
+
+		p := NewProvider(providers, &mocks.StoreProvider{}, &olmocks.DCASConfig{})
 		require.NotNil(t, p)
+		defer p.Close()
 
 		stub := shimtest.NewMockStub(ns1, &mockChaincode{})
 		client, err := p.CreateDCASClientStubWrapper(coll1, stub)
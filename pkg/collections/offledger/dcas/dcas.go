@@ -11,6 +11,7 @@ import (
 
 	"github.com/hyperledger/fabric/common/flogging"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	dshelp "github.com/ipfs/go-ipfs-ds-help"
 	"github.com/pkg/errors"
@@ -18,9 +19,10 @@ import (
 
 var logger = flogging.MustGetLogger("ext_offledger")
 
-// Validator is an off-ledger validator that validates the CAS key against the value
-func Validator(_, _, _, key string, value []byte) error {
-	return ValidateDatastoreKey(key, value)
+// Validator is an off-ledger validator that validates the CAS key against the value and ensures
+// that the content ID is allowed by the CID settings of the collection
+func Validator(channelID, ns, coll, key string, value []byte) error {
+	return ValidateCollectionKey(channelID, ns, coll, key, value)
 }
 
 // Decorator is an off-ledger decorator that ensures the key is the hash of the value.
@@ -29,9 +31,10 @@ var Decorator = &decorator{}
 type decorator struct {
 }
 
-// BeforeSave ensures that the given key is the base58 encoded hash of the value.
-func (d *decorator) BeforeSave(key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error) {
-	if err := ValidateDatastoreKey(key.Key, value.Value); err != nil {
+// BeforeSave ensures that the given key is the base58 encoded hash of the value and that the
+// content ID is allowed by the CID settings of the collection.
+func (d *decorator) BeforeSave(channelID string, key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error) {
+	if err := ValidateCollectionKey(channelID, key.Namespace, key.Collection, key.Key, value.Value); err != nil {
 		return nil, nil, err
 	}
 
@@ -48,11 +51,37 @@ func (d *decorator) AfterQuery(key *storeapi.Key, value *storeapi.ExpiringValue)
 	return key, value, nil
 }
 
+// ValidateCollectionKey validates the given data store key, ensuring that it conforms to the encoding
+// used by the DCAS store and that the content ID is allowed by the CID settings of the given collection
+func ValidateCollectionKey(channelID, ns, coll, key string, value []byte) error {
+	cID, err := validateDatastoreKey(key, value)
+	if err != nil {
+		return err
+	}
+
+	settings, err := CIDSettingsProvider.GetCIDSettings(channelID, ns, coll)
+	if err != nil {
+		return err
+	}
+
+	if err := settings.Validate(cID); err != nil {
+		return errors.WithMessagef(err, "invalid data store key [%s] for collection [%s:%s]", key, ns, coll)
+	}
+
+	return nil
+}
+
 // ValidateDatastoreKey validates the given data store key, ensuring that it conforms to the
 // encoding used by the DCAS store store
 func ValidateDatastoreKey(key string, value []byte) error {
+	_, err := validateDatastoreKey(key, value)
+
+	return err
+}
+
+func validateDatastoreKey(key string, value []byte) (cid.Cid, error) {
 	if value == nil {
-		return errors.Errorf("attempt to put nil value for key [%s]", key)
+		return cid.Cid{}, errors.Errorf("attempt to put nil value for key [%s]", key)
 	}
 
 	logger.Debugf("Validating provided key [%s]", key)
@@ -66,7 +95,7 @@ func ValidateDatastoreKey(key string, value []byte) error {
 
 	cID, err := dshelp.DsKeyToCid(datastore.NewKey(k))
 	if err != nil {
-		return errors.WithMessagef(err, "invalid CAS key [%s]", key)
+		return cid.Cid{}, errors.WithMessagef(err, "invalid CAS key [%s]", key)
 	}
 
 	prefix := cID.Prefix()
@@ -77,14 +106,14 @@ func ValidateDatastoreKey(key string, value []byte) error {
 	if err != nil {
 		logger.Debugf("Error creating CID for value using CID version [%d], codec [%d] and multi-hash type [%d]: %s", prefix.Version, prefix.Codec, prefix.MhType, err)
 
-		return errors.WithMessagef(err, "error creating CID using using CID version [%d], codec [%d] and multi-hash type [%d]", prefix.Version, prefix.Codec, prefix.MhType)
+		return cid.Cid{}, errors.WithMessagef(err, "error creating CID using using CID version [%d], codec [%d] and multi-hash type [%d]", prefix.Version, prefix.Codec, prefix.MhType)
 	}
 
 	if cID.String() != expectedCid.String() {
-		return errors.Errorf("invalid data store key [%s] - CID [%s] for CID version [%d], codec [%d] and multi-hash type [%d] - it should be [%s]", key, cID, prefix.Version, prefix.Codec, prefix.MhType, expectedCid)
+		return cid.Cid{}, errors.Errorf("invalid data store key [%s] - CID [%s] for CID version [%d], codec [%d] and multi-hash type [%d] - it should be [%s]", key, cID, prefix.Version, prefix.Codec, prefix.MhType, expectedCid)
 	}
 
 	logger.Debugf("Validated CAS key [%s] using CID version [%d], codec [%d] and multi-hash type [%d]. CID: %s", key, prefix.Version, prefix.Codec, prefix.MhType, cID)
 
-	return nil
+	return cID, nil
 }
@@ -13,6 +13,9 @@ import (
 	"github.com/ipfs/go-cid"
 	mh "github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/config"
 )
 
 const (
@@ -42,6 +45,26 @@ func TestValidator(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "nil value for key")
 	})
+
+	t.Run("CID not allowed for collection -> error", func(t *testing.T) {
+		configService := &mocks.ConfigService{}
+		configService.GetReturns(config.NewValue("tx1", cidConfig2, config.FormatJSON), nil)
+
+		CIDSettingsProvider = newTestCIDSettingsProvider(t, configService)
+		defer func() { CIDSettingsProvider = &cidSettingsProvider{} }()
+
+		key, err := GetCASKey(value, CIDV1, cid.Raw, mh.SHA2_256)
+		require.NoError(t, err)
+
+		err = Validator(channel1, ns1, coll1, key, value)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "multihash type [18]")
+
+		key, err = GetCASKey(value, CIDV1, cid.Raw, mh.SHA3_256)
+		require.NoError(t, err)
+
+		require.NoError(t, Validator(channel1, ns1, coll1, key, value))
+	})
 }
 
 func TestDecorator_BeforeSave(t *testing.T) {
@@ -55,7 +78,7 @@ func TestDecorator_BeforeSave(t *testing.T) {
 		require.NoError(t, err)
 
 		key := storeapi.NewKey(txID1, ns1, coll1, dsk)
-		k, v, err := Decorator.BeforeSave(key, value)
+		k, v, err := Decorator.BeforeSave(channel1, key, value)
 		require.NoError(t, err)
 		require.Equal(t, key.Key, k.Key)
 		require.Equal(t, value, v)
@@ -63,13 +86,13 @@ func TestDecorator_BeforeSave(t *testing.T) {
 
 	t.Run("Empty key -> fail", func(t *testing.T) {
 		key := storeapi.NewKey(txID1, ns1, coll1, "")
-		_, _, err := Decorator.BeforeSave(key, value)
+		_, _, err := Decorator.BeforeSave(channel1, key, value)
 		require.Error(t, err)
 	})
 
 	t.Run("Invalid key -> error", func(t *testing.T) {
 		key := storeapi.NewKey(txID1, ns1, coll1, "key1")
-		k, v, err := Decorator.BeforeSave(key, value)
+		k, v, err := Decorator.BeforeSave(channel1, key, value)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid CAS key")
 		require.Nil(t, k)
@@ -77,7 +100,7 @@ func TestDecorator_BeforeSave(t *testing.T) {
 	})
 
 	t.Run("Nil value -> error", func(t *testing.T) {
-		k, v, err := Decorator.BeforeSave(storeapi.NewKey(txID1, ns1, coll1, "key1"), &storeapi.ExpiringValue{})
+		k, v, err := Decorator.BeforeSave(channel1, storeapi.NewKey(txID1, ns1, coll1, "key1"), &storeapi.ExpiringValue{})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "nil value for key")
 		require.Nil(t, k)
@@ -0,0 +1,160 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+)
+
+// Sweeper removes unreferenced blocks from a DCAS collection
+type Sweeper interface {
+	Sweep() error
+}
+
+// Manager maintains a reference tracker for each DCAS collection and periodically invokes
+// the registered sweepers in order to remove unreferenced blocks. References are tracked even
+// if periodic sweeps are disabled since they're needed in order to delete content.
+type Manager struct {
+	mutex           sync.RWMutex
+	leveldbProvider *leveldbhelper.Provider
+	trackers        map[string]*Tracker
+	sweepers        map[string]Sweeper
+	done            chan struct{}
+	stopped         chan struct{}
+	closed          bool
+}
+
+// NewManager returns a new garbage collection manager which sweeps at the given interval.
+// Periodic sweeps are disabled if the interval is zero.
+func NewManager(interval time.Duration) (*Manager, error) {
+	dbPath := config.GetDCASGCLevelDBPath()
+
+	logger.Debugf("Constructing DCAS GC manager - dbPath=%s, interval=%s", dbPath, interval)
+
+	ldbProvider, err := leveldbhelper.NewProvider(
+		&leveldbhelper.Conf{
+			DBPath: dbPath,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		leveldbProvider: ldbProvider,
+		trackers:        make(map[string]*Tracker),
+		sweepers:        make(map[string]Sweeper),
+		done:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+	}
+
+	m.periodicSweep(interval)
+
+	return m, nil
+}
+
+// Tracker returns the reference tracker for the given collection
+func (m *Manager) Tracker(channelID, ns, coll string) *Tracker {
+	name := trackerName(channelID, ns, coll)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.trackers[name]
+	if !ok {
+		t = newTracker(name, m.leveldbProvider.GetDBHandle(name))
+		m.trackers[name] = t
+	}
+
+	return t
+}
+
+// Register registers a sweeper for the given collection. The sweeper is invoked at every interval.
+func (m *Manager) Register(channelID, ns, coll string, sweeper Sweeper) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.sweepers[trackerName(channelID, ns, coll)] = sweeper
+}
+
+// Close stops the periodic sweep and closes the database
+func (m *Manager) Close() {
+	m.mutex.Lock()
+
+	if m.closed {
+		m.mutex.Unlock()
+
+		return
+	}
+
+	m.closed = true
+	close(m.done)
+
+	m.mutex.Unlock()
+
+	// Wait for a sweep that may be in progress to complete before closing the database
+	<-m.stopped
+
+	m.leveldbProvider.Close()
+}
+
+// Sweep invokes all of the registered sweepers
+func (m *Manager) Sweep() {
+	for name, s := range m.getSweepers() {
+		if err := s.Sweep(); err != nil {
+			logger.Errorf("Error sweeping unreferenced blocks for [%s]: %s", name, err)
+		}
+	}
+}
+
+func (m *Manager) getSweepers() map[string]Sweeper {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	sweepers := make(map[string]Sweeper)
+	for name, s := range m.sweepers {
+		sweepers[name] = s
+	}
+
+	return sweepers
+}
+
+func (m *Manager) periodicSweep(interval time.Duration) {
+	if interval <= 0 {
+		logger.Infof("Periodic DCAS garbage collection is disabled")
+
+		close(m.stopped)
+
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer close(m.stopped)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Sweep()
+			case <-m.done:
+				logger.Infof("Periodic DCAS garbage collection is exiting")
+				return
+			}
+		}
+	}()
+}
+
+func trackerName(channelID, ns, coll string) string {
+	return fmt.Sprintf("%s$%s$%s", channelID, ns, coll)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gc
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	viper "github.com/spf13/viper2015"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+)
+
+const (
+	channel1 = "channel1"
+	ns1      = "ns1"
+	coll1    = "coll1"
+	coll2    = "coll2"
+)
+
+func TestManager(t *testing.T) {
+	defer removeDBPath(t)
+
+	m, err := NewManager(50 * time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	defer m.Close()
+
+	t.Run("Tracker", func(t *testing.T) {
+		t1 := m.Tracker(channel1, ns1, coll1)
+		require.NotNil(t, t1)
+		require.True(t, t1 == m.Tracker(channel1, ns1, coll1))
+
+		t2 := m.Tracker(channel1, ns1, coll2)
+		require.NotNil(t, t2)
+		require.False(t, t1 == t2)
+	})
+
+	t.Run("Periodic sweep", func(t *testing.T) {
+		s1 := &mockSweeper{}
+		s2 := &mockSweeper{err: errors.New("injected sweep error")}
+
+		m.Register(channel1, ns1, coll1, s1)
+		m.Register(channel1, ns1, coll2, s2)
+
+		time.Sleep(200 * time.Millisecond)
+
+		require.True(t, s1.Count() > 0)
+		require.True(t, s2.Count() > 0)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		m.Close()
+
+		// Multiple calls to Close are allowed
+		require.NotPanics(t, m.Close)
+	})
+}
+
+func TestMain(m *testing.M) {
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/dcasgc")
+	removeDBPath(nil)
+
+	os.Exit(m.Run())
+}
+
+type mockSweeper struct {
+	count int32
+	err   error
+}
+
+func (s *mockSweeper) Sweep() error {
+	atomic.AddInt32(&s.count, 1)
+
+	return s.err
+}
+
+func (s *mockSweeper) Count() int {
+	return int(atomic.LoadInt32(&s.count))
+}
+
+func removeDBPath(t testing.TB) {
+	if err := os.RemoveAll(config.GetDCASGCLevelDBPath()); err != nil && t != nil {
+		t.Fatalf("Err: %s", err)
+	}
+}
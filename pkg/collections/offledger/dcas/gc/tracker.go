@@ -0,0 +1,216 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gc
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("ext_offledger")
+
+const (
+	rootPrefix         = "r"
+	refCountPrefix     = "c"
+	unreferencedPrefix = "u"
+	keySep             = "!"
+)
+
+// Tracker records the blocks that make up each DCAS root along with a reference count for each block.
+// A block is referenced once by every root that contains it. When the reference count of a block drops
+// to zero then the block is marked as unreferenced and may be removed by a sweep.
+type Tracker struct {
+	mutex sync.Mutex
+	db    *leveldbhelper.DBHandle
+	name  string
+}
+
+func newTracker(name string, db *leveldbhelper.DBHandle) *Tracker {
+	return &Tracker{
+		name: name,
+		db:   db,
+	}
+}
+
+// AddRoot records the given root along with the CIDs of all of the blocks that the root is made up of
+// (including the root itself). The reference count of each block is incremented. Adding a root that is
+// already tracked has no effect.
+func (t *Tracker) AddRoot(root string, blocks []string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	rootKey := newKey(rootPrefix, root)
+
+	existing, err := t.db.Get(rootKey)
+	if err != nil {
+		return errors.WithMessagef(err, "error loading root [%s]", root)
+	}
+
+	if existing != nil {
+		logger.Debugf("[%s] Root [%s] is already tracked", t.name, root)
+
+		return nil
+	}
+
+	blocks = unique(blocks)
+
+	rootBytes, err := json.Marshal(blocks)
+	if err != nil {
+		return errors.WithMessage(err, "error marshalling blocks")
+	}
+
+	batch := t.db.NewUpdateBatch()
+	batch.Put(rootKey, rootBytes)
+
+	for _, c := range blocks {
+		count, err := t.refCount(c)
+		if err != nil {
+			return err
+		}
+
+		batch.Put(newKey(refCountPrefix, c), []byte(strconv.Itoa(count+1)))
+		batch.Delete(newKey(unreferencedPrefix, c))
+	}
+
+	logger.Debugf("[%s] Adding root [%s] with %d blocks", t.name, root, len(blocks))
+
+	return t.db.WriteBatch(batch, true)
+}
+
+// Release removes the given root and decrements the reference count of each of its blocks. Blocks whose
+// reference count drops to zero are marked as unreferenced. False is returned if the root is not tracked.
+func (t *Tracker) Release(root string) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	rootKey := newKey(rootPrefix, root)
+
+	rootBytes, err := t.db.Get(rootKey)
+	if err != nil {
+		return false, errors.WithMessagef(err, "error loading root [%s]", root)
+	}
+
+	if rootBytes == nil {
+		logger.Debugf("[%s] Root [%s] is not tracked", t.name, root)
+
+		return false, nil
+	}
+
+	var blocks []string
+	if err := json.Unmarshal(rootBytes, &blocks); err != nil {
+		return false, errors.WithMessagef(err, "error unmarshalling blocks for root [%s]", root)
+	}
+
+	batch := t.db.NewUpdateBatch()
+	batch.Delete(rootKey)
+
+	for _, c := range blocks {
+		count, err := t.refCount(c)
+		if err != nil {
+			return false, err
+		}
+
+		if count <= 1 {
+			logger.Debugf("[%s] Block [%s] is no longer referenced", t.name, c)
+
+			batch.Delete(newKey(refCountPrefix, c))
+			batch.Put(newKey(unreferencedPrefix, c), []byte{})
+		} else {
+			batch.Put(newKey(refCountPrefix, c), []byte(strconv.Itoa(count-1)))
+		}
+	}
+
+	logger.Debugf("[%s] Releasing root [%s] with %d blocks", t.name, root, len(blocks))
+
+	return true, t.db.WriteBatch(batch, true)
+}
+
+// IsReferenced returns true if the block with the given CID is referenced by at least one root
+func (t *Tracker) IsReferenced(c string) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	count, err := t.refCount(c)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Unreferenced returns the CIDs of all blocks that are no longer referenced by any root
+func (t *Tracker) Unreferenced() ([]string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	it, err := t.db.GetIterator(newKey(unreferencedPrefix, ""), []byte(unreferencedPrefix+string(keySep[0]+1)))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var cids []string
+	for it.Next() {
+		cids = append(cids, string(it.Key()[len(unreferencedPrefix)+len(keySep):]))
+	}
+
+	return cids, it.Error()
+}
+
+// Purged removes the unreferenced markers for the given CIDs after the blocks have been removed from the store
+func (t *Tracker) Purged(cids ...string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	batch := t.db.NewUpdateBatch()
+	for _, c := range cids {
+		batch.Delete(newKey(unreferencedPrefix, c))
+	}
+
+	return t.db.WriteBatch(batch, true)
+}
+
+func (t *Tracker) refCount(c string) (int, error) {
+	v, err := t.db.Get(newKey(refCountPrefix, c))
+	if err != nil {
+		return 0, errors.WithMessagef(err, "error loading reference count for [%s]", c)
+	}
+
+	if v == nil {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, errors.WithMessagef(err, "invalid reference count for [%s]", c)
+	}
+
+	return count, nil
+}
+
+func newKey(prefix, c string) []byte {
+	return []byte(prefix + keySep + c)
+}
+
+func unique(cids []string) []string {
+	m := make(map[string]struct{})
+
+	var result []string
+	for _, c := range cids {
+		if _, ok := m[c]; !ok {
+			m[c] = struct{}{}
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
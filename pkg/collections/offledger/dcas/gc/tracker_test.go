@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	root1  = "root1"
+	root2  = "root2"
+	block1 = "block1"
+	block2 = "block2"
+	block3 = "block3"
+)
+
+func TestTracker(t *testing.T) {
+	defer removeDBPath(t)
+
+	m, err := NewManager(time.Hour)
+	require.NoError(t, err)
+	defer m.Close()
+
+	tracker := m.Tracker(channel1, ns1, coll1)
+	require.NotNil(t, tracker)
+
+	t.Run("Release untracked root", func(t *testing.T) {
+		released, err := tracker.Release(root1)
+		require.NoError(t, err)
+		require.False(t, released)
+	})
+
+	t.Run("Shared blocks", func(t *testing.T) {
+		require.NoError(t, tracker.AddRoot(root1, []string{root1, block1, block2, block2}))
+		require.NoError(t, tracker.AddRoot(root2, []string{root2, block2, block3}))
+
+		// Adding the same root again should have no effect
+		require.NoError(t, tracker.AddRoot(root2, []string{root2, block2, block3}))
+
+		unreferenced, err := tracker.Unreferenced()
+		require.NoError(t, err)
+		require.Empty(t, unreferenced)
+
+		referenced, err := tracker.IsReferenced(block2)
+		require.NoError(t, err)
+		require.True(t, referenced)
+
+		released, err := tracker.Release(root1)
+		require.NoError(t, err)
+		require.True(t, released)
+
+		referenced, err = tracker.IsReferenced(block1)
+		require.NoError(t, err)
+		require.False(t, referenced)
+
+		referenced, err = tracker.IsReferenced(block2)
+		require.NoError(t, err)
+		require.True(t, referenced)
+
+		unreferenced, err = tracker.Unreferenced()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{root1, block1}, unreferenced)
+
+		released, err = tracker.Release(root1)
+		require.NoError(t, err)
+		require.False(t, released)
+
+		released, err = tracker.Release(root2)
+		require.NoError(t, err)
+		require.True(t, released)
+
+		unreferenced, err = tracker.Unreferenced()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{root1, root2, block1, block2, block3}, unreferenced)
+
+		require.NoError(t, tracker.Purged(root1, block1))
+
+		unreferenced, err = tracker.Unreferenced()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{root2, block2, block3}, unreferenced)
+	})
+
+	t.Run("Block referenced again", func(t *testing.T) {
+		require.NoError(t, tracker.AddRoot(root1, []string{root1, block2}))
+
+		unreferenced, err := tracker.Unreferenced()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{root2, block3}, unreferenced)
+	})
+
+	t.Run("Separate collections", func(t *testing.T) {
+		tracker2 := m.Tracker(channel1, ns1, coll2)
+
+		unreferenced, err := tracker2.Unreferenced()
+		require.NoError(t, err)
+		require.Empty(t, unreferenced)
+	})
+}
@@ -50,3 +50,31 @@ func (s *ChaincodeStubWrapper) Put(key datastore.Key, value []byte) error {
 
 	return s.stub.PutPrivateData(s.collection, key.String(), value)
 }
+
+// Has returns whether the `key` is mapped to a `value`.
+func (s *ChaincodeStubWrapper) Has(key datastore.Key) (bool, error) {
+	v, err := s.stub.GetPrivateData(s.collection, key.String())
+	if err != nil {
+		return false, err
+	}
+
+	return len(v) > 0, nil
+}
+
+// GetSize returns the size of the `value` named by `key`.
+// GetSize will return ErrNotFound if the key is not mapped to a value.
+func (s *ChaincodeStubWrapper) GetSize(key datastore.Key) (int, error) {
+	v, err := s.Get(key)
+	if err != nil {
+		return -1, err
+	}
+
+	return len(v), nil
+}
+
+// Delete removes the value for given `key`.
+func (s *ChaincodeStubWrapper) Delete(key datastore.Key) error {
+	logger.Debugf("Deleting key %s", key)
+
+	return s.stub.DelPrivateData(s.collection, key.String())
+}
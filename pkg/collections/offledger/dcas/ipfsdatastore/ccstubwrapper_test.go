@@ -52,6 +52,55 @@ func TestStubWrapper_Get(t *testing.T) {
 	})
 }
 
+func TestStubWrapper_Has(t *testing.T) {
+	dskey := datastore.NewKey(key1)
+
+	stub := shimtest.NewMockStub(ns1, &mockChaincode{})
+	c := NewStubWrapper(coll1, stub)
+
+	has, err := c.Has(dskey)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, stub.PutPrivateData(coll1, dskey.String(), []byte("value1")))
+
+	has, err = c.Has(dskey)
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestStubWrapper_GetSize(t *testing.T) {
+	value1 := []byte("value1")
+	dskey := datastore.NewKey(key1)
+
+	stub := shimtest.NewMockStub(ns1, &mockChaincode{})
+	c := NewStubWrapper(coll1, stub)
+
+	size, err := c.GetSize(dskey)
+	require.EqualError(t, err, datastore.ErrNotFound.Error())
+	require.Equal(t, -1, size)
+
+	require.NoError(t, stub.PutPrivateData(coll1, dskey.String(), value1))
+
+	size, err = c.GetSize(dskey)
+	require.NoError(t, err)
+	require.Equal(t, len(value1), size)
+}
+
+func TestStubWrapper_Delete(t *testing.T) {
+	dskey := datastore.NewKey(key1)
+
+	stub := &mockStub{MockStub: shimtest.NewMockStub(ns1, &mockChaincode{})}
+	require.NoError(t, stub.PutPrivateData(coll1, dskey.String(), []byte("value1")))
+
+	c := NewStubWrapper(coll1, stub)
+	require.NoError(t, c.Delete(dskey))
+
+	value, err := c.Get(dskey)
+	require.EqualError(t, err, datastore.ErrNotFound.Error())
+	require.Empty(t, value)
+}
+
 type mockChaincode struct {
 }
 
@@ -61,3 +110,12 @@ func (cc *mockChaincode) Init(shim.ChaincodeStubInterface) pb.Response {
 func (cc *mockChaincode) Invoke(shim.ChaincodeStubInterface) pb.Response {
 	return shim.Success(nil)
 }
+
+// mockStub adds private data deletion, which isn't implemented by shimtest.MockStub
+type mockStub struct {
+	*shimtest.MockStub
+}
+
+func (s *mockStub) DelPrivateData(collection, key string) error {
+	return s.PutPrivateData(collection, key, nil)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
+	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("ext_offledger")
@@ -25,20 +26,9 @@ func (s *dataStore) Has(key datastore.Key) (bool, error) {
 	return false, nil
 }
 
-// GetSize returns the size of the `value` named by `key`.
-// Note: This function is never called for the DCAS so we'll leave
-// it unimplemented. If it is ever called in the future then
-// an implementation will need to be provided.
-func (s *dataStore) GetSize(key datastore.Key) (int, error) {
-	panic("not implemented")
-}
-
-// Query searches the datastore and returns a query result.
-// Note: This function is never called for the DCAS so we'll leave
-// it unimplemented. If it is ever called in the future then
-// an implementation will need to be provided.
+// Query is not supported since off-ledger data may not be queried by key prefix.
 func (s *dataStore) Query(q query.Query) (query.Results, error) {
-	panic("not implemented")
+	return nil, errors.New("query not supported by DCAS data store")
 }
 
 // Sync does nothing.
@@ -58,11 +48,3 @@ func (s *dataStore) Batch() (datastore.Batch, error) {
 	// Not supported
 	return nil, datastore.ErrBatchUnsupported
 }
-
-// Delete removes the value for given `key`.
-// Note: This function is never called for the DCAS so we'll leave
-// it unimplemented. If it is ever called in the future then
-// an implementation will need to be provided.
-func (s *dataStore) Delete(key datastore.Key) error {
-	panic("not implemented")
-}
@@ -41,23 +41,8 @@ func TestDataStore_Has(t *testing.T) {
 
 func TestDataStore_Query(t *testing.T) {
 	ds := &dataStore{}
-	require.Panics(t, func() {
-		_, err := ds.Query(query.Query{})
-		require.NoError(t, err)
-	})
-}
-
-func TestDataStore_Delete(t *testing.T) {
-	ds := &dataStore{}
-	require.Panics(t, func() {
-		require.NoError(t, ds.Delete(datastore.NewKey(key1)))
-	})
-}
-
-func TestDataStore_GetSize(t *testing.T) {
-	ds := &dataStore{}
-	require.Panics(t, func() {
-		_, err := ds.GetSize(datastore.NewKey(key1))
-		require.NoError(t, err)
-	})
+	results, err := ds.Query(query.Query{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "query not supported")
+	require.Nil(t, results)
 }
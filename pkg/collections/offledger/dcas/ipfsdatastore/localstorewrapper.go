@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ipfsdatastore
+
+import (
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/hyperledger/fabric/extensions/collections/api/support"
+	"github.com/ipfs/go-datastore"
+	"github.com/pkg/errors"
+)
+
+type localStore interface {
+	GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error)
+	DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error
+}
+
+// LocalStoreWrapper implements the github.com/ipfs/go-datastore.Batching interface.
+// This implementation uses the local peer's off-ledger store, i.e. data is not retrieved from other
+// peers and deletes are not distributed to other peers. Put is not supported.
+type LocalStoreWrapper struct {
+	*dataStore
+	store           localStore
+	configRetriever support.CollectionConfigRetriever
+	namespace       string
+	collection      string
+}
+
+// NewLocalStoreWrapper returns an IPFS data store that wraps the local off-ledger store
+func NewLocalStoreWrapper(ns, coll string, store localStore, configRetriever support.CollectionConfigRetriever) *LocalStoreWrapper {
+	return &LocalStoreWrapper{
+		store:           store,
+		configRetriever: configRetriever,
+		namespace:       ns,
+		collection:      coll,
+	}
+}
+
+// Get retrieves the object `value` named by `key` from the local store.
+// Get will return ErrNotFound if the key is not mapped to a value.
+func (s *LocalStoreWrapper) Get(key datastore.Key) ([]byte, error) {
+	logger.Debugf("Getting key %s from local store", key)
+
+	v, err := s.store.GetData(storeapi.NewKey("", s.namespace, s.collection, key.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil || len(v.Value) == 0 {
+		return nil, datastore.ErrNotFound
+	}
+
+	return v.Value, nil
+}
+
+// Put is not supported since data must be distributed to other peers when it is stored
+func (s *LocalStoreWrapper) Put(key datastore.Key, value []byte) error {
+	return errors.New("put not supported by local DCAS data store")
+}
+
+// GetSize returns the size of the `value` named by `key`.
+// GetSize will return ErrNotFound if the key is not mapped to a value.
+func (s *LocalStoreWrapper) GetSize(key datastore.Key) (int, error) {
+	v, err := s.Get(key)
+	if err != nil {
+		return -1, err
+	}
+
+	return len(v), nil
+}
+
+// Delete removes the value for given `key` from the local store.
+func (s *LocalStoreWrapper) Delete(key datastore.Key) error {
+	logger.Debugf("Deleting key %s from local store", key)
+
+	config, err := s.configRetriever.Config(s.namespace, s.collection)
+	if err != nil {
+		return errors.WithMessagef(err, "error retrieving collection config for [%s:%s]", s.namespace, s.collection)
+	}
+
+	return s.store.DeleteData(config, storeapi.NewMultiKey("", s.namespace, s.collection, key.String()))
+}
@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ipfsdatastore
+
+import (
+	"fmt"
+	"testing"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	spmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/storeprovider/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+)
+
+func TestLocalStoreWrapper(t *testing.T) {
+	value1 := []byte("value1")
+	dsKey := datastore.NewKey(key1)
+
+	configRetriever := mocks.NewCollectionConfigRetriever().WithCollectionConfig(&pb.StaticCollectionConfig{Name: coll1})
+
+	t.Run("Put -> error", func(t *testing.T) {
+		c := NewLocalStoreWrapper(ns1, coll1, spmocks.NewStore(), configRetriever)
+		require.EqualError(t, c.Put(dsKey, value1), "put not supported by local DCAS data store")
+	})
+
+	t.Run("Get, GetSize and Delete -> success", func(t *testing.T) {
+		store := spmocks.NewStore().Data(storeapi.NewKey("", ns1, coll1, dsKey.String()), &storeapi.ExpiringValue{Value: value1})
+
+		c := NewLocalStoreWrapper(ns1, coll1, store, configRetriever)
+
+		value, err := c.Get(dsKey)
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+
+		size, err := c.GetSize(dsKey)
+		require.NoError(t, err)
+		require.Equal(t, len(value1), size)
+
+		require.NoError(t, c.Delete(dsKey))
+
+		value, err = c.Get(dsKey)
+		require.EqualError(t, err, datastore.ErrNotFound.Error())
+		require.Nil(t, value)
+
+		size, err = c.GetSize(dsKey)
+		require.EqualError(t, err, datastore.ErrNotFound.Error())
+		require.Equal(t, -1, size)
+	})
+
+	t.Run("Store error -> error", func(t *testing.T) {
+		errExpected := fmt.Errorf("injected store error")
+
+		c := NewLocalStoreWrapper(ns1, coll1, spmocks.NewStore().Error(errExpected), configRetriever)
+
+		_, err := c.Get(dsKey)
+		require.EqualError(t, err, errExpected.Error())
+
+		require.EqualError(t, c.Delete(dsKey), errExpected.Error())
+	})
+
+	t.Run("Collection config error -> error", func(t *testing.T) {
+		c := NewLocalStoreWrapper(ns1, "coll2", spmocks.NewStore(), configRetriever)
+
+		err := c.Delete(dsKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error retrieving collection config")
+	})
+}
@@ -53,3 +53,31 @@ func (s *OffLedgerClientWrapper) Put(key datastore.Key, value []byte) error {
 
 	return s.olclient.Put(s.namespace, s.collection, key.String(), value)
 }
+
+// Has returns whether the `key` is mapped to a `value`.
+func (s *OffLedgerClientWrapper) Has(key datastore.Key) (bool, error) {
+	v, err := s.olclient.Get(s.namespace, s.collection, key.String())
+	if err != nil {
+		return false, err
+	}
+
+	return len(v) > 0, nil
+}
+
+// GetSize returns the size of the `value` named by `key`.
+// GetSize will return ErrNotFound if the key is not mapped to a value.
+func (s *OffLedgerClientWrapper) GetSize(key datastore.Key) (int, error) {
+	v, err := s.Get(key)
+	if err != nil {
+		return -1, err
+	}
+
+	return len(v), nil
+}
+
+// Delete removes the value for given `key`.
+func (s *OffLedgerClientWrapper) Delete(key datastore.Key) error {
+	logger.Debugf("Deleting key %s", key)
+
+	return s.olclient.Delete(s.namespace, s.collection, key.String())
+}
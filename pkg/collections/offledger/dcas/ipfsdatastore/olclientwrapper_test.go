@@ -69,3 +69,80 @@ func TestOffLedgerClientWrapper_Get(t *testing.T) {
 		require.Empty(t, value)
 	})
 }
+
+func TestOffLedgerClientWrapper_Has(t *testing.T) {
+	t.Run("Has - not found -> false", func(t *testing.T) {
+		c := NewOLClientWrapper(ns1, coll1, &olmocks.OffLedgerClient{})
+		has, err := c.Has(datastore.NewKey(key1))
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+
+	t.Run("Has - found -> true", func(t *testing.T) {
+		olClient := &olmocks.OffLedgerClient{}
+		olClient.GetReturns([]byte("value1"), nil)
+
+		c := NewOLClientWrapper(ns1, coll1, olClient)
+		has, err := c.Has(datastore.NewKey(key1))
+		require.NoError(t, err)
+		require.True(t, has)
+	})
+
+	t.Run("Has - olclient error -> error", func(t *testing.T) {
+		errExpected := fmt.Errorf("injected OL client error")
+
+		olClient := &olmocks.OffLedgerClient{}
+		olClient.GetReturns(nil, errExpected)
+
+		c := NewOLClientWrapper(ns1, coll1, olClient)
+		has, err := c.Has(datastore.NewKey(key1))
+		require.EqualError(t, err, errExpected.Error())
+		require.False(t, has)
+	})
+}
+
+func TestOffLedgerClientWrapper_GetSize(t *testing.T) {
+	t.Run("GetSize - not found -> error", func(t *testing.T) {
+		c := NewOLClientWrapper(ns1, coll1, &olmocks.OffLedgerClient{})
+		size, err := c.GetSize(datastore.NewKey(key1))
+		require.EqualError(t, err, datastore.ErrNotFound.Error())
+		require.Equal(t, -1, size)
+	})
+
+	t.Run("GetSize -> success", func(t *testing.T) {
+		value1 := []byte("value1")
+
+		olClient := &olmocks.OffLedgerClient{}
+		olClient.GetReturns(value1, nil)
+
+		c := NewOLClientWrapper(ns1, coll1, olClient)
+		size, err := c.GetSize(datastore.NewKey(key1))
+		require.NoError(t, err)
+		require.Equal(t, len(value1), size)
+	})
+}
+
+func TestOffLedgerClientWrapper_Delete(t *testing.T) {
+	t.Run("Delete -> success", func(t *testing.T) {
+		olClient := &olmocks.OffLedgerClient{}
+
+		c := NewOLClientWrapper(ns1, coll1, olClient)
+		require.NoError(t, c.Delete(datastore.NewKey(key1)))
+		require.Equal(t, 1, olClient.DeleteCallCount())
+
+		ns, coll, keys := olClient.DeleteArgsForCall(0)
+		require.Equal(t, ns1, ns)
+		require.Equal(t, coll1, coll)
+		require.Equal(t, []string{datastore.NewKey(key1).String()}, keys)
+	})
+
+	t.Run("Delete - olclient error -> error", func(t *testing.T) {
+		errExpected := fmt.Errorf("injected OL client error")
+
+		olClient := &olmocks.OffLedgerClient{}
+		olClient.DeleteReturns(errExpected)
+
+		c := NewOLClientWrapper(ns1, coll1, olClient)
+		require.EqualError(t, c.Delete(datastore.NewKey(key1)), errExpected.Error())
+	})
+}
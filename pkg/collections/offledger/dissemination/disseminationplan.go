@@ -22,6 +22,7 @@ import (
 	viper "github.com/spf13/viper2015"
 
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/quota"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/implicitpolicy"
 )
 
@@ -46,7 +47,7 @@ func ComputeDisseminationPlan(
 		return nil, true, errors.WithMessage(err, "error unmarshalling KV read/write set")
 	}
 
-	err = validateAll(collConfig.Type, kvRwSet)
+	err = validateAll(channelID, ns, rwSet.CollectionName, collConfig.Type, kvRwSet)
 	if err != nil {
 		return nil, true, errors.WithMessagef(err, "one or more keys did not validate for collection [%s:%s]", ns, rwSet.CollectionName)
 	}
@@ -84,23 +85,35 @@ func ComputeDisseminationPlan(
 	}}, true, nil
 }
 
-func validateAll(collType pb.CollectionType, kvRWSet *kvrwset.KVRWSet) error {
+func validateAll(channelID, ns, coll string, collType pb.CollectionType, kvRWSet *kvrwset.KVRWSet) error {
 	for _, ws := range kvRWSet.Writes {
-		if err := validate(collType, ws); err != nil {
+		if err := validate(channelID, ns, coll, collType, ws); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func validate(collType pb.CollectionType, ws *kvrwset.KVWrite) error {
-	if collType == pb.CollectionType_COL_DCAS && ws.Value != nil {
-		return dcas.ValidateDatastoreKey(ws.Key, ws.Value)
+func validate(channelID, ns, coll string, collType pb.CollectionType, ws *kvrwset.KVWrite) error {
+	if ws.IsDelete || ws.Value == nil {
+		return nil
+	}
+
+	// The key count and total bytes quotas are enforced by the store since they depend on the collection's usage
+	if err := getLimits(ns, coll).ValidateValueSize(ns, coll, ws.Key, len(ws.Value)); err != nil {
+		return err
+	}
+
+	if collType == pb.CollectionType_COL_DCAS {
+		return dcas.ValidateCollectionKey(channelID, ns, coll, ws.Key, ws.Value)
 	}
 
 	return nil
 }
 
+// getLimits returns the limits of the given collection. This variable may be overridden by unit tests.
+var getLimits = quota.GetLimits
+
 // unmarshalKVRWSet unmarshals the given KV rw-set bytes. This variable may be overridden by unit tests.
 var unmarshalKVRWSet = func(bytes []byte) (*kvrwset.KVRWSet, error) {
 	kvRwSet := &kvrwset.KVRWSet{}
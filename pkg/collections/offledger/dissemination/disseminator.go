@@ -101,6 +101,18 @@ func (d *Disseminator) ResolvePeersForRetrieval(includePeer PeerFilter) discover
 	return peersForRetrieval
 }
 
+// ResolvePeersForQuery resolves to the set of remote committers in the collection's member orgs to which
+// a query should be sent. Committers are chosen since they always store the collection data.
+func (d *Disseminator) ResolvePeersForQuery() discovery.PeerGroup {
+	orgs := d.resolveOrgsForRetrieval()
+
+	peers := d.getPeersWithRole(roles.CommitterRole, orgs).Remote()
+
+	logger.Debugf("[%s] Peers for query from orgs %s: %s", d.ChannelID(), orgs, peers)
+
+	return peers
+}
+
 func (d *Disseminator) resolveOrgsForRetrieval() []string {
 	orgs := keys(d.policy.MemberOrgs())
 
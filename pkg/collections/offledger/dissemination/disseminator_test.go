@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/quota"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/implicitpolicy"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
@@ -298,6 +299,48 @@ func TestDisseminator_ResolvePeersForRetrieval(t *testing.T) {
 	})
 }
 
+func TestDisseminator_ResolvePeersForQuery(t *testing.T) {
+	channelID := "testchannel"
+
+	gossip := mocks.NewMockGossipAdapter().
+		Self(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID)).
+		Member(org1MSPID, mocks.NewMember(p2Org1Endpoint, p2Org1PKIID, committerRole)).
+		Member(org1MSPID, mocks.NewMember(p3Org1Endpoint, p3Org1PKIID, endorserRole)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID, endorserRole)).
+		Member(org2MSPID, mocks.NewMember(p2Org2Endpoint, p2Org2PKIID, committerRole)).
+		Member(org3MSPID, mocks.NewMember(p1Org3Endpoint, p1Org3PKIID, committerRole, endorserRole)).
+		Member(org4MSPID, mocks.NewMember(p1Org4Endpoint, p1Org4PKIID, committerRole))
+
+	d := New(channelID, ns1, coll1,
+		&mocks.MockAccessPolicy{
+			MaxPeerCount: 2,
+			Orgs:         []string{org1MSPID, org2MSPID, org3MSPID},
+		}, gossip)
+
+	t.Run("All committers in member orgs", func(t *testing.T) {
+		peers := d.ResolvePeersForQuery()
+		require.Len(t, peers, 3)
+
+		for _, p := range peers {
+			require.True(t, p.HasRole(roles.CommitterRole))
+			require.NotEqual(t, org4MSPID, p.MSPID)
+		}
+	})
+
+	t.Run("Clustered mode", func(t *testing.T) {
+		roles.SetRoles(map[roles.Role]struct{}{roles.EndorserRole: {}})
+		require.True(t, roles.IsClustered())
+		defer roles.SetRoles(nil)
+
+		peers := d.ResolvePeersForQuery()
+		require.Len(t, peers, 2)
+
+		for _, p := range peers {
+			require.NotEqual(t, org1MSPID, p.MSPID)
+		}
+	})
+}
+
 func TestComputeDisseminationPlan(t *testing.T) {
 	channelID := "testchannel"
 
@@ -443,6 +486,26 @@ func TestComputeDisseminationPlan(t *testing.T) {
 		assert.False(t, criteria.IsEligible(p3Org3))
 	})
 
+	t.Run("Value size exceeded", func(t *testing.T) {
+		getLimits = func(ns, coll string) *quota.Limits {
+			return &quota.Limits{MaxValueSize: 5}
+		}
+		defer func() { getLimits = quota.GetLimits }()
+
+		rwSet := mocks.NewPvtReadWriteSetCollectionBuilder(coll1).
+			Write(key1, []byte("value1")).
+			Build()
+		colConfig := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+		}
+
+		dPlan, handled, err := ComputeDisseminationPlan(channelID, ns1, rwSet, colConfig, colAP, nil, gossip)
+		require.Error(t, err)
+		require.True(t, handled)
+		require.Nil(t, dPlan)
+		require.Contains(t, err.Error(), "exceeds the maximum value size of 5 bytes")
+	})
+
 	t.Run("Unmarshal error", func(t *testing.T) {
 		value1 := []byte(`{"field1":"value1"}`)
 		key1, err := dcas.GetCASKey(value1, dcas.CIDV1, cid.Raw, mh.SHA2_256)
@@ -3,6 +3,7 @@ package mocks
 
 import (
 	"sync"
+	"time"
 )
 
 type DCASConfig struct {
@@ -42,6 +43,15 @@ type DCASConfig struct {
 	getDCASBlockLayoutReturnsOnCall map[int]struct {
 		result1 string
 	}
+	GetDCASGCIntervalStub        func() time.Duration
+	getDCASGCIntervalMutex       sync.RWMutex
+	getDCASGCIntervalArgsForCall []struct{}
+	getDCASGCIntervalReturns     struct {
+		result1 time.Duration
+	}
+	getDCASGCIntervalReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -206,6 +216,46 @@ func (fake *DCASConfig) GetDCASBlockLayoutReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *DCASConfig) GetDCASGCInterval() time.Duration {
+	fake.getDCASGCIntervalMutex.Lock()
+	ret, specificReturn := fake.getDCASGCIntervalReturnsOnCall[len(fake.getDCASGCIntervalArgsForCall)]
+	fake.getDCASGCIntervalArgsForCall = append(fake.getDCASGCIntervalArgsForCall, struct{}{})
+	fake.recordInvocation("GetDCASGCInterval", []interface{}{})
+	fake.getDCASGCIntervalMutex.Unlock()
+	if fake.GetDCASGCIntervalStub != nil {
+		return fake.GetDCASGCIntervalStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.getDCASGCIntervalReturns.result1
+}
+
+func (fake *DCASConfig) GetDCASGCIntervalCallCount() int {
+	fake.getDCASGCIntervalMutex.RLock()
+	defer fake.getDCASGCIntervalMutex.RUnlock()
+	return len(fake.getDCASGCIntervalArgsForCall)
+}
+
+func (fake *DCASConfig) GetDCASGCIntervalReturns(result1 time.Duration) {
+	fake.GetDCASGCIntervalStub = nil
+	fake.getDCASGCIntervalReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *DCASConfig) GetDCASGCIntervalReturnsOnCall(i int, result1 time.Duration) {
+	fake.GetDCASGCIntervalStub = nil
+	if fake.getDCASGCIntervalReturnsOnCall == nil {
+		fake.getDCASGCIntervalReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.getDCASGCIntervalReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
 func (fake *DCASConfig) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -217,6 +267,8 @@ func (fake *DCASConfig) Invocations() map[string][][]interface{} {
 	defer fake.getDCASMaxBlockSizeMutex.RUnlock()
 	fake.getDCASBlockLayoutMutex.RLock()
 	defer fake.getDCASBlockLayoutMutex.RUnlock()
+	fake.getDCASGCIntervalMutex.RLock()
+	defer fake.getDCASGCIntervalMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -43,6 +43,14 @@ func (m *retriever) Query(ctxt context.Context, key *storeapi.QueryKey) (storeap
 	return newResultsIterator(), nil
 }
 
+func (m *retriever) QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	return newResultsIterator(), &storeapi.QueryResponseMetadata{}, nil
+}
+
+func (m *retriever) GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	return newResultsIterator(), nil
+}
+
 type resultsIterator struct {
 }
 
@@ -94,6 +94,47 @@ type OffLedgerClient struct {
 		result1 commonledger.ResultsIterator
 		result2 error
 	}
+	NewBatchStub        func() *client.Batch
+	newBatchMutex       sync.RWMutex
+	newBatchArgsForCall []struct{}
+	newBatchReturns     struct {
+		result1 *client.Batch
+	}
+	newBatchReturnsOnCall map[int]struct {
+		result1 *client.Batch
+	}
+	PutIfLocalRevisionStub        func(ns, coll, key string, value []byte, revision string) error
+	putIfLocalRevisionMutex       sync.RWMutex
+	putIfLocalRevisionArgsForCall []struct {
+		ns       string
+		coll     string
+		key      string
+		value    []byte
+		revision string
+	}
+	putIfLocalRevisionReturns struct {
+		result1 error
+	}
+	putIfLocalRevisionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetWithRevisionStub        func(ns, coll, key string) ([]byte, string, error)
+	getWithRevisionMutex       sync.RWMutex
+	getWithRevisionArgsForCall []struct {
+		ns   string
+		coll string
+		key  string
+	}
+	getWithRevisionReturns struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}
+	getWithRevisionReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -418,6 +459,159 @@ func (fake *OffLedgerClient) QueryReturnsOnCall(i int, result1 commonledger.Resu
 	}{result1, result2}
 }
 
+func (fake *OffLedgerClient) NewBatch() *client.Batch {
+	fake.newBatchMutex.Lock()
+	ret, specificReturn := fake.newBatchReturnsOnCall[len(fake.newBatchArgsForCall)]
+	fake.newBatchArgsForCall = append(fake.newBatchArgsForCall, struct{}{})
+	fake.recordInvocation("NewBatch", []interface{}{})
+	fake.newBatchMutex.Unlock()
+	if fake.NewBatchStub != nil {
+		return fake.NewBatchStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.newBatchReturns.result1
+}
+
+func (fake *OffLedgerClient) NewBatchCallCount() int {
+	fake.newBatchMutex.RLock()
+	defer fake.newBatchMutex.RUnlock()
+	return len(fake.newBatchArgsForCall)
+}
+
+func (fake *OffLedgerClient) NewBatchReturns(result1 *client.Batch) {
+	fake.NewBatchStub = nil
+	fake.newBatchReturns = struct {
+		result1 *client.Batch
+	}{result1}
+}
+
+func (fake *OffLedgerClient) NewBatchReturnsOnCall(i int, result1 *client.Batch) {
+	fake.NewBatchStub = nil
+	if fake.newBatchReturnsOnCall == nil {
+		fake.newBatchReturnsOnCall = make(map[int]struct {
+			result1 *client.Batch
+		})
+	}
+	fake.newBatchReturnsOnCall[i] = struct {
+		result1 *client.Batch
+	}{result1}
+}
+
+func (fake *OffLedgerClient) PutIfLocalRevision(ns string, coll string, key string, value []byte, revision string) error {
+	var valueCopy []byte
+	if value != nil {
+		valueCopy = make([]byte, len(value))
+		copy(valueCopy, value)
+	}
+	fake.putIfLocalRevisionMutex.Lock()
+	ret, specificReturn := fake.putIfLocalRevisionReturnsOnCall[len(fake.putIfLocalRevisionArgsForCall)]
+	fake.putIfLocalRevisionArgsForCall = append(fake.putIfLocalRevisionArgsForCall, struct {
+		ns       string
+		coll     string
+		key      string
+		value    []byte
+		revision string
+	}{ns, coll, key, valueCopy, revision})
+	fake.recordInvocation("PutIfLocalRevision", []interface{}{ns, coll, key, valueCopy, revision})
+	fake.putIfLocalRevisionMutex.Unlock()
+	if fake.PutIfLocalRevisionStub != nil {
+		return fake.PutIfLocalRevisionStub(ns, coll, key, value, revision)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.putIfLocalRevisionReturns.result1
+}
+
+func (fake *OffLedgerClient) PutIfLocalRevisionCallCount() int {
+	fake.putIfLocalRevisionMutex.RLock()
+	defer fake.putIfLocalRevisionMutex.RUnlock()
+	return len(fake.putIfLocalRevisionArgsForCall)
+}
+
+func (fake *OffLedgerClient) PutIfLocalRevisionArgsForCall(i int) (string, string, string, []byte, string) {
+	fake.putIfLocalRevisionMutex.RLock()
+	defer fake.putIfLocalRevisionMutex.RUnlock()
+	return fake.putIfLocalRevisionArgsForCall[i].ns, fake.putIfLocalRevisionArgsForCall[i].coll, fake.putIfLocalRevisionArgsForCall[i].key, fake.putIfLocalRevisionArgsForCall[i].value, fake.putIfLocalRevisionArgsForCall[i].revision
+}
+
+func (fake *OffLedgerClient) PutIfLocalRevisionReturns(result1 error) {
+	fake.PutIfLocalRevisionStub = nil
+	fake.putIfLocalRevisionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OffLedgerClient) PutIfLocalRevisionReturnsOnCall(i int, result1 error) {
+	fake.PutIfLocalRevisionStub = nil
+	if fake.putIfLocalRevisionReturnsOnCall == nil {
+		fake.putIfLocalRevisionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.putIfLocalRevisionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OffLedgerClient) GetWithRevision(ns string, coll string, key string) ([]byte, string, error) {
+	fake.getWithRevisionMutex.Lock()
+	ret, specificReturn := fake.getWithRevisionReturnsOnCall[len(fake.getWithRevisionArgsForCall)]
+	fake.getWithRevisionArgsForCall = append(fake.getWithRevisionArgsForCall, struct {
+		ns   string
+		coll string
+		key  string
+	}{ns, coll, key})
+	fake.recordInvocation("GetWithRevision", []interface{}{ns, coll, key})
+	fake.getWithRevisionMutex.Unlock()
+	if fake.GetWithRevisionStub != nil {
+		return fake.GetWithRevisionStub(ns, coll, key)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fake.getWithRevisionReturns.result1, fake.getWithRevisionReturns.result2, fake.getWithRevisionReturns.result3
+}
+
+func (fake *OffLedgerClient) GetWithRevisionCallCount() int {
+	fake.getWithRevisionMutex.RLock()
+	defer fake.getWithRevisionMutex.RUnlock()
+	return len(fake.getWithRevisionArgsForCall)
+}
+
+func (fake *OffLedgerClient) GetWithRevisionArgsForCall(i int) (string, string, string) {
+	fake.getWithRevisionMutex.RLock()
+	defer fake.getWithRevisionMutex.RUnlock()
+	return fake.getWithRevisionArgsForCall[i].ns, fake.getWithRevisionArgsForCall[i].coll, fake.getWithRevisionArgsForCall[i].key
+}
+
+func (fake *OffLedgerClient) GetWithRevisionReturns(result1 []byte, result2 string, result3 error) {
+	fake.GetWithRevisionStub = nil
+	fake.getWithRevisionReturns = struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *OffLedgerClient) GetWithRevisionReturnsOnCall(i int, result1 []byte, result2 string, result3 error) {
+	fake.GetWithRevisionStub = nil
+	if fake.getWithRevisionReturnsOnCall == nil {
+		fake.getWithRevisionReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 string
+			result3 error
+		})
+	}
+	fake.getWithRevisionReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *OffLedgerClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -433,6 +627,12 @@ func (fake *OffLedgerClient) Invocations() map[string][][]interface{} {
 	defer fake.getMultipleKeysMutex.RUnlock()
 	fake.queryMutex.RLock()
 	defer fake.queryMutex.RUnlock()
+	fake.newBatchMutex.RLock()
+	defer fake.newBatchMutex.RUnlock()
+	fake.putIfLocalRevisionMutex.RLock()
+	defer fake.putIfLocalRevisionMutex.RUnlock()
+	fake.getWithRevisionMutex.RLock()
+	defer fake.getWithRevisionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
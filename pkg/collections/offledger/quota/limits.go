@@ -0,0 +1,116 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quota
+
+import (
+	"fmt"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+)
+
+// Limits contains the limits of an off-ledger collection. A limit of zero means that there is no limit.
+type Limits struct {
+	MaxValueSize  int
+	MaxKeyCount   int
+	MaxTotalBytes int64
+}
+
+// LimitsFunc returns the limits of the given collection
+type LimitsFunc func(ns, coll string) *Limits
+
+// GetLimits returns the limits of the given collection from the peer configuration
+func GetLimits(ns, coll string) *Limits {
+	return &Limits{
+		MaxValueSize:  config.GetOLCollMaxValueSize(ns, coll),
+		MaxKeyCount:   config.GetOLCollMaxKeyCount(ns, coll),
+		MaxTotalBytes: config.GetOLCollMaxTotalBytes(ns, coll),
+	}
+}
+
+// ValidateValueSize returns an ExceededError if the given value size exceeds the maximum value size
+func (l *Limits) ValidateValueSize(ns, coll, key string, size int) error {
+	if l.MaxValueSize > 0 && size > l.MaxValueSize {
+		return &ExceededError{
+			Namespace:  ns,
+			Collection: coll,
+			Key:        key,
+			Limit:      ValueSize,
+			Value:      int64(size),
+			Max:        int64(l.MaxValueSize),
+		}
+	}
+
+	return nil
+}
+
+// ValidateUsage returns an ExceededError if the given number of keys or total bytes exceeds the collection's quota
+func (l *Limits) ValidateUsage(ns, coll string, keys int, bytes int64) error {
+	if l.MaxKeyCount > 0 && keys > l.MaxKeyCount {
+		return &ExceededError{
+			Namespace:  ns,
+			Collection: coll,
+			Limit:      KeyCount,
+			Value:      int64(keys),
+			Max:        int64(l.MaxKeyCount),
+		}
+	}
+
+	if l.MaxTotalBytes > 0 && bytes > l.MaxTotalBytes {
+		return &ExceededError{
+			Namespace:  ns,
+			Collection: coll,
+			Limit:      TotalBytes,
+			Value:      bytes,
+			Max:        l.MaxTotalBytes,
+		}
+	}
+
+	return nil
+}
+
+// HasQuota returns true if the number of keys or the total bytes of the collection is limited,
+// in which case the usage of the collection needs to be tracked.
+func (l *Limits) HasQuota() bool {
+	return l.MaxKeyCount > 0 || l.MaxTotalBytes > 0
+}
+
+// Limit identifies a collection limit
+type Limit string
+
+const (
+	// ValueSize is the maximum size of a single value
+	ValueSize Limit = "maxValueSize"
+	// KeyCount is the maximum number of keys in a collection
+	KeyCount Limit = "maxKeyCount"
+	// TotalBytes is the maximum total size of the values in a collection
+	TotalBytes Limit = "maxTotalBytes"
+)
+
+// ExceededError is returned when a write is rejected because it would exceed one of the limits of a collection
+type ExceededError struct {
+	Namespace  string
+	Collection string
+	Key        string
+	Limit      Limit
+	Value      int64
+	Max        int64
+}
+
+// Error returns the error message
+func (e *ExceededError) Error() string {
+	switch e.Limit {
+	case ValueSize:
+		return fmt.Sprintf("size of value for key [%s] in collection [%s:%s] is %d bytes which exceeds the maximum value size of %d bytes",
+			e.Key, e.Namespace, e.Collection, e.Value, e.Max)
+	case KeyCount:
+		return fmt.Sprintf("write would increase the number of keys in collection [%s:%s] to %d which exceeds the maximum key count of %d",
+			e.Namespace, e.Collection, e.Value, e.Max)
+	default:
+		return fmt.Sprintf("write would increase the total size of the values in collection [%s:%s] to %d bytes which exceeds the maximum of %d bytes",
+			e.Namespace, e.Collection, e.Value, e.Max)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLimits(t *testing.T) {
+	l := GetLimits(ns1, coll1)
+	require.NotNil(t, l)
+	require.Equal(t, &Limits{}, l)
+	require.False(t, l.HasQuota())
+}
+
+func TestLimits(t *testing.T) {
+	l := &Limits{MaxValueSize: 10, MaxKeyCount: 2, MaxTotalBytes: 15}
+	require.True(t, l.HasQuota())
+
+	t.Run("Value size", func(t *testing.T) {
+		require.NoError(t, l.ValidateValueSize(ns1, coll1, key1, 10))
+
+		err := l.ValidateValueSize(ns1, coll1, key1, 11)
+		require.EqualError(t, err, "size of value for key [key1] in collection [ns1:coll1] is 11 bytes which exceeds the maximum value size of 10 bytes")
+
+		exceededErr, ok := err.(*ExceededError)
+		require.True(t, ok)
+		require.Equal(t, ValueSize, exceededErr.Limit)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		require.NoError(t, l.ValidateUsage(ns1, coll1, 2, 15))
+
+		err := l.ValidateUsage(ns1, coll1, 3, 15)
+		require.EqualError(t, err, "write would increase the number of keys in collection [ns1:coll1] to 3 which exceeds the maximum key count of 2")
+
+		err = l.ValidateUsage(ns1, coll1, 2, 16)
+		require.EqualError(t, err, "write would increase the total size of the values in collection [ns1:coll1] to 16 bytes which exceeds the maximum of 15 bytes")
+	})
+
+	t.Run("No limits", func(t *testing.T) {
+		l := &Limits{}
+		require.False(t, l.HasQuota())
+		require.NoError(t, l.ValidateValueSize(ns1, coll1, key1, 1000000))
+		require.NoError(t, l.ValidateUsage(ns1, coll1, 1000000, 1000000))
+	})
+}
@@ -0,0 +1,331 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quota
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	fabricmetrics "github.com/hyperledger/fabric/common/metrics"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+)
+
+var logger = flogging.MustGetLogger("ext_offledger")
+
+const (
+	metricsNamespace = "offledger"
+	subsystem        = "quota"
+	statsdFormat     = "%{#fqname}.%{channel}.%{namespace}.%{collection}"
+)
+
+var labelNames = []string{"channel", "namespace", "collection"}
+
+type usageMetrics struct {
+	provider fabricmetrics.Provider
+	keys     fabricmetrics.Gauge
+	bytes    fabricmetrics.Gauge
+}
+
+var (
+	mutex         sync.Mutex
+	cachedMetrics *usageMetrics
+)
+
+// getMetrics returns the usage metrics. The metrics are created only once per metrics provider
+// since a provider (e.g. Prometheus) doesn't allow the same metric to be registered twice.
+func getMetrics(p fabricmetrics.Provider) *usageMetrics {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if cachedMetrics != nil && cachedMetrics.provider == p {
+		return cachedMetrics
+	}
+
+	cachedMetrics = &usageMetrics{
+		provider: p,
+		keys: p.NewGauge(fabricmetrics.GaugeOpts{
+			Namespace:    metricsNamespace,
+			Subsystem:    subsystem,
+			Name:         "keys",
+			Help:         "The number of keys in an off-ledger collection that has a quota.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+		bytes: p.NewGauge(fabricmetrics.GaugeOpts{
+			Namespace:    metricsNamespace,
+			Subsystem:    subsystem,
+			Name:         "bytes",
+			Help:         "The total size of the values in an off-ledger collection that has a quota.",
+			LabelNames:   labelNames,
+			StatsdFormat: statsdFormat,
+		}),
+	}
+
+	return cachedMetrics
+}
+
+// LoadFunc returns all of the (unexpired) keys and values of the given collection
+type LoadFunc func(ns, coll string) ([]*api.KeyValue, error)
+
+type collKey struct {
+	namespace  string
+	collection string
+}
+
+type usage struct {
+	sizes    map[string]int
+	bytes    int64
+	loadedAt time.Time
+}
+
+// collUsage holds the usage of a collection. The mutex serializes the writes to the collection so that the
+// usage checked before a write is the usage that is updated after the write.
+type collUsage struct {
+	mutex sync.Mutex
+	usage *usage
+}
+
+// Tracker enforces the limits of the off-ledger collections of a channel. The usage of a collection (number of keys
+// and total bytes) is only tracked if the collection has a quota. Usage is loaded from the database the first time
+// the collection is written to and is then maintained as keys are written and deleted. Since values may expire (or
+// may be written by other peers that share the database) the usage is reloaded before a write is rejected. A full reload
+// is expensive so the usage of a collection is reloaded at most once per reload interval.
+//
+// Writes to a collection must be made while holding the lock returned by Lock so that concurrent writers can't
+// exceed the quota. Usage is loaded while holding the lock of the collection only, so loading the usage of one
+// collection doesn't block the writes to other collections.
+type Tracker struct {
+	channelID      string
+	getLimits      LimitsFunc
+	load           LoadFunc
+	reloadInterval time.Duration
+	metrics        *usageMetrics
+	mutex          sync.Mutex
+	usage          map[collKey]*collUsage
+}
+
+// NewTracker returns a new quota tracker for the given channel. Collection usage is reported to the given metrics provider.
+func NewTracker(channelID string, getLimits LimitsFunc, load LoadFunc, reloadInterval time.Duration, metricsProvider fabricmetrics.Provider) *Tracker {
+	return &Tracker{
+		channelID:      channelID,
+		getLimits:      getLimits,
+		load:           load,
+		reloadInterval: reloadInterval,
+		metrics:        getMetrics(metricsProvider),
+		usage:          make(map[collKey]*collUsage),
+	}
+}
+
+// Lock locks the usage of the given collection and returns the function that unlocks it. The lock must be held
+// from the Check of a write until the Update that follows the write. Nothing is locked if the collection doesn't
+// have a quota.
+func (t *Tracker) Lock(ns, coll string) func() {
+	if !t.getLimits(ns, coll).HasQuota() {
+		return func() {}
+	}
+
+	c := t.collUsage(ns, coll)
+	c.mutex.Lock()
+
+	return c.mutex.Unlock
+}
+
+// Check returns an ExceededError if any of the given writes would exceed the limits of the collection.
+// A write with a nil value is a delete. The caller must hold the lock of the collection.
+func (t *Tracker) Check(ns, coll string, writes ...*api.KeyValue) error {
+	limits := t.getLimits(ns, coll)
+
+	for _, w := range writes {
+		if w.Value == nil {
+			continue
+		}
+
+		if err := limits.ValidateValueSize(ns, coll, w.Key, len(w.Value.Value)); err != nil {
+			logger.Debugf("[%s] Rejecting write: %s", t.channelID, err)
+
+			return err
+		}
+	}
+
+	if !limits.HasQuota() {
+		return nil
+	}
+
+	c := t.collUsage(ns, coll)
+
+	u, err := t.getUsage(ns, coll, c)
+	if err != nil {
+		return err
+	}
+
+	keys, bytes := u.after(writes)
+	err = limits.ValidateUsage(ns, coll, keys, bytes)
+	if err == nil {
+		return nil
+	}
+
+	if time.Since(u.loadedAt) < t.reloadInterval {
+		logger.Debugf("[%s] Rejecting write: %s. Usage was loaded less than %s ago.", t.channelID, err, t.reloadInterval)
+
+		return err
+	}
+
+	logger.Debugf("[%s] Quota of collection [%s:%s] would be exceeded. Reloading usage before rejecting the write.", t.channelID, ns, coll)
+
+	u, err = t.loadUsage(ns, coll, c)
+	if err != nil {
+		return err
+	}
+
+	keys, bytes = u.after(writes)
+	if err := limits.ValidateUsage(ns, coll, keys, bytes); err != nil {
+		logger.Debugf("[%s] Rejecting write: %s", t.channelID, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Update applies the given writes (which have been persisted) to the usage of the collection. The caller must
+// hold the lock of the collection.
+func (t *Tracker) Update(ns, coll string, writes ...*api.KeyValue) {
+	t.mutex.Lock()
+	c, ok := t.usage[collKey{namespace: ns, collection: coll}]
+	t.mutex.Unlock()
+
+	if !ok || c.usage == nil {
+		// Usage isn't tracked for this collection
+		return
+	}
+
+	u := c.usage
+
+	for _, w := range writes {
+		u.bytes -= int64(u.sizes[w.Key])
+
+		if w.Value == nil {
+			delete(u.sizes, w.Key)
+			continue
+		}
+
+		u.sizes[w.Key] = len(w.Value.Value)
+		u.bytes += int64(len(w.Value.Value))
+	}
+
+	t.updateMetrics(ns, coll, u)
+}
+
+// Usage returns the number of keys and total bytes of the given collection. Zeros are returned
+// if the collection doesn't have a quota.
+func (t *Tracker) Usage(ns, coll string) (int, int64, error) {
+	if !t.getLimits(ns, coll).HasQuota() {
+		return 0, 0, nil
+	}
+
+	c := t.collUsage(ns, coll)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	u, err := t.getUsage(ns, coll, c)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return len(u.sizes), u.bytes, nil
+}
+
+// collUsage returns the usage holder of the given collection, creating it if necessary
+func (t *Tracker) collUsage(ns, coll string) *collUsage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := collKey{namespace: ns, collection: coll}
+
+	c, ok := t.usage[key]
+	if !ok {
+		c = &collUsage{}
+		t.usage[key] = c
+	}
+
+	return c
+}
+
+func (t *Tracker) getUsage(ns, coll string, c *collUsage) (*usage, error) {
+	if c.usage != nil {
+		return c.usage, nil
+	}
+
+	return t.loadUsage(ns, coll, c)
+}
+
+func (t *Tracker) loadUsage(ns, coll string, c *collUsage) (*usage, error) {
+	kvs, err := t.load(ns, coll)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error loading usage of collection [%s:%s]", ns, coll)
+	}
+
+	u := &usage{sizes: make(map[string]int), loadedAt: time.Now()}
+	for _, kv := range kvs {
+		if kv.Value == nil {
+			continue
+		}
+
+		u.sizes[kv.Key] = len(kv.Value.Value)
+		u.bytes += int64(len(kv.Value.Value))
+	}
+
+	logger.Debugf("[%s] Loaded usage of collection [%s:%s] - Keys: %d, Bytes: %d", t.channelID, ns, coll, len(u.sizes), u.bytes)
+
+	c.usage = u
+	t.updateMetrics(ns, coll, u)
+
+	return u, nil
+}
+
+func (t *Tracker) updateMetrics(ns, coll string, u *usage) {
+	labels := []string{"channel", t.channelID, "namespace", ns, "collection", coll}
+
+	t.metrics.keys.With(labels...).Set(float64(len(u.sizes)))
+	t.metrics.bytes.With(labels...).Set(float64(u.bytes))
+}
+
+// after returns the number of keys and total bytes after the given writes are applied
+func (u *usage) after(writes []*api.KeyValue) (int, int64) {
+	keys := len(u.sizes)
+	bytes := u.bytes
+
+	// A key may be written more than once so keep track of the sizes that are replaced
+	sizes := make(map[string]int)
+
+	for _, w := range writes {
+		size, ok := sizes[w.Key]
+		if !ok {
+			size, ok = u.sizes[w.Key]
+		}
+
+		if ok && size >= 0 {
+			keys--
+			bytes -= int64(size)
+		}
+
+		if w.Value == nil {
+			sizes[w.Key] = -1
+			continue
+		}
+
+		keys++
+		bytes += int64(len(w.Value.Value))
+		sizes[w.Key] = len(w.Value.Value)
+	}
+
+	return keys, bytes
+}
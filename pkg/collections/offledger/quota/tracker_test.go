@@ -0,0 +1,257 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics/metricsfakes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+)
+
+const (
+	channel1 = "channel1"
+	ns1      = "ns1"
+	coll1    = "coll1"
+	coll2    = "coll2"
+	key1     = "key1"
+	key2     = "key2"
+	key3     = "key3"
+	txID1    = "tx1"
+)
+
+func TestTracker(t *testing.T) {
+	keysGauge := &metricsfakes.Gauge{}
+	keysGauge.WithReturns(keysGauge)
+	bytesGauge := &metricsfakes.Gauge{}
+	bytesGauge.WithReturns(bytesGauge)
+
+	p := &metricsfakes.Provider{}
+	p.NewGaugeReturnsOnCall(0, keysGauge)
+	p.NewGaugeReturnsOnCall(1, bytesGauge)
+
+	limits := map[string]*Limits{
+		coll1: {MaxValueSize: 5, MaxKeyCount: 3, MaxTotalBytes: 12},
+		coll2: {MaxValueSize: 5},
+	}
+
+	getLimits := func(ns, coll string) *Limits {
+		return limits[coll]
+	}
+
+	// The database initially contains one key
+	db := map[string][]byte{key1: []byte("12345")}
+	loadCount := 0
+
+	load := func(ns, coll string) ([]*api.KeyValue, error) {
+		loadCount++
+
+		var kvs []*api.KeyValue
+		for k, v := range db {
+			kvs = append(kvs, api.NewKeyValue(k, v, txID1, time.Time{}))
+		}
+
+		return kvs, nil
+	}
+
+	tracker := NewTracker(channel1, getLimits, load, 0, p)
+	require.NotNil(t, tracker)
+
+	// The metrics should only be created once
+	require.NotNil(t, NewTracker("channel2", getLimits, load, 0, p))
+	require.Equal(t, 2, p.NewGaugeCallCount())
+
+	put := func(key, value string) *api.KeyValue {
+		return api.NewKeyValue(key, []byte(value), txID1, time.Time{})
+	}
+
+	t.Run("Value size exceeded", func(t *testing.T) {
+		err := tracker.Check(ns1, coll2, put(key1, "123456"))
+		require.Error(t, err)
+		require.IsType(t, &ExceededError{}, err)
+
+		// No quota for coll2 so usage isn't loaded
+		require.NoError(t, tracker.Check(ns1, coll2, put(key1, "12345"), put(key2, "12345")))
+		require.Zero(t, loadCount)
+
+		keys, bytes, err := tracker.Usage(ns1, coll2)
+		require.NoError(t, err)
+		require.Zero(t, keys)
+		require.Zero(t, bytes)
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		require.NoError(t, tracker.Check(ns1, coll1, put(key2, "12345")))
+		require.Equal(t, 1, loadCount)
+		require.Equal(t, float64(1), keysGauge.SetArgsForCall(keysGauge.SetCallCount()-1))
+		require.Equal(t, float64(5), bytesGauge.SetArgsForCall(bytesGauge.SetCallCount()-1))
+
+		db[key2] = []byte("12345")
+		tracker.Update(ns1, coll1, put(key2, "12345"))
+		require.Equal(t, float64(2), keysGauge.SetArgsForCall(keysGauge.SetCallCount()-1))
+		require.Equal(t, float64(10), bytesGauge.SetArgsForCall(bytesGauge.SetCallCount()-1))
+
+		keys, bytes, err := tracker.Usage(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, 2, keys)
+		require.Equal(t, int64(10), bytes)
+
+		// Total bytes would be exceeded
+		err = tracker.Check(ns1, coll1, put(key3, "123"))
+		require.Error(t, err)
+		exceededErr, ok := err.(*ExceededError)
+		require.True(t, ok)
+		require.Equal(t, TotalBytes, exceededErr.Limit)
+		require.Equal(t, 2, loadCount, "usage should have been reloaded before rejecting the write")
+
+		// Replacing an existing value only counts the difference
+		require.NoError(t, tracker.Check(ns1, coll1, put(key2, "12"), put(key3, "12")))
+
+		// Deleting a key frees up space
+		require.NoError(t, tracker.Check(ns1, coll1, &api.KeyValue{Key: key1}, put(key3, "123")))
+
+		// Writing and then deleting the same key in a batch
+		require.NoError(t, tracker.Check(ns1, coll1, put(key3, "12345"), &api.KeyValue{Key: key3}))
+
+		delete(db, key1)
+		db[key3] = []byte("1")
+		tracker.Update(ns1, coll1, &api.KeyValue{Key: key1}, put(key3, "1"))
+
+		keys, bytes, err = tracker.Usage(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, 2, keys)
+		require.Equal(t, int64(6), bytes)
+
+		// Key count would be exceeded
+		err = tracker.Check(ns1, coll1, put(key1, "1"), put("key4", "1"))
+		require.Error(t, err)
+		exceededErr, ok = err.(*ExceededError)
+		require.True(t, ok)
+		require.Equal(t, KeyCount, exceededErr.Limit)
+	})
+
+	t.Run("Stale usage", func(t *testing.T) {
+		tracker := NewTracker(channel1, getLimits, load, 0, p)
+
+		db = map[string][]byte{key1: []byte("12345"), key2: []byte("12345")}
+		require.NoError(t, tracker.Check(ns1, coll1, put(key3, "1")))
+
+		// The values expired
+		db = map[string][]byte{}
+		require.NoError(t, tracker.Check(ns1, coll1, put(key3, "12345")))
+	})
+
+	t.Run("Reload interval", func(t *testing.T) {
+		tracker := NewTracker(channel1, getLimits, load, 50*time.Millisecond, p)
+
+		db = map[string][]byte{key1: []byte("12345"), key2: []byte("12345")}
+		loadCount = 0
+
+		err := tracker.Check(ns1, coll1, put(key3, "12345"))
+		require.Error(t, err)
+		require.IsType(t, &ExceededError{}, err)
+
+		// The usage was just loaded so it's not reloaded before rejecting the write
+		require.Error(t, tracker.Check(ns1, coll1, put(key3, "12345")))
+		require.Equal(t, 1, loadCount)
+
+		// The values expired but the usage isn't reloaded until the interval has elapsed
+		db = map[string][]byte{}
+		require.Error(t, tracker.Check(ns1, coll1, put(key3, "12345")))
+		require.Equal(t, 1, loadCount)
+
+		time.Sleep(60 * time.Millisecond)
+
+		require.NoError(t, tracker.Check(ns1, coll1, put(key3, "12345")))
+		require.Equal(t, 2, loadCount)
+	})
+
+	t.Run("Concurrent writers", func(t *testing.T) {
+		var mutex sync.Mutex
+		db := make(map[string][]byte)
+
+		tracker := NewTracker(channel1, getLimits, func(ns, coll string) ([]*api.KeyValue, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			var kvs []*api.KeyValue
+			for k, v := range db {
+				kvs = append(kvs, api.NewKeyValue(k, v, txID1, time.Time{}))
+			}
+
+			return kvs, nil
+		}, 0, p)
+
+		const n = 20
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+
+				defer tracker.Lock(ns1, coll1)()
+
+				w := put(fmt.Sprintf("key_%d", i), "1")
+				if err := tracker.Check(ns1, coll1, w); err != nil {
+					return
+				}
+
+				// Simulate the write to the database
+				time.Sleep(time.Millisecond)
+
+				mutex.Lock()
+				db[w.Key] = w.Value.Value
+				mutex.Unlock()
+
+				tracker.Update(ns1, coll1, w)
+			}(i)
+		}
+
+		wg.Wait()
+
+		require.Len(t, db, 3)
+
+		keys, bytes, err := tracker.Usage(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, 3, keys)
+		require.Equal(t, int64(3), bytes)
+	})
+
+	t.Run("No quota -> not locked", func(t *testing.T) {
+		unlock := tracker.Lock(ns1, coll2)
+		defer unlock()
+
+		// The collection can be locked again since nothing was locked
+		tracker.Lock(ns1, coll2)()
+	})
+
+	t.Run("Load error", func(t *testing.T) {
+		errExpected := errors.New("injected load error")
+
+		tracker := NewTracker(channel1, getLimits, func(ns, coll string) ([]*api.KeyValue, error) {
+			return nil, errExpected
+		}, 0, p)
+
+		err := tracker.Check(ns1, coll1, put(key1, "1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		_, _, err = tracker.Usage(ns1, coll1)
+		require.Error(t, err)
+
+		// Usage isn't tracked if it wasn't loaded
+		tracker.Update(ns1, coll1, put(key1, "1"))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package reconciler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// numBuckets is the number of buckets into which the keys of a collection are divided
+const numBuckets = 64
+
+// keyVersion contains a key along with the ID of the transaction that last wrote the key and the time at which
+// the value was written. Only the key and transaction ID are included in the digest since each peer records its
+// own write time.
+type keyVersion struct {
+	Key       string    `json:"key"`
+	TxID      string    `json:"txid"`
+	WriteTime time.Time `json:"write_time"`
+}
+
+// digest is a two-level Merkle summary of the keys in a collection. Each key is assigned to a bucket
+// according to the hash of the key, and the hash of each bucket is computed over the keys (and their
+// versions) in the bucket. The root hash is computed over all of the bucket hashes.
+type digest struct {
+	Root    []byte   `json:"root"`
+	Buckets [][]byte `json:"buckets"`
+}
+
+// newDigest returns the digest for the given keys, which must be ordered by key
+func newDigest(keys []*keyVersion) *digest {
+	hashers := make([]hash.Hash, numBuckets)
+	for _, k := range keys {
+		b := bucketForKey(k.Key)
+		if hashers[b] == nil {
+			hashers[b] = sha256.New()
+		}
+
+		writeField(hashers[b], k.Key)
+		writeField(hashers[b], k.TxID)
+	}
+
+	d := &digest{Buckets: make([][]byte, numBuckets)}
+
+	root := sha256.New()
+	for i, h := range hashers {
+		if h == nil {
+			continue
+		}
+
+		d.Buckets[i] = h.Sum(nil)
+
+		idx := make([]byte, 2)
+		binary.BigEndian.PutUint16(idx, uint16(i))
+
+		root.Write(idx)
+		root.Write(d.Buckets[i])
+	}
+
+	d.Root = root.Sum(nil)
+
+	return d
+}
+
+// diff returns the indexes of the buckets which differ between this digest and the given digest
+func (d *digest) diff(other *digest) ([]int, error) {
+	if len(other.Buckets) != numBuckets {
+		return nil, errors.Errorf("invalid number of buckets in digest: %d", len(other.Buckets))
+	}
+
+	if bytes.Equal(d.Root, other.Root) {
+		return nil, nil
+	}
+
+	var buckets []int
+	for i := range d.Buckets {
+		if !bytes.Equal(d.Buckets[i], other.Buckets[i]) {
+			buckets = append(buckets, i)
+		}
+	}
+
+	return buckets, nil
+}
+
+func bucketForKey(key string) int {
+	h := sha256.Sum256([]byte(key))
+
+	return int(binary.BigEndian.Uint16(h[:2])) % numBuckets
+}
+
+func writeField(h hash.Hash, field string) {
+	h.Write([]byte(field))
+	h.Write([]byte{0})
+}
@@ -0,0 +1,63 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest(t *testing.T) {
+	keys := []*keyVersion{
+		{Key: "key1", TxID: "tx1"},
+		{Key: "key2", TxID: "tx1"},
+		{Key: "key3", TxID: "tx2"},
+	}
+
+	d := newDigest(keys)
+	require.NotEmpty(t, d.Root)
+	require.Len(t, d.Buckets, numBuckets)
+
+	t.Run("Same keys -> no diff", func(t *testing.T) {
+		buckets, err := d.diff(newDigest(keys))
+		require.NoError(t, err)
+		require.Empty(t, buckets)
+	})
+
+	t.Run("Different version -> diff", func(t *testing.T) {
+		buckets, err := d.diff(newDigest([]*keyVersion{
+			{Key: "key1", TxID: "tx1"},
+			{Key: "key2", TxID: "tx3"},
+			{Key: "key3", TxID: "tx2"},
+		}))
+		require.NoError(t, err)
+		require.Equal(t, []int{bucketForKey("key2")}, buckets)
+	})
+
+	t.Run("Missing key -> diff", func(t *testing.T) {
+		buckets, err := d.diff(newDigest(keys[:2]))
+		require.NoError(t, err)
+		require.Equal(t, []int{bucketForKey("key3")}, buckets)
+	})
+
+	t.Run("Empty digest -> diff", func(t *testing.T) {
+		empty := newDigest(nil)
+		require.NotEqual(t, d.Root, empty.Root)
+
+		buckets, err := empty.diff(d)
+		require.NoError(t, err)
+		require.NotEmpty(t, buckets)
+	})
+
+	t.Run("Invalid digest -> error", func(t *testing.T) {
+		buckets, err := d.diff(&digest{Root: []byte("root")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid number of buckets")
+		require.Empty(t, buckets)
+	})
+}
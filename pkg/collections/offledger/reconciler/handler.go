@@ -0,0 +1,142 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package reconciler
+
+import (
+	"encoding/json"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+)
+
+type requestHandler func(r *reconciler, request *reconcileRequest) (interface{}, error)
+
+// handleDigestRequest responds with the digest of the keys in the requested collection
+func (p *Provider) handleDigestRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder) {
+	p.handleRequest(channelID, req, responder, func(r *reconciler, request *reconcileRequest) (interface{}, error) {
+		keys, err := r.getLocalKeys(request.Namespace, request.Collection)
+		if err != nil {
+			return nil, err
+		}
+
+		return newDigest(keys), nil
+	})
+}
+
+// handleKeysRequest responds with the keys (and their versions) in the requested buckets of the collection
+func (p *Provider) handleKeysRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder) {
+	p.handleRequest(channelID, req, responder, func(r *reconciler, request *reconcileRequest) (interface{}, error) {
+		keys, err := r.getLocalKeys(request.Namespace, request.Collection)
+		if err != nil {
+			return nil, err
+		}
+
+		buckets := make(map[int]struct{})
+		for _, b := range request.Buckets {
+			buckets[b] = struct{}{}
+		}
+
+		resp := &keysResponse{}
+		for _, k := range keys {
+			if _, ok := buckets[bucketForKey(k.Key)]; ok {
+				resp.Keys = append(resp.Keys, k)
+			}
+		}
+
+		return resp, nil
+	})
+}
+
+// handleValuesRequest responds with the values of the requested keys. At most one batch of values is returned.
+func (p *Provider) handleValuesRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder) {
+	p.handleRequest(channelID, req, responder, func(r *reconciler, request *reconcileRequest) (interface{}, error) {
+		keys := request.Keys
+		if batchSize := config.GetOLCollReconcileBatchSize(); len(keys) > batchSize {
+			keys = keys[:batchSize]
+		}
+
+		resp := &valuesResponse{}
+		for _, k := range keys {
+			value, txID, err := r.store.GetDataWithRevision(storeapi.NewKey("", request.Namespace, request.Collection, k))
+			if err != nil {
+				return nil, err
+			}
+
+			if value == nil {
+				// The key was advertised by the cached keys but it has since been deleted without a
+				// committed transaction (e.g. by garbage collection) so the cached keys are out of date
+				r.invalidate(request.Namespace, request.Collection)
+				continue
+			}
+
+			resp.Values = append(resp.Values, &keyValue{
+				Key:       k,
+				TxID:      txID,
+				Value:     value.Value,
+				Expiry:    value.Expiry,
+				WriteTime: value.WriteTime,
+			})
+		}
+
+		return resp, nil
+	})
+}
+
+// handleRequest authorizes the requesting peer, invokes the given handler and responds with the result. A nil
+// response is sent if the request is invalid, if the requesting peer is not authorized or if the handler fails.
+func (p *Provider) handleRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder, handle requestHandler) {
+	request := &reconcileRequest{}
+	if err := json.Unmarshal(req.Request, request); err != nil {
+		logger.Warningf("[%s] Error unmarshalling reconcile request of type [%s]: %s", channelID, req.DataType, err)
+		responder.Respond(nil)
+		return
+	}
+
+	resp, err := p.handle(channelID, requesterMSPID(responder), request, handle)
+	if err != nil {
+		logger.Warningf("[%s] Error handling reconcile request of type [%s] for [%s:%s]: %s", channelID, req.DataType, request.Namespace, request.Collection, err)
+		responder.Respond(nil)
+		return
+	}
+
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		logger.Errorf("[%s] Error marshalling reconcile response of type [%s]: %s", channelID, req.DataType, err)
+		responder.Respond(nil)
+		return
+	}
+
+	responder.Respond(respBytes)
+}
+
+func (p *Provider) handle(channelID, mspID string, request *reconcileRequest, handle requestHandler) (interface{}, error) {
+	r := p.getReconciler(channelID)
+
+	policy, err := r.ccRetriever.Policy(request.Namespace, request.Collection)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get policy for [%s:%s]", request.Namespace, request.Collection)
+	}
+
+	if _, ok := policy.MemberOrgs()[mspID]; !ok || mspID == "" {
+		return nil, errors.Errorf("requesting MSP [%s] is not a member of collection [%s:%s]", mspID, request.Namespace, request.Collection)
+	}
+
+	return handle(r, request)
+}
+
+func requesterMSPID(responder appdata.Responder) string {
+	requester, ok := responder.(appdata.Requester)
+	if !ok {
+		return ""
+	}
+
+	return requester.RequesterMSPID()
+}
@@ -0,0 +1,633 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/hyperledger/fabric/extensions/collections/api/support"
+	"github.com/hyperledger/fabric/extensions/endorser/api"
+	gossipapi "github.com/hyperledger/fabric/extensions/gossip/api"
+	"github.com/hyperledger/fabric/gossip/comm"
+	"github.com/pkg/errors"
+
+	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dissemination"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/version"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
+)
+
+var logger = flogging.MustGetLogger("ext_offledger")
+
+const (
+	digestDataType = "offledger-reconcile-digest"
+	keysDataType   = "offledger-reconcile-keys"
+	valuesDataType = "offledger-reconcile-values"
+)
+
+type appDataHandlerRegistry interface {
+	Register(dataType string, handler appdata.Handler) error
+}
+
+type chaincodeInfoProvider interface {
+	AllChaincodesInfo(channelName string, qe ledger.SimpleQueryExecutor) (map[string]*ledger.DeployedChaincodeInfo, error)
+}
+
+// Providers contains the dependencies of the reconciler
+type Providers struct {
+	BlockPublisherProvider api.BlockPublisherProvider
+	StoreProvider          collcommon.StoreProvider
+	GossipProvider         collcommon.GossipProvider
+	CCProvider             collcommon.CollectionConfigProvider
+	IdentifierProvider     collcommon.IdentifierProvider
+	LedgerProvider         collcommon.LedgerProvider
+	HandlerRegistry        appDataHandlerRegistry
+	CCInfoProvider         chaincodeInfoProvider
+}
+
+// Provider periodically reconciles the off-ledger collection data held by the local peer with the data held
+// by other members of the collection. At each interval, a digest of the keys of each collection is exchanged
+// with a randomly chosen member. The keys in the buckets that differ are then compared and any values that are
+// missing from the local store, or that were written more recently than the local values, are pulled from the
+// remote peer and stored locally. Keys that were deleted locally are not pulled.
+//
+// Reconciliation is disabled by default and is enabled with the coll.offledger.reconcile.enabled setting. The
+// off-ledger collections on a channel are registered for reconciliation when the peer joins the channel and
+// as writes to new collections are committed.
+type Provider struct {
+	*Providers
+	mutex       sync.RWMutex
+	reconcilers map[string]*reconciler
+	done        chan struct{}
+	stopped     chan struct{}
+	closed      bool
+}
+
+// NewProvider returns a new reconciler provider
+func NewProvider(providers *Providers) *Provider {
+	logger.Info("Creating off-ledger collection reconciler")
+
+	p := &Provider{
+		Providers:   providers,
+		reconcilers: make(map[string]*reconciler),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	for dataType, handler := range map[string]appdata.Handler{
+		digestDataType: p.handleDigestRequest,
+		keysDataType:   p.handleKeysRequest,
+		valuesDataType: p.handleValuesRequest,
+	} {
+		if err := providers.HandlerRegistry.Register(dataType, handler); err != nil {
+			// Should never happen
+			panic(err)
+		}
+	}
+
+	if !config.IsOLCollReconcileEnabled() {
+		logger.Info("Periodic off-ledger collection reconciliation is disabled")
+
+		close(p.stopped)
+
+		return p
+	}
+
+	p.periodicReconcile(config.GetOLCollReconcileInterval())
+
+	return p
+}
+
+// ChannelJoined is invoked when the peer joins a channel. The off-ledger collections that are defined on the channel
+// are registered for reconciliation and new collections are registered as writes to the collections are committed.
+func (p *Provider) ChannelJoined(channelID string) {
+	if !config.IsOLCollReconcileEnabled() {
+		return
+	}
+
+	if !roles.IsCommitter() {
+		logger.Debugf("[%s] Off-ledger collection data is not reconciled since this peer is not a committer", channelID)
+		return
+	}
+
+	r := p.getReconciler(channelID)
+
+	if err := r.registerCollections(); err != nil {
+		logger.Warningf("[%s] Error registering off-ledger collections for reconciliation: %s", channelID, err)
+	}
+
+	logger.Infof("[%s] Adding collection hash write handler for off-ledger collection reconciliation", channelID)
+
+	p.BlockPublisherProvider.ForChannel(channelID).AddCollHashWriteHandler(r.handleCollHashWrite)
+}
+
+// Close stops the periodic reconciliation
+func (p *Provider) Close() {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+
+		return
+	}
+
+	p.closed = true
+	close(p.done)
+
+	p.mutex.Unlock()
+
+	// Wait for a reconciliation that may be in progress to complete
+	<-p.stopped
+}
+
+// Reconcile reconciles the registered collections on all channels
+func (p *Provider) Reconcile() {
+	for _, r := range p.getReconcilers() {
+		r.reconcile()
+	}
+}
+
+func (p *Provider) getReconciler(channelID string) *reconciler {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	r, ok := p.reconcilers[channelID]
+	if !ok {
+		r = &reconciler{
+			Providers:   p.Providers,
+			channelID:   channelID,
+			ccRetriever: p.CCProvider.ForChannel(channelID),
+			store:       p.StoreProvider.StoreForChannel(channelID),
+			gossip:      p.GossipProvider.GetGossipService(),
+			reqMgr:      requestmgr.Get(channelID),
+			collections: make(map[collKey]*localKeys),
+		}
+		p.reconcilers[channelID] = r
+	}
+
+	return r
+}
+
+func (p *Provider) getReconcilers() []*reconciler {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	var reconcilers []*reconciler
+	for _, r := range p.reconcilers {
+		reconcilers = append(reconcilers, r)
+	}
+
+	return reconcilers
+}
+
+func (p *Provider) periodicReconcile(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer close(p.stopped)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.Reconcile()
+			case <-p.done:
+				logger.Infof("Periodic off-ledger collection reconciliation is exiting")
+				return
+			}
+		}
+	}()
+}
+
+type collKey struct {
+	ns   string
+	coll string
+}
+
+type reconcileRequest struct {
+	Namespace  string   `json:"ns"`
+	Collection string   `json:"coll"`
+	Buckets    []int    `json:"buckets,omitempty"`
+	Keys       []string `json:"keys,omitempty"`
+}
+
+type keysResponse struct {
+	Keys []*keyVersion `json:"keys"`
+}
+
+type keyValue struct {
+	Key       string    `json:"key"`
+	TxID      string    `json:"txid"`
+	Value     []byte    `json:"value"`
+	Expiry    time.Time `json:"expiry"`
+	WriteTime time.Time `json:"write_time"`
+}
+
+type valuesResponse struct {
+	Values []*keyValue `json:"values"`
+}
+
+// localKeys caches the keys (and their versions) of a registered collection so that the keys don't have to be
+// loaded from the store at every interval. The keys are reloaded only after the collection has changed.
+type localKeys struct {
+	keys   []*keyVersion
+	loaded bool
+	// version is incremented whenever the collection changes so that keys loaded
+	// concurrently with a change aren't cached
+	version uint64
+}
+
+type reconciler struct {
+	*Providers
+	channelID   string
+	ccRetriever support.CollectionConfigRetriever
+	store       storeapi.Store
+	gossip      gossipapi.GossipService
+	reqMgr      requestmgr.RequestMgr
+	mutex       sync.RWMutex
+	collections map[collKey]*localKeys
+}
+
+// registerCollections registers all of the off-ledger collections of the chaincodes deployed on the channel
+func (r *reconciler) registerCollections() error {
+	l := r.LedgerProvider.GetLedger(r.channelID)
+	if l == nil {
+		return errors.Errorf("ledger not found for channel [%s]", r.channelID)
+	}
+
+	qe, err := l.NewQueryExecutor()
+	if err != nil {
+		return errors.WithMessage(err, "error creating query executor")
+	}
+	defer qe.Done()
+
+	ccInfos, err := r.CCInfoProvider.AllChaincodesInfo(r.channelID, qe)
+	if err != nil {
+		return errors.WithMessage(err, "error getting deployed chaincodes")
+	}
+
+	for ns, ccInfo := range ccInfos {
+		if ccInfo == nil || ccInfo.ExplicitCollectionConfigPkg == nil {
+			continue
+		}
+
+		for _, c := range ccInfo.ExplicitCollectionConfigPkg.Config {
+			collConfig := c.GetStaticCollectionConfig()
+			if collConfig != nil && isOffLedger(collConfig.Type) {
+				r.register(ns, collConfig.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// handleCollHashWrite registers the collection for reconciliation if it is an off-ledger collection. The cached
+// keys of a registered collection are invalidated since the write changes the keys held by the local store.
+func (r *reconciler) handleCollHashWrite(txMetadata gossipapi.TxMetadata, ns, coll string, _ *kvrwset.KVWriteHash) error {
+	if r.invalidate(ns, coll) {
+		return nil
+	}
+
+	collConfig, err := r.ccRetriever.Config(ns, coll)
+	if err != nil {
+		logger.Debugf("[%s] Unable to get config for collection [%s:%s] in TxID [%s]: %s", r.channelID, ns, coll, txMetadata.TxID, err)
+		return nil
+	}
+
+	if !isOffLedger(collConfig.Type) {
+		return nil
+	}
+
+	r.register(ns, coll)
+
+	return nil
+}
+
+func (r *reconciler) register(ns, coll string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := collKey{ns: ns, coll: coll}
+	if _, ok := r.collections[key]; ok {
+		return
+	}
+
+	logger.Infof("[%s] Registering off-ledger collection [%s:%s] for reconciliation", r.channelID, ns, coll)
+
+	r.collections[key] = &localKeys{}
+}
+
+// invalidate clears the cached keys of the given collection. False is returned if the collection isn't registered.
+func (r *reconciler) invalidate(ns, coll string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, ok := r.collections[collKey{ns: ns, coll: coll}]
+	if !ok {
+		return false
+	}
+
+	c.keys = nil
+	c.loaded = false
+	c.version++
+
+	return true
+}
+
+func (r *reconciler) getCollections() []collKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var keys []collKey
+	for key := range r.collections {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (r *reconciler) reconcile() {
+	for _, key := range r.getCollections() {
+		if err := r.reconcileCollection(key.ns, key.coll); err != nil {
+			logger.Warningf("[%s] Error reconciling off-ledger collection [%s:%s]: %s", r.channelID, key.ns, key.coll, err)
+		}
+	}
+}
+
+func (r *reconciler) reconcileCollection(ns, coll string) error {
+	collConfig, err := r.ccRetriever.Config(ns, coll)
+	if err != nil {
+		return errors.WithMessagef(err, "unable to get config for [%s:%s]", ns, coll)
+	}
+
+	policy, err := r.ccRetriever.Policy(ns, coll)
+	if err != nil {
+		return errors.WithMessagef(err, "unable to get policy for [%s:%s]", ns, coll)
+	}
+
+	localMSPID, err := r.IdentifierProvider.GetIdentifier()
+	if err != nil {
+		return errors.WithMessage(err, "unable to get local MSP ID")
+	}
+
+	if _, ok := policy.MemberOrgs()[localMSPID]; !ok {
+		logger.Debugf("[%s] Local peer is not a member of collection [%s:%s]. Nothing to reconcile.", r.channelID, ns, coll)
+		return nil
+	}
+
+	peers := dissemination.New(r.channelID, ns, coll, policy, r.gossip).ResolvePeersForQuery()
+	if len(peers) == 0 {
+		logger.Debugf("[%s] No peers available to reconcile collection [%s:%s]", r.channelID, ns, coll)
+		return nil
+	}
+
+	peer := peers[rand.Intn(len(peers))]
+
+	localKeys, err := r.getLocalKeys(ns, coll)
+	if err != nil {
+		return err
+	}
+
+	remoteDigest := &digest{}
+	if err := r.request(peer, digestDataType, &reconcileRequest{Namespace: ns, Collection: coll}, remoteDigest); err != nil {
+		return errors.WithMessagef(err, "error requesting digest from [%s]", peer)
+	}
+
+	buckets, err := newDigest(localKeys).diff(remoteDigest)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid digest from [%s]", peer)
+	}
+
+	if len(buckets) == 0 {
+		logger.Debugf("[%s] Collection [%s:%s] is in sync with [%s]", r.channelID, ns, coll, peer)
+		return nil
+	}
+
+	logger.Debugf("[%s] %d bucket(s) differ between local peer and [%s] for collection [%s:%s]", r.channelID, len(buckets), peer, ns, coll)
+
+	remoteKeys := &keysResponse{}
+	if err := r.request(peer, keysDataType, &reconcileRequest{Namespace: ns, Collection: coll, Buckets: buckets}, remoteKeys); err != nil {
+		return errors.WithMessagef(err, "error requesting keys from [%s]", peer)
+	}
+
+	keys, err := r.keysToPull(ns, coll, localKeys, remoteKeys.Keys)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		logger.Debugf("[%s] No keys need to be pulled from [%s] for collection [%s:%s]", r.channelID, peer, ns, coll)
+		return nil
+	}
+
+	logger.Infof("[%s] Pulling %d key(s) from [%s] for collection [%s:%s]", r.channelID, len(keys), peer, ns, coll)
+
+	batchSize := config.GetOLCollReconcileBatchSize()
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		if err := r.pullValues(peer, collConfig, ns, keys[start:end]); err != nil {
+			return err
+		}
+	}
+
+	r.invalidate(ns, coll)
+
+	return nil
+}
+
+func (r *reconciler) pullValues(peer *discovery.Member, collConfig *pb.StaticCollectionConfig, ns string, keys []string) error {
+	values := &valuesResponse{}
+	if err := r.request(peer, valuesDataType, &reconcileRequest{Namespace: ns, Collection: collConfig.Name, Keys: keys}, values); err != nil {
+		return errors.WithMessagef(err, "error requesting values from [%s]", peer)
+	}
+
+	for _, v := range values.Values {
+		key := storeapi.NewKey(v.TxID, ns, collConfig.Name, v.Key)
+
+		logger.Debugf("[%s] Storing reconciled key [%s]", r.channelID, key)
+
+		// The original write time is preserved so that the value isn't considered to be newer than it is
+		if err := r.store.PutData(collConfig, key, &storeapi.ExpiringValue{Value: v.Value, Expiry: v.Expiry, WriteTime: v.WriteTime}); err != nil {
+			logger.Warningf("[%s] Error storing reconciled key [%s]: %s", r.channelID, key, err)
+		}
+	}
+
+	return nil
+}
+
+// keysToPull returns the remote keys that are missing from the local store (and weren't deleted locally) or
+// that were written more recently than the local value
+func (r *reconciler) keysToPull(ns, coll string, localKeys, remoteKeys []*keyVersion) ([]string, error) {
+	localVersions := make(map[string]*version.Version)
+	for _, k := range localKeys {
+		localVersions[k.Key] = version.New(k.TxID, k.WriteTime)
+	}
+
+	var keys []string
+	for _, k := range remoteKeys {
+		localVersion, ok := localVersions[k.Key]
+		if ok {
+			if localVersion.TxID != k.TxID && version.New(k.TxID, k.WriteTime).IsNewerThan(localVersion) {
+				keys = append(keys, k.Key)
+			}
+
+			continue
+		}
+
+		deleted, err := r.isDeleted(ns, coll, k)
+		if err != nil {
+			return nil, err
+		}
+
+		if deleted {
+			logger.Debugf("[%s] Key [%s:%s:%s] was deleted locally and won't be pulled", r.channelID, ns, coll, k.Key)
+			continue
+		}
+
+		keys = append(keys, k.Key)
+	}
+
+	return keys, nil
+}
+
+// isDeleted returns true if the given key was deleted from the local store. A deleted key is only pulled again if
+// the remote value was written after the key was deleted by a transaction.
+func (r *reconciler) isDeleted(ns, coll string, k *keyVersion) (bool, error) {
+	tombstone, err := r.store.GetTombstone(storeapi.NewKey("", ns, coll, k.Key))
+	if err != nil {
+		return false, errors.WithMessagef(err, "error getting tombstone for [%s:%s:%s]", ns, coll, k.Key)
+	}
+
+	if tombstone == nil {
+		return false, nil
+	}
+
+	return tombstone.TxID == "" || !version.New(k.TxID, k.WriteTime).IsNewerThan(version.New(tombstone.TxID, tombstone.WriteTime)), nil
+}
+
+// getLocalKeys returns the keys (and their versions) of the given collection, ordered by key. The keys of a
+// registered collection are cached until the collection changes.
+func (r *reconciler) getLocalKeys(ns, coll string) ([]*keyVersion, error) {
+	key := collKey{ns: ns, coll: coll}
+
+	r.mutex.RLock()
+	c, registered := r.collections[key]
+	var cached localKeys
+	if registered {
+		cached = *c
+	}
+	r.mutex.RUnlock()
+
+	if cached.loaded {
+		return cached.keys, nil
+	}
+
+	keys, err := r.loadLocalKeys(ns, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	if registered {
+		r.mutex.Lock()
+		if c.version == cached.version {
+			c.keys = keys
+			c.loaded = true
+		}
+		r.mutex.Unlock()
+	}
+
+	return keys, nil
+}
+
+func (r *reconciler) loadLocalKeys(ns, coll string) ([]*keyVersion, error) {
+	logger.Debugf("[%s] Loading keys of collection [%s:%s]", r.channelID, ns, coll)
+
+	it, err := r.store.GetDataByRange(storeapi.NewRangeKey("", ns, coll, "", ""))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error getting keys for [%s:%s]", ns, coll)
+	}
+	defer it.Close()
+
+	var keys []*keyVersion
+	for {
+		next, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error getting keys for [%s:%s]", ns, coll)
+		}
+
+		if next == nil {
+			return keys, nil
+		}
+
+		keys = append(keys, &keyVersion{Key: next.Key.Key, TxID: next.Key.EndorsedAtTxID, WriteTime: next.WriteTime})
+	}
+}
+
+// request sends the given request to the given peer and unmarshals the response into resp
+func (r *reconciler) request(peer *discovery.Member, dataType string, request *reconcileRequest, resp interface{}) error {
+	reqBytes, err := json.Marshal(request)
+	if err != nil {
+		return errors.WithMessage(err, "error marshalling request")
+	}
+
+	ctxt, cancel := context.WithTimeout(context.Background(), config.GetOLCollPullTimeout())
+	defer cancel()
+
+	req := r.reqMgr.NewRequest()
+
+	logger.Debugf("[%s] Sending Gossip request %d of type [%s] to [%s]", r.channelID, req.ID(), dataType, peer)
+
+	r.gossip.Send(r.createRequestMsg(req.ID(), dataType, reqBytes), &comm.RemotePeer{Endpoint: peer.Endpoint, PKIID: peer.PKIid})
+
+	res, err := req.GetResponse(ctxt)
+	if err != nil {
+		return err
+	}
+
+	data, ok := res.Data.([]byte)
+	if !ok || len(data) == 0 {
+		return errors.New("empty response")
+	}
+
+	return json.Unmarshal(data, resp)
+}
+
+func isOffLedger(collType pb.CollectionType) bool {
+	return collType == pb.CollectionType_COL_OFFLEDGER || collType == pb.CollectionType_COL_DCAS
+}
+
+func (r *reconciler) createRequestMsg(reqID uint64, dataType string, reqBytes []byte) *gproto.GossipMessage {
+	return &gproto.GossipMessage{
+		Tag:     gproto.GossipMessage_CHAN_ONLY,
+		Channel: []byte(r.channelID),
+		Content: &gproto.GossipMessage_AppDataReq{
+			AppDataReq: &gproto.AppDataRequest{
+				Nonce:    reqID,
+				DataType: dataType,
+				Request:  reqBytes,
+			},
+		},
+	}
+}
@@ -0,0 +1,452 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package reconciler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/core/ledger"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	gossipapi "github.com/hyperledger/fabric/extensions/gossip/api"
+	gcommon "github.com/hyperledger/fabric/gossip/common"
+	"github.com/pkg/errors"
+	viper "github.com/spf13/viper2015"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
+)
+
+const (
+	channelID = "testchannel"
+	ns1       = "chaincode1"
+	coll1     = "collection1"
+	coll2     = "collection2"
+	coll3     = "collection3"
+
+	org1MSPID = "Org1MSP"
+	org2MSPID = "Org2MSP"
+	org3MSPID = "Org3MSP"
+
+	key1 = "key1"
+	key2 = "key2"
+	key3 = "key3"
+
+	txID1 = "tx1"
+	txID2 = "tx2"
+	txID3 = "tx3"
+	txID4 = "tx4"
+)
+
+var (
+	p1Org1PKIID = gcommon.PKIidType("pkiid_P1O1")
+	p1Org2PKIID = gcommon.PKIidType("pkiid_P1O2")
+
+	// value3 was written before value1 which was written before value2
+	value1 = &storeapi.ExpiringValue{Value: []byte("value1"), WriteTime: time.Now().UTC().Add(-2 * time.Hour)}
+	value2 = &storeapi.ExpiringValue{Value: []byte("value2"), WriteTime: time.Now().UTC().Add(-time.Hour)}
+	value3 = &storeapi.ExpiringValue{Value: []byte("value3"), WriteTime: time.Now().UTC().Add(-3 * time.Hour)}
+)
+
+func TestReconciler(t *testing.T) {
+	oldEnabled := viper.Get("coll.offledger.reconcile.enabled")
+	defer viper.Set("coll.offledger.reconcile.enabled", oldEnabled)
+	viper.Set("coll.offledger.reconcile.enabled", true)
+
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			Orgs: []string{org1MSPID, org2MSPID},
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll1,
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_PRIVATE,
+			Name: coll2,
+		})
+
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	ledgerProvider := &mocks.LedgerProvider{}
+	ledgerProvider.GetLedgerReturns(&mocks.Ledger{
+		QueryExecutor: mocks.NewQueryExecutor(),
+	})
+
+	// Set up the remote peer
+	remoteStore := mocks.NewDataStore()
+	require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, key1), value1))
+	require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID2, ns1, coll1, key2), value2))
+	require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID2, ns1, coll1, key3), value3))
+
+	remoteRegistry := newMockHandlerRegistry()
+	remote := newProvider(t, remoteRegistry, remoteStore, ccProvider, ledgerProvider, mocks.NewMockGossipAdapter(), org2MSPID)
+	defer remote.Close()
+
+	// Set up the local peer
+	localStore := mocks.NewDataStore()
+	require.NoError(t, localStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, key1), value1))
+	require.NoError(t, localStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, key2), value1))
+
+	gossip := mocks.NewMockGossipAdapter()
+	gossip.Self(org1MSPID, mocks.NewMember("p1.org1.com", p1Org1PKIID)).
+		Member(org2MSPID, mocks.NewMember("p1.org2.com", p1Org2PKIID, string(roles.CommitterRole))).
+		MessageHandler(remoteRegistry.newGossipHandler(org1MSPID))
+
+	localRegistry := newMockHandlerRegistry()
+	local := newProvider(t, localRegistry, localStore, ccProvider, ledgerProvider, gossip, org1MSPID)
+	defer local.Close()
+
+	blockPublisher := mocks.NewBlockPublisher()
+	local.BlockPublisherProvider = mocks.NewBlockPublisherProvider().WithBlockPublisher(blockPublisher)
+
+	local.ChannelJoined(channelID)
+	require.NotNil(t, blockPublisher.HandleCollHashWrite)
+
+	// The off-ledger collections of the deployed chaincodes are registered when the channel is joined
+	require.Equal(t, []collKey{{ns: ns1, coll: coll1}}, local.getReconciler(channelID).getCollections())
+
+	txMetadata := gossipapi.TxMetadata{BlockNum: 30, TxID: txID2}
+	require.NoError(t, blockPublisher.HandleCollHashWrite(txMetadata, ns1, coll1, nil))
+	require.NoError(t, blockPublisher.HandleCollHashWrite(txMetadata, ns1, coll1, nil))
+	require.NoError(t, blockPublisher.HandleCollHashWrite(txMetadata, ns1, coll2, nil))
+	require.NoError(t, blockPublisher.HandleCollHashWrite(txMetadata, ns1, coll3, nil))
+	require.Equal(t, []collKey{{ns: ns1, coll: coll1}}, local.getReconciler(channelID).getCollections())
+
+	t.Run("Reconcile -> success", func(t *testing.T) {
+		oldVal := viper.Get("coll.offledger.reconcile.batchSize")
+		defer viper.Set("coll.offledger.reconcile.batchSize", oldVal)
+		viper.Set("coll.offledger.reconcile.batchSize", 1)
+
+		local.Reconcile()
+
+		value, txID, err := localStore.GetDataWithRevision(storeapi.NewKey("", ns1, coll1, key1))
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+		require.Equal(t, txID1, txID)
+
+		// key2 was updated more recently on the remote peer
+		value, txID, err = localStore.GetDataWithRevision(storeapi.NewKey("", ns1, coll1, key2))
+		require.NoError(t, err)
+		require.Equal(t, value2, value)
+		require.Equal(t, txID2, txID)
+
+		// key3 was missing from the local peer
+		value, txID, err = localStore.GetDataWithRevision(storeapi.NewKey("", ns1, coll1, key3))
+		require.NoError(t, err)
+		require.Equal(t, value3, value)
+		require.Equal(t, txID2, txID)
+
+		keys, err := local.getReconciler(channelID).getLocalKeys(ns1, coll1)
+		require.NoError(t, err)
+
+		remoteKeys, err := remote.getReconciler(channelID).getLocalKeys(ns1, coll1)
+		require.NoError(t, err)
+
+		buckets, err := newDigest(keys).diff(newDigest(remoteKeys))
+		require.NoError(t, err)
+		require.Empty(t, buckets)
+	})
+
+	t.Run("Local value is newer -> not replaced", func(t *testing.T) {
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID3, ns1, coll1, key1), value3))
+
+		local.Reconcile()
+
+		value, txID, err := localStore.GetDataWithRevision(storeapi.NewKey("", ns1, coll1, key1))
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+		require.Equal(t, txID1, txID)
+	})
+
+	t.Run("Deleted locally -> not pulled", func(t *testing.T) {
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, "key5"), value1))
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, "key6"), value1))
+
+		require.NoError(t, localStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, "key5"), value1))
+		require.NoError(t, localStore.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, "key6"), value1))
+
+		// key5 is deleted by a transaction and key6 is deleted locally (e.g. by garbage collection)
+		require.NoError(t, localStore.DeleteData(coll1Config, storeapi.NewMultiKey(txID2, ns1, coll1, "key5")))
+		require.NoError(t, localStore.DeleteData(coll1Config, storeapi.NewMultiKey("", ns1, coll1, "key6")))
+		require.NoError(t, blockPublisher.HandleCollHashWrite(gossipapi.TxMetadata{BlockNum: 20, TxID: txID2}, ns1, coll1, nil))
+
+		local.Reconcile()
+
+		value, err := localStore.GetData(storeapi.NewKey("", ns1, coll1, "key5"))
+		require.NoError(t, err)
+		require.Nil(t, value)
+
+		value, err = localStore.GetData(storeapi.NewKey("", ns1, coll1, "key6"))
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+
+	t.Run("Written after local delete -> pulled", func(t *testing.T) {
+		value4 := &storeapi.ExpiringValue{Value: []byte("value4"), WriteTime: time.Now().UTC()}
+
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID4, ns1, coll1, "key5"), value4))
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID4, ns1, coll1, "key6"), value4))
+
+		local.Reconcile()
+
+		value, txID, err := localStore.GetDataWithRevision(storeapi.NewKey("", ns1, coll1, "key5"))
+		require.NoError(t, err)
+		require.Equal(t, value4, value)
+		require.Equal(t, txID4, txID)
+
+		// key6 was deleted without a transaction so it's never pulled
+		value, err = localStore.GetData(storeapi.NewKey("", ns1, coll1, "key6"))
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+
+	t.Run("Cached keys", func(t *testing.T) {
+		r := local.getReconciler(channelID)
+
+		keys, err := r.getLocalKeys(ns1, coll1)
+		require.NoError(t, err)
+
+		// The store isn't scanned again until the collection changes
+		localStore.Error(errors.New("injected store error"))
+
+		cachedKeys, err := r.getLocalKeys(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, keys, cachedKeys)
+
+		require.NoError(t, blockPublisher.HandleCollHashWrite(gossipapi.TxMetadata{BlockNum: 40, TxID: txID4}, ns1, coll1, nil))
+
+		_, err = r.getLocalKeys(ns1, coll1)
+		require.Error(t, err)
+
+		localStore.Error(nil)
+
+		keys, err = r.getLocalKeys(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, keys, cachedKeys)
+	})
+
+	t.Run("Not a member -> nothing reconciled", func(t *testing.T) {
+		require.NoError(t, remoteStore.PutData(coll1Config, storeapi.NewKey(txID2, ns1, coll1, "key4"), value1))
+
+		identifierProvider := &mocks.IdentifierProvider{}
+		identifierProvider.GetIdentifierReturns(org3MSPID, nil)
+
+		restore := local.IdentifierProvider
+		local.IdentifierProvider = identifierProvider
+		defer func() { local.IdentifierProvider = restore }()
+
+		local.Reconcile()
+
+		value, err := localStore.GetData(storeapi.NewKey("", ns1, coll1, "key4"))
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+
+	t.Run("Remote error -> nothing reconciled", func(t *testing.T) {
+		remoteStore.Error(errors.New("injected store error"))
+		defer remoteStore.Error(nil)
+
+		require.NotPanics(t, local.Reconcile)
+
+		value, err := localStore.GetData(storeapi.NewKey("", ns1, coll1, "key4"))
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+
+	t.Run("Chaincode info error -> collections registered on write", func(t *testing.T) {
+		restore := local.CCInfoProvider
+		local.CCInfoProvider = mocks.NewChaincodeInfoProvider().WithError(errors.New("injected chaincode info error"))
+		defer func() { local.CCInfoProvider = restore }()
+
+		bp := mocks.NewBlockPublisher()
+		local.BlockPublisherProvider = mocks.NewBlockPublisherProvider().WithBlockPublisher(bp)
+
+		local.ChannelJoined("channel3")
+		require.NotNil(t, bp.HandleCollHashWrite)
+		require.Empty(t, local.getReconciler("channel3").getCollections())
+	})
+
+	t.Run("Non-committer -> no handler", func(t *testing.T) {
+		reset := roles.SetRole(roles.EndorserRole)
+		defer reset()
+
+		bp := mocks.NewBlockPublisher()
+		local.BlockPublisherProvider = mocks.NewBlockPublisherProvider().WithBlockPublisher(bp)
+
+		local.ChannelJoined("channel2")
+		require.Nil(t, bp.HandleCollHashWrite)
+	})
+}
+
+func TestReconciler_Disabled(t *testing.T) {
+	oldEnabled := viper.Get("coll.offledger.reconcile.enabled")
+	defer viper.Set("coll.offledger.reconcile.enabled", oldEnabled)
+	viper.Set("coll.offledger.reconcile.enabled", false)
+
+	p := newProvider(t, newMockHandlerRegistry(), mocks.NewDataStore(), &mocks.CollectionConfigProvider{},
+		&mocks.LedgerProvider{}, mocks.NewMockGossipAdapter(), org1MSPID)
+
+	bp := mocks.NewBlockPublisher()
+	p.BlockPublisherProvider = mocks.NewBlockPublisherProvider().WithBlockPublisher(bp)
+
+	p.ChannelJoined(channelID)
+	require.Nil(t, bp.HandleCollHashWrite)
+	require.Empty(t, p.getReconcilers())
+
+	// Close must not wait for periodic reconciliation which was never started
+	p.Close()
+}
+
+func TestProvider_HandleRequest(t *testing.T) {
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccProvider.ForChannelReturns(mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			Orgs: []string{org1MSPID, org2MSPID},
+		}).
+		WithCollectionConfig(coll1Config),
+	)
+
+	store := mocks.NewDataStore()
+	require.NoError(t, store.PutData(coll1Config, storeapi.NewKey(txID1, ns1, coll1, key1), value1))
+
+	registry := newMockHandlerRegistry()
+	p := newProvider(t, registry, store, ccProvider, &mocks.LedgerProvider{}, mocks.NewMockGossipAdapter(), org2MSPID)
+	defer p.Close()
+
+	reqBytes, err := json.Marshal(&reconcileRequest{Namespace: ns1, Collection: coll1, Keys: []string{key1, key2}})
+	require.NoError(t, err)
+
+	t.Run("Values -> success", func(t *testing.T) {
+		responder := &mockResponder{mspID: org1MSPID}
+		registry.handlers[valuesDataType](channelID, &gproto.AppDataRequest{DataType: valuesDataType, Request: reqBytes}, responder)
+		require.NotNil(t, responder.data)
+
+		resp := &valuesResponse{}
+		require.NoError(t, json.Unmarshal(responder.data, resp))
+		require.Len(t, resp.Values, 1)
+		require.Equal(t, key1, resp.Values[0].Key)
+		require.Equal(t, txID1, resp.Values[0].TxID)
+		require.Equal(t, value1.Value, resp.Values[0].Value)
+	})
+
+	t.Run("Not a member -> nil", func(t *testing.T) {
+		responder := &mockResponder{mspID: org3MSPID}
+		registry.handlers[digestDataType](channelID, &gproto.AppDataRequest{DataType: digestDataType, Request: reqBytes}, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Unknown requester -> nil", func(t *testing.T) {
+		responder := &mockResponder{}
+		registry.handlers[keysDataType](channelID, &gproto.AppDataRequest{DataType: keysDataType, Request: reqBytes}, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Invalid request -> nil", func(t *testing.T) {
+		responder := &mockResponder{mspID: org1MSPID}
+		registry.handlers[keysDataType](channelID, &gproto.AppDataRequest{DataType: keysDataType, Request: []byte("{")}, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Duplicate registration -> panic", func(t *testing.T) {
+		require.Panics(t, func() {
+			newProvider(t, registry, store, ccProvider, &mocks.LedgerProvider{}, mocks.NewMockGossipAdapter(), org2MSPID)
+		})
+	})
+}
+
+var coll1Config = &pb.StaticCollectionConfig{
+	Type: pb.CollectionType_COL_OFFLEDGER,
+	Name: coll1,
+}
+
+func newProvider(t *testing.T, registry *mockHandlerRegistry, store *mocks.DataStore, ccProvider *mocks.CollectionConfigProvider,
+	ledgerProvider *mocks.LedgerProvider, gossip *mocks.MockGossipAdapter, mspID string) *Provider {
+	t.Helper()
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(store)
+
+	gossipProvider := &mocks.GossipProvider{}
+	gossipProvider.GetGossipServiceReturns(gossip)
+
+	identifierProvider := &mocks.IdentifierProvider{}
+	identifierProvider.GetIdentifierReturns(mspID, nil)
+
+	return NewProvider(&Providers{
+		BlockPublisherProvider: mocks.NewBlockPublisherProvider(),
+		StoreProvider:          storeProvider,
+		GossipProvider:         gossipProvider,
+		CCProvider:             ccProvider,
+		IdentifierProvider:     identifierProvider,
+		LedgerProvider:         ledgerProvider,
+		HandlerRegistry:        registry,
+		CCInfoProvider: mocks.NewChaincodeInfoProvider().WithData(ns1, &ledger.DeployedChaincodeInfo{
+			Name: ns1,
+			ExplicitCollectionConfigPkg: &pb.CollectionConfigPackage{
+				Config: []*pb.CollectionConfig{
+					{Payload: &pb.CollectionConfig_StaticCollectionConfig{StaticCollectionConfig: coll1Config}},
+					{Payload: &pb.CollectionConfig_StaticCollectionConfig{StaticCollectionConfig: &pb.StaticCollectionConfig{
+						Type: pb.CollectionType_COL_PRIVATE,
+						Name: coll2,
+					}}},
+				},
+			},
+		}),
+	})
+}
+
+type mockHandlerRegistry struct {
+	handlers map[string]appdata.Handler
+}
+
+func newMockHandlerRegistry() *mockHandlerRegistry {
+	return &mockHandlerRegistry{handlers: make(map[string]appdata.Handler)}
+}
+
+func (m *mockHandlerRegistry) Register(dataType string, handler appdata.Handler) error {
+	if _, ok := m.handlers[dataType]; ok {
+		return errors.Errorf("handler for data type [%s] already registered", dataType)
+	}
+
+	m.handlers[dataType] = handler
+
+	return nil
+}
+
+// newGossipHandler returns a Gossip message handler which dispatches application data requests to the
+// registered handlers and sends the response back to the requester
+func (m *mockHandlerRegistry) newGossipHandler(requesterMSPID string) mocks.MessageHandler {
+	return func(msg *gproto.GossipMessage) {
+		req := msg.GetAppDataReq()
+
+		responder := &mockResponder{mspID: requesterMSPID}
+		m.handlers[req.DataType](string(msg.Channel), req, responder)
+
+		requestmgr.Get(string(msg.Channel)).Respond(req.Nonce, &requestmgr.Response{Data: responder.data})
+	}
+}
+
+type mockResponder struct {
+	mspID string
+	data  []byte
+}
+
+func (m *mockResponder) Respond(data []byte) {
+	m.data = data
+}
+
+func (m *mockResponder) RequesterMSPID() string {
+	return m.mspID
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/multirequest"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
 	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
 )
 
@@ -44,11 +45,16 @@ type Decorator interface {
 	AfterQuery(key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error)
 }
 
+type appDataHandlerRegistry interface {
+	Register(dataType string, handler appdata.Handler) error
+}
+
 // Provider is a collection data data provider.
 type Provider struct {
 	*collcommon.Providers
-	validators map[pb.CollectionType]Validator
-	decorators map[pb.CollectionType]Decorator
+	validators      map[pb.CollectionType]Validator
+	decorators      map[pb.CollectionType]Decorator
+	handlerRegistry appDataHandlerRegistry
 }
 
 // Option is a provider option
@@ -68,6 +74,13 @@ func WithDecorator(collType pb.CollectionType, decorator Decorator) Option {
 	}
 }
 
+// WithAppDataHandlerRegistry sets the registry with which the remote query handler is registered
+func WithAppDataHandlerRegistry(registry appDataHandlerRegistry) Option {
+	return func(p *Provider) {
+		p.handlerRegistry = registry
+	}
+}
+
 // NewProvider returns a new collection data provider
 func NewProvider(providers *collcommon.Providers, opts ...Option) olapi.Provider {
 	p := &Provider{
@@ -80,6 +93,16 @@ func NewProvider(providers *collcommon.Providers, opts ...Option) olapi.Provider
 	for _, opt := range opts {
 		opt(p)
 	}
+
+	if p.handlerRegistry != nil {
+		logger.Info("Registering off-ledger remote query handler")
+
+		if err := p.handlerRegistry.Register(queryDataType, p.handleQueryRequest); err != nil {
+			// Should never happen
+			panic(err)
+		}
+	}
+
 	return p
 }
 
@@ -110,6 +133,9 @@ func (p *Provider) RetrieverForChannel(channelID string) olapi.Retriever {
 type resolver interface {
 	// ResolvePeersForRetrieval resolves to a set of peers from which data should be retrieved
 	ResolvePeersForRetrieval(filter dissemination.PeerFilter) discovery.PeerGroup
+
+	// ResolvePeersForQuery resolves to the set of remote peers to which a query should be sent
+	ResolvePeersForQuery() discovery.PeerGroup
 }
 
 type collKey struct {
@@ -239,23 +265,83 @@ func (r *retriever) getDataFromRemotePeers(ctxt context.Context, key *storeapi.M
 	return values, nil
 }
 
-// Query executes the given rich query
+// Query executes the given rich query. The query is executed on the local store if the local peer is a member
+// of the collection and holds a copy of the data, otherwise the query is sent to the members of the collection.
 func (r *retriever) Query(ctxt context.Context, key *storeapi.QueryKey) (storeapi.ResultsIterator, error) {
 	authorized, err := r.isAuthorized(key.Namespace, key.Collection)
 	if err != nil {
 		return nil, err
 	}
+
+	collConfig, err := r.Config(key.Namespace, key.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if !authorized && collConfig.MemberOnlyRead {
+		logger.Infof("[%s] This peer does not have access to the collection [%s:%s]", r.channelID, key.Namespace, key.Collection)
+		return noResultsIt, nil
+	}
+
+	var it storeapi.ResultsIterator
+	if authorized && hasLocalCopy(collConfig) {
+		it, err = r.store.Query(key)
+	} else {
+		logger.Debugf("[%s] Local peer is not a member of [%s:%s] or does not hold a copy of the data. Querying remote peers.", r.channelID, key.Namespace, key.Collection)
+		it, err = r.queryRemotePeers(ctxt, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decorate(key.Namespace, key.Collection, it)
+}
+
+// QueryWithPagination returns at most pageSize results from the given query starting at the given bookmark
+func (r *retriever) QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	authorized, err := r.isAuthorized(key.Namespace, key.Collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !authorized {
+		logger.Infof("[%s] This peer does not have access to the collection [%s:%s]", r.channelID, key.Namespace, key.Collection)
+		return noResultsIt, &storeapi.QueryResponseMetadata{}, nil
+	}
+
+	it, metadata, err := r.store.QueryWithPagination(key, bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	it, err = r.decorate(key.Namespace, key.Collection, it)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return it, metadata, nil
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (r *retriever) GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	authorized, err := r.isAuthorized(key.Namespace, key.Collection)
+	if err != nil {
+		return nil, err
+	}
 	if !authorized {
 		logger.Infof("[%s] This peer does not have access to the collection [%s:%s]", r.channelID, key.Namespace, key.Collection)
 		return noResultsIt, nil
 	}
 
-	it, err := r.store.Query(key)
+	it, err := r.store.GetDataByRange(key)
 	if err != nil {
 		return nil, err
 	}
 
-	decorator, err := r.getDecorator(key.Namespace, key.Collection)
+	return r.decorate(key.Namespace, key.Collection, it)
+}
+
+func (r *retriever) decorate(ns, coll string, it storeapi.ResultsIterator) (storeapi.ResultsIterator, error) {
+	decorator, err := r.getDecorator(ns, coll)
 	if err != nil {
 		return nil, err
 	}
@@ -506,6 +592,11 @@ func (r *retriever) createCollDataRequestMsg(req requestmgr.Request, key *storea
 	}
 }
 
+// hasLocalCopy returns true if the local peer persists data for the given collection
+func hasLocalCopy(collConfig *pb.StaticCollectionConfig) bool {
+	return roles.IsCommitter() || collConfig.MaximumPeerCount == 0
+}
+
 func asRemotePeers(members []*discovery.Member) []*comm.RemotePeer {
 	var peers []*comm.RemotePeer
 	for _, m := range members {
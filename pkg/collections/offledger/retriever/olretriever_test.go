@@ -470,6 +470,58 @@ func TestRetriever_Query(t *testing.T) {
 		require.Nil(t, next)
 	})
 
+	t.Run("Query with pagination -> success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, metadata, err := retriever.QueryWithPagination(ctx, dcasQueryKey, "", 1)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		require.NotNil(t, metadata)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+		require.NotEmpty(t, metadata.Bookmark)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, valueX, next.Value)
+		require.Equal(t, keyX, next.Key.Key)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+		it.Close()
+
+		it, metadata, err = retriever.QueryWithPagination(ctx, dcasQueryKey, metadata.Bookmark, 1)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, valueY, next.Value)
+		require.Equal(t, keyY, next.Key.Key)
+		it.Close()
+	})
+
+	t.Run("Query with pagination access denied -> empty", func(t *testing.T) {
+		identifierProvider.GetIdentifierReturns(org3MSPID, nil)
+		defer func() { identifierProvider.GetIdentifierReturns(org1MSPID, nil) }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, metadata, err := retriever.QueryWithPagination(ctx, offLedgerQueryKey, "", 1)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		require.NotNil(t, metadata)
+		require.Zero(t, metadata.FetchedRecordsCount)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
 	t.Run("Query iterator error", func(t *testing.T) {
 		expectedErr := errors.New("injected error")
 		localStore.WithResultsIteratorError(expectedErr)
@@ -512,6 +564,102 @@ func TestRetriever_Query(t *testing.T) {
 	})
 }
 
+func TestRetriever_GetDataByRange(t *testing.T) {
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			MaxPeerCount: 2,
+			Orgs:         []string{org1MSPID, org2MSPID},
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll1,
+		})
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	identifierProvider := &mocks.IdentifierProvider{}
+	identifierProvider.GetIdentifierReturns(org1MSPID, nil)
+
+	localStore := mocks.NewDataStore().
+		Data(storeapi.NewKey(txID1, ns1, coll1, key2), value2).
+		Data(storeapi.NewKey(txID1, ns1, coll1, key1), value1).
+		Data(storeapi.NewKey(txID1, ns1, coll1, key3), value3)
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(localStore)
+
+	gossipProvider := &mocks.GossipProvider{}
+	gossipProvider.GetGossipServiceReturns(mocks.NewMockGossipAdapter())
+
+	providers := &collcommon.Providers{
+		BlockPublisherProvider: mocks.NewBlockPublisherProvider(),
+		StoreProvider:          storeProvider,
+		GossipProvider:         gossipProvider,
+		CCProvider:             ccProvider,
+		IdentifierProvider:     identifierProvider,
+	}
+
+	retriever := NewProvider(providers).RetrieverForChannel(channelID)
+	require.NotNil(t, retriever)
+
+	rangeKey := storeapi.NewRangeKey(txID, ns1, coll1, key1, key3)
+
+	t.Run("Range -> success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.GetDataByRange(ctx, rangeKey)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key1, next.Key.Key)
+		require.Equal(t, value1.Value, next.Value)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key2, next.Key.Key)
+		require.Equal(t, value2.Value, next.Value)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("Range access denied -> empty", func(t *testing.T) {
+		identifierProvider.GetIdentifierReturns(org3MSPID, nil)
+		defer func() { identifierProvider.GetIdentifierReturns(org1MSPID, nil) }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.GetDataByRange(ctx, rangeKey)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("Store error -> fail", func(t *testing.T) {
+		errExpected := errors.New("injected store error")
+		localStore.Error(errExpected)
+		defer localStore.Error(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.GetDataByRange(ctx, rangeKey)
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, it)
+	})
+}
+
 func TestRetriever_AccessDenied(t *testing.T) {
 	ccProvider := &mocks.CollectionConfigProvider{}
 	ccRetriever := mocks.NewCollectionConfigRetriever().
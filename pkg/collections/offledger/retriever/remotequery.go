@@ -0,0 +1,337 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package retriever
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/leveldbstore"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/version"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+)
+
+// queryDataType is the application data type for remote off-ledger queries
+const queryDataType = "offledger-query"
+
+type remoteQueryRequest struct {
+	TxID       string `json:"txid"`
+	Namespace  string `json:"ns"`
+	Collection string `json:"coll"`
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results"`
+}
+
+type remoteQueryResult struct {
+	Key       string    `json:"key"`
+	TxID      string    `json:"txid"`
+	Value     []byte    `json:"value"`
+	Expiry    time.Time `json:"expiry"`
+	WriteTime time.Time `json:"write_time"`
+}
+
+type remoteQueryResponse struct {
+	Results []*remoteQueryResult `json:"results"`
+}
+
+// handleQueryRequest executes a query on behalf of a remote peer and responds with the results. A nil
+// response is sent if the request is invalid, if the requesting peer is not authorized or if the query fails.
+func (p *Provider) handleQueryRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder) {
+	request := &remoteQueryRequest{}
+	if err := json.Unmarshal(req.Request, request); err != nil {
+		logger.Warningf("[%s] Error unmarshalling remote query request: %s", channelID, err)
+		responder.Respond(nil)
+		return
+	}
+
+	results, err := p.executeQuery(channelID, requesterMSPID(responder), request)
+	if err != nil {
+		logger.Warningf("[%s] Error executing remote query on [%s:%s]: %s", channelID, request.Namespace, request.Collection, err)
+		responder.Respond(nil)
+		return
+	}
+
+	resBytes, err := json.Marshal(&remoteQueryResponse{Results: results})
+	if err != nil {
+		logger.Errorf("[%s] Error marshalling remote query response: %s", channelID, err)
+		responder.Respond(nil)
+		return
+	}
+
+	logger.Debugf("[%s] Responding with %d result(s) for remote query on [%s:%s]", channelID, len(results), request.Namespace, request.Collection)
+
+	responder.Respond(resBytes)
+}
+
+func (p *Provider) executeQuery(channelID, mspID string, request *remoteQueryRequest) ([]*remoteQueryResult, error) {
+	authorized, err := p.isRequesterAuthorized(channelID, mspID, request.Namespace, request.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if !authorized {
+		return nil, errors.Errorf("requesting MSP [%s] is not authorized to query [%s:%s]", mspID, request.Namespace, request.Collection)
+	}
+
+	it, err := p.StoreProvider.StoreForChannel(channelID).Query(storeapi.NewQueryKey(request.TxID, request.Namespace, request.Collection, request.Query))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	maxResults := getQueryMaxResultsPerPeer()
+	if request.MaxResults > 0 && request.MaxResults < maxResults {
+		maxResults = request.MaxResults
+	}
+
+	var results []*remoteQueryResult
+	for len(results) < maxResults {
+		next, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if next == nil {
+			break
+		}
+
+		results = append(results, &remoteQueryResult{
+			Key:       next.Key.Key,
+			TxID:      next.Key.EndorsedAtTxID,
+			Value:     next.Value,
+			Expiry:    next.Expiry,
+			WriteTime: next.WriteTime,
+		})
+	}
+
+	return results, nil
+}
+
+// isRequesterAuthorized returns true if the given MSP is a member of the collection or
+// if the collection allows reads from non-members
+func (p *Provider) isRequesterAuthorized(channelID, mspID, ns, coll string) (bool, error) {
+	if mspID == "" {
+		return false, nil
+	}
+
+	ccRetriever := p.CCProvider.ForChannel(channelID)
+
+	policy, err := ccRetriever.Policy(ns, coll)
+	if err != nil {
+		return false, errors.WithMessagef(err, "unable to get policy for [%s:%s]", ns, coll)
+	}
+
+	if _, ok := policy.MemberOrgs()[mspID]; ok {
+		return true, nil
+	}
+
+	collConfig, err := ccRetriever.Config(ns, coll)
+	if err != nil {
+		return false, errors.WithMessagef(err, "unable to get config for [%s:%s]", ns, coll)
+	}
+
+	return !collConfig.MemberOnlyRead, nil
+}
+
+func requesterMSPID(responder appdata.Responder) string {
+	requester, ok := responder.(appdata.Requester)
+	if !ok {
+		return ""
+	}
+
+	return requester.RequesterMSPID()
+}
+
+// queryRemotePeers sends the query to the collection members and merges the results. If more than one
+// peer returns the same key then the most recently written value is chosen.
+func (r *retriever) queryRemotePeers(ctxt context.Context, key *storeapi.QueryKey) (storeapi.ResultsIterator, error) {
+	res, err := r.getResolver(key.Namespace, key.Collection)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get resolver for channel [%s] and [%s:%s]", r.channelID, key.Namespace, key.Collection)
+	}
+
+	peers := res.ResolvePeersForQuery()
+	if len(peers) == 0 {
+		logger.Infof("[%s] No peers available to query [%s:%s]", r.channelID, key.Namespace, key.Collection)
+		return noResultsIt, nil
+	}
+
+	maxResults := getQueryMaxResultsPerPeer()
+
+	reqBytes, err := json.Marshal(&remoteQueryRequest{
+		TxID:       key.EndorsedAtTxID,
+		Namespace:  key.Namespace,
+		Collection: key.Collection,
+		Query:      key.Query,
+		MaxResults: maxResults,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "error marshalling remote query request")
+	}
+
+	ctxt, cancel := context.WithTimeout(ctxt, getQueryTimeout())
+	defer cancel()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var responses [][]*remoteQueryResult
+
+	for _, peer := range peers {
+		wg.Add(1)
+
+		go func(peer *discovery.Member) {
+			defer wg.Done()
+
+			results, err := r.queryPeer(ctxt, reqBytes, peer)
+			if err != nil {
+				logger.Debugf("[%s] Error querying [%s:%s] on [%s]: %s", r.channelID, key.Namespace, key.Collection, peer, err)
+				return
+			}
+
+			if len(results) > maxResults {
+				logger.Debugf("[%s] Peer [%s] returned %d results for [%s:%s] - truncating to %d", r.channelID, peer, len(results), key.Namespace, key.Collection, maxResults)
+				results = results[:maxResults]
+			}
+
+			mutex.Lock()
+			responses = append(responses, results)
+			mutex.Unlock()
+		}(peer)
+	}
+
+	wg.Wait()
+
+	logger.Debugf("[%s] Got %d of %d response(s) for query on [%s:%s]", r.channelID, len(responses), len(peers), key.Namespace, key.Collection)
+
+	return newResultsIterator(r.mergeResults(key, responses)), nil
+}
+
+func (r *retriever) queryPeer(ctxt context.Context, reqBytes []byte, peer *discovery.Member) ([]*remoteQueryResult, error) {
+	req := r.reqMgr.NewRequest()
+
+	logger.Debugf("[%s] Sending Gossip query request %d to [%s]", r.channelID, req.ID(), peer)
+
+	r.gossipAdapter.Send(r.createQueryRequestMsg(req.ID(), reqBytes), asRemotePeers(discovery.PeerGroup{peer})...)
+
+	res, err := req.GetResponse(ctxt)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := res.Data.([]byte)
+	if !ok || len(data) == 0 {
+		return nil, errors.Errorf("empty response from peer [%s]", peer)
+	}
+
+	response := &remoteQueryResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, errors.WithMessagef(err, "error unmarshalling query response from peer [%s]", peer)
+	}
+
+	return response.Results, nil
+}
+
+// mergeResults de-duplicates the results by key, choosing the most recently written value of each key, and
+// orders them according to the sort fields of the query. The results are ordered by key if the query has no
+// sort fields.
+func (r *retriever) mergeResults(key *storeapi.QueryKey, responses [][]*remoteQueryResult) []*storeapi.QueryResult {
+	resultsByKey := make(map[string]*remoteQueryResult)
+
+	for _, results := range responses {
+		for _, result := range results {
+			existing, ok := resultsByKey[result.Key]
+			if !ok || version.New(result.TxID, result.WriteTime).IsNewerThan(version.New(existing.TxID, existing.WriteTime)) {
+				resultsByKey[result.Key] = result
+			}
+		}
+	}
+
+	var keys []string
+	for k := range resultsByKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	merged := make([]*storeapi.QueryResult, len(keys))
+	for i, k := range keys {
+		result := resultsByKey[k]
+		merged[i] = &storeapi.QueryResult{
+			Key: storeapi.NewKey(result.TxID, key.Namespace, key.Collection, result.Key),
+			ExpiringValue: &storeapi.ExpiringValue{
+				Value:     result.Value,
+				Expiry:    result.Expiry,
+				WriteTime: result.WriteTime,
+			},
+		}
+	}
+
+	sorted, err := leveldbstore.SortAndLimit(key.Query, merged)
+	if err != nil {
+		logger.Warningf("[%s] Unable to sort the merged results of the query on [%s:%s] - results are ordered by key: %s", r.channelID, key.Namespace, key.Collection, err)
+		return merged
+	}
+
+	return sorted
+}
+
+func (r *retriever) createQueryRequestMsg(reqID uint64, reqBytes []byte) *gproto.GossipMessage {
+	return &gproto.GossipMessage{
+		Tag:     gproto.GossipMessage_CHAN_ONLY,
+		Channel: []byte(r.channelID),
+		Content: &gproto.GossipMessage_AppDataReq{
+			AppDataReq: &gproto.AppDataRequest{
+				Nonce:    reqID,
+				DataType: queryDataType,
+				Request:  reqBytes,
+			},
+		},
+	}
+}
+
+type resultsIterator struct {
+	results []*storeapi.QueryResult
+	next    int
+}
+
+func newResultsIterator(results []*storeapi.QueryResult) *resultsIterator {
+	return &resultsIterator{results: results}
+}
+
+// Next returns the next item in the result set or nil if there are no more results
+func (it *resultsIterator) Next() (*storeapi.QueryResult, error) {
+	if it.next >= len(it.results) {
+		return nil, nil
+	}
+
+	result := it.results[it.next]
+	it.next++
+
+	return result, nil
+}
+
+// Close has no effect
+func (it *resultsIterator) Close() {
+}
+
+// getQueryTimeout may be overridden by unit tests
+var getQueryTimeout = func() time.Duration {
+	return config.GetOLCollQueryTimeout()
+}
+
+// getQueryMaxResultsPerPeer may be overridden by unit tests
+var getQueryMaxResultsPerPeer = func() int {
+	return config.GetOLCollQueryMaxResultsPerPeer()
+}
@@ -0,0 +1,425 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package retriever
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
+)
+
+const coll3 = "collection3"
+
+func TestRetriever_RemoteQuery(t *testing.T) {
+	queryKey := storeapi.NewQueryKey(txID, ns1, coll1, "remote query")
+
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			MaxPeerCount: 2,
+			Orgs:         []string{org1MSPID, org2MSPID},
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll1,
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type:             pb.CollectionType_COL_OFFLEDGER,
+			Name:             coll2,
+			MaximumPeerCount: 2,
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type:           pb.CollectionType_COL_OFFLEDGER,
+			Name:           coll3,
+			MemberOnlyRead: true,
+		})
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	// The local peer is in Org3 which is not a member of the collection
+	identifierProvider := &mocks.IdentifierProvider{}
+	identifierProvider.GetIdentifierReturns(org3MSPID, nil)
+
+	now := time.Now().UTC()
+
+	msgHandler := newMockQueryMsgHandler(channelID).
+		Response([]*remoteQueryResult{
+			{Key: key2, TxID: txID, Value: value2.Value, WriteTime: now.Add(-time.Minute)},
+			{Key: key1, TxID: txID, Value: value1.Value, WriteTime: now.Add(-time.Minute)},
+		}).
+		Response([]*remoteQueryResult{
+			{Key: key1, TxID: txID1, Value: value3.Value, WriteTime: now},
+			{Key: key3, TxID: txID1, Value: value4.Value, WriteTime: now},
+		})
+
+	gossip := mocks.NewMockGossipAdapter()
+	gossip.Self(org3MSPID, mocks.NewMember(p1Org3Endpoint, p1Org3PKIID)).
+		Member(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID, committerRole)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID, committerRole)).
+		MessageHandler(msgHandler.Handle)
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(mocks.NewDataStore())
+
+	gossipProvider := &mocks.GossipProvider{}
+	gossipProvider.GetGossipServiceReturns(gossip)
+
+	providers := &collcommon.Providers{
+		BlockPublisherProvider: mocks.NewBlockPublisherProvider(),
+		StoreProvider:          storeProvider,
+		GossipProvider:         gossipProvider,
+		CCProvider:             ccProvider,
+		IdentifierProvider:     identifierProvider,
+	}
+
+	retriever := NewProvider(providers).RetrieverForChannel(channelID)
+	require.NotNil(t, retriever)
+
+	t.Run("Non-member -> success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.Query(ctx, queryKey)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		results := readAll(t, it)
+		require.Len(t, results, 3)
+
+		// The most recently written value for key1 should be chosen
+		require.Equal(t, key1, results[0].Key.Key)
+		require.Equal(t, txID1, results[0].Key.EndorsedAtTxID)
+		require.Equal(t, value3.Value, results[0].Value)
+		require.Equal(t, now, results[0].WriteTime)
+		require.Equal(t, key2, results[1].Key.Key)
+		require.Equal(t, value2.Value, results[1].Value)
+		require.Equal(t, key3, results[2].Key.Key)
+		require.Equal(t, value4.Value, results[2].Value)
+	})
+
+	t.Run("No local copy -> success", func(t *testing.T) {
+		identifierProvider.GetIdentifierReturns(org1MSPID, nil)
+		defer func() { identifierProvider.GetIdentifierReturns(org3MSPID, nil) }()
+
+		roles.SetRoles(map[roles.Role]struct{}{roles.EndorserRole: {}})
+		defer roles.SetRoles(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.Query(ctx, storeapi.NewQueryKey(txID, ns1, coll2, "remote query"))
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		require.Len(t, readAll(t, it), 3)
+	})
+
+	t.Run("Max results per peer -> success", func(t *testing.T) {
+		restore := getQueryMaxResultsPerPeer
+		defer func() { getQueryMaxResultsPerPeer = restore }()
+		getQueryMaxResultsPerPeer = func() int { return 1 }
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.Query(ctx, queryKey)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		results := readAll(t, it)
+		require.Len(t, results, 2)
+		require.Equal(t, key1, results[0].Key.Key)
+		require.Equal(t, value3.Value, results[0].Value)
+		require.Equal(t, key2, results[1].Key.Key)
+	})
+
+	t.Run("Timeout -> empty", func(t *testing.T) {
+		restore := getQueryTimeout
+		defer func() { getQueryTimeout = restore }()
+		getQueryTimeout = func() time.Duration { return 10 * time.Millisecond }
+
+		msgHandler.Delay(100 * time.Millisecond)
+		defer msgHandler.Delay(0)
+
+		it, err := retriever.Query(context.Background(), queryKey)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		require.Empty(t, readAll(t, it))
+	})
+
+	t.Run("Member only read -> empty", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		it, err := retriever.Query(ctx, storeapi.NewQueryKey(txID, ns1, coll3, "remote query"))
+		require.NoError(t, err)
+		require.NotNil(t, it)
+
+		require.Empty(t, readAll(t, it))
+	})
+}
+
+func TestRetriever_MergeResults(t *testing.T) {
+	r := &retriever{channelID: channelID}
+
+	now := time.Now().UTC()
+
+	responses := [][]*remoteQueryResult{
+		{
+			{Key: key1, TxID: txID1, Value: []byte(`{"Field1":3}`), WriteTime: now.Add(-time.Minute)},
+			{Key: key2, TxID: txID, Value: []byte(`{"Field1":2}`), WriteTime: now},
+		},
+		{
+			{Key: key1, TxID: txID, Value: []byte(`{"Field1":1}`), WriteTime: now},
+			{Key: key3, TxID: txID, Value: []byte(`{"Field1":4}`)},
+		},
+	}
+
+	t.Run("Sorted query", func(t *testing.T) {
+		results := r.mergeResults(storeapi.NewQueryKey(txID, ns1, coll1, `{"selector":{"Field1":{"$gt":0}},"sort":[{"Field1":"desc"}],"limit":2}`), responses)
+		require.Len(t, results, 2)
+		require.Equal(t, key3, results[0].Key.Key)
+		require.Equal(t, key2, results[1].Key.Key)
+	})
+
+	t.Run("Unsorted query -> ordered by key", func(t *testing.T) {
+		results := r.mergeResults(storeapi.NewQueryKey(txID, ns1, coll1, `{"selector":{"Field1":{"$gt":0}}}`), responses)
+		require.Len(t, results, 3)
+		require.Equal(t, key1, results[0].Key.Key)
+		require.Equal(t, txID, results[0].Key.EndorsedAtTxID)
+		require.Equal(t, []byte(`{"Field1":1}`), results[0].Value)
+		require.Equal(t, key2, results[1].Key.Key)
+		require.Equal(t, key3, results[2].Key.Key)
+	})
+}
+
+func TestProvider_HandleQueryRequest(t *testing.T) {
+	queryKey := storeapi.NewQueryKey(txID, ns1, coll1, "remote query")
+
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			Orgs: []string{org1MSPID, org2MSPID},
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type:           pb.CollectionType_COL_OFFLEDGER,
+			Name:           coll1,
+			MemberOnlyRead: true,
+		}).
+		WithCollectionConfig(&pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll2,
+		})
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	localStore := mocks.NewDataStore().
+		WithQueryResults(queryKey, []*storeapi.QueryResult{
+			{Key: storeapi.NewKey(txID1, ns1, coll1, key1), ExpiringValue: value1},
+			{Key: storeapi.NewKey(txID1, ns1, coll1, key2), ExpiringValue: value2},
+		}).
+		WithQueryResults(storeapi.NewQueryKey(txID, ns1, coll2, "remote query"), []*storeapi.QueryResult{
+			{Key: storeapi.NewKey(txID1, ns1, coll2, key1), ExpiringValue: value1},
+		})
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(localStore)
+
+	registry := &mockAppDataHandlerRegistry{}
+
+	p := NewProvider(&collcommon.Providers{
+		StoreProvider: storeProvider,
+		CCProvider:    ccProvider,
+	}, WithAppDataHandlerRegistry(registry)).(*Provider)
+	require.NotNil(t, registry.handlers[queryDataType])
+
+	reqBytes, err := json.Marshal(&remoteQueryRequest{
+		TxID:       txID,
+		Namespace:  ns1,
+		Collection: coll1,
+		Query:      "remote query",
+	})
+	require.NoError(t, err)
+
+	req := &gproto.AppDataRequest{DataType: queryDataType, Request: reqBytes}
+
+	reqBytes2, err := json.Marshal(&remoteQueryRequest{
+		TxID:       txID,
+		Namespace:  ns1,
+		Collection: coll2,
+		Query:      "remote query",
+	})
+	require.NoError(t, err)
+
+	req2 := &gproto.AppDataRequest{DataType: queryDataType, Request: reqBytes2}
+
+	t.Run("Member -> success", func(t *testing.T) {
+		responder := &mockQueryResponder{mspID: org1MSPID}
+		p.handleQueryRequest(channelID, req, responder)
+		require.NotNil(t, responder.data)
+
+		res := &remoteQueryResponse{}
+		require.NoError(t, json.Unmarshal(responder.data, res))
+		require.Len(t, res.Results, 2)
+		require.Equal(t, key1, res.Results[0].Key)
+		require.Equal(t, txID1, res.Results[0].TxID)
+		require.Equal(t, value1.Value, res.Results[0].Value)
+	})
+
+	t.Run("Max results -> success", func(t *testing.T) {
+		restore := getQueryMaxResultsPerPeer
+		defer func() { getQueryMaxResultsPerPeer = restore }()
+		getQueryMaxResultsPerPeer = func() int { return 1 }
+
+		responder := &mockQueryResponder{mspID: org2MSPID}
+		p.handleQueryRequest(channelID, req, responder)
+		require.NotNil(t, responder.data)
+
+		res := &remoteQueryResponse{}
+		require.NoError(t, json.Unmarshal(responder.data, res))
+		require.Len(t, res.Results, 1)
+	})
+
+	t.Run("Non-member with member-only read -> nil", func(t *testing.T) {
+		responder := &mockQueryResponder{mspID: org3MSPID}
+		p.handleQueryRequest(channelID, req, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Non-member -> success", func(t *testing.T) {
+		responder := &mockQueryResponder{mspID: org3MSPID}
+		p.handleQueryRequest(channelID, req2, responder)
+		require.NotNil(t, responder.data)
+
+		res := &remoteQueryResponse{}
+		require.NoError(t, json.Unmarshal(responder.data, res))
+		require.Len(t, res.Results, 1)
+	})
+
+	t.Run("Unknown requester -> nil", func(t *testing.T) {
+		responder := &mockQueryResponder{}
+		p.handleQueryRequest(channelID, req, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Invalid request -> nil", func(t *testing.T) {
+		responder := &mockQueryResponder{mspID: org1MSPID}
+		p.handleQueryRequest(channelID, &gproto.AppDataRequest{DataType: queryDataType, Request: []byte("{")}, responder)
+		require.Nil(t, responder.data)
+	})
+
+	t.Run("Store error -> nil", func(t *testing.T) {
+		localStore.Error(errors.New("injected store error"))
+		defer localStore.Error(nil)
+
+		responder := &mockQueryResponder{mspID: org1MSPID}
+		p.handleQueryRequest(channelID, req, responder)
+		require.Nil(t, responder.data)
+	})
+}
+
+func readAll(t *testing.T, it storeapi.ResultsIterator) []*storeapi.QueryResult {
+	var results []*storeapi.QueryResult
+	for {
+		next, err := it.Next()
+		require.NoError(t, err)
+
+		if next == nil {
+			return results
+		}
+
+		results = append(results, next)
+	}
+}
+
+type mockQueryMsgHandler struct {
+	channelID string
+	mutex     sync.Mutex
+	responses [][]*remoteQueryResult
+	next      int
+	delay     time.Duration
+}
+
+func newMockQueryMsgHandler(channelID string) *mockQueryMsgHandler {
+	return &mockQueryMsgHandler{channelID: channelID}
+}
+
+// Response adds a response. Responses are returned in round-robin order.
+func (m *mockQueryMsgHandler) Response(results []*remoteQueryResult) *mockQueryMsgHandler {
+	m.responses = append(m.responses, results)
+	return m
+}
+
+func (m *mockQueryMsgHandler) Delay(delay time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.delay = delay
+}
+
+func (m *mockQueryMsgHandler) Handle(msg *gproto.GossipMessage) {
+	req := msg.GetAppDataReq()
+
+	m.mutex.Lock()
+	results := m.responses[m.next%len(m.responses)]
+	m.next++
+	delay := m.delay
+	m.mutex.Unlock()
+
+	time.Sleep(delay)
+
+	resBytes, err := json.Marshal(&remoteQueryResponse{Results: results})
+	if err != nil {
+		panic(err)
+	}
+
+	requestmgr.Get(m.channelID).Respond(req.Nonce, &requestmgr.Response{Data: resBytes})
+}
+
+type mockQueryResponder struct {
+	mspID string
+	data  []byte
+}
+
+func (m *mockQueryResponder) Respond(data []byte) {
+	m.data = data
+}
+
+func (m *mockQueryResponder) RequesterMSPID() string {
+	return m.mspID
+}
+
+type mockAppDataHandlerRegistry struct {
+	handlers map[string]appdata.Handler
+}
+
+func (m *mockAppDataHandlerRegistry) Register(dataType string, handler appdata.Handler) error {
+	if m.handlers == nil {
+		m.handlers = make(map[string]appdata.Handler)
+	}
+
+	m.handlers[dataType] = handler
+
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storeprovider
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+)
+
+const numKeyLockStripes = 256
+
+// keyLocks serializes writes to the same key while allowing writes to different keys to proceed concurrently.
+// A key is mapped to one of a fixed number of mutexes (stripes) so unrelated keys may occasionally share a stripe.
+type keyLocks struct {
+	stripes [numKeyLockStripes]sync.Mutex
+}
+
+// lock locks the stripes of the given keys and returns a function that unlocks them.
+// The stripes are always locked in ascending order so that concurrent callers don't deadlock.
+func (l *keyLocks) lock(keys ...*storeapi.Key) func() {
+	indexes := make(map[uint32]struct{})
+	for _, key := range keys {
+		indexes[stripeIndex(key.Namespace, key.Collection, key.Key)] = struct{}{}
+	}
+
+	sorted := make([]int, 0, len(indexes))
+	for i := range indexes {
+		sorted = append(sorted, int(i))
+	}
+
+	sort.Ints(sorted)
+
+	for _, i := range sorted {
+		l.stripes[i].Lock()
+	}
+
+	return func() {
+		for j := len(sorted) - 1; j >= 0; j-- {
+			l.stripes[sorted[j]].Unlock()
+		}
+	}
+}
+
+func stripeIndex(ns, coll, key string) uint32 {
+	h := fnv.New32a()
+
+	// Writes to a hash never return an error
+	_, _ = h.Write([]byte(ns))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(coll))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+
+	return h.Sum32() % numKeyLockStripes
+}
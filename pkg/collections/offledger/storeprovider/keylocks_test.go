@@ -0,0 +1,84 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storeprovider
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyLocks(t *testing.T) {
+	l := &keyLocks{}
+
+	k1 := storeapi.NewKey("", ns1, coll1, key1)
+	k2 := storeapi.NewKey("", ns1, coll1, key2)
+
+	t.Run("Same key -> serialized", func(t *testing.T) {
+		unlock := l.lock(k1)
+
+		locked := make(chan struct{})
+		go func() {
+			defer l.lock(k1)()
+			close(locked)
+		}()
+
+		select {
+		case <-locked:
+			t.Fatal("expecting the second lock on the same key to block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		unlock()
+
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the lock")
+		}
+	})
+
+	t.Run("Multiple keys -> no deadlock", func(t *testing.T) {
+		var keys []*storeapi.Key
+		for i := 0; i < 100; i++ {
+			keys = append(keys, storeapi.NewKey("", ns1, coll1, fmt.Sprintf("k%d", i)))
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				// Lock the keys in different orders
+				reordered := append(append([]*storeapi.Key{}, keys[i*10:]...), keys[:i*10]...)
+				reordered = append(reordered, k1, k2, k1)
+
+				l.lock(reordered...)()
+			}(i)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("deadlock locking multiple keys")
+		}
+	})
+
+	require.Equal(t, stripeIndex(ns1, coll1, key1), stripeIndex(ns1, coll1, key1))
+	require.True(t, stripeIndex(ns1, coll1, key1) < numKeyLockStripes)
+}
@@ -13,6 +13,7 @@ import (
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	proto "github.com/hyperledger/fabric-protos-go/transientstore"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
@@ -21,19 +22,39 @@ import (
 	"github.com/pkg/errors"
 
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/quota"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/cache"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/encryption"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/implicitpolicy"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
 )
 
 var logger = flogging.MustGetLogger("ext_offledger")
 
+// tombstoneCollSuffix is appended to the name of a collection to form the name of the
+// database that holds the tombstones of the keys deleted from the collection
+const tombstoneCollSuffix = "$deleted"
+
+// tombstoneValue is the value stored for a tombstone since a nil value deletes the key
+var tombstoneValue = []byte{1}
+
 type providers struct {
 	dbProvider           api.DBProvider
 	identifierProvider   collcommon.IdentifierProvider
 	identityDeserializer msp.IdentityDeserializer
 	collConfigRetriever  support.CollectionConfigRetriever
+	keyProvider          encryption.KeyProvider
+	metricsProvider      metrics.Provider
+}
+
+// withDBProvider returns a copy of the providers that uses the given DB provider
+func (p *providers) withDBProvider(dbProvider api.DBProvider) *providers {
+	c := *p
+	c.dbProvider = dbProvider
+
+	return &c
 }
 
 type store struct {
@@ -41,6 +62,11 @@ type store struct {
 	channelID   string
 	cache       *cache.Cache
 	collConfigs map[pb.CollectionType]*collTypeConfig
+	quota       *quota.Tracker
+	// keyLocks serializes writes to the same key so that the revision check and the put of a conditional put are atomic
+	keyLocks keyLocks
+	// tombstoneDBProvider provides the tombstone databases. Tombstones are never encrypted.
+	tombstoneDBProvider api.DBProvider
 }
 
 type olConfig struct {
@@ -55,15 +81,27 @@ func newStore(
 	logger.Debugf("constructing collection data store")
 
 	store := &store{
-		providers:   providers,
-		channelID:   channelID,
-		collConfigs: collConfigs,
+		providers:           providers,
+		channelID:           channelID,
+		collConfigs:         collConfigs,
+		tombstoneDBProvider: providers.dbProvider,
 	}
 
+	dbProvider := providers.dbProvider
+
+	if store.encryptionEnabledForAnyType() {
+		logger.Debugf("[%s] Encryption is enabled for one or more collection types", channelID)
+
+		dbProvider = encryption.NewDBProvider(providers.dbProvider, providers.keyProvider, store.encryptionEnabled)
+		store.providers = providers.withDBProvider(dbProvider)
+	}
+
+	store.quota = quota.NewTracker(channelID, getLimits, loadAll(channelID, dbProvider), config.GetOLCollQuotaReloadInterval(), providers.metricsProvider)
+
 	if cfg.cacheSize > 0 {
 		logger.Debugf("Off-ledger cache is enabled. Cache size: %d", cfg.cacheSize)
 
-		store.cache = cache.New(channelID, providers.dbProvider, cfg.cacheSize)
+		store.cache = cache.New(channelID, dbProvider, cfg.cacheSize, providers.metricsProvider)
 	} else {
 		logger.Debugf("Off-ledger cache is disabled")
 	}
@@ -76,6 +114,24 @@ func (s *store) Close() {
 	s.dbProvider.Close()
 }
 
+// CacheSize returns the number of keys of the given collection that are held in the cache
+func (s *store) CacheSize(ns, coll string) int {
+	if s.cache == nil {
+		return 0
+	}
+
+	return s.cache.Size(ns, coll)
+}
+
+// FlushCache removes the keys of the given collection from the cache and returns the number of keys removed
+func (s *store) FlushCache(ns, coll string) int {
+	if s.cache == nil {
+		return 0
+	}
+
+	return s.cache.Flush(ns, coll)
+}
+
 // Persist persists all data within the private data simulation results
 func (s *store) Persist(txID string, privateSimulationResultsWithConfig *proto.TxPvtReadWriteSetWithConfigInfo) error {
 	rwSet, err := rwsetutil.TxPvtRwSetFromProtoMsg(privateSimulationResultsWithConfig.PvtRwset)
@@ -83,6 +139,8 @@ func (s *store) Persist(txID string, privateSimulationResultsWithConfig *proto.T
 		return errors.WithMessage(err, "error getting pvt RW set from bytes")
 	}
 
+	defer s.keyLocks.lock(writeKeys(rwSet)...)()
+
 	for _, nsRWSet := range rwSet.NsPvtRwSet {
 		for _, collRWSet := range nsRWSet.CollPvtRwSets {
 			if err := s.persistColl(txID, nsRWSet.NameSpace, privateSimulationResultsWithConfig.CollectionConfigs, collRWSet); err != nil {
@@ -96,6 +154,42 @@ func (s *store) Persist(txID string, privateSimulationResultsWithConfig *proto.T
 
 // PutData returns the  data for the given key
 func (s *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue) error {
+	defer s.keyLocks.lock(key)()
+
+	return s.putData(config, key, value)
+}
+
+// PutDataIfLocalRevision stores the key/value only if the revision of the value in the local store matches the
+// given revision. The revision of a stored value is the ID of the transaction that stored it, although the CouchDB
+// revision of the value is also accepted. An empty revision indicates that the key must not exist. The revision
+// is not checked by other peers, which persist the disseminated value unconditionally.
+func (s *store) PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error {
+	if key.EndorsedAtTxID == "" {
+		return errors.Errorf("transaction ID is required for a conditional put of key [%s]", key)
+	}
+
+	defer s.keyLocks.lock(key)()
+
+	current, err := s.getValue(key.Namespace, key.Collection, key.Key)
+	if err != nil {
+		return err
+	}
+
+	if !revisionMatches(current, revision) {
+		var actual string
+		if current != nil {
+			actual = current.TxID
+		}
+
+		logger.Debugf("[%s] Revision conflict for key [%s]. Expected revision [%s] but stored revision is [%s]", s.channelID, key, revision, actual)
+
+		return &storeapi.RevisionConflictError{Key: key, Expected: revision, Actual: actual}
+	}
+
+	return s.putData(config, key, value)
+}
+
+func (s *store) putData(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue) error {
 	if value.Value == nil {
 		return errors.Errorf("attempt to put nil value for key [%s]", key)
 	}
@@ -103,6 +197,11 @@ func (s *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, va
 		return errors.Errorf("invalid collection config for key [%s]", key)
 	}
 
+	writeTime := value.WriteTime
+	if writeTime.IsZero() {
+		writeTime = time.Now().UTC()
+	}
+
 	key, value, err := s.beforeSave(config, key, value)
 	if err != nil {
 		return err
@@ -116,17 +215,28 @@ func (s *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, va
 		}
 	}
 
+	kv := api.NewKeyValue(key.Key, value.Value, key.EndorsedAtTxID, value.Expiry)
+	kv.WriteTime = writeTime
+
+	defer s.quota.Lock(key.Namespace, key.Collection)()
+
+	if err := s.quota.Check(key.Namespace, key.Collection, kv); err != nil {
+		return err
+	}
+
 	db, err := s.dbProvider.GetDB(s.channelID, key.Collection, key.Namespace)
 	if err != nil {
 		return err
 	}
 
 	logger.Debugf("[%s] Putting key [%s] to DB", s.channelID, key)
-	err = db.Put(api.NewKeyValue(key.Key, value.Value, key.EndorsedAtTxID, value.Expiry))
+	err = db.Put(kv)
 	if err != nil {
 		return err
 	}
 
+	s.quota.Update(key.Namespace, key.Collection, kv)
+
 	if s.cacheEnabledForType(config.Type) {
 		logger.Debugf("[%s] Putting key [%s] to cache", s.channelID, key)
 
@@ -135,6 +245,7 @@ func (s *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, va
 				Value:      value.Value,
 				TxID:       key.EndorsedAtTxID,
 				ExpiryTime: value.Expiry,
+				WriteTime:  writeTime,
 			},
 		)
 	}
@@ -142,11 +253,137 @@ func (s *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, va
 	return nil
 }
 
+// DeleteData deletes the given keys
+func (s *store) DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error {
+	if config.Name != key.Collection {
+		return errors.Errorf("invalid collection config for key [%s]", key)
+	}
+
+	lockKeys := make([]*storeapi.Key, len(key.Keys))
+	for i, k := range key.Keys {
+		lockKeys[i] = storeapi.NewKey(key.EndorsedAtTxID, key.Namespace, key.Collection, k)
+	}
+
+	defer s.keyLocks.lock(lockKeys...)()
+
+	keys := make([]string, len(key.Keys))
+	for i, k := range key.Keys {
+		dKey, err := s.beforeLoad(config, storeapi.NewKey(key.EndorsedAtTxID, key.Namespace, key.Collection, k))
+		if err != nil {
+			return err
+		}
+		keys[i] = dKey.Key
+	}
+
+	db, err := s.dbProvider.GetDB(s.channelID, key.Collection, key.Namespace)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("[%s] Deleting keys %s from DB", s.channelID, key)
+
+	if err := s.deleteKeys(db, key.Namespace, key.Collection, keys); err != nil {
+		return err
+	}
+
+	if s.cacheEnabledForType(config.Type) {
+		logger.Debugf("[%s] Deleting keys %s from cache", s.channelID, key)
+
+		s.cache.Delete(key.Namespace, key.Collection, keys...)
+	}
+
+	s.putTombstones(key.EndorsedAtTxID, key.Namespace, key.Collection, keys...)
+
+	return nil
+}
+
+// deleteKeys deletes the given keys from the DB and updates the usage of the collection
+func (s *store) deleteKeys(db api.DB, ns, coll string, keys []string) error {
+	defer s.quota.Lock(ns, coll)()
+
+	if err := db.Delete(keys...); err != nil {
+		return errors.WithMessagef(err, "error deleting keys from [%s:%s]", ns, coll)
+	}
+
+	deletes := make([]*api.KeyValue, len(keys))
+	for i, k := range keys {
+		deletes[i] = &api.KeyValue{Key: k}
+	}
+
+	s.quota.Update(ns, coll, deletes...)
+
+	return nil
+}
+
+// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted
+func (s *store) GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error) {
+	db, err := s.tombstoneDBProvider.GetDB(s.channelID, key.Collection+tombstoneCollSuffix, key.Namespace)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error getting tombstone database")
+	}
+
+	value, err := db.Get(key.Key)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error loading tombstone for key [%s]", key)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	return &storeapi.Tombstone{TxID: value.TxID, WriteTime: value.WriteTime}, nil
+}
+
+// putTombstones records the deletion of the given keys by the given transaction so that the keys aren't restored
+// from other peers by reconciliation. Tombstones are only written if reconciliation is enabled and they expire
+// after the configured TTL. The keys have already been deleted when the tombstones are written so an error is
+// logged rather than returned.
+func (s *store) putTombstones(txID, ns, coll string, keys ...string) {
+	if !config.IsOLCollReconcileEnabled() {
+		return
+	}
+
+	db, err := s.tombstoneDBProvider.GetDB(s.channelID, coll+tombstoneCollSuffix, ns)
+	if err != nil {
+		logger.Warningf("[%s] Error getting tombstone database for [%s:%s]: %s", s.channelID, ns, coll, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	expiry := now.Add(config.GetOLCollTombstoneTTL())
+
+	tombstones := make([]*api.KeyValue, len(keys))
+	for i, k := range keys {
+		tombstones[i] = api.NewKeyValue(k, tombstoneValue, txID, expiry)
+		tombstones[i].WriteTime = now
+	}
+
+	logger.Debugf("[%s] Putting tombstones for keys %s in [%s:%s]", s.channelID, keys, ns, coll)
+
+	if err := db.Put(tombstones...); err != nil {
+		logger.Warningf("[%s] Error putting tombstones for keys %s to [%s:%s] - the keys may be restored by reconciliation: %s", s.channelID, keys, ns, coll, err)
+	}
+}
+
 // GetData returns the  data for the given key
 func (s *store) GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error) {
 	return s.getData(key.EndorsedAtTxID, key.Namespace, key.Collection, key.Key)
 }
 
+// GetDataWithRevision returns the data for the given key along with the revision of the stored value
+func (s *store) GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error) {
+	value, err := s.getValue(key.Namespace, key.Collection, key.Key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if value == nil || value.TxID == key.EndorsedAtTxID {
+		return nil, "", nil
+	}
+
+	return &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime, WriteTime: value.WriteTime}, value.TxID, nil
+}
+
 // GetDataMultipleKeys returns the  data for the given keys
 func (s *store) GetDataMultipleKeys(key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
 	return s.getDataMultipleKeys(key.EndorsedAtTxID, key.Namespace, key.Collection, key.Keys...)
@@ -164,20 +401,63 @@ func (s *store) Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, error)
 		return nil, err
 	}
 
+	return s.newResultsIterator(key.EndorsedAtTxID, key.Namespace, key.Collection, results), nil
+}
+
+// QueryWithPagination executes the given query and returns at most pageSize results starting at the given bookmark
+func (s *store) QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.Errorf("invalid page size [%d] - page size must be greater than 0", pageSize)
+	}
+
+	db, err := s.dbProvider.GetDB(s.channelID, key.Collection, key.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, nextBookmark, err := db.QueryWithPagination(key.Query, bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	it := s.newResultsIterator(key.EndorsedAtTxID, key.Namespace, key.Collection, results)
+
+	return it, &storeapi.QueryResponseMetadata{
+		FetchedRecordsCount: int32(len(it.results)),
+		Bookmark:            nextBookmark,
+	}, nil
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (s *store) GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	db, err := s.dbProvider.GetDB(s.channelID, key.Collection, key.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := db.GetByRange(key.StartKey, key.EndKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newResultsIterator(key.EndorsedAtTxID, key.Namespace, key.Collection, results), nil
+}
+
+func (s *store) newResultsIterator(txID, ns, coll string, results []*api.KeyValue) *resultsIterator {
 	var queryResults []*storeapi.QueryResult
 	for _, result := range results {
-		if result.TxID == key.EndorsedAtTxID {
-			logger.Debugf("[%s] Key [%s:%s:%s] was persisted in same transaction [%s] as caller. Not adding key to result set.", s.channelID, key.Namespace, key.Collection, result.Key, key.EndorsedAtTxID)
+		if result.TxID == txID {
+			logger.Debugf("[%s] Key [%s:%s:%s] was persisted in same transaction [%s] as caller. Not adding key to result set.", s.channelID, ns, coll, result.Key, txID)
 		} else {
 			r := &storeapi.QueryResult{
-				Key:           storeapi.NewKey(result.TxID, key.Namespace, key.Collection, result.Key),
-				ExpiringValue: &storeapi.ExpiringValue{Value: result.Value.Value, Expiry: result.ExpiryTime},
+				Key:           storeapi.NewKey(result.TxID, ns, coll, result.Key),
+				ExpiringValue: &storeapi.ExpiringValue{Value: result.Value.Value, Expiry: result.ExpiryTime, WriteTime: result.WriteTime},
 			}
 			queryResults = append(queryResults, r)
 		}
 	}
 
-	return newResultsIterator(queryResults), nil
+	return newResultsIterator(queryResults)
 }
 
 func (s *store) persistColl(txID string, ns string, collConfigPkgs map[string]*pb.CollectionConfigPackage, collRWSet *rwsetutil.CollPvtRwSet) error {
@@ -210,6 +490,12 @@ func (s *store) persistColl(txID string, ns string, collConfigPkgs map[string]*p
 		return err
 	}
 
+	defer s.quota.Lock(ns, collConfig.Name)()
+
+	if err := s.quota.Check(ns, collConfig.Name, batch...); err != nil {
+		return err
+	}
+
 	if s.cacheEnabledForType(collConfig.Type) {
 		s.updateCache(ns, batch, collRWSet)
 	}
@@ -220,60 +506,98 @@ func (s *store) persistColl(txID string, ns string, collConfigPkgs map[string]*p
 		return nil
 	}
 
-	return s.persist(ns, collRWSet.CollectionName, batch)
+	return s.persist(txID, ns, collRWSet.CollectionName, batch)
 }
 
-func (s *store) persist(ns, coll string, batch []*api.KeyValue) error {
+func (s *store) persist(txID, ns, coll string, batch []*api.KeyValue) error {
 	db, err := s.dbProvider.GetDB(s.channelID, coll, ns)
 	if err != nil {
 		return err
 	}
 
-	err = db.Put(batch...)
+	puts, deletes := splitBatch(batch)
+
+	err = db.Put(puts...)
 	if err != nil {
 		return errors.WithMessagef(err, "error persisting to [%s:%s]", ns, coll)
 	}
 
+	if len(deletes) > 0 {
+		err = db.Delete(deletes...)
+		if err != nil {
+			return errors.WithMessagef(err, "error deleting from [%s:%s]", ns, coll)
+		}
+	}
+
+	s.quota.Update(ns, coll, batch...)
+
+	if len(deletes) > 0 {
+		s.putTombstones(txID, ns, coll, deletes...)
+	}
+
 	return nil
 }
 
+// loadAll returns a function that loads all of the keys/values of a collection from the given DB provider
+func loadAll(channelID string, dbProvider api.DBProvider) quota.LoadFunc {
+	return func(ns, coll string) ([]*api.KeyValue, error) {
+		db, err := dbProvider.GetDB(channelID, coll, ns)
+		if err != nil {
+			return nil, err
+		}
+
+		return db.GetByRange("", "")
+	}
+}
+
 func (s *store) updateCache(ns string, batch []*api.KeyValue, collRWSet *rwsetutil.CollPvtRwSet) {
+	puts, deletes := splitBatch(batch)
+
+	for _, kv := range puts {
+		logger.Debugf("[%s] Putting key [%s:%s:%s] in Tx [%s]", s.channelID, ns, collRWSet.CollectionName, kv.Key, kv.TxID)
+		s.cache.Put(ns, collRWSet.CollectionName, kv.Key, kv.Value)
+	}
+
+	if len(deletes) > 0 {
+		logger.Debugf("[%s] Deleting keys [%s:%s:%s]", s.channelID, ns, collRWSet.CollectionName, deletes)
+		s.cache.Delete(ns, collRWSet.CollectionName, deletes...)
+	}
+}
+
+// writeKeys returns the keys of all of the writes in the given private read/write set
+func writeKeys(rwSet *rwsetutil.TxPvtRwSet) []*storeapi.Key {
+	var keys []*storeapi.Key
+	for _, nsRWSet := range rwSet.NsPvtRwSet {
+		for _, collRWSet := range nsRWSet.CollPvtRwSets {
+			for _, w := range collRWSet.KvRwSet.Writes {
+				keys = append(keys, storeapi.NewKey("", nsRWSet.NameSpace, collRWSet.CollectionName, w.Key))
+			}
+		}
+	}
+
+	return keys
+}
+
+// splitBatch splits the given batch into the keys/values to be stored and the keys to be deleted
+func splitBatch(batch []*api.KeyValue) ([]*api.KeyValue, []string) {
+	var puts []*api.KeyValue
+	var deletes []string
 	for _, kv := range batch {
 		if kv.Value != nil {
-			logger.Debugf("[%s] Putting key [%s:%s:%s] in Tx [%s]", s.channelID, ns, collRWSet.CollectionName, kv.Key, kv.TxID)
-			s.cache.Put(ns, collRWSet.CollectionName, kv.Key, kv.Value)
+			puts = append(puts, kv)
 		} else {
-			logger.Debugf("[%s] Deleting key [%s:%s:%s]", s.channelID, ns, collRWSet.CollectionName, kv.Key)
-			s.cache.Delete(ns, collRWSet.CollectionName, kv.Key)
+			deletes = append(deletes, kv.Key)
 		}
 	}
+	return puts, deletes
 }
 
 func (s *store) getData(txID, ns, coll, key string) (*storeapi.ExpiringValue, error) {
-	cacheEnabled, err := s.cacheEnabled(ns, coll)
+	value, err := s.getValue(ns, coll, key)
 	if err != nil {
 		return nil, err
 	}
 
-	var value *api.Value
-
-	if !cacheEnabled {
-		var db api.DB
-		db, err = s.dbProvider.GetDB(s.channelID, coll, ns)
-		if err != nil {
-			return nil, errors.WithMessage(err, "error getting database")
-		}
-		value, err = db.Get(key)
-		if err != nil {
-			return nil, errors.WithMessage(err, "error loading value")
-		}
-	} else {
-		value, err = s.cache.Get(ns, coll, key)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	if value == nil {
 		return nil, nil
 	}
@@ -284,7 +608,40 @@ func (s *store) getData(txID, ns, coll, key string) (*storeapi.ExpiringValue, er
 		return nil, nil
 	}
 
-	return &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime}, nil
+	return &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime, WriteTime: value.WriteTime}, nil
+}
+
+func (s *store) getValue(ns, coll, key string) (*api.Value, error) {
+	cacheEnabled, err := s.cacheEnabled(ns, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheEnabled {
+		return s.cache.Get(ns, coll, key)
+	}
+
+	db, err := s.dbProvider.GetDB(s.channelID, coll, ns)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error getting database")
+	}
+
+	value, err := db.Get(key)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error loading value")
+	}
+
+	return value, nil
+}
+
+// revisionMatches returns true if the given revision matches either the transaction ID or the
+// database revision of the given value. An empty revision matches only if the value doesn't exist.
+func revisionMatches(value *api.Value, revision string) bool {
+	if value == nil {
+		return revision == ""
+	}
+
+	return revision != "" && (revision == value.TxID || revision == value.Revision)
 }
 
 func (s *store) getDataMultipleKeys(txID, ns, coll string, keys ...string) (storeapi.ExpiringValues, error) {
@@ -327,7 +684,7 @@ func (s *store) getDataMultipleKeys(txID, ns, coll string, keys ...string) (stor
 			if value.TxID == txID {
 				logger.Debugf("[%s] Key [%s:%s:%s] was persisted in same transaction [%s] as caller. Returning nil.", s.channelID, ns, coll, keys[i], txID)
 			} else {
-				v = &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime}
+				v = &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime, WriteTime: value.WriteTime}
 			}
 		}
 		ret = append(ret, v)
@@ -337,12 +694,17 @@ func (s *store) getDataMultipleKeys(txID, ns, coll string, keys ...string) (stor
 }
 
 func (s *store) createBatch(txID, ns string, config *pb.StaticCollectionConfig, collRWSet *rwsetutil.CollPvtRwSet, expiryTime time.Time) ([]*api.KeyValue, error) {
+	writeTime := time.Now().UTC()
+
 	var batch []*api.KeyValue
 	for _, w := range collRWSet.KvRwSet.Writes {
 		kv, err := s.newKeyValue(txID, ns, config, expiryTime, w)
 		if err != nil {
 			return nil, err
 		}
+		if kv.Value != nil {
+			kv.WriteTime = writeTime
+		}
 		batch = append(batch, kv)
 	}
 	return batch, nil
@@ -442,7 +804,7 @@ func (s *store) beforeSave(config *pb.StaticCollectionConfig, key *storeapi.Key,
 	if !ok || cfg.decorator == nil {
 		return key, value, nil
 	}
-	return cfg.decorator.BeforeSave(key, value)
+	return cfg.decorator.BeforeSave(s.channelID, key, value)
 }
 
 func (s *store) beforeLoad(config *pb.StaticCollectionConfig, key *storeapi.Key) (*storeapi.Key, error) {
@@ -480,6 +842,33 @@ func (s *store) cacheEnabledForType(collType pb.CollectionType) bool {
 	return typeConfig.enableCache
 }
 
+func (s *store) encryptionEnabled(_, ns, coll string) (bool, error) {
+	collConfig, err := s.collConfigRetriever.Config(ns, coll)
+	if err != nil {
+		return false, err
+	}
+
+	typeConfig, ok := s.collConfigs[collConfig.Type]
+	if !ok {
+		return false, nil
+	}
+
+	return typeConfig.enableEncryption, nil
+}
+
+func (s *store) encryptionEnabledForAnyType() bool {
+	for _, c := range s.collConfigs {
+		if c.enableEncryption {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getLimits returns the limits of the given collection. This variable may be overridden by unit tests.
+var getLimits quota.LimitsFunc = quota.GetLimits
+
 // getLocalMSPID returns the MSP ID of the local peer. This variable may be overridden by unit tests.
 var getLocalMSPID = func(identifierProvider collcommon.IdentifierProvider) (string, error) {
 	return identifierProvider.GetIdentifier()
@@ -14,15 +14,19 @@ import (
 	"time"
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	"github.com/ipfs/go-cid"
 	mh "github.com/multiformats/go-multihash"
+	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/quota"
 	olstoreapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/encryption"
 	olmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/mocks"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
 	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
@@ -50,6 +54,7 @@ const (
 	key1 = "key1"
 	key2 = "key2"
 	key3 = "key3"
+	key4 = "key4"
 )
 
 var (
@@ -370,6 +375,31 @@ func TestStore_PutData(t *testing.T) {
 		assert.NoError(t, err)
 		require.NotNil(t, v)
 		assert.Equal(t, value1_1, v.Value)
+		assert.False(t, v.WriteTime.IsZero())
+	})
+
+	t.Run("Original write time -> preserved", func(t *testing.T) {
+		writeTime := time.Now().UTC().Add(-time.Hour)
+
+		err := s.PutData(
+			collConfig,
+			&storeapi.Key{
+				EndorsedAtTxID: txID1,
+				Namespace:      ns1,
+				Collection:     coll1,
+				Key:            key2,
+			},
+			&storeapi.ExpiringValue{
+				Value:     value2_1,
+				WriteTime: writeTime,
+			},
+		)
+		assert.NoError(t, err)
+
+		v, err := s.GetData(&storeapi.Key{EndorsedAtTxID: txID2, Namespace: ns1, Collection: coll1, Key: key2})
+		assert.NoError(t, err)
+		require.NotNil(t, v)
+		assert.True(t, writeTime.Equal(v.WriteTime))
 	})
 
 	t.Run("Nil value -> error", func(t *testing.T) {
@@ -432,6 +462,226 @@ func TestStore_PutData(t *testing.T) {
 	})
 }
 
+func TestStore_PutDataIfLocalRevision(t *testing.T) {
+	getLocalMSPID = func(collcommon.IdentifierProvider) (string, error) { return org1MSP, nil }
+
+	typeConfig = map[pb.CollectionType]*collTypeConfig{
+		pb.CollectionType_COL_OFFLEDGER: {
+			enableCache: true,
+		},
+	}
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, typeConfig, newMockProviders())
+	require.NotNil(t, s)
+
+	defer s.Close()
+
+	collConfig := &pb.StaticCollectionConfig{
+		Type: pb.CollectionType_COL_OFFLEDGER,
+		Name: coll1,
+	}
+
+	t.Run("Create if not exists -> success", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(txID1, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_1}, "")
+		require.NoError(t, err)
+
+		v, rev, err := s.GetDataWithRevision(storeapi.NewKey(txID2, ns1, coll1, key1))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Equal(t, value1_1, v.Value)
+		require.Equal(t, txID1, rev)
+	})
+
+	t.Run("Create if already exists -> conflict", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(txID2, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_2}, "")
+		require.Error(t, err)
+		require.True(t, storeapi.IsRevisionConflict(err))
+
+		conflictErr, ok := err.(*storeapi.RevisionConflictError)
+		require.True(t, ok)
+		require.Empty(t, conflictErr.Expected)
+		require.Equal(t, txID1, conflictErr.Actual)
+	})
+
+	t.Run("Matching revision -> success", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(txID2, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_2}, txID1)
+		require.NoError(t, err)
+
+		v, rev, err := s.GetDataWithRevision(storeapi.NewKey(txID3, ns1, coll1, key1))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Equal(t, value1_2, v.Value)
+		require.Equal(t, txID2, rev)
+	})
+
+	t.Run("Stale revision -> conflict", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(txID3, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value2_1}, txID1)
+		require.Error(t, err)
+		require.True(t, storeapi.IsRevisionConflict(err))
+		require.Contains(t, err.Error(), "expected revision [txid1] but the stored revision is [txid2]")
+
+		v, err := s.GetData(storeapi.NewKey(txID4, ns1, coll1, key1))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Equal(t, value1_2, v.Value)
+	})
+
+	t.Run("Revision of non-existent key -> conflict", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(txID3, ns1, coll1, key2), &storeapi.ExpiringValue{Value: value2_1}, txID1)
+		require.True(t, storeapi.IsRevisionConflict(err))
+	})
+
+	t.Run("No transaction ID -> error", func(t *testing.T) {
+		err := s.PutDataIfLocalRevision(collConfig, storeapi.NewKey("", ns1, coll1, key1), &storeapi.ExpiringValue{Value: value2_1}, txID2)
+		require.Error(t, err)
+		require.False(t, storeapi.IsRevisionConflict(err))
+		require.Contains(t, err.Error(), "transaction ID is required")
+	})
+
+	t.Run("Concurrent puts with same revision -> only one succeeds", func(t *testing.T) {
+		const n = 10
+
+		errs := make(chan error, n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				errs <- s.PutDataIfLocalRevision(collConfig, storeapi.NewKey(fmt.Sprintf("tx_%d", i), ns1, coll1, key3), &storeapi.ExpiringValue{Value: value3_1}, "")
+			}(i)
+		}
+
+		var succeeded, conflicts int
+
+		for i := 0; i < n; i++ {
+			err := <-errs
+			if err == nil {
+				succeeded++
+			} else if storeapi.IsRevisionConflict(err) {
+				conflicts++
+			}
+		}
+
+		require.Equal(t, 1, succeeded)
+		require.Equal(t, n-1, conflicts)
+	})
+}
+
+func TestStore_DeleteData(t *testing.T) {
+	getLocalMSPID = func(collcommon.IdentifierProvider) (string, error) { return org1MSP, nil }
+
+	oldEnabled := viper.Get("coll.offledger.reconcile.enabled")
+	defer viper.Set("coll.offledger.reconcile.enabled", oldEnabled)
+	viper.Set("coll.offledger.reconcile.enabled", true)
+
+	dbProvider := olmocks.NewDBProvider()
+	providers := newMockProviders()
+	providers.dbProvider = dbProvider
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, typeConfig, providers)
+	require.NotNil(t, s)
+	defer s.Close()
+
+	collConfig := &pb.StaticCollectionConfig{
+		Type: pb.CollectionType_COL_OFFLEDGER,
+		Name: coll1,
+	}
+
+	require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_1}))
+	require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key2), &storeapi.ExpiringValue{Value: value1_2}))
+
+	t.Run("Invalid collection -> error", func(t *testing.T) {
+		err := s.DeleteData(collConfig, storeapi.NewMultiKey(txID2, ns1, coll2, key1))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid collection config")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		// Load the values into the cache
+		values, err := s.GetDataMultipleKeys(storeapi.NewMultiKey(txID2, ns1, coll1, key1, key2))
+		require.NoError(t, err)
+		require.Len(t, values, 2)
+		require.NotNil(t, values[0])
+		require.NotNil(t, values[1])
+
+		require.NoError(t, s.DeleteData(collConfig, storeapi.NewMultiKey(txID2, ns1, coll1, key1, key3)))
+
+		values, err = s.GetDataMultipleKeys(storeapi.NewMultiKey(txID3, ns1, coll1, key1, key2))
+		require.NoError(t, err)
+		require.Len(t, values, 2)
+		require.Nil(t, values[0])
+		require.NotNil(t, values[1])
+
+		v, err := dbProvider.MockDB(ns1, coll1).Get(key1)
+		require.NoError(t, err)
+		require.Nil(t, v)
+
+		tombstone, err := s.GetTombstone(storeapi.NewKey("", ns1, coll1, key1))
+		require.NoError(t, err)
+		require.NotNil(t, tombstone)
+		require.Equal(t, txID2, tombstone.TxID)
+
+		tombstone, err = s.GetTombstone(storeapi.NewKey("", ns1, coll1, key2))
+		require.NoError(t, err)
+		require.Nil(t, tombstone)
+	})
+
+	t.Run("Persist delete -> tombstone", func(t *testing.T) {
+		b := mocks.NewPvtReadWriteSetBuilder()
+		b.Namespace(ns1).Collection(coll1).
+			OffLedgerConfig("OR('Org1MSP.member')", 1, 2, "").
+			Delete(key2)
+
+		require.NoError(t, s.Persist(txID3, b.Build()))
+
+		value, err := s.GetData(storeapi.NewKey(txID1, ns1, coll1, key2))
+		require.NoError(t, err)
+		require.Nil(t, value)
+
+		tombstone, err := s.GetTombstone(storeapi.NewKey("", ns1, coll1, key2))
+		require.NoError(t, err)
+		require.NotNil(t, tombstone)
+		require.Equal(t, txID3, tombstone.TxID)
+	})
+
+	t.Run("Tombstone DB error -> deleted", func(t *testing.T) {
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key3), &storeapi.ExpiringValue{Value: value3_1}))
+
+		dbProvider.MockDB(ns1, coll1+tombstoneCollSuffix).WithError(errors.New("injected tombstone DB error"))
+		defer dbProvider.MockDB(ns1, coll1+tombstoneCollSuffix).WithError(nil)
+
+		require.NoError(t, s.DeleteData(collConfig, storeapi.NewMultiKey(txID4, ns1, coll1, key3)))
+
+		value, err := s.GetData(storeapi.NewKey(txID1, ns1, coll1, key3))
+		require.NoError(t, err)
+		require.Nil(t, value)
+	})
+
+	t.Run("Reconcile disabled -> no tombstone", func(t *testing.T) {
+		viper.Set("coll.offledger.reconcile.enabled", false)
+		defer viper.Set("coll.offledger.reconcile.enabled", true)
+
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key4), &storeapi.ExpiringValue{Value: value4_1}))
+		require.NoError(t, s.DeleteData(collConfig, storeapi.NewMultiKey(txID4, ns1, coll1, key4)))
+
+		value, err := s.GetData(storeapi.NewKey(txID1, ns1, coll1, key4))
+		require.NoError(t, err)
+		require.Nil(t, value)
+
+		tombstone, err := s.GetTombstone(storeapi.NewKey("", ns1, coll1, key4))
+		require.NoError(t, err)
+		require.Nil(t, tombstone)
+	})
+
+	t.Run("DB error -> error", func(t *testing.T) {
+		errExpected := errors.New("injected DB error")
+		dbProvider.MockDB(ns1, coll1).WithError(errExpected)
+		defer dbProvider.MockDB(ns1, coll1).WithError(nil)
+
+		err := s.DeleteData(collConfig, storeapi.NewMultiKey(txID2, ns1, coll1, key2))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
 func TestStore_DBError(t *testing.T) {
 	getLocalMSPID = func(collcommon.IdentifierProvider) (string, error) { return org1MSP, nil }
 
@@ -700,6 +950,173 @@ func TestStore_ExecuteQuery(t *testing.T) {
 	})
 }
 
+func TestStore_QueryWithPagination(t *testing.T) {
+	const query = "some query"
+
+	results := []*olstoreapi.KeyValue{
+		{Key: key1, Value: &olstoreapi.Value{Value: value1_1, TxID: txID1}},
+		{Key: key2, Value: &olstoreapi.Value{Value: value1_2, TxID: txID2}},
+		{Key: key3, Value: &olstoreapi.Value{Value: value1_1, TxID: txID2}},
+	}
+
+	dbProvider := olmocks.NewDBProvider().WithQueryResults(ns1, coll1, query, results)
+	providers := newMockProviders()
+	providers.dbProvider = dbProvider
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, typeConfig, providers)
+	require.NotNil(t, s)
+	defer s.Close()
+
+	t.Run("Pages in new transaction -> valid", func(t *testing.T) {
+		it, metadata, err := s.QueryWithPagination(storeapi.NewQueryKey(txID3, ns1, coll1, query), "", 2)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		require.NotNil(t, metadata)
+		require.Equal(t, int32(2), metadata.FetchedRecordsCount)
+		require.NotEmpty(t, metadata.Bookmark)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key1, next.Key.Key)
+		it.Close()
+
+		it, metadata, err = s.QueryWithPagination(storeapi.NewQueryKey(txID3, ns1, coll1, query), metadata.Bookmark, 2)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+		require.Empty(t, metadata.Bookmark)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key3, next.Key.Key)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+		it.Close()
+	})
+
+	t.Run("Page in same transaction -> key omitted", func(t *testing.T) {
+		it, metadata, err := s.QueryWithPagination(storeapi.NewQueryKey(txID1, ns1, coll1, query), "", 2)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+		require.NotEmpty(t, metadata.Bookmark)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key2, next.Key.Key)
+		it.Close()
+	})
+
+	t.Run("Invalid page size -> fail", func(t *testing.T) {
+		it, metadata, err := s.QueryWithPagination(storeapi.NewQueryKey(txID1, ns1, coll1, query), "", 0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid page size")
+		require.Nil(t, it)
+		require.Nil(t, metadata)
+	})
+
+	t.Run("DB provider error -> fail", func(t *testing.T) {
+		errExpected := errors.New("injected error")
+		dbProvider.WithError(errExpected)
+		defer dbProvider.WithError(nil)
+
+		it, _, err := s.QueryWithPagination(storeapi.NewQueryKey(txID1, ns1, coll1, query), "", 2)
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, it)
+	})
+
+	t.Run("DB error -> fail", func(t *testing.T) {
+		errExpected := errors.New("injected error")
+		dbProvider.MockDB(ns1, coll1).WithError(errExpected)
+		defer dbProvider.MockDB(ns1, coll1).WithError(nil)
+
+		it, _, err := s.QueryWithPagination(storeapi.NewQueryKey(txID1, ns1, coll1, query), "", 2)
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, it)
+	})
+}
+
+func TestStore_GetDataByRange(t *testing.T) {
+	dbProvider := olmocks.NewDBProvider().
+		WithValue(ns1, coll1, key3, &olstoreapi.Value{Value: value1_1, TxID: txID2}).
+		WithValue(ns1, coll1, key1, &olstoreapi.Value{Value: value1_1, TxID: txID1}).
+		WithValue(ns1, coll1, key2, &olstoreapi.Value{Value: value1_2, TxID: txID2})
+	providers := newMockProviders()
+	providers.dbProvider = dbProvider
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, typeConfig, providers)
+	require.NotNil(t, s)
+	defer s.Close()
+
+	t.Run("Range in new transaction -> valid", func(t *testing.T) {
+		it, err := s.GetDataByRange(storeapi.NewRangeKey(txID3, ns1, coll1, key1, key3))
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key1, next.Key.Key)
+		require.Equal(t, txID1, next.Key.EndorsedAtTxID)
+		require.Equal(t, value1_1, next.Value)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key2, next.Key.Key)
+		require.Equal(t, value1_2, next.Value)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("Range in same transaction -> key omitted", func(t *testing.T) {
+		it, err := s.GetDataByRange(storeapi.NewRangeKey(txID1, ns1, coll1, key1, ""))
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		defer it.Close()
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key2, next.Key.Key)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		require.Equal(t, key3, next.Key.Key)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("DB provider error -> fail", func(t *testing.T) {
+		errExpected := errors.New("injected error")
+		dbProvider.WithError(errExpected)
+		defer dbProvider.WithError(nil)
+
+		it, err := s.GetDataByRange(storeapi.NewRangeKey(txID1, ns1, coll1, key1, key3))
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, it)
+	})
+
+	t.Run("DB error -> fail", func(t *testing.T) {
+		errExpected := errors.New("injected error")
+		dbProvider.MockDB(ns1, coll1).WithError(errExpected)
+		defer dbProvider.MockDB(ns1, coll1).WithError(nil)
+
+		it, err := s.GetDataByRange(storeapi.NewRangeKey(txID1, ns1, coll1, key1, key3))
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, it)
+	})
+}
+
 func TestStore_PutAndGet_NoCache(t *testing.T) {
 	s := newStore(channelID, &olConfig{cacheSize: 0}, typeConfig, newMockProviders())
 	require.NotNil(t, s)
@@ -959,6 +1376,176 @@ func TestStore_CacheEnabled(t *testing.T) {
 	})
 }
 
+func TestStore_Encryption(t *testing.T) {
+	getLocalMSPID = func(collcommon.IdentifierProvider) (string, error) { return org1MSP, nil }
+
+	keyProvider, err := encryption.NewKeyProvider([]byte("01234567890123456789012345678901"))
+	require.NoError(t, err)
+
+	dbProvider := olmocks.NewDBProvider()
+
+	providers := newMockProviders()
+	providers.dbProvider = dbProvider
+	providers.keyProvider = keyProvider
+
+	encryptedTypeConfig := map[pb.CollectionType]*collTypeConfig{
+		pb.CollectionType_COL_OFFLEDGER: {
+			enableCache:      true,
+			enableEncryption: true,
+		},
+	}
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, encryptedTypeConfig, providers)
+	require.NotNil(t, s)
+	defer s.Close()
+
+	// The given providers aren't modified
+	require.Equal(t, dbProvider, providers.dbProvider)
+
+	collConfig := &pb.StaticCollectionConfig{
+		Type: pb.CollectionType_COL_OFFLEDGER,
+		Name: coll1,
+	}
+
+	require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_1}))
+
+	// The value in the database is encrypted
+	dbValue, err := dbProvider.MockDB(ns1, coll1).Get(key1)
+	require.NoError(t, err)
+	require.NotNil(t, dbValue)
+	require.NotEqual(t, value1_1, dbValue.Value)
+
+	v, err := s.GetData(storeapi.NewKey(txID2, ns1, coll1, key1))
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, value1_1, v.Value)
+
+	// Flush the cache to force the value to be loaded (and decrypted) from the database
+	require.Equal(t, 1, s.FlushCache(ns1, coll1))
+
+	values, err := s.GetDataMultipleKeys(storeapi.NewMultiKey(txID2, ns1, coll1, key1))
+	require.NoError(t, err)
+	require.Len(t, values, 1)
+	require.Equal(t, value1_1, values[0].Value)
+
+	_, err = s.Query(&storeapi.QueryKey{EndorsedAtTxID: txID2, Namespace: ns1, Collection: coll1, Query: `{"selector":{}}`})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rich queries are not supported")
+}
+
+func TestStore_Quota(t *testing.T) {
+	getLocalMSPID = func(collcommon.IdentifierProvider) (string, error) { return org1MSP, nil }
+
+	getLimits = func(ns, coll string) *quota.Limits {
+		switch coll {
+		case coll1:
+			return &quota.Limits{MaxValueSize: 10, MaxKeyCount: 2}
+		case coll0:
+			return &quota.Limits{MaxKeyCount: 3}
+		default:
+			return &quota.Limits{}
+		}
+	}
+	defer func() { getLimits = quota.GetLimits }()
+
+	quotaTypeConfig := map[pb.CollectionType]*collTypeConfig{
+		pb.CollectionType_COL_OFFLEDGER: {enableCache: true},
+	}
+
+	s := newStore(channelID, &olConfig{cacheSize: 100}, quotaTypeConfig, newMockProviders())
+	require.NotNil(t, s)
+	defer s.Close()
+
+	collConfig := &pb.StaticCollectionConfig{
+		Type: pb.CollectionType_COL_OFFLEDGER,
+		Name: coll1,
+	}
+
+	require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key1), &storeapi.ExpiringValue{Value: value1_1}))
+
+	t.Run("Value size exceeded", func(t *testing.T) {
+		err := s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key2), &storeapi.ExpiringValue{Value: []byte("a value that is too large")})
+		require.Error(t, err)
+		require.IsType(t, &quota.ExceededError{}, err)
+		require.Equal(t, quota.ValueSize, err.(*quota.ExceededError).Limit)
+	})
+
+	t.Run("Key count exceeded", func(t *testing.T) {
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key2), &storeapi.ExpiringValue{Value: value1_2}))
+
+		err := s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key3), &storeapi.ExpiringValue{Value: value3_1})
+		require.Error(t, err)
+		require.Equal(t, quota.KeyCount, err.(*quota.ExceededError).Limit)
+
+		b := mocks.NewPvtReadWriteSetBuilder()
+		b.Namespace(ns1).
+			Collection(coll1).
+			OffLedgerConfig("OR('Org1MSP.member')", 1, 2, "1m").
+			Write(key3, value3_1)
+
+		err = s.Persist(txID2, b.Build())
+		require.Error(t, err)
+		require.Equal(t, quota.KeyCount, err.(*quota.ExceededError).Limit)
+
+		// Updating an existing key doesn't increase the key count
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key2), &storeapi.ExpiringValue{Value: value2_1}))
+	})
+
+	t.Run("Key deleted", func(t *testing.T) {
+		require.NoError(t, s.DeleteData(collConfig, storeapi.NewMultiKey(txID2, ns1, coll1, key1)))
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll1, key3), &storeapi.ExpiringValue{Value: value3_1}))
+
+		keys, bytes, err := s.quota.Usage(ns1, coll1)
+		require.NoError(t, err)
+		require.Equal(t, 2, keys)
+		require.Equal(t, int64(len(value2_1)+len(value3_1)), bytes)
+	})
+
+	t.Run("Concurrent writers -> quota not exceeded", func(t *testing.T) {
+		collConfig := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll0,
+		}
+
+		const n = 20
+
+		errs := make(chan error, n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				errs <- s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll0, fmt.Sprintf("key_%d", i)), &storeapi.ExpiringValue{Value: value1_1})
+			}(i)
+		}
+
+		var succeeded, exceeded int
+
+		for i := 0; i < n; i++ {
+			err := <-errs
+			if err == nil {
+				succeeded++
+			} else if _, ok := err.(*quota.ExceededError); ok {
+				exceeded++
+			}
+		}
+
+		require.Equal(t, 3, succeeded)
+		require.Equal(t, n-3, exceeded)
+
+		keys, _, err := s.quota.Usage(ns1, coll0)
+		require.NoError(t, err)
+		require.Equal(t, 3, keys)
+	})
+
+	t.Run("No limits", func(t *testing.T) {
+		collConfig := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll2,
+		}
+
+		require.NoError(t, s.PutData(collConfig, storeapi.NewKey(txID1, ns1, coll2, key1), &storeapi.ExpiringValue{Value: []byte("a value that isn't too large")}))
+	})
+}
+
 func newMockProviders() *providers {
 	collConfigRetriever := mocks.NewCollectionConfigRetriever()
 
@@ -975,5 +1562,6 @@ func newMockProviders() *providers {
 		identifierProvider:   &mocks.IdentifierProvider{},
 		identityDeserializer: &mocks.IdentityDeserializer{},
 		collConfigRetriever:  collConfigRetriever,
+		metricsProvider:      &disabled.Provider{},
 	}
 }
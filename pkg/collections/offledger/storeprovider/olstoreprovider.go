@@ -10,12 +10,16 @@ import (
 	"sync"
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
 
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
 	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/couchdbstore"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/encryption"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
@@ -41,7 +45,7 @@ func WithCollectionType(collType pb.CollectionType, opts ...CollOption) Option {
 // Decorator allows the key/value to be modified/validated before being persisted.
 type Decorator interface {
 	// BeforeSave has the opportunity to decorate the key and/or value before the key-value is saved.
-	BeforeSave(key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error)
+	BeforeSave(channelID string, key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error)
 
 	// BeforeLoad has the opportunity to decorate the key before it is loaded/deleted.
 	BeforeLoad(key *storeapi.Key) (*storeapi.Key, error)
@@ -61,9 +65,34 @@ func WithCacheEnabled() CollOption {
 	}
 }
 
+// WithEncryptionEnabled enables encryption at rest for the given collection type. Rich queries are
+// not supported on collections of this type since the values are opaque to the database.
+func WithEncryptionEnabled() CollOption {
+	return func(c *collTypeConfig) {
+		c.enableEncryption = true
+	}
+}
+
+// WithKeyProvider sets the provider of the key-encryption keys that wrap the data keys of encrypted
+// collections. If not set then the keys are loaded from the configured key file.
+func WithKeyProvider(keyProvider encryption.KeyProvider) Option {
+	return func(p *StoreProvider) {
+		p.keyProvider = keyProvider
+	}
+}
+
+// WithMetricsProvider sets the provider to which the cache and quota metrics are reported.
+// If not set then metrics are disabled.
+func WithMetricsProvider(metricsProvider metrics.Provider) Option {
+	return func(p *StoreProvider) {
+		p.metricsProvider = metricsProvider
+	}
+}
+
 type collTypeConfig struct {
-	decorator   Decorator
-	enableCache bool
+	decorator        Decorator
+	enableCache      bool
+	enableEncryption bool
 }
 
 // New returns a store provider factory
@@ -79,15 +108,19 @@ func New(
 		identityDeserializerProvider: identityDeserializerProvider,
 		collConfigs:                  make(map[pb.CollectionType]*collTypeConfig),
 		collConfigProvider:           collConfigProvider,
+		metricsProvider:              &disabled.Provider{},
 	}
 
-	// OFF_LEDGER collection type supported by default
-	opts = append(opts, WithCollectionType(pb.CollectionType_COL_OFFLEDGER))
-
 	// Apply options
 	for _, opt := range opts {
 		opt(p)
 	}
+
+	// OFF_LEDGER collection type supported by default
+	if _, ok := p.collConfigs[pb.CollectionType_COL_OFFLEDGER]; !ok {
+		WithCollectionType(pb.CollectionType_COL_OFFLEDGER)(p)
+	}
+
 	return p
 }
 
@@ -100,6 +133,8 @@ type StoreProvider struct {
 	identityDeserializerProvider collcommon.IdentityDeserializerProvider
 	collConfigs                  map[pb.CollectionType]*collTypeConfig
 	collConfigProvider           collcommon.CollectionConfigProvider
+	keyProvider                  encryption.KeyProvider
+	metricsProvider              metrics.Provider
 }
 
 // StoreForChannel returns the store for the given channel
@@ -109,6 +144,11 @@ func (sp *StoreProvider) StoreForChannel(channelID string) olapi.Store {
 	return sp.stores[channelID]
 }
 
+// DBProvider returns the provider of the databases in which off-ledger data is stored
+func (sp *StoreProvider) DBProvider() api.DBProvider {
+	return sp.dbProvider
+}
+
 // OpenStore opens the store for the given channel
 func (sp *StoreProvider) OpenStore(channelID string) (olapi.Store, error) {
 	sp.Lock()
@@ -116,6 +156,15 @@ func (sp *StoreProvider) OpenStore(channelID string) (olapi.Store, error) {
 
 	store, ok := sp.stores[channelID]
 	if !ok {
+		if sp.encryptionEnabled() && sp.keyProvider == nil {
+			keyProvider, err := getKeyProvider()
+			if err != nil {
+				return nil, errors.WithMessage(err, "error loading key provider for encrypted collections")
+			}
+
+			sp.keyProvider = keyProvider
+		}
+
 		store = newStore(
 			channelID,
 			&olConfig{cacheSize: config.GetOLCollCacheSize()},
@@ -125,6 +174,8 @@ func (sp *StoreProvider) OpenStore(channelID string) (olapi.Store, error) {
 				identifierProvider:   sp.identifierProvider,
 				identityDeserializer: sp.identityDeserializerProvider.GetIdentityDeserializer(channelID),
 				collConfigRetriever:  sp.collConfigProvider.ForChannel(channelID),
+				keyProvider:          sp.keyProvider,
+				metricsProvider:      sp.metricsProvider,
 			},
 		)
 		sp.stores[channelID] = store
@@ -139,6 +190,21 @@ func (sp *StoreProvider) Close() {
 	}
 }
 
+func (sp *StoreProvider) encryptionEnabled() bool {
+	for _, c := range sp.collConfigs {
+		if c.enableEncryption {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getKeyProvider returns the key provider for encrypted collections. This var may be overridden by unit tests
+var getKeyProvider = func() (encryption.KeyProvider, error) {
+	return encryption.NewFileKeyProvider(config.GetOLCollEncryptionKeyFile())
+}
+
 // getDBProvider returns the DB provider. This var may be overridden by unit tests
 var getDBProvider = func() api.DBProvider {
 	return couchdbstore.NewDBProvider()
@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/encryption"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
 )
 
@@ -62,6 +63,45 @@ func TestStoreProvider_WithDecorator(t *testing.T) {
 	assert.NotNil(t, config)
 }
 
+func TestStoreProvider_WithEncryption(t *testing.T) {
+	t.Run("Key file not configured -> error", func(t *testing.T) {
+		f := New(
+			&mocks.IdentifierProvider{},
+			&mocks.IdentityDeserializerProvider{},
+			&mocks.CollectionConfigProvider{},
+			WithCollectionType(pb.CollectionType_COL_OFFLEDGER, WithEncryptionEnabled()),
+		)
+		require.NotNil(t, f)
+
+		config, ok := f.collConfigs[pb.CollectionType_COL_OFFLEDGER]
+		require.True(t, ok)
+		require.True(t, config.enableEncryption)
+
+		s, err := f.OpenStore("channel1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error loading key provider for encrypted collections")
+		require.Nil(t, s)
+	})
+
+	t.Run("With key provider -> success", func(t *testing.T) {
+		keyProvider, err := encryption.NewKeyProvider([]byte("01234567890123456789012345678901"))
+		require.NoError(t, err)
+
+		f := New(
+			&mocks.IdentifierProvider{},
+			&mocks.IdentityDeserializerProvider{},
+			&mocks.CollectionConfigProvider{},
+			WithCollectionType(pb.CollectionType_COL_OFFLEDGER, WithEncryptionEnabled()),
+			WithKeyProvider(keyProvider),
+		)
+		require.NotNil(t, f)
+
+		s, err := f.OpenStore("channel1")
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(testMain(m))
 }
@@ -69,7 +109,7 @@ func TestMain(m *testing.M) {
 type mockDecorator struct {
 }
 
-func (d *mockDecorator) BeforeSave(key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error) {
+func (d *mockDecorator) BeforeSave(_ string, key *storeapi.Key, value *storeapi.ExpiringValue) (*storeapi.Key, *storeapi.ExpiringValue, error) {
 	return key, value, nil
 }
 
@@ -16,6 +16,8 @@ type Value struct {
 	TxID       string
 	ExpiryTime time.Time
 	Revision   string
+	// WriteTime is the time at which the value was originally written
+	WriteTime time.Time
 }
 
 // KeyValue is a struct to store a key value pair
@@ -40,8 +42,12 @@ func (k *KeyValue) String() string {
 // DB persists collection data.
 type DB interface {
 	// Put stores the given set of keys/values. If expiry time is 0 then the data lives forever.
+	// A key with a nil value is deleted.
 	Put(keyVal ...*KeyValue) error
 
+	// Delete deletes the given keys
+	Delete(keys ...string) error
+
 	// Get returns the value for the given key or nil if the key doesn't exist
 	Get(key string) (*Value, error)
 
@@ -53,6 +59,14 @@ type DB interface {
 
 	// Query returns a set of keys/values for the given query
 	Query(query string) ([]*KeyValue, error)
+
+	// QueryWithPagination returns at most pageSize keys/values for the given query starting at the given
+	// bookmark, along with the bookmark of the next page
+	QueryWithPagination(query string, bookmark string, pageSize int32) ([]*KeyValue, string, error)
+
+	// GetByRange returns the keys/values from startKey (inclusive) to endKey (exclusive), ordered by key.
+	// If endKey is empty then all keys from startKey are returned. Expired values are not returned.
+	GetByRange(startKey, endKey string) ([]*KeyValue, error)
 }
 
 // DBProvider returns the persister for the given namespace/collection
@@ -12,7 +12,10 @@ import (
 
 	"github.com/bluele/gcache"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/common/cachestats"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
 )
 
@@ -23,6 +26,7 @@ type Cache struct {
 	channelID  string
 	cache      gcache.Cache
 	dbProvider api.DBProvider
+	stats      *cachestats.Stats
 }
 
 type cacheKey struct {
@@ -36,12 +40,21 @@ func (k cacheKey) String() string {
 }
 
 // New returns a new collection data cache
-func New(channelID string, dbProvider api.DBProvider, size int) *Cache {
+func New(channelID string, dbProvider api.DBProvider, size int, metricsProvider metrics.Provider) *Cache {
 	c := &Cache{
 		channelID:  channelID,
 		dbProvider: dbProvider,
+		stats:      cachestats.New(metricsProvider, "offledger", channelID),
 	}
-	c.cache = gcache.New(size).ARC().LoaderExpireFunc(
+	c.cache = gcache.New(size).ARC().AddedFunc(
+		func(k, _ interface{}) {
+			key := k.(cacheKey)
+			c.stats.Added(key.namespace, key.collection, key.key)
+		}).EvictedFunc(
+		func(k, _ interface{}) {
+			key := k.(cacheKey)
+			c.stats.Evicted(key.namespace, key.collection, key.key)
+		}).LoaderExpireFunc(
 		func(k interface{}) (interface{}, *time.Duration, error) {
 			key := k.(cacheKey)
 			v, remainingTime, err := c.load(key)
@@ -78,15 +91,22 @@ func (c *Cache) Put(ns, coll, key string, value *api.Value) {
 	}
 }
 
-// Delete deletes the given key.
-func (c *Cache) Delete(ns, coll, key string) {
-	cKey := cacheKey{
-		namespace:  ns,
-		collection: coll,
-		key:        key,
+// Delete deletes the given keys. A nil value is cached for each deleted key so that a stale value
+// isn't reloaded from the DB before the delete has been persisted (possibly by another peer).
+func (c *Cache) Delete(ns, coll string, keys ...string) {
+	for _, key := range keys {
+		cKey := cacheKey{
+			namespace:  ns,
+			collection: coll,
+			key:        key,
+		}
+
+		logger.Debugf("[%s] Deleting key [%s]", c.channelID, cKey)
+
+		if err := c.cache.Set(cKey, (*api.Value)(nil)); err != nil {
+			panic("Set must never return an error")
+		}
 	}
-	logger.Debugf("[%s] Removing key [%s]", c.channelID, cKey)
-	c.cache.Remove(cKey)
 }
 
 // Get returns the values for the given keys
@@ -97,6 +117,12 @@ func (c *Cache) Get(ns, coll, key string) (*api.Value, error) {
 		key:        key,
 	}
 
+	if c.cache.Has(cKey) {
+		c.stats.Hit(ns, coll)
+	} else {
+		c.stats.Miss(ns, coll)
+	}
+
 	value, err := c.cache.Get(cKey)
 	if err != nil {
 		logger.Warningf("[%s] Error getting key [%s]: %s", c.channelID, cKey, err)
@@ -134,6 +160,28 @@ func (c *Cache) GetMultiple(ns, coll string, keys ...string) ([]*api.Value, erro
 	return values, nil
 }
 
+// Size returns the number of keys in the cache for the given collection
+func (c *Cache) Size(ns, coll string) int {
+	return c.stats.Size(ns, coll)
+}
+
+// Flush removes all keys of the given collection from the cache and returns the number of keys removed.
+// The data remains in the database and is reloaded into the cache on the next read.
+func (c *Cache) Flush(ns, coll string) int {
+	keys := c.stats.Remove(ns, coll)
+	for _, key := range keys {
+		c.cache.Remove(cacheKey{
+			namespace:  ns,
+			collection: coll,
+			key:        key,
+		})
+	}
+
+	logger.Debugf("[%s] Flushed [%d] keys of collection [%s:%s] from the cache", c.channelID, len(keys), ns, coll)
+
+	return len(keys)
+}
+
 func (c *Cache) load(key cacheKey) (*api.Value, *time.Duration, error) {
 	db, err := c.dbProvider.GetDB(c.channelID, key.collection, key.namespace)
 	if err != nil {
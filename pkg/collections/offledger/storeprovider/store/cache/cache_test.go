@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
@@ -50,7 +51,7 @@ func TestCache_PutAndGet(t *testing.T) {
 
 	dbProvider := mocks.NewDBProvider()
 
-	c := New(channelID, dbProvider, 100)
+	c := New(channelID, dbProvider, 100, &disabled.Provider{})
 	require.NotNil(t, c)
 
 	c.Put(ns1, coll1, key1, v1)
@@ -86,6 +87,73 @@ func TestCache_PutAndGet(t *testing.T) {
 	require.Nil(t, v) // Should have expired
 }
 
+func TestCache_Delete(t *testing.T) {
+	dbProvider := mocks.NewDBProvider().
+		WithValue(ns1, coll1, key1, &api.Value{Value: value1, TxID: txID1}).
+		WithValue(ns1, coll1, key2, &api.Value{Value: value2, TxID: txID1})
+
+	c := New(channelID, dbProvider, 100, &disabled.Provider{})
+	require.NotNil(t, c)
+
+	c.Put(ns1, coll1, key3, &api.Value{Value: value2, TxID: txID1})
+
+	values, err := c.GetMultiple(ns1, coll1, key1, key2, key3)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	require.NotNil(t, values[0])
+	require.NotNil(t, values[1])
+	require.NotNil(t, values[2])
+
+	c.Delete(ns1, coll1, key1, key3)
+
+	// The value for key1 is still in the DB but should not be reloaded into the cache
+	values, err = c.GetMultiple(ns1, coll1, key1, key2, key3)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	require.Nil(t, values[0])
+	require.NotNil(t, values[1])
+	require.Nil(t, values[2])
+
+	// Put after delete
+	c.Put(ns1, coll1, key1, &api.Value{Value: value2, TxID: txID1})
+
+	v, err := c.Get(ns1, coll1, key1)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, value2, v.Value)
+}
+
+func TestCache_SizeAndFlush(t *testing.T) {
+	dbProvider := mocks.NewDBProvider().
+		WithValue(ns1, coll1, key1, &api.Value{Value: value1, TxID: txID1})
+
+	c := New(channelID, dbProvider, 100, &disabled.Provider{})
+	require.NotNil(t, c)
+
+	c.Put(ns1, coll1, key2, &api.Value{Value: value2, TxID: txID1})
+	c.Put(ns1, coll2, key1, &api.Value{Value: value2, TxID: txID1})
+	require.Equal(t, 1, c.Size(ns1, coll1))
+	require.Equal(t, 1, c.Size(ns1, coll2))
+
+	// Loading key1 from the DB adds it to the cache
+	v, err := c.Get(ns1, coll1, key1)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, 2, c.Size(ns1, coll1))
+
+	require.Equal(t, 2, c.Flush(ns1, coll1))
+	require.Zero(t, c.Size(ns1, coll1))
+	require.Equal(t, 1, c.Size(ns1, coll2))
+	require.Zero(t, c.Flush(ns1, coll1))
+
+	// The value is reloaded from the DB after the flush
+	v, err = c.Get(ns1, coll1, key1)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, value1, v.Value)
+	require.Equal(t, 1, c.Size(ns1, coll1))
+}
+
 func TestCache_LoadFromDB(t *testing.T) {
 	valueWithExpiry := &api.Value{
 		Value:      []byte("value1"),
@@ -104,7 +172,7 @@ func TestCache_LoadFromDB(t *testing.T) {
 		WithValue(ns1, coll1, key2, valueWithNoExpiry).
 		WithValue(ns1, coll1, key3, expiredValue)
 
-	c := New(channelID, dbProvider, 100)
+	c := New(channelID, dbProvider, 100, &disabled.Provider{})
 	require.NotNil(t, c)
 
 	// Not found
@@ -132,7 +200,7 @@ func TestCache_LoadFromDBError(t *testing.T) {
 		dbProvider := mocks.NewDBProvider().
 			WithError(expectedErr)
 
-		c := New(channelID, dbProvider, 100)
+		c := New(channelID, dbProvider, 100, &disabled.Provider{})
 		require.NotNil(t, c)
 
 		v, err := c.Get(ns1, coll1, key1)
@@ -146,7 +214,7 @@ func TestCache_LoadFromDBError(t *testing.T) {
 		dbProvider := mocks.NewDBProvider()
 		dbProvider.MockDB(ns1, coll1).WithError(expectedErr)
 
-		c := New(channelID, dbProvider, 100)
+		c := New(channelID, dbProvider, 100, &disabled.Provider{})
 		require.NotNil(t, c)
 
 		v, err := c.Get(ns1, coll1, key1)
@@ -182,7 +250,7 @@ func TestCache_ReloadFromDB(t *testing.T) {
 	dbProvider := mocks.NewDBProvider().
 		WithValue(ns1, coll1, key1, v1)
 
-	c := New(channelID, dbProvider, 1)
+	c := New(channelID, dbProvider, 1, &disabled.Provider{})
 	require.NotNil(t, c)
 
 	// v1 should be retrieved from the DB and cached
@@ -228,7 +296,7 @@ func Test_Concurrency(t *testing.T) {
 
 	dbProvider := mocks.NewDBProvider()
 
-	c := New(channelID, dbProvider, 100)
+	c := New(channelID, dbProvider, 100, &disabled.Provider{})
 
 	var wWg sync.WaitGroup
 	wWg.Add(nWriters)
@@ -22,14 +22,15 @@ import (
 var logger = flogging.MustGetLogger("ext_offledger")
 
 const (
-	fieldsField = "fields"
+	fieldsField   = "fields"
+	limitField    = "limit"
+	bookmarkField = "bookmark"
 )
 
 type docType int32
 
 const (
-	typeDelete docType = iota
-	typeJSON
+	typeJSON docType = iota
 	typeAttachment
 )
 
@@ -41,6 +42,9 @@ const (
 	}`
 )
 
+// rangeQueryLimit is the maximum number of documents retrieved from CouchDB in a single range request
+const rangeQueryLimit = 1000
+
 type dbstore struct {
 	dbName string
 	db     *couchdb.CouchDatabase
@@ -53,15 +57,26 @@ func newDBStore(db *couchdb.CouchDatabase, dbName string) *dbstore {
 
 //-----------------Interface implementation functions--------------------//
 // AddKey adds dataModel to db
+// A key with a nil value is deleted.
 func (s *dbstore) Put(keyVal ...*api.KeyValue) error {
 	var docs []*couchdb.CouchDoc
+	var deletedKeys []string
 	for _, kv := range keyVal {
+		if kv.Value == nil || kv.Value.Value == nil {
+			deletedKeys = append(deletedKeys, kv.Key)
+			continue
+		}
+
 		dataDoc, err := s.createCouchDoc(kv.Key, kv.Value)
 		if err != nil {
 			return err
 		}
-		if dataDoc != nil {
-			docs = append(docs, dataDoc)
+		docs = append(docs, dataDoc)
+	}
+
+	if len(deletedKeys) > 0 {
+		if err := s.Delete(deletedKeys...); err != nil {
+			return err
 		}
 	}
 
@@ -105,26 +120,42 @@ func (s *dbstore) GetMultiple(keys ...string) ([]*api.Value, error) {
 
 // Query executes a query against the CouchDB and returns the key/value result set
 func (s *dbstore) Query(query string) ([]*api.KeyValue, error) {
-	query, err := decorateQuery(query)
+	query, err := decorateQuery(query, "", 0)
 	if err != nil {
 		return nil, err
 	}
 
-	results, _, err := s.db.QueryDocuments(query)
+	responses, _, err := s.queryDocuments(query)
+	return responses, err
+}
+
+// QueryWithPagination executes a query against the database and returns at most pageSize results
+// starting at the given bookmark, along with the bookmark of the next page
+func (s *dbstore) QueryWithPagination(query string, bookmark string, pageSize int32) ([]*api.KeyValue, string, error) {
+	query, err := decorateQuery(query, bookmark, pageSize)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	return s.queryDocuments(query)
+}
+
+func (s *dbstore) queryDocuments(query string) ([]*api.KeyValue, string, error) {
+	results, nextBookmark, err := s.db.QueryDocuments(query)
+	if err != nil {
+		return nil, "", err
 	}
 
 	if len(results) == 0 {
 		logger.Debugf("No results for query [%s]", query)
-		return nil, nil
+		return nil, nextBookmark, nil
 	}
 
 	var responses []*api.KeyValue
 	for _, result := range results {
 		value, err := unmarshalData(result.Value, result.Attachments)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		responses = append(responses, &api.KeyValue{
 			Key:   result.ID,
@@ -133,9 +164,75 @@ func (s *dbstore) Query(query string) ([]*api.KeyValue, error) {
 		logger.Debugf("Added result for query [%s]: Key [%s], Revision [%s], TxID [%s], Expiry [%s], Value [%s]", query, result.ID, value.Revision, value.TxID, value.ExpiryTime, value.Value)
 	}
 
+	return responses, nextBookmark, nil
+}
+
+// GetByRange returns the keys/values from startKey (inclusive) to endKey (exclusive), ordered by key
+func (s *dbstore) GetByRange(startKey, endKey string) ([]*api.KeyValue, error) {
+	now := time.Now()
+
+	var responses []*api.KeyValue
+	for {
+		results, nextStartKey, err := s.db.ReadDocRange(startKey, endKey, rangeQueryLimit)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read range [%s-%s] from db [%s]", startKey, endKey, s.dbName)
+		}
+
+		for _, result := range results {
+			if isInternalKey(result.ID) {
+				continue
+			}
+
+			value, err := unmarshalData(result.Value, result.Attachments)
+			if err != nil {
+				return nil, err
+			}
+
+			if !value.ExpiryTime.IsZero() && value.ExpiryTime.Before(now) {
+				logger.Debugf("[%s] Key [%s] has expired. Not adding key to result set.", s.dbName, result.ID)
+				continue
+			}
+
+			responses = append(responses, &api.KeyValue{Key: result.ID, Value: value})
+		}
+
+		if nextStartKey == "" || nextStartKey == endKey {
+			break
+		}
+		startKey = nextStartKey
+	}
+
 	return responses, nil
 }
 
+// Delete deletes the given keys from the db. The documents, along with any attachments, are removed.
+func (s *dbstore) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	metadata, err := s.db.BatchRetrieveDocumentMetadata(keys)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to retrieve revisions for keys %s from db [%s]", keys, s.dbName)
+	}
+
+	var docs []*docRevision
+	for _, md := range metadata {
+		if md.ID == "" || md.Rev == "" {
+			// The document doesn't exist or has already been deleted
+			continue
+		}
+		docs = append(docs, &docRevision{ID: md.ID, Rev: md.Rev})
+	}
+
+	if len(docs) == 0 {
+		logger.Debugf("[%s] None of the keys %s were found to delete", s.dbName, keys)
+		return nil
+	}
+
+	return s.deleteDocs(docs)
+}
+
 // DeleteExpiredKeys delete expired keys from db
 func (s *dbstore) DeleteExpiredKeys() error {
 	data, err := fetchExpiryData(s.db, time.Now())
@@ -147,27 +244,30 @@ func (s *dbstore) DeleteExpiredKeys() error {
 		return nil
 	}
 
-	docs := make([]*couchdb.CouchDoc, 0)
-	docIDs := make([]string, 0)
-	for _, doc := range data {
-		updateDoc := &expiryData{ID: doc.ID, Rev: doc.Rev, Deleted: true}
+	return s.deleteDocs(data)
+}
+
+func (s *dbstore) deleteDocs(docs []*docRevision) error {
+	couchDocs := make([]*couchdb.CouchDoc, 0, len(docs))
+	docIDs := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		// A deleted document must not contain any attachments so that the attachments are also removed
+		updateDoc := &docRevision{ID: doc.ID, Rev: doc.Rev, Deleted: true}
 		jsonBytes, err := jsonMarshal(updateDoc)
 		if err != nil {
 			return err
 		}
-		couchDoc := couchdb.CouchDoc{JSONValue: jsonBytes}
-		docs = append(docs, &couchDoc)
+		couchDocs = append(couchDocs, &couchdb.CouchDoc{JSONValue: jsonBytes})
 		docIDs = append(docIDs, updateDoc.ID)
 	}
 
-	if len(docs) > 0 {
-		_, err := s.db.BatchUpdateDocuments(docs)
-		if err != nil {
-			return errors.WithMessage(err, fmt.Sprintf("BatchUpdateDocuments failed for [%d] documents", len(docs)))
-		}
-		logger.Debugf("Deleted expired keys %s from db", docIDs)
+	_, err := s.db.BatchUpdateDocuments(couchDocs)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("BatchUpdateDocuments failed for [%d] documents", len(couchDocs)))
 	}
 
+	logger.Debugf("[%s] Deleted keys %s from db", s.dbName, docIDs)
+
 	return nil
 }
 
@@ -203,11 +303,17 @@ func unmarshalData(jsonValue []byte, attachments []*couchdb.AttachmentInfo) (*ap
 		return nil, err
 	}
 
+	data.WriteTime, err = getWriteTime(jsonResult)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete the meta-data fields so that they're not returned as part of the value
 	delete(jsonResult, idField)
 	delete(jsonResult, revField)
 	delete(jsonResult, txnIDField)
 	delete(jsonResult, expiryField)
+	delete(jsonResult, writeTimeField)
 
 	// handle binary or json data
 	// nolint : S1031: unnecessary nil check around range (gosimple) -- here actual logic is implemnted for
@@ -243,7 +349,7 @@ func (s *dbstore) fetchRevision(key string) (string, error) {
 func (s *dbstore) createCouchDoc(key string, value *api.Value) (*couchdb.CouchDoc, error) {
 	var err error
 	var revision string
-	if value != nil && value.Revision != "" {
+	if value.Revision != "" {
 		revision = value.Revision
 	} else {
 		revision, err = s.fetchRevision(key)
@@ -256,10 +362,6 @@ func (s *dbstore) createCouchDoc(key string, value *api.Value) (*couchdb.CouchDo
 	if err != nil {
 		return nil, err
 	}
-	if docTypeVal == typeDelete && jsonMapVal == nil {
-		logger.Debugf("[%s] Current key/revision not found to delete [%s]", s.dbName, key)
-		return nil, nil
-	}
 
 	jsonBytes, err := jsonMapVal.toBytes()
 	if err != nil {
@@ -267,7 +369,7 @@ func (s *dbstore) createCouchDoc(key string, value *api.Value) (*couchdb.CouchDo
 	}
 
 	couchDoc := couchdb.CouchDoc{JSONValue: jsonBytes}
-	if value != nil && docTypeVal == typeAttachment {
+	if docTypeVal == typeAttachment {
 		couchDoc.Attachments = append([]*couchdb.AttachmentInfo{}, asAttachment(value.Value))
 	}
 
@@ -276,13 +378,13 @@ func (s *dbstore) createCouchDoc(key string, value *api.Value) (*couchdb.CouchDo
 
 //-----------------helper functions--------------------//
 
-type expiryData struct {
+type docRevision struct {
 	ID      string `json:"_id"`
 	Rev     string `json:"_rev"`
 	Deleted bool   `json:"_deleted"`
 }
 
-func fetchExpiryData(db *couchdb.CouchDatabase, expiry time.Time) ([]*expiryData, error) {
+func fetchExpiryData(db *couchdb.CouchDatabase, expiry time.Time) ([]*docRevision, error) {
 	results, _, err := db.QueryDocuments(fmt.Sprintf(fetchExpiryDataQuery, expiry.UnixNano()/int64(time.Millisecond)))
 	if err != nil {
 		return nil, err
@@ -292,9 +394,9 @@ func fetchExpiryData(db *couchdb.CouchDatabase, expiry time.Time) ([]*expiryData
 		return nil, nil
 	}
 
-	var responses []*expiryData
+	var responses []*docRevision
 	for _, result := range results {
-		var data expiryData
+		var data docRevision
 		err = jsonUnmarshal(result.Value, &data)
 		if err != nil {
 			return nil, errors.Wrapf(err, "result from DB is not JSON encoded")
@@ -305,6 +407,11 @@ func fetchExpiryData(db *couchdb.CouchDatabase, expiry time.Time) ([]*expiryData
 	return responses, nil
 }
 
+// isInternalKey returns true if the given document ID is reserved by CouchDB (e.g. design documents)
+func isInternalKey(key string) bool {
+	return strings.HasPrefix(key, "_")
+}
+
 func getExpiry(jsonResult jsonMap) (time.Time, error) {
 	jnExpiry, ok := jsonResult[expiryField].(json.Number)
 	if !ok {
@@ -322,6 +429,38 @@ func getExpiry(jsonResult jsonMap) (time.Time, error) {
 	return time.Unix(0, nExpiry*int64(time.Millisecond)), nil
 }
 
+// getWriteTime returns the write time of the document. A zero time is returned for documents
+// that were stored without a write time.
+func getWriteTime(jsonResult jsonMap) (time.Time, error) {
+	value, ok := jsonResult[writeTimeField]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	jnWriteTime, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, errors.Errorf("write time [%+v] is not a valid JSON number. Type: %s", value, reflect.TypeOf(value))
+	}
+
+	nWriteTime, err := jnWriteTime.Int64()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if nWriteTime == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(0, nWriteTime).UTC(), nil
+}
+
+func getUnixWriteTime(writeTime time.Time) int64 {
+	if writeTime.IsZero() {
+		return 0
+	}
+	return writeTime.UnixNano()
+}
+
 func getUnixExpiry(expiry time.Time) int64 {
 	if expiry.IsZero() {
 		return 0
@@ -329,7 +468,9 @@ func getUnixExpiry(expiry time.Time) int64 {
 	return expiry.UnixNano() / int64(time.Millisecond)
 }
 
-func decorateQuery(query string) (string, error) {
+// decorateQuery adds the internal fields to the query. If pageSize is greater than 0 then the limit
+// and bookmark of the query are set to the given values.
+func decorateQuery(query string, bookmark string, pageSize int32) (string, error) {
 	// create a generic map unmarshal the json
 	jsonQuery := make(jsonMap)
 	decoder := json.NewDecoder(bytes.NewBuffer([]byte(query)))
@@ -353,7 +494,16 @@ func decorateQuery(query string) (string, error) {
 	}
 
 	// Append the internal fields
-	jsonQuery[fieldsField] = append(fields, idField, revField, txnIDField, expiryField)
+	jsonQuery[fieldsField] = append(fields, idField, revField, txnIDField, expiryField, writeTimeField)
+
+	if pageSize > 0 {
+		jsonQuery[limitField] = pageSize
+		if bookmark != "" {
+			jsonQuery[bookmarkField] = bookmark
+		} else {
+			delete(jsonQuery, bookmarkField)
+		}
+	}
 
 	decoratedQuery, err := jsonMarshal(jsonQuery)
 	if err != nil {
@@ -370,18 +520,10 @@ func newJSONMap(key string, revision string, value *api.Value) (jsonMap, docType
 		return nil, docTypeVal, err
 	}
 
-	if docTypeVal == typeDelete {
-		if revision == "" {
-			return nil, typeDelete, nil
-		}
-		m[deletedField] = true
-	}
-
 	m[idField] = key
-	if value != nil {
-		m[txnIDField] = value.TxID
-		m[expiryField] = getUnixExpiry(value.ExpiryTime)
-	}
+	m[txnIDField] = value.TxID
+	m[expiryField] = getUnixExpiry(value.ExpiryTime)
+	m[writeTimeField] = getUnixWriteTime(value.WriteTime)
 
 	if revision != "" {
 		m[revField] = revision
@@ -394,8 +536,6 @@ func jsonMapFromValue(value *api.Value) (jsonMap, docType, error) {
 	m := make(jsonMap)
 
 	switch {
-	case value == nil || value.Value == nil:
-		return m, typeDelete, nil
 	case jsonUnmarshal(value.Value, &m) == nil && m != nil:
 		// Value is a JSON value. Ensure that it doesn't contain any reserved fields
 		if err := m.checkReservedFieldsNotPresent(); err != nil {
@@ -23,9 +23,9 @@ import (
 const (
 	idField            = "_id"
 	revField           = "_rev"
-	deletedField       = "_deleted"
 	txnIDField         = "~txnID"
 	expiryField        = "~expiry"
+	writeTimeField     = "~writeTime"
 	binaryWrapperField = "valueBytes"
 	expiryIndexName    = "by_expiry"
 	expiryIndexDoc     = "indexExpiry"
@@ -142,6 +142,29 @@ func TestGetKeysFromDB(t *testing.T) {
 		require.NoError(t, err)
 		require.Nil(t, v)
 	})
+
+	t.Run("Delete keys -> success", func(t *testing.T) {
+		db3, err := provider.GetDB("testchannel", coll3, ns1)
+		require.NoError(t, err)
+		require.NotNil(t, db3)
+
+		err = db3.Put(
+			api.NewKeyValue(key1, value1, txID1, time.Now().UTC().Add(1*time.Minute)),
+			api.NewKeyValue(key2, []byte(`{"Field1":"value2"}`), txID1, time.Time{}),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, db3.Delete(key1, key2, key3))
+
+		vals, err := db3.GetMultiple(key1, key2)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(vals))
+		require.Nil(t, vals[0])
+		require.Nil(t, vals[1])
+
+		// Delete again
+		require.NoError(t, db3.Delete(key1))
+	})
 }
 
 type testValue struct {
@@ -364,6 +387,83 @@ func TestDbstore_Query(t *testing.T) {
 	})
 }
 
+func TestDbstore_QueryWithPagination(t *testing.T) {
+	provider := NewDBProvider()
+	defer provider.Close()
+
+	db, err := provider.GetDB("testchannel", "pagedcoll", ns1)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	err = db.Put(
+		api.NewKeyValue("page1", []byte(`{"Field1":"paged"}`), txID1, time.Time{}),
+		api.NewKeyValue("page2", []byte(`{"Field1":"paged"}`), txID1, time.Time{}),
+		api.NewKeyValue("page3", []byte(`{"Field1":"paged"}`), txID1, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	const query = `{"selector":{"Field1":"paged"},"fields":["Field1"]}`
+
+	results, bookmark, err := db.QueryWithPagination(query, "", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "page1", results[0].Key)
+	require.Equal(t, "page2", results[1].Key)
+	require.NotEmpty(t, bookmark)
+
+	results, bookmark, err = db.QueryWithPagination(query, bookmark, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "page3", results[0].Key)
+
+	results, _, err = db.QueryWithPagination(query, bookmark, 2)
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		results, _, err := db.QueryWithPagination(`"selector":}`, "", 2)
+		require.Error(t, err)
+		require.Empty(t, results)
+	})
+}
+
+func TestDbstore_GetByRange(t *testing.T) {
+	provider := NewDBProvider()
+	defer provider.Close()
+
+	db, err := provider.GetDB("testchannel", coll2, ns1)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	err = db.Put(
+		api.NewKeyValue("range1", value1, txID1, time.Time{}),
+		api.NewKeyValue("range2", []byte(`{"Field1":"value2"}`), txID1, time.Now().UTC().Add(1*time.Minute)),
+		api.NewKeyValue("range3", value2, txID2, time.Time{}),
+		api.NewKeyValue("range4", value2, txID2, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	results, err := db.GetByRange("range1", "range4")
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "range1", results[0].Key)
+	require.Equal(t, value1, results[0].Value.Value)
+	require.Equal(t, "range2", results[1].Key)
+	require.JSONEq(t, `{"Field1":"value2"}`, string(results[1].Value.Value))
+	require.Equal(t, "range3", results[2].Key)
+	require.Equal(t, txID2, results[2].TxID)
+
+	results, err = db.GetByRange("range3", "")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "range3", results[0].Key)
+	require.Equal(t, "range4", results[1].Key)
+
+	results, err = db.GetByRange("rangeX", "rangeY")
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
 func TestDeleteExpiredKeysFromDB(t *testing.T) {
 	provider := NewDBProvider()
 	defer provider.Close()
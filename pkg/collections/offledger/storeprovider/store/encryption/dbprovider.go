@@ -0,0 +1,307 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+)
+
+var logger = flogging.MustGetLogger("ext_offledger")
+
+// EnabledFunc returns true if the values of the given collection are to be encrypted
+type EnabledFunc func(channelID, ns, coll string) (bool, error)
+
+// DBProvider is a DB provider which encrypts the values of collections for which encryption is enabled. Values are
+// encrypted with a data key which is generated for each collection. The data key is wrapped by a key-encryption
+// key from the key provider and the wrapped data key is stored along with each value, so a value may always be
+// decrypted as long as the key-encryption key that wrapped its data key is available.
+//
+// Since the values are opaque to the underlying database, rich (JSON) queries are not supported on encrypted
+// collections. Values that were stored before encryption was enabled are returned as is.
+type DBProvider struct {
+	api.DBProvider
+	keyProvider KeyProvider
+	isEnabled   EnabledFunc
+	mutex       sync.RWMutex
+	dataKeys    map[string]*dataKey
+	unwrapped   map[string]cipher.AEAD
+}
+
+type dataKey struct {
+	aead       cipher.AEAD
+	kekID      string
+	wrappedKey []byte
+}
+
+// NewDBProvider returns a DB provider which encrypts the values of the collections for which isEnabled returns true
+func NewDBProvider(dbProvider api.DBProvider, keyProvider KeyProvider, isEnabled EnabledFunc) *DBProvider {
+	return &DBProvider{
+		DBProvider:  dbProvider,
+		keyProvider: keyProvider,
+		isEnabled:   isEnabled,
+		dataKeys:    make(map[string]*dataKey),
+		unwrapped:   make(map[string]cipher.AEAD),
+	}
+}
+
+// GetDB returns the DB for the given channel, namespace and collection. If encryption is enabled for the
+// collection then the returned DB encrypts values on the way in and decrypts them on the way out.
+func (p *DBProvider) GetDB(channelID string, coll string, ns string) (api.DB, error) {
+	db, err := p.DBProvider.GetDB(channelID, coll, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := p.isEnabled(channelID, ns, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return db, nil
+	}
+
+	return &encryptedDB{
+		DB:        db,
+		provider:  p,
+		channelID: channelID,
+		ns:        ns,
+		coll:      coll,
+	}, nil
+}
+
+// dataKeyFor returns the data key for the given collection, generating (and wrapping) a new key if necessary
+func (p *DBProvider) dataKeyFor(channelID, ns, coll string) (*dataKey, error) {
+	id := strings.Join([]string{channelID, ns, coll}, ":")
+
+	p.mutex.RLock()
+	dk, ok := p.dataKeys[id]
+	p.mutex.RUnlock()
+
+	if ok {
+		return dk, nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	dk, ok = p.dataKeys[id]
+	if ok {
+		return dk, nil
+	}
+
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.Wrap(err, "error generating data key")
+	}
+
+	kekID, wrappedKey, err := p.keyProvider.WrapKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("[%s] Generated data key for collection [%s:%s] wrapped by key-encryption key [%s]", channelID, ns, coll, kekID)
+
+	dk = &dataKey{aead: aead, kekID: kekID, wrappedKey: wrappedKey}
+	p.dataKeys[id] = dk
+	p.unwrapped[kekID+string(wrappedKey)] = aead
+
+	return dk, nil
+}
+
+// unwrap returns the cipher for the given wrapped data key
+func (p *DBProvider) unwrap(kekID string, wrappedKey []byte) (cipher.AEAD, error) {
+	id := kekID + string(wrappedKey)
+
+	p.mutex.RLock()
+	aead, ok := p.unwrapped[id]
+	p.mutex.RUnlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	key, err := p.keyProvider.UnwrapKey(kekID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	p.unwrapped[id] = aead
+	p.mutex.Unlock()
+
+	return aead, nil
+}
+
+type encryptedDB struct {
+	api.DB
+	provider  *DBProvider
+	channelID string
+	ns        string
+	coll      string
+}
+
+// Put encrypts the values and stores them in the underlying DB
+func (db *encryptedDB) Put(keyVals ...*api.KeyValue) error {
+	encrypted := make([]*api.KeyValue, len(keyVals))
+
+	for i, kv := range keyVals {
+		if kv.Value == nil || kv.Value.Value == nil {
+			// Delete
+			encrypted[i] = kv
+			continue
+		}
+
+		value, err := db.encrypt(kv.Key, kv.Value.Value)
+		if err != nil {
+			return errors.WithMessagef(err, "error encrypting value for key [%s]", kv.Key)
+		}
+
+		v := *kv.Value
+		v.Value = value
+
+		encrypted[i] = &api.KeyValue{Key: kv.Key, Value: &v}
+	}
+
+	return db.DB.Put(encrypted...)
+}
+
+// Get returns the decrypted value for the given key
+func (db *encryptedDB) Get(key string) (*api.Value, error) {
+	value, err := db.DB.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.decryptValue(key, value)
+}
+
+// GetMultiple returns the decrypted values for the given keys
+func (db *encryptedDB) GetMultiple(keys ...string) ([]*api.Value, error) {
+	values, err := db.DB.GetMultiple(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != len(keys) {
+		return nil, errors.New("not all of the values were returned for the set of keys")
+	}
+
+	decrypted := make([]*api.Value, len(values))
+	for i, value := range values {
+		decrypted[i], err = db.decryptValue(keys[i], value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decrypted, nil
+}
+
+// GetByRange returns the decrypted keys/values in the given range
+func (db *encryptedDB) GetByRange(startKey, endKey string) ([]*api.KeyValue, error) {
+	results, err := db.DB.GetByRange(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]*api.KeyValue, len(results))
+	for i, kv := range results {
+		value, err := db.decryptValue(kv.Key, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted[i] = &api.KeyValue{Key: kv.Key, Value: value}
+	}
+
+	return decrypted, nil
+}
+
+// Query is not supported on an encrypted collection since the values are opaque to the database
+func (db *encryptedDB) Query(string) ([]*api.KeyValue, error) {
+	return nil, db.queryNotSupported()
+}
+
+// QueryWithPagination is not supported on an encrypted collection since the values are opaque to the database
+func (db *encryptedDB) QueryWithPagination(string, string, int32) ([]*api.KeyValue, string, error) {
+	return nil, "", db.queryNotSupported()
+}
+
+func (db *encryptedDB) queryNotSupported() error {
+	return errors.Errorf("rich queries are not supported on encrypted collection [%s:%s]", db.ns, db.coll)
+}
+
+func (db *encryptedDB) encrypt(key string, value []byte) ([]byte, error) {
+	dk, err := db.provider.dataKeyFor(db.channelID, db.ns, db.coll)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dk.aead, value, db.additionalData(key))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &envelope{
+		kekID:      dk.kekID,
+		wrappedKey: dk.wrappedKey,
+		ciphertext: ciphertext,
+	}
+
+	return e.bytes(), nil
+}
+
+func (db *encryptedDB) decryptValue(key string, value *api.Value) (*api.Value, error) {
+	if value == nil || !isEnvelope(value.Value) {
+		return value, nil
+	}
+
+	e, err := parseEnvelope(value.Value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error parsing encrypted value for key [%s]", key)
+	}
+
+	aead, err := db.provider.unwrap(e.kekID, e.wrappedKey)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error unwrapping data key for key [%s]", key)
+	}
+
+	plaintext, err := open(aead, e.ciphertext, db.additionalData(key))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error decrypting value for key [%s]", key)
+	}
+
+	v := *value
+	v.Value = plaintext
+
+	return &v, nil
+}
+
+// additionalData binds the ciphertext to the key so that an encrypted value may not be moved to another key
+func (db *encryptedDB) additionalData(key string) []byte {
+	return []byte(strings.Join([]string{db.channelID, db.ns, db.coll, key}, "\x00"))
+}
@@ -0,0 +1,223 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/mocks"
+)
+
+const (
+	channel1 = "channel1"
+	ns1      = "ns1"
+	coll1    = "coll1"
+	coll2    = "coll2"
+	key1     = "key1"
+	key2     = "key2"
+	key3     = "key3"
+	txID1    = "tx1"
+)
+
+var (
+	value1 = []byte(`{"field":"value1"}`)
+	value2 = []byte("value2")
+)
+
+func TestDBProvider(t *testing.T) {
+	keyProvider, err := NewKeyProvider(kek1)
+	require.NoError(t, err)
+
+	dbProvider := mocks.NewDBProvider()
+
+	p := NewDBProvider(dbProvider, keyProvider, func(channelID, ns, coll string) (bool, error) {
+		return coll == coll1, nil
+	})
+
+	db, err := p.GetDB(channel1, coll1, ns1)
+	require.NoError(t, err)
+	require.IsType(t, &encryptedDB{}, db)
+
+	expiry := time.Now().Add(time.Minute)
+
+	require.NoError(t, db.Put(
+		api.NewKeyValue(key1, value1, txID1, expiry),
+		api.NewKeyValue(key2, value2, txID1, time.Time{}),
+	))
+
+	t.Run("Stored values are encrypted", func(t *testing.T) {
+		v, err := dbProvider.MockDB(ns1, coll1).Get(key1)
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.True(t, isEnvelope(v.Value))
+		require.False(t, bytes.Contains(v.Value, value1))
+		require.Equal(t, txID1, v.TxID)
+		require.Equal(t, expiry, v.ExpiryTime)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		v, err := db.Get(key1)
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Equal(t, value1, v.Value)
+		require.Equal(t, txID1, v.TxID)
+
+		v, err = db.Get(key3)
+		require.NoError(t, err)
+		require.Nil(t, v)
+	})
+
+	t.Run("GetMultiple", func(t *testing.T) {
+		values, err := db.GetMultiple(key1, key2, key3)
+		require.NoError(t, err)
+		require.Len(t, values, 3)
+		require.Equal(t, value1, values[0].Value)
+		require.Equal(t, value2, values[1].Value)
+		require.Nil(t, values[2])
+	})
+
+	t.Run("GetByRange", func(t *testing.T) {
+		results, err := db.GetByRange(key1, "")
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, key1, results[0].Key)
+		require.Equal(t, value1, results[0].Value.Value)
+		require.Equal(t, key2, results[1].Key)
+		require.Equal(t, value2, results[1].Value.Value)
+	})
+
+	t.Run("Query -> not supported", func(t *testing.T) {
+		_, err := db.Query(`{"selector":{"field":"value1"}}`)
+		require.EqualError(t, err, "rich queries are not supported on encrypted collection [ns1:coll1]")
+
+		_, _, err = db.QueryWithPagination(`{"selector":{"field":"value1"}}`, "", 10)
+		require.EqualError(t, err, "rich queries are not supported on encrypted collection [ns1:coll1]")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, db.Put(api.NewKeyValue(key3, value2, txID1, time.Time{})))
+		require.NoError(t, db.Put(&api.KeyValue{Key: key3}))
+
+		v, err := db.Get(key3)
+		require.NoError(t, err)
+		require.Nil(t, v)
+	})
+
+	t.Run("Plaintext value stored before encryption was enabled", func(t *testing.T) {
+		dbProvider.WithValue(ns1, coll1, key3, &api.Value{Value: value2, TxID: txID1})
+
+		v, err := db.Get(key3)
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Equal(t, value2, v.Value)
+	})
+
+	t.Run("Value moved to another key -> error", func(t *testing.T) {
+		v, err := dbProvider.MockDB(ns1, coll1).Get(key1)
+		require.NoError(t, err)
+
+		dbProvider.WithValue(ns1, coll1, key3, v)
+
+		_, err = db.Get(key3)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error decrypting value for key [key3]")
+	})
+
+	t.Run("Rotated key-encryption key", func(t *testing.T) {
+		rotatedKeyProvider, err := NewKeyProvider(kek2, kek1)
+		require.NoError(t, err)
+
+		p2 := NewDBProvider(dbProvider, rotatedKeyProvider, func(string, string, string) (bool, error) { return true, nil })
+
+		db2, err := p2.GetDB(channel1, coll1, ns1)
+		require.NoError(t, err)
+
+		v, err := db2.Get(key1)
+		require.NoError(t, err)
+		require.Equal(t, value1, v.Value)
+
+		// The key-encryption key that wrapped the data key isn't available
+		newKeyProvider, err := NewKeyProvider(kek2)
+		require.NoError(t, err)
+
+		p3 := NewDBProvider(dbProvider, newKeyProvider, func(string, string, string) (bool, error) { return true, nil })
+
+		db3, err := p3.GetDB(channel1, coll1, ns1)
+		require.NoError(t, err)
+
+		_, err = db3.Get(key1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unwrapping data key")
+	})
+
+	t.Run("Encryption not enabled", func(t *testing.T) {
+		db, err := p.GetDB(channel1, coll2, ns1)
+		require.NoError(t, err)
+		require.NotNil(t, db)
+
+		_, ok := db.(*encryptedDB)
+		require.False(t, ok)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		errExpected := errors.New("injected error")
+
+		pErr := NewDBProvider(dbProvider, keyProvider, func(string, string, string) (bool, error) { return false, errExpected })
+		_, err := pErr.GetDB(channel1, coll1, ns1)
+		require.EqualError(t, err, errExpected.Error())
+
+		dbProvider.MockDB(ns1, coll1).WithError(errExpected)
+		defer dbProvider.MockDB(ns1, coll1).WithError(nil)
+
+		_, err = db.Get(key1)
+		require.EqualError(t, err, errExpected.Error())
+
+		_, err = db.GetMultiple(key1)
+		require.EqualError(t, err, errExpected.Error())
+
+		_, err = db.GetByRange(key1, "")
+		require.EqualError(t, err, errExpected.Error())
+
+		dbProvider.WithError(errExpected)
+		defer dbProvider.WithError(nil)
+
+		_, err = p.GetDB(channel1, coll1, ns1)
+		require.EqualError(t, err, errExpected.Error())
+	})
+}
+
+func TestEnvelope(t *testing.T) {
+	e := &envelope{
+		kekID:      "kek1",
+		wrappedKey: []byte("wrapped"),
+		ciphertext: []byte("ciphertext"),
+	}
+
+	b := e.bytes()
+	require.True(t, isEnvelope(b))
+
+	e2, err := parseEnvelope(b)
+	require.NoError(t, err)
+	require.Equal(t, e, e2)
+
+	_, err = parseEnvelope([]byte("plaintext"))
+	require.EqualError(t, err, "value is not encrypted")
+
+	_, err = parseEnvelope(envelopePrefix)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid key-encryption key ID")
+
+	_, err = parseEnvelope(append(append([]byte{}, envelopePrefix...), 100, 'x'))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds remaining bytes")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// envelopePrefix identifies an encrypted value. The leading zero byte ensures that an
+// encrypted value is never mistaken for JSON (and is therefore stored as an attachment in CouchDB).
+var envelopePrefix = []byte{0, 'e', 'n', 'c', 1}
+
+// envelope contains an encrypted value along with the wrapped data key that was used to encrypt it
+type envelope struct {
+	kekID      string
+	wrappedKey []byte
+	ciphertext []byte
+}
+
+// isEnvelope returns true if the given value was produced by envelope.bytes
+func isEnvelope(value []byte) bool {
+	return bytes.HasPrefix(value, envelopePrefix)
+}
+
+func (e *envelope) bytes() []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(envelopePrefix)
+	writeField(buf, []byte(e.kekID))
+	writeField(buf, e.wrappedKey)
+	buf.Write(e.ciphertext)
+
+	return buf.Bytes()
+}
+
+func parseEnvelope(value []byte) (*envelope, error) {
+	if !isEnvelope(value) {
+		return nil, errors.New("value is not encrypted")
+	}
+
+	r := bytes.NewReader(value[len(envelopePrefix):])
+
+	kekID, err := readField(r)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid key-encryption key ID")
+	}
+
+	wrappedKey, err := readField(r)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid wrapped data key")
+	}
+
+	return &envelope{
+		kekID:      string(kekID),
+		wrappedKey: wrappedKey,
+		ciphertext: value[len(value)-r.Len():],
+	}, nil
+}
+
+func writeField(buf *bytes.Buffer, field []byte) {
+	l := make([]byte, binary.MaxVarintLen64)
+	buf.Write(l[:binary.PutUvarint(l, uint64(len(field)))])
+	buf.Write(field)
+}
+
+func readField(r *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading field length")
+	}
+
+	if l > uint64(r.Len()) {
+		return nil, errors.Errorf("field length %d exceeds remaining bytes %d", l, r.Len())
+	}
+
+	field := make([]byte, l)
+	if _, err := r.Read(field); err != nil && l > 0 {
+		return nil, errors.Wrap(err, "error reading field")
+	}
+
+	return field, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KeyProvider wraps and unwraps data keys using key-encryption keys (KEK) which never leave the provider.
+// A provider may be backed by a local file, a PKCS#11 token, etc.
+type KeyProvider interface {
+	// WrapKey encrypts the given data key with the current key-encryption key and returns
+	// the ID of the key-encryption key along with the wrapped data key
+	WrapKey(dataKey []byte) (kekID string, wrappedKey []byte, err error)
+
+	// UnwrapKey decrypts the given data key using the key-encryption key with the given ID
+	UnwrapKey(kekID string, wrappedKey []byte) ([]byte, error)
+}
+
+// FileKeyProvider is a KeyProvider that loads its key-encryption keys from a local file. The file contains one
+// hex-encoded 256-bit AES key per line. The first key is used to wrap new data keys and the remaining keys
+// are only used to unwrap data keys that were wrapped before the key-encryption key was rotated.
+type FileKeyProvider struct {
+	currentKEKID string
+	keks         map[string]cipher.AEAD
+}
+
+// NewFileKeyProvider returns a key provider which loads its key-encryption keys from the given file
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	if path == "" {
+		return nil, errors.New("key file path is required")
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading key file [%s]", path)
+	}
+
+	var keys [][]byte
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key in key file [%s]", path)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.Errorf("no keys found in key file [%s]", path)
+	}
+
+	return NewKeyProvider(keys...)
+}
+
+// NewKeyProvider returns a key provider for the given 256-bit key-encryption keys. The first key is
+// used to wrap new data keys.
+func NewKeyProvider(keys ...[]byte) (*FileKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key-encryption key is required")
+	}
+
+	p := &FileKeyProvider{keks: make(map[string]cipher.AEAD)}
+
+	for i, key := range keys {
+		if len(key) != keySize {
+			return nil, errors.Errorf("invalid key-encryption key size: %d - expecting %d bytes", len(key), keySize)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		id := kekID(key)
+		if i == 0 {
+			p.currentKEKID = id
+		}
+
+		p.keks[id] = aead
+	}
+
+	return p, nil
+}
+
+// WrapKey encrypts the given data key with the current key-encryption key
+func (p *FileKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(p.keks[p.currentKEKID], dataKey, nil)
+	if err != nil {
+		return "", nil, errors.WithMessage(err, "error wrapping data key")
+	}
+
+	return p.currentKEKID, wrapped, nil
+}
+
+// UnwrapKey decrypts the given data key with the key-encryption key with the given ID
+func (p *FileKeyProvider) UnwrapKey(kekID string, wrappedKey []byte) ([]byte, error) {
+	aead, ok := p.keks[kekID]
+	if !ok {
+		return nil, errors.Errorf("key-encryption key [%s] not found", kekID)
+	}
+
+	dataKey, err := open(aead, wrappedKey, nil)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error unwrapping data key with key-encryption key [%s]", kekID)
+	}
+
+	return dataKey, nil
+}
+
+// kekID returns an ID for the given key-encryption key which may be safely persisted
+// alongside the data, i.e. the ID doesn't reveal the key.
+func kekID(key []byte) string {
+	h := sha256.Sum256(key)
+
+	return hex.EncodeToString(h[:8])
+}
+
+const keySize = 32
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating GCM")
+	}
+
+	return aead, nil
+}
+
+// seal encrypts the given plaintext and returns the nonce followed by the ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "error generating nonce")
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the given nonce-prefixed ciphertext
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce := ciphertext[:aead.NonceSize()]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decrypting")
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	kek1 = []byte("01234567890123456789012345678901")
+	kek2 = []byte("abcdefghijklmnopqrstuvwxyzabcdef")
+)
+
+func TestFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kek")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	keyFile := filepath.Join(dir, "kek")
+
+	t.Run("Success", func(t *testing.T) {
+		contents := "# Current key\n" + hex.EncodeToString(kek2) + "\n\n# Previous key\n" + hex.EncodeToString(kek1) + "\n"
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte(contents), 0600))
+
+		p, err := NewFileKeyProvider(keyFile)
+		require.NoError(t, err)
+		require.Equal(t, kekID(kek2), p.currentKEKID)
+		require.Len(t, p.keks, 2)
+	})
+
+	t.Run("No path -> error", func(t *testing.T) {
+		_, err := NewFileKeyProvider("")
+		require.EqualError(t, err, "key file path is required")
+	})
+
+	t.Run("File not found -> error", func(t *testing.T) {
+		_, err := NewFileKeyProvider(filepath.Join(dir, "xxx"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading key file")
+	})
+
+	t.Run("Invalid key -> error", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte("not hex"), 0600))
+
+		_, err := NewFileKeyProvider(keyFile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid key in key file")
+	})
+
+	t.Run("No keys -> error", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte("# no keys\n"), 0600))
+
+		_, err := NewFileKeyProvider(keyFile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no keys found in key file")
+	})
+
+	t.Run("Invalid key size -> error", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(keyFile, []byte(hex.EncodeToString([]byte("short"))), 0600))
+
+		_, err := NewFileKeyProvider(keyFile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid key-encryption key size")
+	})
+}
+
+func TestFileKeyProvider_WrapUnwrap(t *testing.T) {
+	dataKey := []byte("data key data key data key data!")
+
+	p1, err := NewKeyProvider(kek1)
+	require.NoError(t, err)
+
+	kekID1, wrapped1, err := p1.WrapKey(dataKey)
+	require.NoError(t, err)
+	require.Equal(t, kekID(kek1), kekID1)
+	require.NotEqual(t, dataKey, wrapped1)
+
+	unwrapped, err := p1.UnwrapKey(kekID1, wrapped1)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unwrapped)
+
+	t.Run("Rotated key", func(t *testing.T) {
+		p2, err := NewKeyProvider(kek2, kek1)
+		require.NoError(t, err)
+
+		kekID2, wrapped2, err := p2.WrapKey(dataKey)
+		require.NoError(t, err)
+		require.Equal(t, kekID(kek2), kekID2)
+
+		unwrapped, err := p2.UnwrapKey(kekID2, wrapped2)
+		require.NoError(t, err)
+		require.Equal(t, dataKey, unwrapped)
+
+		// Data keys that were wrapped with the previous key may still be unwrapped
+		unwrapped, err = p2.UnwrapKey(kekID1, wrapped1)
+		require.NoError(t, err)
+		require.Equal(t, dataKey, unwrapped)
+
+		// The old provider doesn't have the new key
+		_, err = p1.UnwrapKey(kekID2, wrapped2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("Invalid wrapped key -> error", func(t *testing.T) {
+		_, err := p1.UnwrapKey(kekID1, []byte("an invalid wrapped data key of sufficient length"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unwrapping data key")
+
+		_, err = p1.UnwrapKey(kekID1, []byte("x"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ciphertext is too short")
+	})
+
+	t.Run("No keys -> error", func(t *testing.T) {
+		_, err := NewKeyProvider()
+		require.Error(t, err)
+	})
+}
@@ -7,8 +7,10 @@ package leveldbstore
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/gob"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,33 +24,60 @@ var logger = flogging.MustGetLogger("ext_offledger")
 
 var compositeKeySep = "!"
 
+// errStopScan is returned by a scan function in order to stop the scan once all of the required results are found
+var errStopScan = errors.New("scan stopped")
+
 type store struct {
-	db     *leveldbhelper.DBHandle
-	dbName string
+	db      *leveldbhelper.DBHandle
+	indexDB *leveldbhelper.DBHandle
+	indexes []*index
+	dbName  string
 }
 
-// newDBStore constructs an instance of db store
-func newDBStore(db *leveldbhelper.DBHandle, dbName string) *store {
-	return &store{db, dbName}
+// newDBStore constructs an instance of db store. The given index definitions are applied to the index DB.
+func newDBStore(db, indexDB *leveldbhelper.DBHandle, dbName string, indexDefinitions []string) (*store, error) {
+	s := &store{
+		db:      db,
+		indexDB: indexDB,
+		indexes: parseIndexes(dbName, indexDefinitions),
+		dbName:  dbName,
+	}
+
+	if err := s.applyIndexes(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
 }
 
-// AddKey add cache key to db
+// Put adds the given keys/values to the db. A key with a nil value is deleted.
 func (s *store) Put(keyVal ...*api.KeyValue) error {
 	batch := s.db.NewUpdateBatch()
+	updates := newIndexUpdates()
 	for _, kv := range keyVal {
-		err := s.addToBatch(batch, kv)
+		err := s.addToBatch(batch, updates, kv)
 		if err != nil {
 			return err
 		}
 	}
-	return s.db.WriteBatch(batch, true)
+	return s.writeBatch(batch, updates)
 }
 
-func (s *store) addToBatch(batch *leveldbhelper.UpdateBatch, kv *api.KeyValue) error {
+// Delete deletes the given keys from the db, along with their entries in the expiry and secondary indexes
+func (s *store) Delete(keys ...string) error {
+	batch := s.db.NewUpdateBatch()
+	updates := newIndexUpdates()
+	for _, key := range keys {
+		if err := s.addDeleteToBatch(batch, updates, key); err != nil {
+			return err
+		}
+	}
+	return s.writeBatch(batch, updates)
+}
+
+func (s *store) addToBatch(batch *leveldbhelper.UpdateBatch, updates *indexUpdates, kv *api.KeyValue) error {
 	if kv.Value == nil {
-		logger.Debugf("Deleting key [%s]", kv.Key)
-		batch.Delete(encodeKey(kv.Key, time.Time{}))
-		return nil
+		return s.addDeleteToBatch(batch, updates, kv.Key)
 	}
 
 	logger.Debugf("Adding key [%s]", kv.Key)
@@ -56,6 +85,20 @@ func (s *store) addToBatch(batch *leveldbhelper.UpdateBatch, kv *api.KeyValue) e
 	if err != nil {
 		return errors.WithMessagef(err, "failed to encode value for key [%s]", kv.Value)
 	}
+
+	if len(s.indexes) > 0 {
+		current, err := s.Get(kv.Key)
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			updates.remove(s.indexEntries(kv.Key, current.Value)...)
+		}
+
+		updates.add(s.indexEntries(kv.Key, kv.Value.Value)...)
+	}
+
 	batch.Put(encodeKey(kv.Key, time.Time{}), encodedVal)
 
 	if !kv.ExpiryTime.IsZero() {
@@ -68,6 +111,29 @@ func (s *store) addToBatch(batch *leveldbhelper.UpdateBatch, kv *api.KeyValue) e
 	return nil
 }
 
+func (s *store) addDeleteToBatch(batch *leveldbhelper.UpdateBatch, updates *indexUpdates, key string) error {
+	current, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		logger.Debugf("[%s] Key [%s] not found to delete", s.dbName, key)
+		return nil
+	}
+
+	logger.Debugf("[%s] Deleting key [%s]", s.dbName, key)
+
+	batch.Delete(encodeKey(key, time.Time{}))
+	if !current.ExpiryTime.IsZero() {
+		batch.Delete(encodeKey(key, current.ExpiryTime))
+	}
+
+	updates.remove(s.indexEntries(key, current.Value)...)
+
+	return nil
+}
+
 // GetKey get cache key from db
 func (s *store) Get(key string) (*api.Value, error) {
 	logger.Debugf("load key [%s] from db", key)
@@ -98,27 +164,237 @@ func (s *store) GetMultiple(keys ...string) ([]*api.Value, error) {
 	return values, nil
 }
 
-// Query is not implemented
+// Query executes the given Mango query against the JSON values in the db. Values that are not
+// JSON and values that have expired are not included in the results. If the selector requires all of
+// the fields of one of the secondary indexes and contains a range or equality condition on the index's
+// first field then only the matching range of the index is scanned, otherwise all of the keys are scanned.
 func (s *store) Query(query string) ([]*api.KeyValue, error) {
-	panic("not implemented")
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.execute(q, query)
+}
+
+// QueryWithPagination executes the given query and returns at most pageSize results starting at the
+// given bookmark along with the bookmark of the next page. The bookmark is an opaque encoding of the
+// offset of the page within the result set. An empty bookmark is returned once the results are exhausted.
+// Unless the query specifies a sort, the scan stops as soon as the page is full.
+func (s *store) QueryWithPagination(query string, bookmark string, pageSize int32) ([]*api.KeyValue, string, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	offset, err := decodeBookmark(bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+
+	q.skip += offset
+	q.limit = int(pageSize)
+
+	results, err := s.execute(q, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(results) < q.limit {
+		return results, "", nil
+	}
+
+	return results, encodeBookmark(offset + len(results)), nil
+}
+
+// execute runs the given query. If the query doesn't specify a sort then the results are returned in scan order, so
+// the matches before the skip offset are discarded as they're found and the scan stops as soon as the limit is reached.
+// Otherwise all of the matches are collected and sorted before skip and limit are applied.
+func (s *store) execute(q *query, rawQuery string) ([]*api.KeyValue, error) {
+	values := make(map[string]*api.Value)
+	sorted := len(q.sort) > 0
+	skipped := 0
+
+	var results []*queryResult
+	err := s.scan(q, func(key string, v *api.Value) error {
+		doc, err := unmarshalJSON(v.Value)
+		if err != nil {
+			logger.Debugf("[%s] Value for key [%s] is not JSON. Not adding key to result set.", s.dbName, key)
+			return nil
+		}
+
+		match, err := q.matches(doc)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to execute query [%s]", rawQuery)
+		}
+
+		if !match {
+			return nil
+		}
+
+		if !sorted && skipped < q.skip {
+			skipped++
+			return nil
+		}
+
+		values[key] = v
+		results = append(results, &queryResult{key: key, doc: doc})
+
+		if !sorted && q.limit > 0 && len(results) >= q.limit {
+			return errStopScan
+		}
+
+		return nil
+	})
+	if err != nil && err != errStopScan {
+		return nil, err
+	}
+
+	if sorted {
+		results = q.apply(results)
+	}
+
+	var responses []*api.KeyValue
+	for _, r := range results {
+		v := values[r.key]
+
+		value, err := q.project(r.doc, v.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to project fields for key [%s]", r.key)
+		}
+
+		responses = append(responses, &api.KeyValue{
+			Key:   r.key,
+			Value: &api.Value{Value: value, TxID: v.TxID, ExpiryTime: v.ExpiryTime, WriteTime: v.WriteTime},
+		})
+	}
+
+	logger.Debugf("[%s] Query [%s] returned %d results", s.dbName, rawQuery, len(responses))
+
+	return responses, nil
+}
+
+func encodeBookmark(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeBookmark(bookmark string) (int, error) {
+	if bookmark == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(bookmark)
+	if err != nil {
+		return 0, errors.Errorf("invalid bookmark [%s]", bookmark)
+	}
+
+	offset, err := strconv.Atoi(string(b))
+	if err != nil || offset < 0 {
+		return 0, errors.Errorf("invalid bookmark [%s]", bookmark)
+	}
+
+	return offset, nil
+}
+
+// scan invokes the given function for each unexpired value which may match the given query
+func (s *store) scan(q *query, fn func(key string, v *api.Value) error) error {
+	idx, ranges, ok := indexScan(s.indexes, q.selector)
+	if !ok {
+		logger.Debugf("[%s] No index may be used for the query. Scanning all keys.", s.dbName)
+
+		return s.iterate(nil, nil, fn)
+	}
+
+	logger.Debugf("[%s] Using index [%s] for the query", s.dbName, idx.name)
+
+	return s.iterateIndex(ranges, fn)
+}
+
+// GetByRange returns the keys/values from startKey (inclusive) to endKey (exclusive), ordered by key
+func (s *store) GetByRange(startKey, endKey string) ([]*api.KeyValue, error) {
+	var end []byte
+	if endKey != "" {
+		end = []byte(endKey)
+	}
+
+	var results []*api.KeyValue
+	err := s.iterate([]byte(startKey), end, func(key string, v *api.Value) error {
+		results = append(results, &api.KeyValue{Key: key, Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// iterate invokes the given function for each unexpired value in the range [startKey, endKey), ordered by key
+func (s *store) iterate(startKey, endKey []byte, fn func(key string, v *api.Value) error) error {
+	itr, err := s.db.GetIterator(startKey, endKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get iterator for db [%s]", s.dbName)
+	}
+	defer itr.Release()
+
+	now := time.Now()
+
+	for itr.Next() {
+		if len(itr.Value()) == 0 {
+			// This is an entry in the expiry index
+			continue
+		}
+
+		key := string(itr.Key())
+
+		v, err := decodeVal(itr.Value())
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode value for key [%s]", key)
+		}
+
+		if !v.ExpiryTime.IsZero() && v.ExpiryTime.Before(now) {
+			logger.Debugf("[%s] Key [%s] has expired", s.dbName, key)
+			continue
+		}
+
+		if err := fn(key, v); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrapf(itr.Error(), "failed to iterate over db [%s]", s.dbName)
 }
 
 // DeleteExpiredKeys delete expired keys from db
 func (s *store) DeleteExpiredKeys() error {
 	dbBatch := s.db.NewUpdateBatch()
+	updates := newIndexUpdates()
 	itr, err := s.db.GetIterator(nil, []byte(fmt.Sprintf("%d%s", time.Now().UTC().UnixNano(), compositeKeySep)))
 	if err != nil {
 		return err
 	}
+	defer itr.Release()
 
 	for itr.Next() {
 		key := string(itr.Key())
+		dataKey := key[strings.Index(key, compositeKeySep)+1:]
 		dbBatch.Delete([]byte(key))
-		dbBatch.Delete([]byte(key[strings.Index(key, compositeKeySep)+1:]))
+		dbBatch.Delete([]byte(dataKey))
+
+		if len(s.indexes) > 0 {
+			current, err := s.Get(dataKey)
+			if err != nil {
+				return err
+			}
+
+			if current != nil {
+				updates.remove(s.indexEntries(dataKey, current.Value)...)
+			}
+		}
 	}
 
 	if dbBatch.Len() > 0 {
-		err := s.db.WriteBatch(dbBatch, true)
+		err := s.writeBatch(dbBatch, updates)
 		if err != nil {
 			return errors.Errorf("failed to delete keys in db %s", err.Error())
 		}
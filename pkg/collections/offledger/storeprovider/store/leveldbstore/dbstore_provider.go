@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/trustbloc/fabric-peer-ext/pkg/chaincode/ucc"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
@@ -65,8 +66,15 @@ func (p *LevelDBProvider) GetDB(channelID string, coll string, ns string) (api.D
 
 	s, ok = p.stores[dbName]
 	if !ok {
-		indexStore := p.leveldbProvider.GetDBHandle(dbName)
-		s = newDBStore(indexStore, dbName)
+		var err error
+		s, err = newDBStore(
+			p.leveldbProvider.GetDBHandle(dbName),
+			p.leveldbProvider.GetDBHandle(indexDBName(ns, coll)),
+			dbName, getCollectionIndexes(ns, coll),
+		)
+		if err != nil {
+			return nil, err
+		}
 		p.stores[dbName] = s
 	}
 
@@ -124,3 +132,31 @@ func (p *LevelDBProvider) periodicPurge() {
 func dbName(ns, coll string) string {
 	return fmt.Sprintf("%s$%s", ns, coll)
 }
+
+func indexDBName(ns, coll string) string {
+	return fmt.Sprintf("%s$%s$idx", ns, coll)
+}
+
+// getCollectionIndexes returns the index definitions of the given collection from the DB artifacts of
+// the in-process user chaincode with the given name. LevelDB artifacts are used if they're provided,
+// otherwise the CouchDB artifacts are used since the index definitions have the same format.
+// This var may be overridden by unit tests.
+var getCollectionIndexes = func(ns, coll string) []string {
+	for _, cc := range ucc.Chaincodes() {
+		if cc.Name() != ns {
+			continue
+		}
+
+		artifacts := cc.GetDBArtifacts([]string{coll})
+
+		for _, dbType := range []config.DBType{config.LevelDBType, config.CouchDBType} {
+			a, ok := artifacts[dbType]
+			if ok && a != nil && len(a.CollectionIndexes[coll]) > 0 {
+				logger.Debugf("Found %d %s index definition(s) for collection [%s:%s]", len(a.CollectionIndexes[coll]), dbType, ns, coll)
+				return a.CollectionIndexes[coll]
+			}
+		}
+	}
+
+	return nil
+}
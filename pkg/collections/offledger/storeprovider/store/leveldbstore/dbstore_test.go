@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
@@ -139,12 +140,311 @@ func TestStore_Query(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, db)
 
-	require.PanicsWithValue(t, "not implemented", func() {
-		_, err := db.Query("some query")
+	err = db.Put(
+		api.NewKeyValue("doc1", []byte(`{"Field1":"value1","Field2":12345,"Nested":{"Field3":true}}`), txID1, time.Time{}),
+		api.NewKeyValue("doc2", []byte(`{"Field1":"value2","Field2":12345}`), txID1, time.Now().UTC().Add(1*time.Minute)),
+		api.NewKeyValue("doc3", []byte(`{"Field1":"value3","Field2":200}`), txID2, time.Time{}),
+		api.NewKeyValue("doc4", []byte(`{"Field1":"value4","Field2":100}`), txID2, time.Now().UTC().Add(-1*time.Minute)),
+		api.NewKeyValue("binary", []byte("not JSON"), txID2, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	t.Run("Query one", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field1":"value2"},"fields":["Field1","Field2"]}`)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "doc2", results[0].Key)
+		require.Equal(t, txID1, results[0].TxID)
+		require.False(t, results[0].ExpiryTime.IsZero())
+		require.JSONEq(t, `{"Field1":"value2","Field2":12345}`, string(results[0].Value.Value))
+	})
+
+	t.Run("Query multiple", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field2":12345},"fields":["Field1"]}`)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, "doc1", results[0].Key)
+		require.JSONEq(t, `{"Field1":"value1"}`, string(results[0].Value.Value))
+		require.Equal(t, "doc2", results[1].Key)
+		require.JSONEq(t, `{"Field1":"value2"}`, string(results[1].Value.Value))
+	})
+
+	t.Run("Query operators", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field2":{"$gt":150,"$lt":20000}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc2", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field1":{"$in":["value1","value3","value4"]}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"$or":[{"Field1":"value1"},{"Field2":{"$lte":200}}]}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"$and":[{"Field2":12345},{"Field1":{"$ne":"value1"}}]}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Nested.Field3":{"$exists":true}},"fields":["Nested.Field3"]}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1"}, keys(results))
+		require.JSONEq(t, `{"Nested":{"Field3":true}}`, string(results[0].Value.Value))
+
+		results, err = db.Query(`{"selector":{"Field2":{"$not":{"$eq":12345}}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc3"}, keys(results))
+	})
+
+	t.Run("Query sort and limit", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field2":{"$gt":0}},"sort":[{"Field2":"desc"},"Field1"]}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc2", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field2":{"$gt":0}},"sort":["Field2"],"limit":2}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc3", "doc1"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field2":{"$gt":0}},"sort":["Field2"],"skip":1,"limit":1}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1"}, keys(results))
+	})
+
+	t.Run("Query empty", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field1":"valueX"},"fields":["Field1","Field2"]}`)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		_, err := db.Query(`"selector":}`)
+		require.Error(t, err)
+
+		_, err = db.Query(`{"fields":["Field1"]}`)
+		require.EqualError(t, err, "selector is required")
+
+		_, err = db.Query(`{"selector":{"Field1":"valueX"},"fields":"Field1"}`)
+		require.EqualError(t, err, "fields definition must be an array")
+
+		_, err = db.Query(`{"selector":{"Field1":"valueX"},"sort":[{"Field1":"up"}]}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid sort direction")
+
+		_, err = db.Query(`{"selector":{"Field1":"valueX"},"limit":-1}`)
+		require.EqualError(t, err, "limit must be a non-negative integer")
+
+		_, err = db.Query(`{"selector":{"Field1":{"$regex":"value.*"}}}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported operator [$regex]")
+	})
+}
+
+func TestSortAndLimit(t *testing.T) {
+	newResult := func(key, value string) *storeapi.QueryResult {
+		return &storeapi.QueryResult{
+			Key:           storeapi.NewKey(txID1, ns1, coll1, key),
+			ExpiringValue: &storeapi.ExpiringValue{Value: []byte(value)},
+		}
+	}
+
+	results := []*storeapi.QueryResult{
+		newResult("doc1", `{"Field1":"value1","Field2":2}`),
+		newResult("doc2", `{"Field1":"value2","Field2":3}`),
+		newResult("doc3", `{"Field1":"value3","Field2":1}`),
+	}
+
+	resultKeys := func(results []*storeapi.QueryResult) []string {
+		var k []string
+		for _, r := range results {
+			k = append(k, r.Key.Key)
+		}
+		return k
+	}
+
+	t.Run("Sort", func(t *testing.T) {
+		sorted, err := SortAndLimit(`{"selector":{"Field2":{"$gt":0}},"sort":[{"Field2":"desc"}]}`, results)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2", "doc1", "doc3"}, resultKeys(sorted))
+	})
+
+	t.Run("Sort and limit", func(t *testing.T) {
+		sorted, err := SortAndLimit(`{"selector":{"Field2":{"$gt":0}},"sort":["Field2"],"skip":1,"limit":2}`, results)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc3", "doc1"}, resultKeys(sorted))
+	})
+
+	t.Run("No sort -> order preserved", func(t *testing.T) {
+		sorted, err := SortAndLimit(`{"selector":{"Field2":{"$gt":0}}}`, results)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc2", "doc3"}, resultKeys(sorted))
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		_, err := SortAndLimit(`{"fields":["Field1"]}`, results)
+		require.EqualError(t, err, "selector is required")
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		_, err := SortAndLimit(`{"selector":{"Field2":{"$gt":0}},"sort":["Field2"]}`, append(results, newResult("doc4", "{")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid JSON value for key [doc4]")
+	})
+}
+
+func TestStore_QueryWithPagination(t *testing.T) {
+	defer removeDBPath(t)
+
+	provider, err := NewDBProvider()
+	require.NoError(t, err)
+	defer provider.Close()
+
+	db, err := provider.GetDB(ns1, "", "pagedcoll")
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	err = db.Put(
+		api.NewKeyValue("doc1", []byte(`{"Field1":"value1","Field2":100}`), txID1, time.Time{}),
+		api.NewKeyValue("doc2", []byte(`{"Field1":"value2","Field2":100}`), txID1, time.Time{}),
+		api.NewKeyValue("doc3", []byte(`{"Field1":"value3","Field2":100}`), txID1, time.Time{}),
+		api.NewKeyValue("doc4", []byte(`{"Field1":"value4","Field2":100}`), txID1, time.Now().UTC().Add(-1*time.Minute)),
+		api.NewKeyValue("doc5", []byte(`{"Field1":"value5","Field2":100}`), txID1, time.Time{}),
+		api.NewKeyValue("doc6", []byte(`{"Field1":"value6","Field2":200}`), txID1, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	t.Run("All pages", func(t *testing.T) {
+		const query = `{"selector":{"Field2":100},"sort":[{"Field1":"desc"}]}`
+
+		results, bookmark, err := db.QueryWithPagination(query, "", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc5", "doc3"}, keys(results))
+		require.NotEmpty(t, bookmark)
+
+		results, bookmark, err = db.QueryWithPagination(query, bookmark, 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2", "doc1"}, keys(results))
+		require.NotEmpty(t, bookmark)
+
+		results, bookmark, err = db.QueryWithPagination(query, bookmark, 2)
+		require.NoError(t, err)
+		require.Empty(t, results)
+		require.Empty(t, bookmark)
+	})
+
+	t.Run("Partial last page", func(t *testing.T) {
+		const query = `{"selector":{"Field2":100},"skip":1}`
+
+		results, bookmark, err := db.QueryWithPagination(query, "", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2", "doc3"}, keys(results))
+
+		results, bookmark, err = db.QueryWithPagination(query, bookmark, 2)
 		require.NoError(t, err)
+		require.Equal(t, []string{"doc5"}, keys(results))
+		require.Empty(t, bookmark)
+	})
+
+	t.Run("Invalid bookmark", func(t *testing.T) {
+		_, _, err := db.QueryWithPagination(`{"selector":{"Field2":100}}`, "xxx", 2)
+		require.EqualError(t, err, "invalid bookmark [xxx]")
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		_, _, err := db.QueryWithPagination(`"selector":}`, "", 2)
+		require.Error(t, err)
+	})
+
+	t.Run("Unsorted page -> scan stops once the page is full", func(t *testing.T) {
+		// Add an undecodable value after the first page so that the query fails if the value is scanned
+		require.NoError(t, db.(*store).db.Put([]byte("doc9"), []byte("invalid"), true))
+
+		results, bookmark, err := db.QueryWithPagination(`{"selector":{"Field2":100}}`, "", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc2"}, keys(results))
+		require.NotEmpty(t, bookmark)
+
+		// All values need to be scanned in order to sort the results
+		_, _, err = db.QueryWithPagination(`{"selector":{"Field2":100},"sort":[{"Field1":"desc"}]}`, "", 2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode value for key [doc9]")
 	})
 }
 
+func TestStore_GetByRange(t *testing.T) {
+	defer removeDBPath(t)
+
+	provider, err := NewDBProvider()
+	require.NoError(t, err)
+	defer provider.Close()
+
+	db, err := provider.GetDB(ns1, "", coll2)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	err = db.Put(
+		api.NewKeyValue("key1", value1, txID1, time.Time{}),
+		api.NewKeyValue("key2", value2, txID1, time.Now().UTC().Add(1*time.Minute)),
+		api.NewKeyValue("key3", value1, txID2, time.Now().UTC().Add(-1*time.Minute)),
+		api.NewKeyValue("key4", value2, txID2, time.Time{}),
+		api.NewKeyValue("key5", value2, txID2, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	results, err := db.GetByRange("key1", "key5")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key1", "key2", "key4"}, keys(results))
+	require.Equal(t, value1, results[0].Value.Value)
+	require.Equal(t, txID1, results[1].TxID)
+	require.False(t, results[1].ExpiryTime.IsZero())
+
+	results, err = db.GetByRange("key2", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key2", "key4", "key5"}, keys(results))
+
+	results, err = db.GetByRange("", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key1", "key2", "key4", "key5"}, keys(results))
+
+	results, err = db.GetByRange("keyX", "keyY")
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
+
+func TestStore_Delete(t *testing.T) {
+	defer removeDBPath(t)
+
+	provider, err := NewDBProvider()
+	require.NoError(t, err)
+	defer provider.Close()
+
+	db, err := provider.GetDB(ns1, "", coll1)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	expiry := time.Now().UTC().Add(1 * time.Minute)
+
+	err = db.Put(
+		api.NewKeyValue(key1, value1, txID1, expiry),
+		api.NewKeyValue(key2, value2, txID1, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Delete(key1, key2, "key3"))
+
+	vals, err := db.GetMultiple(key1, key2)
+	require.NoError(t, err)
+	require.Nil(t, vals[0])
+	require.Nil(t, vals[1])
+
+	// The expiry index entry should also have been removed
+	expiryVal, err := db.(*store).db.Get(encodeKey(key1, expiry))
+	require.NoError(t, err)
+	require.Nil(t, expiryVal)
+
+	// Delete again
+	require.NoError(t, db.Delete(key1))
+}
+
 func TestMain(m *testing.M) {
 	removeDBPath(nil)
 	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/offledgerdb_89786")
@@ -153,6 +453,14 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+func keys(results []*api.KeyValue) []string {
+	var keys []string
+	for _, r := range results {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
 func removeDBPath(t testing.TB) {
 	removePath(t, config.GetOLCollLevelDBPath())
 }
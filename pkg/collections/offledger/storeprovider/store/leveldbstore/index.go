@@ -0,0 +1,649 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package leveldbstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/pkg/errors"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+)
+
+const (
+	indexField     = "index"
+	indexNameField = "name"
+	indexTypeField = "type"
+	indexTypeJSON  = "json"
+
+	indexKeySep byte = 0x00
+
+	// Prefixes of the keys in the index DB
+	indexMetadataPrefix = "m"
+	indexEntryPrefix    = "e"
+)
+
+// index is a secondary index on one or more JSON fields of the values in a store. An entry is maintained for each
+// JSON value that contains all of the indexed fields. Entries are ordered by the values of the indexed fields so
+// that a query, whose selector requires all of the indexed fields, may scan a range of the index on the first
+// indexed field instead of scanning all of the keys.
+type index struct {
+	name       string
+	fields     []string
+	definition string
+}
+
+// parseIndex parses a CouchDB Mango index definition, for example:
+// {"index":{"fields":["owner","size"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"json"}
+func parseIndex(definition string) (*index, error) {
+	doc, err := unmarshalJSON([]byte(definition))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid index definition")
+	}
+
+	if t, ok := doc[indexTypeField]; ok && t != indexTypeJSON {
+		return nil, errors.Errorf("unsupported index type [%v]", t)
+	}
+
+	indexDef, ok := doc[indexField].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("index definition must contain an index object")
+	}
+
+	// The fields are defined the same way as the sort fields of a query
+	specs, err := parseSort(indexDef[fieldsField])
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid index fields")
+	}
+
+	if len(specs) == 0 {
+		return nil, errors.New("index must contain at least one field")
+	}
+
+	idx := &index{definition: definition}
+	for _, s := range specs {
+		idx.fields = append(idx.fields, s.field)
+	}
+
+	idx.name, _ = doc[indexNameField].(string)
+	if idx.name == "" {
+		idx.name = strings.Join(idx.fields, ",")
+	}
+
+	return idx, nil
+}
+
+// parseIndexes parses the given index definitions and returns the indexes sorted by name.
+// An index definition is ignored if it's invalid or if an index with the same name was already defined.
+func parseIndexes(dbName string, definitions []string) []*index {
+	indexes := make(map[string]*index)
+	for _, def := range definitions {
+		idx, err := parseIndex(def)
+		if err != nil {
+			logger.Warningf("[%s] Ignoring index definition [%s]: %s", dbName, def, err)
+			continue
+		}
+
+		if _, exists := indexes[idx.name]; exists {
+			logger.Warningf("[%s] Ignoring duplicate index [%s]", dbName, idx.name)
+			continue
+		}
+
+		indexes[idx.name] = idx
+	}
+
+	var sorted []*index
+	for _, idx := range indexes {
+		sorted = append(sorted, idx)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	return sorted
+}
+
+// entryKey returns the key of the index entry for the given document or nil if the document
+// does not contain all of the indexed fields
+func (idx *index) entryKey(key string, doc jsonMap) []byte {
+	entryKey := idx.prefix()
+	for _, field := range idx.fields {
+		v, ok := getField(doc, field)
+		if !ok {
+			return nil
+		}
+		entryKey = append(entryKey, encodeIndexValue(v)...)
+	}
+
+	return append(entryKey, key...)
+}
+
+// prefix returns the prefix of all of the entries in the index
+func (idx *index) prefix() []byte {
+	return indexEntriesPrefix(idx.name)
+}
+
+func indexEntriesPrefix(name string) []byte {
+	p := []byte(indexEntryPrefix)
+	p = append(p, indexKeySep)
+	p = append(p, name...)
+	return append(p, indexKeySep)
+}
+
+func indexMetadataKey(name string) []byte {
+	k := []byte(indexMetadataPrefix)
+	k = append(k, indexKeySep)
+	return append(k, name...)
+}
+
+// keyRange is a range of keys [start, end)
+type keyRange struct {
+	start []byte
+	end   []byte
+}
+
+// indexScan returns the index and the ranges within the index to scan in order to find all of the documents that
+// may match the given selector. Only the first field of an index is used for the scan and only conditions on
+// top-level fields of the selector are considered. Since documents that lack an indexed field have no entry in the
+// index, an index is only used if the selector requires every indexed field to exist. False is returned if none of
+// the indexes may be used.
+func indexScan(indexes []*index, selector jsonMap) (*index, []*keyRange, bool) {
+	var rangeIdx *index
+	var ranges []*keyRange
+
+	for _, idx := range indexes {
+		if !coversFields(selector, idx.fields) {
+			continue
+		}
+
+		condition := selector[idx.fields[0]]
+
+		r, equality, ok := scanRanges(idx.prefix(), condition)
+		if !ok {
+			continue
+		}
+
+		if equality {
+			// An equality scan is preferred over a range scan
+			return idx, r, true
+		}
+
+		if rangeIdx == nil {
+			rangeIdx = idx
+			ranges = r
+		}
+	}
+
+	return rangeIdx, ranges, rangeIdx != nil
+}
+
+// coversFields returns true if the given selector only matches documents that contain all of the given fields
+func coversFields(selector jsonMap, fields []string) bool {
+	for _, field := range fields {
+		condition, ok := selector[field]
+		if !ok || !requiresField(condition) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requiresField returns true if the given condition can't be satisfied by a document that lacks the field
+func requiresField(condition interface{}) bool {
+	cond, isOpMap := condition.(map[string]interface{})
+	if !isOpMap || !isOperatorMap(cond) {
+		// Implicit equality
+		return true
+	}
+
+	for op, operand := range cond {
+		switch op {
+		case opEq, opNe, opGt, opGte, opLt, opLte, opIn, opNin:
+			return true
+		case opExists:
+			if exists, ok := operand.(bool); ok && exists {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// scanRanges returns the ranges of the index (with the given prefix) that contain all of the values which satisfy
+// the given condition. The ranges may contain values that don't satisfy the condition but they never exclude a value
+// which does. True is returned for equality if the ranges were derived from an $eq or $in condition.
+func scanRanges(prefix []byte, condition interface{}) (ranges []*keyRange, equality bool, ok bool) {
+	cond, isOpMap := condition.(map[string]interface{})
+	if !isOpMap || !isOperatorMap(cond) {
+		// Implicit equality
+		if !isScalar(condition) {
+			return nil, false, false
+		}
+		return []*keyRange{equalRange(prefix, condition)}, true, true
+	}
+
+	if operand, exists := cond[opEq]; exists && isScalar(operand) {
+		return []*keyRange{equalRange(prefix, operand)}, true, true
+	}
+
+	if operand, exists := cond[opIn]; exists {
+		if ranges, ok := inRanges(prefix, operand); ok {
+			return ranges, true, true
+		}
+	}
+
+	r := &keyRange{start: prefix, end: prefixEnd(prefix)}
+	for op, operand := range cond {
+		if !isScalar(operand) {
+			continue
+		}
+
+		switch op {
+		case opGt:
+			ok = true
+			r.start = maxKey(r.start, prefixEnd(valueKey(prefix, operand)))
+		case opGte:
+			ok = true
+			r.start = maxKey(r.start, valueKey(prefix, operand))
+		case opLt:
+			ok = true
+			r.end = minKey(r.end, valueKey(prefix, operand))
+		case opLte:
+			ok = true
+			r.end = minKey(r.end, prefixEnd(valueKey(prefix, operand)))
+		}
+	}
+
+	if !ok {
+		return nil, false, false
+	}
+
+	return []*keyRange{r}, false, true
+}
+
+func inRanges(prefix []byte, operand interface{}) ([]*keyRange, bool) {
+	values, ok := operand.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	var ranges []*keyRange
+	for _, v := range values {
+		if !isScalar(v) {
+			return nil, false
+		}
+		ranges = append(ranges, equalRange(prefix, v))
+	}
+
+	return ranges, true
+}
+
+func equalRange(prefix []byte, value interface{}) *keyRange {
+	start := valueKey(prefix, value)
+	return &keyRange{start: start, end: prefixEnd(start)}
+}
+
+func valueKey(prefix []byte, value interface{}) []byte {
+	k := append([]byte{}, prefix...)
+	return append(k, encodeIndexValue(value)...)
+}
+
+// isScalar returns true if the value is null, a boolean, a number or a string. The encoded form
+// of these values has the same ordering and equality as the values themselves.
+func isScalar(v interface{}) bool {
+	r := typeRank(v)
+	return r != rankArray && r != rankObject
+}
+
+// encodeIndexValue encodes the given JSON value such that the encoded values are ordered according to
+// CouchDB collation rules (for null, boolean, number and string values). Each encoded value is
+// self-delimiting so that the values of multiple fields may be concatenated.
+func encodeIndexValue(v interface{}) []byte {
+	rank := typeRank(v)
+	encoded := []byte{byte(rank)}
+
+	switch rank {
+	case rankBool:
+		if v.(bool) {
+			return append(encoded, 1)
+		}
+		return append(encoded, 0)
+	case rankNumber:
+		return append(encoded, encodeFloat(toFloat(v))...)
+	case rankString:
+		return append(encoded, escape([]byte(v.(string)))...)
+	case rankArray, rankObject:
+		b, err := json.Marshal(v)
+		if err != nil {
+			logger.Debugf("Unable to marshal value [%v]: %s", v, err)
+		}
+		return append(encoded, escape(b)...)
+	default:
+		return encoded
+	}
+}
+
+// encodeFloat returns an 8 byte encoding of the given float such that the byte order is the same as the numeric order
+func encodeFloat(f float64) []byte {
+	bits := math.Float64bits(f)
+	if f < 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, bits)
+
+	return b
+}
+
+// escape escapes each 0x00 byte as 0x00 0xFF and terminates the value with 0x00 0x01 so that
+// a value is ordered before any other value of which it is a prefix
+func escape(b []byte) []byte {
+	escaped := make([]byte, 0, len(b)+2)
+	for _, c := range b {
+		escaped = append(escaped, c)
+		if c == 0x00 {
+			escaped = append(escaped, 0xFF)
+		}
+	}
+	return append(escaped, 0x00, 0x01)
+}
+
+// prefixEnd returns the smallest key that is greater than all keys with the given prefix
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	// All bytes are 0xFF so there is no upper bound
+	return nil
+}
+
+func maxKey(k1, k2 []byte) []byte {
+	if bytes.Compare(k1, k2) >= 0 {
+		return k1
+	}
+	return k2
+}
+
+func minKey(k1, k2 []byte) []byte {
+	if k1 == nil {
+		return k2
+	}
+	if k2 == nil || bytes.Compare(k1, k2) <= 0 {
+		return k1
+	}
+	return k2
+}
+
+// indexUpdates holds the index entries to be added and removed as a result of a batch update
+type indexUpdates struct {
+	added   map[string]string
+	removed map[string]struct{}
+}
+
+func newIndexUpdates() *indexUpdates {
+	return &indexUpdates{
+		added:   make(map[string]string),
+		removed: make(map[string]struct{}),
+	}
+}
+
+func (u *indexUpdates) add(entries ...*indexEntry) {
+	for _, e := range entries {
+		u.added[string(e.key)] = e.dataKey
+		delete(u.removed, string(e.key))
+	}
+}
+
+func (u *indexUpdates) remove(entries ...*indexEntry) {
+	for _, e := range entries {
+		if _, ok := u.added[string(e.key)]; ok {
+			continue
+		}
+		u.removed[string(e.key)] = struct{}{}
+	}
+}
+
+// indexEntry is an entry in an index. The key of the entry contains the encoded values of the indexed
+// fields followed by the key of the data and the value of the entry is the key of the data.
+type indexEntry struct {
+	key     []byte
+	dataKey string
+}
+
+// indexEntries returns the entries of all of the indexes for the given key and value. No entries are
+// returned if the value isn't JSON.
+func (s *store) indexEntries(key string, value []byte) []*indexEntry {
+	if len(s.indexes) == 0 || value == nil {
+		return nil
+	}
+
+	doc, err := unmarshalJSON(value)
+	if err != nil {
+		return nil
+	}
+
+	var entries []*indexEntry
+	for _, idx := range s.indexes {
+		if entryKey := idx.entryKey(key, doc); entryKey != nil {
+			entries = append(entries, &indexEntry{key: entryKey, dataKey: key})
+		}
+	}
+
+	return entries
+}
+
+// writeBatch writes the given batch along with the given index updates. New index entries are written before the
+// data and stale index entries are removed after the data so that, if a write fails, the index may contain entries
+// which no longer match the data but it never lacks an entry for the data. (Queries check each value against the
+// selector so stale entries are harmless.)
+func (s *store) writeBatch(batch *leveldbhelper.UpdateBatch, updates *indexUpdates) error {
+	if len(updates.added) > 0 {
+		indexBatch := s.indexDB.NewUpdateBatch()
+		for k, dataKey := range updates.added {
+			indexBatch.Put([]byte(k), []byte(dataKey))
+		}
+
+		if err := s.indexDB.WriteBatch(indexBatch, true); err != nil {
+			return errors.Wrapf(err, "failed to add index entries in db [%s]", s.dbName)
+		}
+	}
+
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+
+	if len(updates.removed) > 0 {
+		indexBatch := s.indexDB.NewUpdateBatch()
+		for k := range updates.removed {
+			indexBatch.Delete([]byte(k))
+		}
+
+		if err := s.indexDB.WriteBatch(indexBatch, true); err != nil {
+			return errors.Wrapf(err, "failed to remove index entries in db [%s]", s.dbName)
+		}
+	}
+
+	return nil
+}
+
+// iterateIndex invokes the given function for each unexpired value referenced by the index entries in
+// the given ranges. The values are ordered by the indexed fields.
+func (s *store) iterateIndex(ranges []*keyRange, fn func(key string, v *api.Value) error) error {
+	visited := make(map[string]struct{})
+
+	for _, r := range ranges {
+		dataKeys, err := s.indexedKeys(r)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range dataKeys {
+			if _, ok := visited[key]; ok {
+				continue
+			}
+			visited[key] = struct{}{}
+
+			v, err := s.Get(key)
+			if err != nil {
+				return err
+			}
+
+			if v == nil {
+				logger.Debugf("[%s] Index entry found for key [%s] but the key doesn't exist", s.dbName, key)
+				continue
+			}
+
+			if !v.ExpiryTime.IsZero() && v.ExpiryTime.Before(time.Now()) {
+				logger.Debugf("[%s] Key [%s] has expired", s.dbName, key)
+				continue
+			}
+
+			if err := fn(key, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *store) indexedKeys(r *keyRange) ([]string, error) {
+	itr, err := s.indexDB.GetIterator(r.start, r.end)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get index iterator for db [%s]", s.dbName)
+	}
+	defer itr.Release()
+
+	var keys []string
+	for itr.Next() {
+		keys = append(keys, string(itr.Value()))
+	}
+
+	return keys, errors.Wrapf(itr.Error(), "failed to iterate over index for db [%s]", s.dbName)
+}
+
+// applyIndexes ensures that the index DB contains the entries for each of the defined indexes. An index
+// is (re)built from the existing data if it's new or if its definition has changed. Indexes that are no
+// longer defined are removed.
+func (s *store) applyIndexes() error {
+	existing, err := s.loadIndexDefinitions()
+	if err != nil {
+		return err
+	}
+
+	defined := make(map[string]*index)
+	for _, idx := range s.indexes {
+		defined[idx.name] = idx
+	}
+
+	for name, definition := range existing {
+		idx, ok := defined[name]
+		if ok && idx.definition == definition {
+			logger.Debugf("[%s] Index [%s] is up to date", s.dbName, name)
+			delete(defined, name)
+			continue
+		}
+
+		logger.Infof("[%s] Removing index [%s]", s.dbName, name)
+
+		if err := s.dropIndex(name); err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range s.indexes {
+		if _, ok := defined[idx.name]; !ok {
+			continue
+		}
+
+		logger.Infof("[%s] Building index [%s] on fields %s", s.dbName, idx.name, idx.fields)
+
+		if err := s.buildIndex(idx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *store) loadIndexDefinitions() (map[string]string, error) {
+	prefix := indexMetadataKey("")
+
+	itr, err := s.indexDB.GetIterator(prefix, prefixEnd(prefix))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get index iterator for db [%s]", s.dbName)
+	}
+	defer itr.Release()
+
+	definitions := make(map[string]string)
+	for itr.Next() {
+		definitions[string(itr.Key()[len(prefix):])] = string(itr.Value())
+	}
+
+	return definitions, errors.Wrapf(itr.Error(), "failed to load index definitions for db [%s]", s.dbName)
+}
+
+func (s *store) dropIndex(name string) error {
+	prefix := indexEntriesPrefix(name)
+
+	itr, err := s.indexDB.GetIterator(prefix, prefixEnd(prefix))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get index iterator for db [%s]", s.dbName)
+	}
+	defer itr.Release()
+
+	batch := s.indexDB.NewUpdateBatch()
+	for itr.Next() {
+		batch.Delete(append([]byte{}, itr.Key()...))
+	}
+
+	if err := itr.Error(); err != nil {
+		return errors.Wrapf(err, "failed to iterate over index [%s] for db [%s]", name, s.dbName)
+	}
+
+	batch.Delete(indexMetadataKey(name))
+
+	return errors.Wrapf(s.indexDB.WriteBatch(batch, true), "failed to remove index [%s] from db [%s]", name, s.dbName)
+}
+
+func (s *store) buildIndex(idx *index) error {
+	batch := s.indexDB.NewUpdateBatch()
+
+	err := s.iterate(nil, nil, func(key string, v *api.Value) error {
+		doc, err := unmarshalJSON(v.Value)
+		if err != nil {
+			return nil
+		}
+
+		if entryKey := idx.entryKey(key, doc); entryKey != nil {
+			batch.Put(entryKey, []byte(key))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	batch.Put(indexMetadataKey(idx.name), []byte(idx.definition))
+
+	return errors.Wrapf(s.indexDB.WriteBatch(batch, true), "failed to build index [%s] for db [%s]", idx.name, s.dbName)
+}
@@ -0,0 +1,342 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package leveldbstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+)
+
+const (
+	field1Index = `{"index":{"fields":["Field1"]},"ddoc":"indexField1Doc","name":"indexField1","type":"json"}`
+	field2Index = `{"index":{"fields":[{"Field2":"asc"},"Field1"]},"ddoc":"indexField2Doc","name":"indexField2","type":"json"}`
+)
+
+func TestEncodeIndexValue(t *testing.T) {
+	// The values are in CouchDB collation order
+	values := []interface{}{
+		nil, false, true,
+		json.Number("-100.5"), json.Number("-1"), json.Number("0"), json.Number("1"), json.Number("2.5"), json.Number("100"),
+		"", "a", "a\x00", "a\x00b", "a\x01", "b", "ba",
+	}
+
+	for i := range values {
+		for j := range values {
+			c := bytes.Compare(encodeIndexValue(values[i]), encodeIndexValue(values[j]))
+			require.Equalf(t, compareValues(values[i], values[j]), c, "unexpected order for [%v] and [%v]", values[i], values[j])
+		}
+	}
+
+	require.Equal(t, encodeIndexValue(json.Number("1")), encodeIndexValue(json.Number("1.0")))
+}
+
+func TestParseIndex(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		idx, err := parseIndex(field2Index)
+		require.NoError(t, err)
+		require.Equal(t, "indexField2", idx.name)
+		require.Equal(t, []string{"Field2", "Field1"}, idx.fields)
+		require.Equal(t, field2Index, idx.definition)
+	})
+
+	t.Run("No name", func(t *testing.T) {
+		idx, err := parseIndex(`{"index":{"fields":["Field1","Nested.Field3"]}}`)
+		require.NoError(t, err)
+		require.Equal(t, "Field1,Nested.Field3", idx.name)
+	})
+
+	t.Run("Invalid definitions", func(t *testing.T) {
+		_, err := parseIndex(`{"index":`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid index definition")
+
+		_, err = parseIndex(`{"index":{"fields":["Field1"]},"type":"text"}`)
+		require.EqualError(t, err, "unsupported index type [text]")
+
+		_, err = parseIndex(`{"name":"index1"}`)
+		require.EqualError(t, err, "index definition must contain an index object")
+
+		_, err = parseIndex(`{"index":{"fields":"Field1"}}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid index fields")
+
+		_, err = parseIndex(`{"index":{"fields":[]}}`)
+		require.EqualError(t, err, "index must contain at least one field")
+	})
+
+	t.Run("Invalid and duplicate definitions are ignored", func(t *testing.T) {
+		indexes := parseIndexes("db1", []string{field2Index, `{"index":{}}`, field1Index, field2Index})
+		require.Len(t, indexes, 2)
+		require.Equal(t, "indexField1", indexes[0].name)
+		require.Equal(t, "indexField2", indexes[1].name)
+	})
+}
+
+func TestIndexScan(t *testing.T) {
+	indexes := parseIndexes("db1", []string{field1Index, field2Index})
+
+	t.Run("Equality preferred", func(t *testing.T) {
+		q, err := parseQuery(`{"selector":{"Field1":{"$gt":"a"},"Field2":5}}`)
+		require.NoError(t, err)
+
+		idx, ranges, ok := indexScan(indexes, q.selector)
+		require.True(t, ok)
+		require.Equal(t, "indexField2", idx.name)
+		require.Len(t, ranges, 1)
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		q, err := parseQuery(`{"selector":{"Field1":{"$gt":"a","$lte":"c"}}}`)
+		require.NoError(t, err)
+
+		idx, ranges, ok := indexScan(indexes, q.selector)
+		require.True(t, ok)
+		require.Equal(t, "indexField1", idx.name)
+		require.Len(t, ranges, 1)
+	})
+
+	t.Run("$in", func(t *testing.T) {
+		q, err := parseQuery(`{"selector":{"Field1":{"$in":["a","b","c"]}}}`)
+		require.NoError(t, err)
+
+		_, ranges, ok := indexScan(indexes, q.selector)
+		require.True(t, ok)
+		require.Len(t, ranges, 3)
+	})
+
+	t.Run("Compound index", func(t *testing.T) {
+		for _, query := range []string{
+			`{"selector":{"Field2":5,"Field1":{"$ne":"a"}}}`,
+			`{"selector":{"Field2":5,"Field1":{"$exists":true}}}`,
+		} {
+			q, err := parseQuery(query)
+			require.NoError(t, err)
+
+			idx, _, ok := indexScan(indexes, q.selector)
+			require.Truef(t, ok, "index should be used for query %s", query)
+			require.Equal(t, "indexField2", idx.name)
+		}
+	})
+
+	t.Run("Index not used", func(t *testing.T) {
+		for _, query := range []string{
+			`{"selector":{"Field3":"a"}}`,
+			`{"selector":{"Field2":5}}`,
+			`{"selector":{"Field2":5,"Field1":{"$exists":false}}}`,
+			`{"selector":{"Field2":5,"Field1":{"$not":{"$eq":"a"}}}}`,
+			`{"selector":{"Field1":{"$ne":"a"}}}`,
+			`{"selector":{"Field1":{"$gt":["a"]}}}`,
+			`{"selector":{"Field1":{"$in":[["a"]]}}}`,
+			`{"selector":{"$or":[{"Field1":"a"},{"Field2":5}]}}`,
+		} {
+			q, err := parseQuery(query)
+			require.NoError(t, err)
+
+			_, _, ok := indexScan(indexes, q.selector)
+			require.Falsef(t, ok, "index should not be used for query %s", query)
+		}
+	})
+}
+
+func TestStore_QueryWithIndexes(t *testing.T) {
+	defer removeDBPath(t)
+	defer setCollectionIndexes(field1Index, field2Index)()
+
+	provider, err := NewDBProvider()
+	require.NoError(t, err)
+	defer provider.Close()
+
+	db, err := provider.GetDB(ns1, "", coll1)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	s := db.(*store)
+	require.Len(t, s.indexes, 2)
+
+	err = db.Put(
+		api.NewKeyValue("doc1", []byte(`{"Field1":"value1","Field2":12345,"Nested":{"Field3":true}}`), txID1, time.Time{}),
+		api.NewKeyValue("doc2", []byte(`{"Field1":"value2","Field2":12345}`), txID1, time.Now().UTC().Add(1*time.Minute)),
+		api.NewKeyValue("doc3", []byte(`{"Field1":"value3","Field2":200}`), txID2, time.Time{}),
+		api.NewKeyValue("doc4", []byte(`{"Field1":"value4","Field2":100}`), txID2, time.Now().UTC().Add(1*time.Minute)),
+		api.NewKeyValue("doc5", []byte(`{"Field1":"value5"}`), txID2, time.Time{}),
+		api.NewKeyValue("binary", []byte("not JSON"), txID2, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, 5, indexEntryCount(t, s, "indexField1"))
+	// doc5 doesn't contain Field2
+	require.Equal(t, 4, indexEntryCount(t, s, "indexField2"))
+
+	t.Run("Query", func(t *testing.T) {
+		results, err := db.Query(`{"selector":{"Field1":"value2"},"fields":["Field1","Field2"]}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2"}, keys(results))
+		require.JSONEq(t, `{"Field1":"value2","Field2":12345}`, string(results[0].Value.Value))
+
+		results, err = db.Query(`{"selector":{"Field2":12345,"Field1":{"$ne":"value1"}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field2":{"$gt":150,"$lt":20000},"Field1":{"$exists":true}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc3", "doc1", "doc2"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field2":{"$lte":200},"Field1":{"$exists":true}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc4", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field1":{"$in":["value1","value3","value1"]}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc3"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field1":{"$gte":"value3"}},"sort":[{"Field1":"desc"}],"limit":2}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc5", "doc4"}, keys(results))
+	})
+
+	t.Run("Partial documents", func(t *testing.T) {
+		require.NoError(t, db.Put(api.NewKeyValue("doc7", []byte(`{"Field2":150}`), txID2, time.Time{})))
+		defer func() { require.NoError(t, db.Delete("doc7")) }()
+
+		// doc7 has no entry in indexField2 since it doesn't contain Field1, so the index must not be used
+		results, err := db.Query(`{"selector":{"Field2":{"$gt":100,"$lt":20000}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1", "doc2", "doc3", "doc7"}, keys(results))
+
+		results, err = db.Query(`{"selector":{"Field2":150}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc7"}, keys(results))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		require.NoError(t, db.Put(api.NewKeyValue("doc1", []byte(`{"Field1":"value6","Field2":12345}`), txID2, time.Time{})))
+
+		results, err := db.Query(`{"selector":{"Field1":"value1"}}`)
+		require.NoError(t, err)
+		require.Empty(t, results)
+
+		results, err = db.Query(`{"selector":{"Field1":"value6"}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc1"}, keys(results))
+
+		require.Equal(t, 5, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 4, indexEntryCount(t, s, "indexField2"))
+
+		// Non-JSON values are not indexed
+		require.NoError(t, db.Put(api.NewKeyValue("doc5", []byte("not JSON"), txID2, time.Time{})))
+		require.Equal(t, 4, indexEntryCount(t, s, "indexField1"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, db.Delete("doc3"))
+		require.NoError(t, db.Put(&api.KeyValue{Key: "doc1"}))
+
+		results, err := db.Query(`{"selector":{"Field2":{"$gt":0},"Field1":{"$exists":true}}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc4", "doc2"}, keys(results))
+
+		require.Equal(t, 2, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 2, indexEntryCount(t, s, "indexField2"))
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		require.NoError(t, db.Put(api.NewKeyValue("doc6", []byte(`{"Field1":"value7","Field2":1}`), txID2, time.Now().UTC().Add(50*time.Millisecond))))
+		require.Equal(t, 3, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 3, indexEntryCount(t, s, "indexField2"))
+
+		// Wait for the periodic purge
+		time.Sleep(300 * time.Millisecond)
+
+		// The entries for doc6 should have been removed
+		require.Equal(t, 2, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 2, indexEntryCount(t, s, "indexField2"))
+	})
+}
+
+func TestStore_ApplyIndexes(t *testing.T) {
+	defer removeDBPath(t)
+
+	provider, err := NewDBProvider()
+	require.NoError(t, err)
+
+	db, err := provider.GetDB(ns1, "", coll1)
+	require.NoError(t, err)
+	require.Empty(t, db.(*store).indexes)
+
+	err = db.Put(
+		api.NewKeyValue("doc1", []byte(`{"Field1":"value1","Field2":12345}`), txID1, time.Time{}),
+		api.NewKeyValue("doc2", []byte(`{"Field1":"value2"}`), txID1, time.Time{}),
+	)
+	require.NoError(t, err)
+
+	provider.Close()
+
+	t.Run("Indexes are built from existing data", func(t *testing.T) {
+		restore := setCollectionIndexes(field1Index, field2Index)
+		defer restore()
+
+		provider, err := NewDBProvider()
+		require.NoError(t, err)
+		defer provider.Close()
+
+		db, err := provider.GetDB(ns1, "", coll1)
+		require.NoError(t, err)
+
+		s := db.(*store)
+		require.Equal(t, 2, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 1, indexEntryCount(t, s, "indexField2"))
+
+		results, err := db.Query(`{"selector":{"Field1":"value2"}}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"doc2"}, keys(results))
+	})
+
+	t.Run("Changed and removed indexes are dropped", func(t *testing.T) {
+		restore := setCollectionIndexes(`{"index":{"fields":["Field2"]},"name":"indexField1"}`)
+		defer restore()
+
+		provider, err := NewDBProvider()
+		require.NoError(t, err)
+		defer provider.Close()
+
+		db, err := provider.GetDB(ns1, "", coll1)
+		require.NoError(t, err)
+
+		s := db.(*store)
+		require.Equal(t, 1, indexEntryCount(t, s, "indexField1"))
+		require.Equal(t, 0, indexEntryCount(t, s, "indexField2"))
+
+		definitions, err := s.loadIndexDefinitions()
+		require.NoError(t, err)
+		require.Len(t, definitions, 1)
+	})
+}
+
+func setCollectionIndexes(definitions ...string) func() {
+	prev := getCollectionIndexes
+	getCollectionIndexes = func(ns, coll string) []string { return definitions }
+	return func() { getCollectionIndexes = prev }
+}
+
+func indexEntryCount(t *testing.T, s *store, name string) int {
+	prefix := indexEntriesPrefix(name)
+
+	itr, err := s.indexDB.GetIterator(prefix, prefixEnd(prefix))
+	require.NoError(t, err)
+	defer itr.Release()
+
+	count := 0
+	for itr.Next() {
+		count++
+	}
+
+	return count
+}
@@ -0,0 +1,573 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package leveldbstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	selectorField = "selector"
+	fieldsField   = "fields"
+	sortField     = "sort"
+	limitField    = "limit"
+	skipField     = "skip"
+
+	opEq     = "$eq"
+	opNe     = "$ne"
+	opGt     = "$gt"
+	opGte    = "$gte"
+	opLt     = "$lt"
+	opLte    = "$lte"
+	opIn     = "$in"
+	opNin    = "$nin"
+	opExists = "$exists"
+	opAnd    = "$and"
+	opOr     = "$or"
+	opNor    = "$nor"
+	opNot    = "$not"
+
+	sortAsc  = "asc"
+	sortDesc = "desc"
+)
+
+type jsonMap map[string]interface{}
+
+type sortSpec struct {
+	field string
+	desc  bool
+}
+
+// query is a parsed subset of a CouchDB Mango query which may be evaluated against JSON values
+type query struct {
+	selector jsonMap
+	fields   []string
+	sort     []sortSpec
+	limit    int
+	skip     int
+}
+
+// parseQuery parses the given Mango query. The following subset is supported:
+// selector (with $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $exists, $and, $or, $nor, $not),
+// fields, sort, limit and skip.
+func parseQuery(q string) (*query, error) {
+	jsonQuery, err := unmarshalJSON([]byte(q))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid query")
+	}
+
+	parsed := &query{}
+
+	for name, value := range jsonQuery {
+		switch name {
+		case selectorField:
+			parsed.selector, err = parseSelector(value)
+		case fieldsField:
+			parsed.fields, err = parseFields(value)
+		case sortField:
+			parsed.sort, err = parseSort(value)
+		case limitField:
+			parsed.limit, err = parseInt(name, value)
+		case skipField:
+			parsed.skip, err = parseInt(name, value)
+		default:
+			logger.Debugf("Ignoring unsupported query field [%s]", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if parsed.selector == nil {
+		return nil, errors.New("selector is required")
+	}
+
+	return parsed, nil
+}
+
+// matches returns true if the given document satisfies the selector
+func (q *query) matches(doc jsonMap) (bool, error) {
+	return matchSelector(doc, q.selector)
+}
+
+// project returns the value containing only the fields in the query. If no fields are
+// specified then the original value is returned.
+func (q *query) project(doc jsonMap, value []byte) ([]byte, error) {
+	if len(q.fields) == 0 {
+		return value, nil
+	}
+
+	projected := make(jsonMap)
+	for _, field := range q.fields {
+		v, ok := getField(doc, field)
+		if !ok {
+			continue
+		}
+		setField(projected, field, v)
+	}
+
+	return json.Marshal(projected)
+}
+
+type queryResult struct {
+	key string
+	doc jsonMap
+}
+
+// apply sorts the given results and applies skip and limit
+func (q *query) apply(results []*queryResult) []*queryResult {
+	q.sortResults(results)
+
+	if q.skip > 0 {
+		if q.skip >= len(results) {
+			return nil
+		}
+		results = results[q.skip:]
+	}
+
+	if q.limit > 0 && q.limit < len(results) {
+		results = results[:q.limit]
+	}
+
+	return results
+}
+
+// sortResults sorts the given results according to the sort fields of the query. The order of the results
+// is preserved if the query has no sort fields.
+func (q *query) sortResults(results []*queryResult) {
+	if len(q.sort) == 0 {
+		return
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		for _, s := range q.sort {
+			vi, _ := getField(results[i].doc, s.field)
+			vj, _ := getField(results[j].doc, s.field)
+			c := compareValues(vi, vj)
+			if c == 0 {
+				continue
+			}
+			if s.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// SortAndLimit sorts the given query results according to the sort fields of the given Mango query and
+// truncates them to the query's limit. Skip is not applied since it is expected to have been applied by the
+// peers that produced the results. This is used to order results that were merged from multiple peers.
+func SortAndLimit(query string, results []*storeapi.QueryResult) ([]*storeapi.QueryResult, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*queryResult, len(results))
+	byKey := make(map[string]*storeapi.QueryResult, len(results))
+
+	for i, r := range results {
+		doc, err := unmarshalJSON(r.Value)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid JSON value for key [%s]", r.Key.Key)
+		}
+
+		docs[i] = &queryResult{key: r.Key.Key, doc: doc}
+		byKey[r.Key.Key] = r
+	}
+
+	q.sortResults(docs)
+
+	if q.limit > 0 && q.limit < len(docs) {
+		docs = docs[:q.limit]
+	}
+
+	sorted := make([]*storeapi.QueryResult, len(docs))
+	for i, d := range docs {
+		sorted[i] = byKey[d.key]
+	}
+
+	return sorted, nil
+}
+
+func parseSelector(value interface{}) (jsonMap, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("selector must be an object")
+	}
+	return m, nil
+}
+
+func parseFields(value interface{}) ([]string, error) {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("fields definition must be an array")
+	}
+
+	fields := make([]string, len(arr))
+	for i, f := range arr {
+		field, ok := f.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid field [%v]", f)
+		}
+		fields[i] = field
+	}
+
+	return fields, nil
+}
+
+func parseSort(value interface{}) ([]sortSpec, error) {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("sort definition must be an array")
+	}
+
+	var specs []sortSpec
+	for _, s := range arr {
+		switch v := s.(type) {
+		case string:
+			specs = append(specs, sortSpec{field: v})
+		case map[string]interface{}:
+			if len(v) != 1 {
+				return nil, errors.Errorf("invalid sort definition [%v]", v)
+			}
+			for field, dir := range v {
+				switch dir {
+				case sortAsc:
+					specs = append(specs, sortSpec{field: field})
+				case sortDesc:
+					specs = append(specs, sortSpec{field: field, desc: true})
+				default:
+					return nil, errors.Errorf("invalid sort direction [%v] for field [%s]", dir, field)
+				}
+			}
+		default:
+			return nil, errors.Errorf("invalid sort definition [%v]", s)
+		}
+	}
+
+	return specs, nil
+}
+
+func parseInt(name string, value interface{}) (int, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, errors.Errorf("%s must be a number", name)
+	}
+
+	i, err := n.Int64()
+	if err != nil || i < 0 {
+		return 0, errors.Errorf("%s must be a non-negative integer", name)
+	}
+
+	return int(i), nil
+}
+
+func matchSelector(doc jsonMap, selector jsonMap) (bool, error) {
+	for name, condition := range selector {
+		match, err := matchField(doc, name, condition)
+		if err != nil {
+			return false, err
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchField(doc jsonMap, name string, condition interface{}) (bool, error) {
+	switch name {
+	case opAnd, opOr, opNor:
+		return matchCombination(doc, name, condition)
+	case opNot:
+		sub, ok := condition.(map[string]interface{})
+		if !ok {
+			return false, errors.Errorf("%s requires an object", opNot)
+		}
+		match, err := matchSelector(doc, sub)
+		return !match, err
+	}
+
+	value, exists := getField(doc, name)
+
+	cond, ok := condition.(map[string]interface{})
+	if !ok || !isOperatorMap(cond) {
+		// Implicit equality
+		return exists && compareValues(value, condition) == 0, nil
+	}
+
+	for op, operand := range cond {
+		match, err := matchOperator(value, exists, op, operand)
+		if err != nil {
+			return false, err
+		}
+		if !match {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func matchCombination(doc jsonMap, op string, condition interface{}) (bool, error) {
+	arr, ok := condition.([]interface{})
+	if !ok {
+		return false, errors.Errorf("%s requires an array", op)
+	}
+
+	for _, c := range arr {
+		sub, ok := c.(map[string]interface{})
+		if !ok {
+			return false, errors.Errorf("%s requires an array of objects", op)
+		}
+
+		match, err := matchSelector(doc, sub)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case op == opAnd && !match:
+			return false, nil
+		case op == opOr && match:
+			return true, nil
+		case op == opNor && match:
+			return false, nil
+		}
+	}
+
+	return op != opOr, nil
+}
+
+func matchOperator(value interface{}, exists bool, op string, operand interface{}) (bool, error) {
+	switch op {
+	case opExists:
+		b, ok := operand.(bool)
+		if !ok {
+			return false, errors.Errorf("%s requires a boolean", opExists)
+		}
+		return exists == b, nil
+	case opIn, opNin:
+		arr, ok := operand.([]interface{})
+		if !ok {
+			return false, errors.Errorf("%s requires an array", op)
+		}
+		return exists && contains(arr, value) == (op == opIn), nil
+	case opNot:
+		sub, ok := operand.(map[string]interface{})
+		if !ok {
+			return false, errors.Errorf("%s requires an object", opNot)
+		}
+		for subOp, subOperand := range sub {
+			match, err := matchOperator(value, exists, subOp, subOperand)
+			if err != nil || !match {
+				return true, err
+			}
+		}
+		return false, nil
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	c := compareValues(value, operand)
+
+	switch op {
+	case opEq:
+		return c == 0, nil
+	case opNe:
+		return c != 0, nil
+	case opGt:
+		return c > 0, nil
+	case opGte:
+		return c >= 0, nil
+	case opLt:
+		return c < 0, nil
+	case opLte:
+		return c <= 0, nil
+	default:
+		return false, errors.Errorf("unsupported operator [%s]", op)
+	}
+}
+
+func isOperatorMap(m map[string]interface{}) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return len(m) > 0
+}
+
+func contains(arr []interface{}, value interface{}) bool {
+	for _, v := range arr {
+		if compareValues(v, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getField returns the value of the given field. Nested fields are separated by '.'.
+func getField(doc jsonMap, field string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(doc)
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setField(doc jsonMap, field string, value interface{}) {
+	parts := strings.Split(field, ".")
+	current := map[string]interface{}(doc)
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// Type ranks follow the CouchDB collation order: null < booleans < numbers < strings < arrays < objects
+const (
+	rankNull = iota
+	rankBool
+	rankNumber
+	rankString
+	rankArray
+	rankObject
+)
+
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return rankNull
+	case bool:
+		return rankBool
+	case json.Number, float64:
+		return rankNumber
+	case string:
+		return rankString
+	case []interface{}:
+		return rankArray
+	default:
+		return rankObject
+	}
+}
+
+// compareValues compares two JSON values using CouchDB collation rules and returns
+// -1 if v1 < v2, 0 if v1 == v2 and 1 if v1 > v2
+func compareValues(v1, v2 interface{}) int {
+	r1, r2 := typeRank(v1), typeRank(v2)
+	if r1 != r2 {
+		return compareInts(r1, r2)
+	}
+
+	switch r1 {
+	case rankBool:
+		return compareBools(v1.(bool), v2.(bool))
+	case rankNumber:
+		return compareFloats(toFloat(v1), toFloat(v2))
+	case rankString:
+		return strings.Compare(v1.(string), v2.(string))
+	case rankArray:
+		return compareArrays(v1.([]interface{}), v2.([]interface{}))
+	case rankObject:
+		b1, _ := json.Marshal(v1)
+		b2, _ := json.Marshal(v2)
+		return bytes.Compare(b1, b2)
+	default:
+		return 0
+	}
+}
+
+func compareArrays(a1, a2 []interface{}) int {
+	for i := 0; i < len(a1) && i < len(a2); i++ {
+		if c := compareValues(a1[i], a2[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a1), len(a2))
+}
+
+func compareInts(i1, i2 int) int {
+	switch {
+	case i1 < i2:
+		return -1
+	case i1 > i2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloats(f1, f2 float64) int {
+	switch {
+	case f1 < f2:
+		return -1
+	case f1 > f2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareBools(b1, b2 bool) int {
+	switch {
+	case b1 == b2:
+		return 0
+	case !b1:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			logger.Debugf("Invalid number [%s]: %s", n, err)
+		}
+		return f
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+func unmarshalJSON(b []byte) (jsonMap, error) {
+	m := make(jsonMap)
+	decoder := json.NewDecoder(bytes.NewBuffer(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
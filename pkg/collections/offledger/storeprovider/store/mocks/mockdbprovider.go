@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
+	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
@@ -125,7 +127,27 @@ func (m *DB) Put(keyVals ...*api.KeyValue) error {
 	}
 
 	for _, kv := range keyVals {
-		m.data[kv.Key] = kv.Value
+		if kv.Value == nil {
+			delete(m.data, kv.Key)
+		} else {
+			m.data[kv.Key] = kv.Value
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes the given keys
+func (m *DB) Delete(keys ...string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, key := range keys {
+		delete(m.data, key)
 	}
 
 	return nil
@@ -159,6 +181,58 @@ func (m *DB) Query(query string) ([]*api.KeyValue, error) {
 	return m.queryResults[query], nil
 }
 
+// QueryWithPagination executes a mock query and returns a page of the key/value result set. The
+// bookmark is the offset of the first result in the page.
+func (m *DB) QueryWithPagination(query string, bookmark string, pageSize int32) ([]*api.KeyValue, string, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+
+	results := m.queryResults[query]
+
+	start := 0
+	if bookmark != "" {
+		offset, err := strconv.Atoi(bookmark)
+		if err != nil {
+			return nil, "", err
+		}
+		start = offset
+	}
+
+	if start >= len(results) {
+		return nil, "", nil
+	}
+
+	end := start + int(pageSize)
+	if end >= len(results) {
+		return results[start:], "", nil
+	}
+
+	return results[start:end], strconv.Itoa(end), nil
+}
+
+// GetByRange returns the keys/values in the given range, ordered by key
+func (m *DB) GetByRange(startKey, endKey string) ([]*api.KeyValue, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var results []*api.KeyValue
+	for k, v := range m.data {
+		if v == nil || k < startKey || (endKey != "" && k >= endKey) {
+			continue
+		}
+		results = append(results, &api.KeyValue{Key: k, Value: v})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	return results, nil
+}
+
 // DeleteExpiredKeys currently does nothing
 func (m *DB) DeleteExpiredKeys() error {
 	m.mutex.RLock()
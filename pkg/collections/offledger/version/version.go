@@ -0,0 +1,37 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package version
+
+import (
+	"time"
+)
+
+// Version identifies a write (or delete) of an off-ledger key. Peers compare versions in order to choose the
+// most recent of the values of a key that they hold.
+type Version struct {
+	// TxID is the ID of the transaction that wrote the key
+	TxID string
+	// WriteTime is the time at which the value was originally written
+	WriteTime time.Time
+}
+
+// New returns a new version
+func New(txID string, writeTime time.Time) *Version {
+	return &Version{TxID: txID, WriteTime: writeTime}
+}
+
+// IsNewerThan returns true if this version is more recent than the given version. Versions are ordered by write
+// time and then by transaction ID so that all peers choose the same version when the write times are equal. A
+// version without a write time (i.e. a value stored before write times were recorded) is older than any version
+// with a write time.
+func (v *Version) IsNewerThan(other *Version) bool {
+	if !v.WriteTime.Equal(other.WriteTime) {
+		return v.WriteTime.After(other.WriteTime)
+	}
+
+	return v.TxID > other.TxID
+}
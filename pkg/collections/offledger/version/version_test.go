@@ -0,0 +1,37 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion_IsNewerThan(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("Later write time", func(t *testing.T) {
+		require.True(t, New("tx1", now.Add(time.Second)).IsNewerThan(New("tx2", now)))
+		require.False(t, New("tx2", now).IsNewerThan(New("tx1", now.Add(time.Second))))
+	})
+
+	t.Run("No write time", func(t *testing.T) {
+		require.True(t, New("tx1", now).IsNewerThan(New("tx2", time.Time{})))
+		require.False(t, New("tx2", time.Time{}).IsNewerThan(New("tx1", now)))
+	})
+
+	t.Run("Same write time -> ordered by transaction ID", func(t *testing.T) {
+		require.True(t, New("tx2", now).IsNewerThan(New("tx1", now)))
+		require.False(t, New("tx1", now).IsNewerThan(New("tx2", now)))
+	})
+
+	t.Run("Same version", func(t *testing.T) {
+		require.False(t, New("tx1", now).IsNewerThan(New("tx1", now)))
+	})
+}
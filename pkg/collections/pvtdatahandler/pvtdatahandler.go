@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/compositekey"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
@@ -104,6 +105,50 @@ func (h *Handler) HandleExecuteQueryOnPrivateData(txID, ns string, config *pb.St
 	}
 }
 
+// HandleExecuteQueryOnPrivateDataWithPagination executes the given query on the collection if the collection is one of the
+// extended collections. At most pageSize results are returned starting at the given bookmark. The returned metadata holds
+// the number of fetched records and the bookmark of the next page.
+func (h *Handler) HandleExecuteQueryOnPrivateDataWithPagination(txID, ns string, config *pb.StaticCollectionConfig, query, bookmark string, pageSize int32) (commonledger.ResultsIterator, *pb.QueryResponseMetadata, bool, error) {
+	switch config.Type {
+	case pb.CollectionType_COL_TRANSIENT:
+		logger.Debugf("Collection [%s:%s] is a TransientData store. Rich queries are not supported for transient data", ns, config.Name)
+		return nil, nil, true, errors.New("rich queries not supported on transient data")
+	case pb.CollectionType_COL_DCAS:
+		fallthrough
+	case pb.CollectionType_COL_OFFLEDGER:
+		logger.Debugf("Collection [%s:%s] is an off-ledger store. Returning page of results for query [%s], bookmark [%s], page size [%d]", ns, config.Name, query, bookmark, pageSize)
+		values, metadata, err := h.executeQueryWithPagination(txID, ns, config.Name, query, bookmark, pageSize)
+		return values, metadata, true, err
+	default:
+		return nil, nil, false, nil
+	}
+}
+
+// HandleGetPrivateDataRangeScanIterator returns an iterator over the given range of keys if the collection is one of the extended collections.
+// The results are ordered by key.
+func (h *Handler) HandleGetPrivateDataRangeScanIterator(txID, ns string, config *pb.StaticCollectionConfig, startKey, endKey string) (commonledger.ResultsIterator, bool, error) {
+	switch config.Type {
+	case pb.CollectionType_COL_TRANSIENT:
+		logger.Debugf("Collection [%s:%s] is a TransientData store. Range queries are not supported for transient data", ns, config.Name)
+		return nil, true, errors.New("range queries not supported on transient data")
+	case pb.CollectionType_COL_DCAS:
+		fallthrough
+	case pb.CollectionType_COL_OFFLEDGER:
+		logger.Debugf("Collection [%s:%s] is an off-ledger store. Returning results for range [%s-%s]", ns, config.Name, startKey, endKey)
+		values, err := h.getDataByRange(txID, ns, config.Name, startKey, endKey)
+		return values, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// HandleGetPrivateDataByPartialCompositeKey returns an iterator over the keys matching the given partial composite key
+// if the collection is one of the extended collections. The results are ordered by key.
+func (h *Handler) HandleGetPrivateDataByPartialCompositeKey(txID, ns string, config *pb.StaticCollectionConfig, objectType string, attributes []string) (commonledger.ResultsIterator, bool, error) {
+	startKey, endKey := compositekey.CreateRangeKeysForPartialCompositeKey(objectType, attributes)
+	return h.HandleGetPrivateDataRangeScanIterator(txID, ns, config, startKey, endKey)
+}
+
 func (h *Handler) getTransientData(txID, ns, coll, key string) ([]byte, error) {
 	ctxt, cancel := context.WithTimeout(context.Background(), config.GetTransientDataPullTimeout())
 	defer cancel()
@@ -191,6 +236,32 @@ func (h *Handler) executeQuery(txID, ns, coll string, query string) (commonledge
 	return newKVIterator(it), nil
 }
 
+func (h *Handler) executeQueryWithPagination(txID, ns, coll, query, bookmark string, pageSize int32) (commonledger.ResultsIterator, *pb.QueryResponseMetadata, error) {
+	ctxt, cancel := context.WithTimeout(context.Background(), config.GetOLCollPullTimeout())
+	defer cancel()
+
+	it, metadata, err := h.collDataProvider.RetrieverForChannel(h.channelID).QueryWithPagination(ctxt, storeapi.NewQueryKey(txID, ns, coll, query), bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newKVIterator(it), &pb.QueryResponseMetadata{
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+func (h *Handler) getDataByRange(txID, ns, coll, startKey, endKey string) (commonledger.ResultsIterator, error) {
+	ctxt, cancel := context.WithTimeout(context.Background(), config.GetOLCollPullTimeout())
+	defer cancel()
+
+	it, err := h.collDataProvider.RetrieverForChannel(h.channelID).GetDataByRange(ctxt, storeapi.NewRangeKey(txID, ns, coll, startKey, endKey))
+	if err != nil {
+		return nil, err
+	}
+	return newKVIterator(it), nil
+}
+
 type kvIterator struct {
 	it storeapi.ResultsIterator
 }
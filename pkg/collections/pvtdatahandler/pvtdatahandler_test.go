@@ -11,10 +11,12 @@ import (
 
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/compositekey"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
 )
 
@@ -229,6 +231,210 @@ func TestHandler_HandleExecuteQueryOnPrivateData(t *testing.T) {
 	})
 }
 
+func TestHandler_HandleExecuteQueryOnPrivateDataWithPagination(t *testing.T) {
+	const query = "some query"
+
+	v1 := []byte("v1")
+	v2 := []byte("v2")
+
+	olResults := []*storeapi.QueryResult{
+		{
+			Key:           storeapi.NewKey(tx1, ns1, coll1, key1),
+			ExpiringValue: &storeapi.ExpiringValue{Value: v1},
+		},
+		{
+			Key:           storeapi.NewKey(tx1, ns1, coll1, key2),
+			ExpiringValue: &storeapi.ExpiringValue{Value: v2},
+		},
+	}
+
+	dataProvider := mocks.NewDataProvider().
+		WithQueryResults(storeapi.NewQueryKey(tx1, ns1, coll1, query), olResults)
+
+	h := New(channelID, dataProvider)
+	require.NotNil(t, h)
+
+	t.Run("Unhandled collection", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{}
+		it, metadata, handled, err := h.HandleExecuteQueryOnPrivateDataWithPagination(tx1, ns1, config, query, "", 1)
+		require.NoError(t, err)
+		require.False(t, handled)
+		require.Nil(t, it)
+		require.Nil(t, metadata)
+	})
+
+	t.Run("Transient Data", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_TRANSIENT,
+			Name: coll1,
+		}
+
+		_, _, handled, err := h.HandleExecuteQueryOnPrivateDataWithPagination(tx1, ns1, config, query, "", 1)
+		require.Error(t, err)
+		require.True(t, handled)
+	})
+
+	t.Run("Off-ledger Data", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll1,
+		}
+
+		it, metadata, handled, err := h.HandleExecuteQueryOnPrivateDataWithPagination(tx1, ns1, config, query, "", 1)
+		require.NoError(t, err)
+		require.True(t, handled)
+		require.NotNil(t, it)
+		require.NotNil(t, metadata)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+		require.NotEmpty(t, metadata.Bookmark)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		kv, ok := next.(*queryresult.KV)
+		require.True(t, ok)
+		require.Equal(t, asPvtDataNs(ns1, coll1), kv.Namespace)
+		require.Equal(t, key1, kv.Key)
+		require.Equal(t, v1, kv.Value)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+
+		it, metadata, handled, err = h.HandleExecuteQueryOnPrivateDataWithPagination(tx1, ns1, config, query, metadata.Bookmark, 1)
+		require.NoError(t, err)
+		require.True(t, handled)
+		require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+
+		next, err = it.Next()
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		kv, ok = next.(*queryresult.KV)
+		require.True(t, ok)
+		require.Equal(t, key2, kv.Key)
+		require.Equal(t, v2, kv.Value)
+	})
+
+	t.Run("Retriever error", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_DCAS,
+			Name: coll2,
+		}
+
+		errExpected := errors.New("injected retriever error")
+		h := New(channelID, mocks.NewDataProvider().WithError(errExpected))
+
+		it, metadata, handled, err := h.HandleExecuteQueryOnPrivateDataWithPagination(tx1, ns1, config, query, "", 1)
+		require.EqualError(t, err, errExpected.Error())
+		require.True(t, handled)
+		require.Nil(t, it)
+		require.Nil(t, metadata)
+	})
+}
+
+func TestHandler_HandleGetPrivateDataRangeScanIterator(t *testing.T) {
+	v1 := []byte("v1")
+	v2 := []byte("v2")
+	v3 := []byte("v3")
+
+	ckey1 := compositekey.Create("type1", []string{"a", "1"})
+	ckey2 := compositekey.Create("type1", []string{"a", "2"})
+	ckey3 := compositekey.Create("type1", []string{"b", "1"})
+
+	dataProvider := mocks.NewDataProvider().
+		WithData(storeapi.NewKey(tx1, ns1, coll1, key2), &storeapi.ExpiringValue{Value: v2}).
+		WithData(storeapi.NewKey(tx1, ns1, coll1, key1), &storeapi.ExpiringValue{Value: v1}).
+		WithData(storeapi.NewKey(tx1, ns1, coll1, "key3"), &storeapi.ExpiringValue{Value: v3}).
+		WithData(storeapi.NewKey(tx1, ns1, coll2, ckey3), &storeapi.ExpiringValue{Value: v3}).
+		WithData(storeapi.NewKey(tx1, ns1, coll2, ckey2), &storeapi.ExpiringValue{Value: v2}).
+		WithData(storeapi.NewKey(tx1, ns1, coll2, ckey1), &storeapi.ExpiringValue{Value: v1})
+
+	h := New(channelID, dataProvider)
+	require.NotNil(t, h)
+
+	t.Run("Unhandled collection", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{}
+		it, handled, err := h.HandleGetPrivateDataRangeScanIterator(tx1, ns1, config, key1, "key3")
+		assert.NoError(t, err)
+		assert.False(t, handled)
+		assert.Nil(t, it)
+	})
+
+	t.Run("Transient Data", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_TRANSIENT,
+			Name: coll1,
+		}
+
+		_, handled, err := h.HandleGetPrivateDataRangeScanIterator(tx1, ns1, config, key1, "key3")
+		require.Error(t, err)
+		require.True(t, handled)
+	})
+
+	t.Run("Off-ledger Data", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll1,
+		}
+
+		it, handled, err := h.HandleGetPrivateDataRangeScanIterator(tx1, ns1, config, key1, "key3")
+		require.NoError(t, err)
+		require.True(t, handled)
+		require.NotNil(t, it)
+
+		requireNextKV(t, it, asPvtDataNs(ns1, coll1), key1, v1)
+		requireNextKV(t, it, asPvtDataNs(ns1, coll1), key2, v2)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("Partial composite key", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_OFFLEDGER,
+			Name: coll2,
+		}
+
+		it, handled, err := h.HandleGetPrivateDataByPartialCompositeKey(tx1, ns1, config, "type1", []string{"a"})
+		require.NoError(t, err)
+		require.True(t, handled)
+		require.NotNil(t, it)
+
+		requireNextKV(t, it, asPvtDataNs(ns1, coll2), ckey1, v1)
+		requireNextKV(t, it, asPvtDataNs(ns1, coll2), ckey2, v2)
+
+		next, err := it.Next()
+		require.NoError(t, err)
+		require.Nil(t, next)
+	})
+
+	t.Run("Retriever error", func(t *testing.T) {
+		config := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_DCAS,
+			Name: coll1,
+		}
+
+		errExpected := errors.New("injected retriever error")
+		h := New(channelID, mocks.NewDataProvider().WithError(errExpected))
+
+		_, handled, err := h.HandleGetPrivateDataRangeScanIterator(tx1, ns1, config, key1, "key3")
+		require.True(t, handled)
+		require.EqualError(t, err, errExpected.Error())
+	})
+}
+
+func requireNextKV(t *testing.T, it commonledger.ResultsIterator, ns, key string, value []byte) {
+	next, err := it.Next()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	kv, ok := next.(*queryresult.KV)
+	require.True(t, ok)
+	require.Equal(t, ns, kv.Namespace)
+	require.Equal(t, key, kv.Key)
+	require.Equal(t, value, kv.Value)
+}
+
 func testHandleGetPrivateData(t *testing.T, config *pb.StaticCollectionConfig) {
 	dataProvider := mocks.NewDataProvider()
 	h := New(channelID, dataProvider)
@@ -19,6 +19,7 @@ import (
 	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/api"
 	olretriever "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/retriever"
 	tdataapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
 )
 
 var logger = flogging.MustGetLogger("ext_retriever")
@@ -106,6 +107,16 @@ func (r *retriever) Query(ctxt context.Context, key *storeapi.QueryKey) (storeap
 	return r.offLedgerRetriever.Query(ctxt, key)
 }
 
+// QueryWithPagination executes the given rich query and returns a page of results starting at the given bookmark
+func (r *retriever) QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	return r.offLedgerRetriever.QueryWithPagination(ctxt, key, bookmark, pageSize)
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (r *retriever) GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	return r.offLedgerRetriever.GetDataByRange(ctxt, key)
+}
+
 // Support defines the supporting functions required by the transient data provider
 type Support interface {
 	Config(channelID, ns, coll string) (*pb.StaticCollectionConfig, error)
@@ -113,7 +124,11 @@ type Support interface {
 	BlockPublisher(channelID string) gossipapi.BlockPublisher
 }
 
+type appDataHandlerRegistry interface {
+	Register(dataType string, handler appdata.Handler) error
+}
+
 // NewOffLedgerProvider returns a new off-ledger retriever provider that supports DCAS
-func NewOffLedgerProvider(providers *collcommon.Providers) olapi.Provider {
-	return olretriever.NewProvider(providers)
+func NewOffLedgerProvider(providers *collcommon.Providers, handlerRegistry appDataHandlerRegistry) olapi.Provider {
+	return olretriever.NewProvider(providers, olretriever.WithAppDataHandlerRegistry(handlerRegistry))
 }
@@ -75,4 +75,14 @@ func TestRetriever(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, it)
 	})
+
+	t.Run("QueryWithPagination", func(t *testing.T) {
+		retriever := p.RetrieverForChannel(channelID)
+		require.NotNil(t, retriever)
+
+		it, metadata, err := retriever.QueryWithPagination(context.Background(), storeapi.NewQueryKey("tx1", "ns1", "coll1", "some query"), "", 10)
+		require.NoError(t, err)
+		require.NotNil(t, it)
+		require.NotNil(t, metadata)
+	})
 }
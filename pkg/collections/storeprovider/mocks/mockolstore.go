@@ -7,6 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
+	"sort"
+	"strconv"
+
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	proto "github.com/hyperledger/fabric-protos-go/transientstore"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
@@ -79,6 +82,8 @@ func (p *StoreProvider) IsStoreClosed() bool {
 // Store implements a mock store
 type Store struct {
 	data         map[storeapi.Key]*storeapi.ExpiringValue
+	revisions    map[storeapi.Key]string
+	tombstones   map[storeapi.Key]*storeapi.Tombstone
 	err          error
 	itErr        error
 	closed       bool
@@ -89,6 +94,8 @@ type Store struct {
 func NewStore() *Store {
 	return &Store{
 		data:         make(map[storeapi.Key]*storeapi.ExpiringValue),
+		revisions:    make(map[storeapi.Key]string),
+		tombstones:   make(map[storeapi.Key]*storeapi.Tombstone),
 		queryResults: make(map[storeapi.QueryKey][]*storeapi.QueryResult),
 	}
 }
@@ -128,15 +135,53 @@ func (m *Store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, va
 	if m.err != nil {
 		return m.err
 	}
-	m.data[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}] = value
+	k := storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}
+	m.data[k] = value
+	m.revisions[k] = key.EndorsedAtTxID
+	return nil
+}
+
+// PutDataIfLocalRevision stores the key/value if the given revision matches the revision of the stored value
+func (m *Store) PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error {
+	if m.err != nil {
+		return m.err
+	}
+	current := m.revisions[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}]
+	if current != revision {
+		return &storeapi.RevisionConflictError{Key: key, Expected: revision, Actual: current}
+	}
+	return m.PutData(config, key, value)
+}
+
+// DeleteData deletes the given keys
+func (m *Store) DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, k := range key.Keys {
+		delete(m.data, storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k})
+		delete(m.revisions, storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k})
+		m.tombstones[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k}] = &storeapi.Tombstone{TxID: key.EndorsedAtTxID}
+	}
 	return nil
 }
 
+// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted
+func (m *Store) GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error) {
+	return m.tombstones[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}], m.err
+}
+
 // GetData gets the value for the given item
 func (m *Store) GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error) {
 	return m.data[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}], m.err
 }
 
+// GetDataWithRevision gets the value for the given item along with the revision of the stored value
+func (m *Store) GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error) {
+	k := storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}
+	return m.data[k], m.revisions[k], m.err
+}
+
 // GetDataMultipleKeys gets the values for the multiple items in a single call
 func (m *Store) GetDataMultipleKeys(key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
 	var values storeapi.ExpiringValues
@@ -158,6 +203,82 @@ func (m *Store) Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, error)
 	return newResultsIterator(m.queryResults[*key], m.itErr), nil
 }
 
+// QueryWithPagination returns a page of the results of the given rich query
+func (m *Store) QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+
+	results, metadata, err := pageResults(m.queryResults[*key], bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newResultsIterator(results, m.itErr), metadata, nil
+}
+
+// pageResults returns the page of results starting at the offset given by the bookmark along with the
+// bookmark of the next page
+func pageResults(results []*storeapi.QueryResult, bookmark string, pageSize int32) ([]*storeapi.QueryResult, *storeapi.QueryResponseMetadata, error) {
+	start := 0
+	if bookmark != "" {
+		offset, err := strconv.Atoi(bookmark)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = offset
+	}
+
+	if start >= len(results) {
+		return nil, &storeapi.QueryResponseMetadata{}, nil
+	}
+
+	end := start + int(pageSize)
+	if end >= len(results) {
+		return results[start:], &storeapi.QueryResponseMetadata{FetchedRecordsCount: int32(len(results) - start)}, nil
+	}
+
+	return results[start:end], &storeapi.QueryResponseMetadata{FetchedRecordsCount: pageSize, Bookmark: strconv.Itoa(end)}, nil
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (m *Store) GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var results []*storeapi.QueryResult
+	for k, v := range m.data {
+		if k.Namespace == key.Namespace && k.Collection == key.Collection && inRange(k.Key, key.StartKey, key.EndKey) {
+			results = append(results, &storeapi.QueryResult{
+				Key:           storeapi.NewKey(key.EndorsedAtTxID, k.Namespace, k.Collection, k.Key),
+				ExpiringValue: v,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key.Key < results[j].Key.Key })
+
+	return newResultsIterator(results, m.itErr), nil
+}
+
+// CacheSize returns the number of keys of the given collection in the mock store
+func (m *Store) CacheSize(ns, coll string) int {
+	size := 0
+	for k := range m.data {
+		if k.Namespace == ns && k.Collection == coll {
+			size++
+		}
+	}
+
+	return size
+}
+
+// FlushCache returns the number of keys of the given collection in the mock store. The keys are not removed.
+func (m *Store) FlushCache(ns, coll string) int {
+	return m.CacheSize(ns, coll)
+}
+
 // Close closes the store
 func (m *Store) Close() {
 	m.closed = true
@@ -193,3 +314,7 @@ func (it *resultsIterator) Next() (*storeapi.QueryResult, error) {
 // Close releases resources occupied by the iterator
 func (it *resultsIterator) Close() {
 }
+
+func inRange(key, startKey, endKey string) bool {
+	return key >= startKey && (endKey == "" || key < endKey)
+}
@@ -20,6 +20,17 @@ type targetStores struct {
 	offLedgerStore     olapi.Store
 }
 
+// toucher is implemented by target stores that can extend the expiry time of transient data
+type toucher interface {
+	TouchTransientData(key *storeapi.MultiKey)
+}
+
+// cacheManager is implemented by target stores that cache collection data
+type cacheManager interface {
+	CacheSize(ns, coll string) int
+	FlushCache(ns, coll string) int
+}
+
 type store struct {
 	channelID string
 	targetStores
@@ -55,16 +66,43 @@ func (d *store) GetTransientDataMultipleKeys(key *storeapi.MultiKey) (storeapi.E
 	return d.transientDataStore.GetTransientDataMultipleKeys(key)
 }
 
+// TouchTransientData extends the expiry time of the given transient data keys
+func (d *store) TouchTransientData(key *storeapi.MultiKey) {
+	if t, ok := d.transientDataStore.(toucher); ok {
+		t.TouchTransientData(key)
+	}
+}
+
 // GetData gets the value for the given key
 func (d *store) GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error) {
 	return d.offLedgerStore.GetData(key)
 }
 
+// GetDataWithRevision gets the value for the given key along with the revision of the stored value
+func (d *store) GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error) {
+	return d.offLedgerStore.GetDataWithRevision(key)
+}
+
 // PutData stores the key/value.
 func (d *store) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue) error {
 	return d.offLedgerStore.PutData(config, key, value)
 }
 
+// PutDataIfLocalRevision stores the key/value only if the revision of the value in the local store matches the given revision
+func (d *store) PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error {
+	return d.offLedgerStore.PutDataIfLocalRevision(config, key, value, revision)
+}
+
+// DeleteData deletes the given keys from the off-ledger store.
+func (d *store) DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error {
+	return d.offLedgerStore.DeleteData(config, key)
+}
+
+// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted
+func (d *store) GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error) {
+	return d.offLedgerStore.GetTombstone(key)
+}
+
 // GetDataMultipleKeys gets the values for multiple keys in a single call
 func (d *store) GetDataMultipleKeys(key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
 	return d.offLedgerStore.GetDataMultipleKeys(key)
@@ -75,6 +113,52 @@ func (d *store) Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, error)
 	return d.offLedgerStore.Query(key)
 }
 
+// QueryWithPagination executes the given rich query against the off-ledger store and returns a page of results
+// starting at the given bookmark
+func (d *store) QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	return d.offLedgerStore.QueryWithPagination(key, bookmark, pageSize)
+}
+
+// GetDataByRange returns the data for the given range of keys from the off-ledger store
+func (d *store) GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	return d.offLedgerStore.GetDataByRange(key)
+}
+
+// CacheSize returns the number of keys of the given collection that are held in the caches of the target stores
+func (d *store) CacheSize(ns, coll string) int {
+	size := 0
+	for _, cm := range d.cacheManagers() {
+		size += cm.CacheSize(ns, coll)
+	}
+
+	return size
+}
+
+// FlushCache removes the keys of the given collection from the caches of the target stores and
+// returns the number of keys removed
+func (d *store) FlushCache(ns, coll string) int {
+	flushed := 0
+	for _, cm := range d.cacheManagers() {
+		flushed += cm.FlushCache(ns, coll)
+	}
+
+	return flushed
+}
+
+func (d *store) cacheManagers() []cacheManager {
+	var managers []cacheManager
+
+	if cm, ok := d.transientDataStore.(cacheManager); ok {
+		managers = append(managers, cm)
+	}
+
+	if cm, ok := d.offLedgerStore.(cacheManager); ok {
+		managers = append(managers, cm)
+	}
+
+	return managers
+}
+
 // Close closes all of the stores store
 func (d *store) Close() {
 	d.transientDataStore.Close()
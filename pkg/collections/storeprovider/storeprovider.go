@@ -11,12 +11,15 @@ import (
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
 	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
 	olstoreprovider "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider"
 	tdapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
 var logger = flogging.MustGetLogger("ext_store")
@@ -79,6 +82,43 @@ func (sp *StoreProvider) OpenStore(channelID string) (storeapi.Store, error) {
 	return store, nil
 }
 
+// CacheSize returns the number of keys of the given collection that are held in the caches of the given channel
+func (sp *StoreProvider) CacheSize(channelID, ns, coll string) (int, error) {
+	s, err := sp.getStore(channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.CacheSize(ns, coll), nil
+}
+
+// FlushCache removes the keys of the given collection from the caches of the given channel and
+// returns the number of keys removed
+func (sp *StoreProvider) FlushCache(channelID, ns, coll string) (int, error) {
+	s, err := sp.getStore(channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	flushed := s.FlushCache(ns, coll)
+
+	logger.Infof("[%s] Flushed [%d] keys of collection [%s:%s] from the cache", channelID, flushed, ns, coll)
+
+	return flushed, nil
+}
+
+func (sp *StoreProvider) getStore(channelID string) (*store, error) {
+	sp.RLock()
+	defer sp.RUnlock()
+
+	s, ok := sp.stores[channelID]
+	if !ok {
+		return nil, errors.Errorf("store not found for channel [%s]", channelID)
+	}
+
+	return s, nil
+}
+
 // Close shuts down all of the stores
 func (sp *StoreProvider) Close() {
 	for _, s := range sp.stores {
@@ -87,15 +127,32 @@ func (sp *StoreProvider) Close() {
 }
 
 // NewOffLedgerProvider creates a new off-ledger store provider that supports DCAS
-func NewOffLedgerProvider(identifierProvider collcommon.IdentifierProvider, idDProvider collcommon.IdentityDeserializerProvider, collConfigProvider collcommon.CollectionConfigProvider) olapi.StoreProvider {
+func NewOffLedgerProvider(identifierProvider collcommon.IdentifierProvider, idDProvider collcommon.IdentityDeserializerProvider, collConfigProvider collcommon.CollectionConfigProvider, metricsProvider metrics.Provider) olapi.StoreProvider {
 	logger.Infof("Creating off-ledger store provider with DCAS")
 
+	var olOpts []olstoreprovider.CollOption
+	dcasOpts := []olstoreprovider.CollOption{
+		olstoreprovider.WithDecorator(dcas.Decorator),
+		olstoreprovider.WithCacheEnabled(),
+	}
+
+	for _, collType := range config.GetOLCollEncryptedCollTypes() {
+		switch pb.CollectionType(pb.CollectionType_value[collType]) {
+		case pb.CollectionType_COL_OFFLEDGER:
+			logger.Infof("Encryption is enabled for collection type [%s]", collType)
+			olOpts = append(olOpts, olstoreprovider.WithEncryptionEnabled())
+		case pb.CollectionType_COL_DCAS:
+			logger.Infof("Encryption is enabled for collection type [%s]", collType)
+			dcasOpts = append(dcasOpts, olstoreprovider.WithEncryptionEnabled())
+		default:
+			logger.Warningf("Encryption is not supported for collection type [%s]", collType)
+		}
+	}
+
 	return olstoreprovider.New(
 		identifierProvider, idDProvider, collConfigProvider,
-		olstoreprovider.WithCollectionType(
-			pb.CollectionType_COL_DCAS,
-			olstoreprovider.WithDecorator(dcas.Decorator),
-			olstoreprovider.WithCacheEnabled(),
-		),
+		olstoreprovider.WithCollectionType(pb.CollectionType_COL_OFFLEDGER, olOpts...),
+		olstoreprovider.WithCollectionType(pb.CollectionType_COL_DCAS, dcasOpts...),
+		olstoreprovider.WithMetricsProvider(metricsProvider),
 	)
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +21,7 @@ import (
 
 func TestStoreProvider(t *testing.T) {
 	tdataProvider := spmocks.NewTransientDataStoreProvider()
-	olProvider := NewOffLedgerProvider(&mocks.IdentifierProvider{}, &mocks.IdentityDeserializerProvider{}, &mocks.CollectionConfigProvider{})
+	olProvider := NewOffLedgerProvider(&mocks.IdentifierProvider{}, &mocks.IdentityDeserializerProvider{}, &mocks.CollectionConfigProvider{}, &disabled.Provider{})
 
 	t.Run("OpenStore - success", func(t *testing.T) {
 		p := New().Initialize(tdataProvider, olProvider)
@@ -63,6 +64,43 @@ func TestStoreProvider(t *testing.T) {
 	})
 }
 
+func TestStoreProvider_Cache(t *testing.T) {
+	const (
+		tx1   = "tx1"
+		ns1   = "ns1"
+		coll1 = "coll1"
+		coll2 = "coll2"
+	)
+
+	olProvider := spmocks.NewOffLedgerStoreProvider().
+		Data(storeapi.NewKey(tx1, ns1, coll1, "key1"), &storeapi.ExpiringValue{Value: []byte("value1")}).
+		Data(storeapi.NewKey(tx1, ns1, coll1, "key2"), &storeapi.ExpiringValue{Value: []byte("value2")})
+
+	p := New().Initialize(spmocks.NewTransientDataStoreProvider(), olProvider)
+	require.NotNil(t, p)
+
+	_, err := p.CacheSize("testchannel", ns1, coll1)
+	require.EqualError(t, err, "store not found for channel [testchannel]")
+
+	_, err = p.FlushCache("testchannel", ns1, coll1)
+	require.EqualError(t, err, "store not found for channel [testchannel]")
+
+	_, err = p.OpenStore("testchannel")
+	require.NoError(t, err)
+
+	size, err := p.CacheSize("testchannel", ns1, coll1)
+	require.NoError(t, err)
+	require.Equal(t, 2, size)
+
+	size, err = p.CacheSize("testchannel", ns1, coll2)
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	flushed, err := p.FlushCache("testchannel", ns1, coll1)
+	require.NoError(t, err)
+	require.Equal(t, 2, flushed)
+}
+
 func TestStore_PutAndGetData(t *testing.T) {
 	const (
 		tx1   = "tx1"
@@ -119,6 +157,24 @@ func TestStore_PutAndGetData(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("PutDataIfLocalRevision", func(t *testing.T) {
+		collConfig := &pb.StaticCollectionConfig{
+			Type: pb.CollectionType_COL_DCAS,
+			Name: coll2,
+		}
+
+		value, rev, err := s.GetDataWithRevision(k3)
+		require.NoError(t, err)
+		require.Equal(t, v1, value)
+		require.Equal(t, tx1, rev)
+
+		require.NoError(t, s.PutDataIfLocalRevision(collConfig, storeapi.NewKey("tx2", ns1, coll2, key1), v2, rev))
+
+		err = s.PutDataIfLocalRevision(collConfig, storeapi.NewKey("tx3", ns1, coll2, key1), v1, rev)
+		require.Error(t, err)
+		require.True(t, storeapi.IsRevisionConflict(err))
+	})
+
 	t.Run("Persist", func(t *testing.T) {
 		err := s.Persist(tx1, mocks.NewPvtReadWriteSetBuilder().Build())
 		assert.NoError(t, err)
@@ -193,4 +249,15 @@ func TestStore_ExecuteQuery(t *testing.T) {
 	next, err = it.Next()
 	require.NoError(t, err)
 	require.Nil(t, next)
+
+	it, metadata, err := s.QueryWithPagination(storeapi.NewQueryKey(tx1, ns1, coll1, query), "", 1)
+	require.NoError(t, err)
+	require.NotNil(t, it)
+	require.Equal(t, int32(1), metadata.FetchedRecordsCount)
+	require.NotEmpty(t, metadata.Bookmark)
+
+	next, err = it.Next()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	require.Equal(t, key1, next.Key.Key)
 }
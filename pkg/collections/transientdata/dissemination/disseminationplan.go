@@ -80,9 +80,7 @@ func ComputeDisseminationPlan(
 func getKeysByEndorser(ns, coll string, kvRwSet *kvrwset.KVRWSet, disseminator *Disseminator) (map[string][]string, error) {
 	keysByEndorser := make(map[string][]string)
 	for _, kvWrite := range kvRwSet.Writes {
-		if kvWrite.IsDelete {
-			continue
-		}
+		// Deletes are disseminated to the same endorsers as puts so that the key is removed from each endorser
 		endorsersForKey, err := disseminator.ResolveEndorsers(kvWrite.Key)
 		if err != nil {
 			return nil, errors.WithMessage(err, "error resolving endorsers for transient data")
@@ -357,8 +357,7 @@ func TestComputeDisseminationPlan(t *testing.T) {
 
 		coll1Builder := mocks.NewPvtReadWriteSetCollectionBuilder(coll1)
 		coll1Builder.
-			Write(key1, []byte("value1")).
-			Delete(key2) // Deletes should be ignored
+			Write(key1, []byte("value1"))
 
 		rwSet := coll1Builder.Build()
 		pvtDataMsg, err := createPrivateDataMessage(channelID, tx1, ns1, rwSet, &pb.CollectionConfigPackage{}, 1000)
@@ -382,6 +381,34 @@ func TestComputeDisseminationPlan(t *testing.T) {
 		assert.True(t, eligibiltyMap[p3Org3.Endpoint])
 	})
 
+	t.Run("Deletes are disseminated to the endorsers of the key", func(t *testing.T) {
+		colAP := &mocks.MockAccessPolicy{
+			ReqPeerCount: 1,
+			MaxPeerCount: 2,
+			Orgs:         []string{org2MSPID, org3MSPID},
+		}
+
+		coll1Builder := mocks.NewPvtReadWriteSetCollectionBuilder(coll1)
+		coll1Builder.Delete(key2)
+
+		rwSet := coll1Builder.Build()
+		pvtDataMsg, err := createPrivateDataMessage(channelID, tx1, ns1, rwSet, &pb.CollectionConfigPackage{}, 1000)
+		require.NoError(t, err)
+
+		dPlan, handled, err := ComputeDisseminationPlan(channelID, ns1, rwSet, colAP, pvtDataMsg, gossip)
+		require.NoError(t, err)
+		require.True(t, handled)
+
+		endorsers, err := New(channelID, ns1, coll1, colAP, gossip).ResolveEndorsers(key2)
+		require.NoError(t, err)
+		require.Equal(t, len(endorsers), len(dPlan))
+
+		eligibiltyMap := getEligibilityMap(t, dPlan, allPeers)
+		for _, p := range allPeers {
+			assert.Equal(t, endorsers.ContainsPeer(p.Endpoint), eligibiltyMap[p.Endpoint])
+		}
+	})
+
 	t.Run("Orgs: 3, Max Peers: 3, Keys: 1", func(t *testing.T) {
 		maxPeers := 3
 
@@ -0,0 +1,134 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package retriever
+
+import (
+	"encoding/json"
+
+	gproto "github.com/hyperledger/fabric-protos-go/gossip"
+	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
+)
+
+// touchDataType is the application data type for requests to extend the expiry time of transient data
+const touchDataType = "transientdata-touch"
+
+type touchRequest struct {
+	Namespace  string   `json:"ns"`
+	Collection string   `json:"coll"`
+	Keys       []string `json:"keys"`
+}
+
+// toucher is implemented by stores that can extend the expiry time of transient data
+type toucher interface {
+	TouchTransientData(key *storeapi.MultiKey)
+}
+
+// handleTouchRequest extends the expiry time of the transient data keys requested by a remote peer. Touch
+// requests are fire-and-forget so no response is sent.
+func (p *Provider) handleTouchRequest(channelID string, req *gproto.AppDataRequest, responder appdata.Responder) {
+	request := &touchRequest{}
+	if err := json.Unmarshal(req.Request, request); err != nil {
+		logger.Warningf("[%s] Error unmarshalling transient data touch request: %s", channelID, err)
+		return
+	}
+
+	authorized, err := p.isRequesterAuthorized(channelID, requesterMSPID(responder), request.Namespace, request.Collection)
+	if err != nil {
+		logger.Warningf("[%s] Error authorizing transient data touch request for [%s:%s]: %s", channelID, request.Namespace, request.Collection, err)
+		return
+	}
+
+	if !authorized {
+		logger.Infof("[%s] Requester is not authorized to touch transient data in [%s:%s]", channelID, request.Namespace, request.Collection)
+		return
+	}
+
+	t, ok := p.StoreProvider.StoreForChannel(channelID).(toucher)
+	if !ok {
+		logger.Debugf("[%s] Store does not support touching transient data", channelID)
+		return
+	}
+
+	logger.Debugf("[%s] Touching %d transient data key(s) in [%s:%s] on request of a remote peer", channelID, len(request.Keys), request.Namespace, request.Collection)
+
+	t.TouchTransientData(storeapi.NewMultiKey("", request.Namespace, request.Collection, request.Keys...))
+}
+
+// isRequesterAuthorized returns true if the given MSP is a member of the collection
+func (p *Provider) isRequesterAuthorized(channelID, mspID, ns, coll string) (bool, error) {
+	if mspID == "" {
+		return false, nil
+	}
+
+	policy, err := p.CCProvider.ForChannel(channelID).Policy(ns, coll)
+	if err != nil {
+		return false, errors.WithMessagef(err, "unable to get policy for [%s:%s]", ns, coll)
+	}
+
+	_, ok := policy.MemberOrgs()[mspID]
+
+	return ok, nil
+}
+
+func requesterMSPID(responder appdata.Responder) string {
+	requester, ok := responder.(appdata.Requester)
+	if !ok {
+		return ""
+	}
+
+	return requester.RequesterMSPID()
+}
+
+// touchRemote sends a key-only touch request to the remote peers in the dissemination plan of each of the given
+// keys so that the expiry time of the remote copies is extended. The requests are fire-and-forget.
+func (r *retriever) touchRemote(res resolver, key *storeapi.MultiKey, indexes []int) {
+	groups := newEndorserGroups()
+
+	for _, i := range indexes {
+		endorsers, err := res.ResolveEndorsers(key.Keys[i])
+		if err != nil {
+			logger.Debugf("[%s] Unable to resolve endorsers to touch key [%s:%s:%s]: %s", r.channelID, key.Namespace, key.Collection, key.Keys[i], err)
+			continue
+		}
+
+		groups.add(endorsers.Remote(), i)
+	}
+
+	for _, e := range groups.groups {
+		keys := make([]string, len(e.indexes))
+		for i, idx := range e.indexes {
+			keys[i] = key.Keys[idx]
+		}
+
+		reqBytes, err := json.Marshal(&touchRequest{Namespace: key.Namespace, Collection: key.Collection, Keys: keys})
+		if err != nil {
+			logger.Errorf("[%s] Error marshalling transient data touch request: %s", r.channelID, err)
+			return
+		}
+
+		logger.Debugf("[%s] Touching %d transient data key(s) in [%s:%s] on [%s]", r.channelID, len(keys), key.Namespace, key.Collection, e.endorser)
+
+		r.gossipProvider.GetGossipService().Send(r.createTouchRequestMsg(reqBytes), asRemotePeers([]*discovery.Member{e.endorser})...)
+	}
+}
+
+func (r *retriever) createTouchRequestMsg(reqBytes []byte) *gproto.GossipMessage {
+	return &gproto.GossipMessage{
+		Tag:     gproto.GossipMessage_CHAN_ONLY,
+		Channel: []byte(r.channelID),
+		Content: &gproto.GossipMessage_AppDataReq{
+			AppDataReq: &gproto.AppDataRequest{
+				DataType: touchDataType,
+				Request:  reqBytes,
+			},
+		},
+	}
+}
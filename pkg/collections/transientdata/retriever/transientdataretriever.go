@@ -27,6 +27,8 @@ import (
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/multirequest"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
+	"github.com/trustbloc/fabric-peer-ext/pkg/config"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
 )
 
 var logger = flogging.MustGetLogger("transientdata")
@@ -36,11 +38,25 @@ type Provider struct {
 	*collcommon.Providers
 }
 
-// NewProvider returns a new transient data provider
-func NewProvider(providers *collcommon.Providers) tdataapi.Provider {
-	return &Provider{
+type appDataHandlerRegistry interface {
+	Register(dataType string, handler appdata.Handler) error
+}
+
+// NewProvider returns a new transient data provider. A handler for touch requests from remote peers is
+// registered with the given registry.
+func NewProvider(providers *collcommon.Providers, handlerRegistry appDataHandlerRegistry) tdataapi.Provider {
+	p := &Provider{
 		Providers: providers,
 	}
+
+	logger.Info("Registering transient data touch handler")
+
+	if err := handlerRegistry.Register(touchDataType, p.handleTouchRequest); err != nil {
+		// Should never happen
+		panic(err)
+	}
+
+	return p
 }
 
 // RetrieverForChannel returns the transient data dataRetriever for the given channel
@@ -53,6 +69,7 @@ func (p *Provider) RetrieverForChannel(channelID string) tdataapi.Retriever {
 		channelID:                 channelID,
 		reqMgr:                    requestmgr.Get(channelID),
 		resolvers:                 make(map[collKey]resolver),
+		touchOnRead:               config.GetTransientDataTouchOnRead(),
 	}
 
 	// Add a handler so that we can remove the resolver for a chaincode that has been upgraded
@@ -90,11 +107,57 @@ type retriever struct {
 	resolvers          map[collKey]resolver
 	lock               sync.RWMutex
 	reqMgr             requestmgr.RequestMgr
+	touchOnRead        bool
 }
 
 type resolveEndorsersFunc func(key *storeapi.Key, excludePeers discovery.PeerGroup) (discovery.PeerGroup, error)
 
+// endorserKeys contains the indexes of the keys that are to be requested from an endorser
+type endorserKeys struct {
+	endorser *discovery.Member
+	indexes  []int
+}
+
+// endorserGroups groups key indexes by endorser
+type endorserGroups struct {
+	groups     []*endorserKeys
+	byEndorser map[string]*endorserKeys
+}
+
+func newEndorserGroups() *endorserGroups {
+	return &endorserGroups{byEndorser: make(map[string]*endorserKeys)}
+}
+
+func (g *endorserGroups) add(endorsers discovery.PeerGroup, index int) {
+	for _, endorser := range endorsers {
+		group, ok := g.byEndorser[endorser.String()]
+		if !ok {
+			group = &endorserKeys{endorser: endorser}
+			g.byEndorser[endorser.String()] = group
+			g.groups = append(g.groups, group)
+		}
+
+		group.indexes = append(group.indexes, index)
+	}
+}
+
 func (r *retriever) GetTransientData(ctxt context.Context, key *storeapi.Key) (*storeapi.ExpiringValue, error) {
+	values, err := r.GetTransientDataMultipleKeys(ctxt, storeapi.NewMultiKey(key.EndorsedAtTxID, key.Namespace, key.Collection, key.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	return values[0], nil
+}
+
+// GetTransientDataMultipleKeys gets the values for the multiple transient data items in a single call. The endorsers
+// of all of the keys are resolved first and the keys are then grouped by endorser so that one request, containing
+// all of the endorser's keys, is sent to each endorser.
+func (r *retriever) GetTransientDataMultipleKeys(ctxt context.Context, key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
+	if len(key.Keys) == 0 {
+		return nil, errors.New("at least one key must be specified")
+	}
+
 	authorized, err := r.isAuthorized(key.Namespace, key.Collection)
 	if err != nil {
 		return nil, err
@@ -103,7 +166,7 @@ func (r *retriever) GetTransientData(ctxt context.Context, key *storeapi.Key) (*
 	if !authorized {
 		logger.Infof("[%s] This peer does not have access to the collection [%s:%s]", r.channelID, key.Namespace, key.Collection)
 
-		return nil, nil
+		return make(storeapi.ExpiringValues, len(key.Keys)), nil
 	}
 
 	res, err := r.getResolver(key.Namespace, key.Collection)
@@ -111,7 +174,41 @@ func (r *retriever) GetTransientData(ctxt context.Context, key *storeapi.Key) (*
 		return nil, errors.WithMessagef(err, "unable to get resolver for channel [%s] and [%s:%s]", r.channelID, key.Namespace, key.Collection)
 	}
 
-	resolvers := []resolveEndorsersFunc{
+	values := make(common.Values, len(key.Keys))
+	excludePeers := make([]discovery.PeerGroup, len(key.Keys))
+
+	for _, resolveEndorsers := range endorserResolvers(res) {
+		endorsers, localIndexes, err := r.groupMissingKeysByEndorser(key, values, excludePeers, resolveEndorsers)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.touchOnRead && len(localIndexes) > 0 {
+			r.touchRemote(res, key, localIndexes)
+		}
+
+		if !values.AllSet() && len(endorsers) > 0 {
+			values = values.Merge(r.getTransientDataFromRemote(ctxt, key, endorsers))
+		}
+
+		if values.AllSet() {
+			logger.Debugf("[%s] Got transient data for all keys in [%s]", r.channelID, key)
+
+			return asExpiringValues(values), nil
+		}
+
+		logger.Debugf("[%s] Transient data for one or more keys in [%s] not found. Attempting to retrieve from other peers...", r.channelID, key)
+	}
+
+	logger.Debugf("[%s] Transient data for one or more keys in [%s] not found on any peer", r.channelID, key)
+
+	return asExpiringValues(values), nil
+}
+
+// endorserResolvers returns the functions that resolve the endorsers from which transient data is retrieved, in
+// the order in which they should be attempted
+func endorserResolvers(res resolver) []resolveEndorsersFunc {
+	return []resolveEndorsersFunc{
 		// Returns the endorsers that (should) have the transient data (based on the hash of the key)
 		func(key *store.Key, _ discovery.PeerGroup) (discovery.PeerGroup, error) {
 			return res.ResolveEndorsers(key.Key)
@@ -128,103 +225,55 @@ func (r *retriever) GetTransientData(ctxt context.Context, key *storeapi.Key) (*
 			return res.ResolveAllEndorsers(excludePeers...)
 		},
 	}
+}
 
-	var excludePeers discovery.PeerGroup
-
-	for _, resolveEndorsers := range resolvers {
-		endorsers, err := resolveEndorsers(key, excludePeers)
-		if err != nil {
-			return nil, errors.WithMessagef(err, "unable to resolve endorsers for channel [%s] and [%s]", r.channelID, key)
-		}
-
-		logger.Debugf("[%s] Endorsers for [%s]: %s", r.channelID, key, endorsers)
+// groupMissingKeysByEndorser resolves the endorsers of each key for which a value hasn't been retrieved yet. If the
+// local peer is one of the endorsers then the value is loaded from the local store, otherwise the key is added to
+// the group of keys of each of the remote endorsers. The indexes of the keys that were found in the local store
+// are also returned so that the expiry time of the remote copies may be extended.
+func (r *retriever) groupMissingKeysByEndorser(key *storeapi.MultiKey, values common.Values, excludePeers []discovery.PeerGroup, resolveEndorsers resolveEndorsersFunc) ([]*endorserKeys, []int, error) {
+	fetchGroups := newEndorserGroups()
 
-		value, err := r.getTransientDataFromEndorsers(ctxt, key, endorsers)
-		if err != nil {
-			return nil, err
-		}
-
-		if value != nil {
-			logger.Debugf("[%s] Transient data for key [%s] was found on endorsers %s.", r.channelID, key, endorsers)
+	var localIndexes []int
 
-			return value, nil
+	for i, k := range key.Keys {
+		if !common.IsNil(values[i]) {
+			continue
 		}
 
-		logger.Debugf("[%s] Transient data for key [%s] not found on endorsers %s. Attempting to retrieve from other peers...", r.channelID, key, endorsers)
+		sKey := storeapi.NewKey(key.EndorsedAtTxID, key.Namespace, key.Collection, k)
 
-		excludePeers = append(excludePeers, endorsers...)
-	}
-
-	logger.Warnf("[%s] Transient data for key [%s] not found on any peer", r.channelID, key)
-
-	return nil, nil
-}
-
-func (r *retriever) getTransientDataFromEndorsers(ctxt context.Context, key *storeapi.Key, endorsers discovery.PeerGroup) (*storeapi.ExpiringValue, error) {
-	if endorsers.ContainsLocal() {
-		value, ok, err := r.getTransientDataFromLocal(key)
+		endorsers, err := resolveEndorsers(sKey, excludePeers[i])
 		if err != nil {
-			return nil, err
+			return nil, nil, errors.WithMessagef(err, "unable to resolve endorsers for channel [%s] and [%s]", r.channelID, sKey)
 		}
 
-		if ok {
-			logger.Debugf("[%s] Transient data for [%s] was found in the local store", r.channelID, key)
-			return value, nil
-		}
+		logger.Debugf("[%s] Endorsers for [%s]: %s", r.channelID, sKey, endorsers)
 
-		logger.Debugf("[%s] Did not find data in the local store for [%s]", r.channelID, key)
-	}
+		excludePeers[i] = append(excludePeers[i], endorsers...)
 
-	return r.getTransientDataFromRemote(ctxt, key, endorsers.Remote())
-}
-
-type valueResp struct {
-	value *storeapi.ExpiringValue
-	err   error
-}
-
-// GetTransientDataMultipleKeys gets the values for the multiple transient data items in a single call
-func (r *retriever) GetTransientDataMultipleKeys(ctxt context.Context, key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
-	if len(key.Keys) == 0 {
-		return nil, errors.New("at least one key must be specified")
-	}
+		if endorsers.ContainsLocal() {
+			value, ok, err := r.getTransientDataFromLocal(sKey)
+			if err != nil {
+				return nil, nil, err
+			}
 
-	var wg sync.WaitGroup
-	wg.Add(len(key.Keys))
-	var mutex sync.Mutex
+			if ok {
+				logger.Debugf("[%s] Transient data for [%s] was found in the local store", r.channelID, sKey)
 
-	// TODO: This can be optimized by sending one request to endorsers that have multiple keys, as opposed to one request per key.
-	responses := make(map[string]*valueResp)
-	for _, k := range key.Keys {
-		go func(key *storeapi.Key) {
-			cctxt, cancel := context.WithCancel(ctxt)
-			defer cancel()
-
-			value, err := r.GetTransientData(cctxt, key)
-			mutex.Lock()
-			responses[key.Key] = &valueResp{value: value, err: err}
-			logger.Debugf("Got response for [%s]: %s, Err: %s", key.Key, value, err)
-			mutex.Unlock()
-			wg.Done()
-		}(storeapi.NewKey(key.EndorsedAtTxID, key.Namespace, key.Collection, k))
-	}
+				values[i] = value
+				localIndexes = append(localIndexes, i)
 
-	wg.Wait()
+				continue
+			}
 
-	// Return the responses in the order of the requested keys
-	values := make(storeapi.ExpiringValues, len(key.Keys))
-	for i, k := range key.Keys {
-		r, ok := responses[k]
-		if !ok {
-			return nil, errors.Errorf("no response for key [%s:%s:%s]", key.Namespace, key.Collection, k)
-		}
-		if r.err != nil {
-			return nil, r.err
+			logger.Debugf("[%s] Did not find data in the local store for [%s]", r.channelID, sKey)
 		}
-		values[i] = r.value
+
+		fetchGroups.add(endorsers.Remote(), i)
 	}
 
-	return values, nil
+	return fetchGroups.groups, localIndexes, nil
 }
 
 func (r *retriever) getTransientDataFromLocal(key *storeapi.Key) (*storeapi.ExpiringValue, bool, error) {
@@ -243,50 +292,69 @@ func (r *retriever) getTransientDataFromLocal(key *storeapi.Key) (*storeapi.Expi
 	return nil, false, nil
 }
 
-func (r *retriever) getTransientDataFromRemote(ctxt context.Context, key *storeapi.Key, endorsers discovery.PeerGroup) (*storeapi.ExpiringValue, error) {
+// getTransientDataFromRemote sends one request to each of the given endorsers (containing the keys of the endorser)
+// and returns the merged values, in the order of the keys in the given multi-key
+func (r *retriever) getTransientDataFromRemote(ctxt context.Context, key *storeapi.MultiKey, endorsers []*endorserKeys) common.Values {
 	cReq := multirequest.New()
-	for _, endorser := range endorsers {
-		logger.Debugf("Adding request to get transient data for [%s] from [%s] ...", key, endorser)
-		cReq.Add(endorser.String(), r.getTransientDataRequest(key, endorser))
+	for _, e := range endorsers {
+		logger.Debugf("Adding request to get transient data for %d key(s) in [%s] from [%s] ...", len(e.indexes), key, e.endorser)
+		cReq.Add(e.endorser.String(), r.getTransientDataRequest(key, e))
 	}
 
 	response := cReq.Execute(ctxt)
 
 	if response.Values.IsEmpty() {
 		logger.Debugf("Got empty transient data response for [%s] ...", key)
-		return nil, nil
+		return nil
 	}
 
 	logger.Debugf("Got non-nil transient data response for [%s] ...", key)
-	return response.Values[0].(*storeapi.ExpiringValue), nil
+	return response.Values
 }
 
-func (r *retriever) getTransientDataRequest(key *storeapi.Key, endorser *discovery.Member) func(ctxt context.Context) (common.Values, error) {
+func (r *retriever) getTransientDataRequest(key *storeapi.MultiKey, e *endorserKeys) multirequest.Request {
 	return func(ctxt context.Context) (common.Values, error) {
-		return r.getTransientDataFromEndorser(ctxt, key, endorser)
+		keys := make([]string, len(e.indexes))
+		for i, idx := range e.indexes {
+			keys[i] = key.Keys[idx]
+		}
+
+		endorserKey := storeapi.NewMultiKey(key.EndorsedAtTxID, key.Namespace, key.Collection, keys...)
+
+		values, err := r.getTransientDataFromEndorser(ctxt, endorserKey, e.endorser)
+		if err != nil {
+			return nil, err
+		}
+
+		// Place the values at the position of the keys in the original request so that the values
+		// received from all of the endorsers may be merged
+		results := make(common.Values, len(key.Keys))
+		for i, idx := range e.indexes {
+			if values[i] != nil {
+				results[idx] = values[i]
+			}
+		}
+
+		return results, nil
 	}
 }
 
-func (r *retriever) getTransientDataFromEndorser(ctxt context.Context, key *storeapi.Key, endorser *discovery.Member) (common.Values, error) {
+func (r *retriever) getTransientDataFromEndorser(ctxt context.Context, key *storeapi.MultiKey, endorser *discovery.Member) (storeapi.ExpiringValues, error) {
 	logger.Debugf("Getting transient data for [%s] from [%s] ...", key, endorser)
 
-	value, err := r.getTransientData(ctxt, key, endorser)
+	values, err := r.getTransientData(ctxt, key, endorser)
 	if err != nil {
 		if err == context.Canceled {
 			logger.Debugf("[%s] Request to get transient data from [%s] for [%s] was cancelled", r.channelID, endorser, key)
 		} else {
 			logger.Debugf("[%s] Error getting transient data from [%s] for [%s]: %s", r.channelID, endorser, key, err)
 		}
-		return common.Values{nil}, err
+		return nil, err
 	}
 
-	if value == nil {
-		logger.Debugf("[%s] Transient data not found on [%s] for [%s]", r.channelID, endorser, key)
-	} else {
-		logger.Debugf("[%s] Got transient data from [%s] for [%s]", r.channelID, endorser, key)
-	}
+	logger.Debugf("[%s] Got transient data from [%s] for [%s]: %s", r.channelID, endorser, key, values)
 
-	return common.Values{value}, nil
+	return values, nil
 }
 
 func (r *retriever) getResolver(ns, coll string) (resolver, error) {
@@ -336,7 +404,7 @@ func (r *retriever) removeResolvers(ns string) {
 	}
 }
 
-func (r *retriever) getTransientData(ctxt context.Context, key *storeapi.Key, endorsers ...*discovery.Member) (*storeapi.ExpiringValue, error) {
+func (r *retriever) getTransientData(ctxt context.Context, key *storeapi.MultiKey, endorsers ...*discovery.Member) (storeapi.ExpiringValues, error) {
 	logger.Debugf("[%s] Sending Gossip request to %s for transient data for [%s]", r.channelID, endorsers, key)
 
 	req := r.reqMgr.NewRequest()
@@ -355,37 +423,40 @@ func (r *retriever) getTransientData(ctxt context.Context, key *storeapi.Key, en
 
 	logger.Debugf("[%s] Got response for %d for transient data for [%s]", r.channelID, req.ID(), key)
 
-	return r.findValue(requestmgr.AsElements(res.Data), key)
-}
+	elements := requestmgr.AsElements(res.Data)
+	values := make(storeapi.ExpiringValues, len(key.Keys))
+	for i, k := range key.Keys {
+		e, ok := elements.Get(key.Namespace, key.Collection, k)
+		if !ok {
+			return nil, errors.Errorf("the response does not contain a value for key [%s:%s:%s]", key.Namespace, key.Collection, k)
+		}
 
-func (r *retriever) findValue(data requestmgr.Elements, key *storeapi.Key) (*storeapi.ExpiringValue, error) {
-	for _, e := range data {
-		if e.Namespace == key.Namespace && e.Collection == key.Collection && e.Key == key.Key {
-			logger.Debugf("[%s] Got response for transient data for [%s]", r.channelID, key)
-			if e.Value == nil {
-				return nil, nil
-			}
-			return &storeapi.ExpiringValue{Value: e.Value, Expiry: e.Expiry}, nil
+		if e.Value != nil {
+			values[i] = &storeapi.ExpiringValue{Value: e.Value, Expiry: e.Expiry}
 		}
 	}
-	return nil, errors.Errorf("expecting a response to a transient data request for [%s] but got a response for another key", key)
+
+	return values, nil
 }
 
-func (r *retriever) createCollDataRequestMsg(req requestmgr.Request, key *storeapi.Key) *gproto.GossipMessage {
+func (r *retriever) createCollDataRequestMsg(req requestmgr.Request, key *storeapi.MultiKey) *gproto.GossipMessage {
+	var digests []*gproto.CollDataDigest
+	for _, k := range key.Keys {
+		digests = append(digests, &gproto.CollDataDigest{
+			Namespace:      key.Namespace,
+			Collection:     key.Collection,
+			Key:            k,
+			EndorsedAtTxID: key.EndorsedAtTxID,
+		})
+	}
+
 	return &gproto.GossipMessage{
 		Tag:     gproto.GossipMessage_CHAN_ONLY,
 		Channel: []byte(r.channelID),
 		Content: &gproto.GossipMessage_CollDataReq{
 			CollDataReq: &gproto.RemoteCollDataRequest{
-				Nonce: req.ID(),
-				Digests: []*gproto.CollDataDigest{
-					{
-						Namespace:      key.Namespace,
-						Collection:     key.Collection,
-						Key:            key.Key,
-						EndorsedAtTxID: key.EndorsedAtTxID,
-					},
-				},
+				Nonce:   req.ID(),
+				Digests: digests,
 			},
 		},
 	}
@@ -422,3 +493,13 @@ func asRemotePeers(members []*discovery.Member) []*comm.RemotePeer {
 	}
 	return peers
 }
+
+func asExpiringValues(cv common.Values) storeapi.ExpiringValues {
+	vals := make(storeapi.ExpiringValues, len(cv))
+	for i, v := range cv {
+		if !common.IsNil(v) {
+			vals[i] = v.(*storeapi.ExpiringValue)
+		}
+	}
+	return vals
+}
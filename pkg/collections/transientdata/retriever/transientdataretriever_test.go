@@ -8,6 +8,7 @@ package retriever
 
 import (
 	"context"
+	"encoding/json"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -23,6 +24,7 @@ import (
 
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/requestmgr"
+	"github.com/trustbloc/fabric-peer-ext/pkg/gossip/appdata"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
 	"github.com/trustbloc/fabric-peer-ext/pkg/roles"
 )
@@ -111,7 +113,7 @@ func TestTransientDataProvider(t *testing.T) {
 		CCProvider:             ccProvider,
 		IdentifierProvider:     identifierProvider,
 	}
-	p := NewProvider(providers)
+	p := NewProvider(providers, &mockAppDataHandlerRegistry{})
 
 	retriever := p.RetrieverForChannel(channelID)
 	require.NotNil(t, retriever)
@@ -283,7 +285,7 @@ func TestTransientDataProvider_AccessDenied(t *testing.T) {
 		CCProvider:             ccProvider,
 		IdentifierProvider:     identifierProvider,
 	}
-	p := NewProvider(providers)
+	p := NewProvider(providers, &mockAppDataHandlerRegistry{})
 
 	retriever := p.RetrieverForChannel(channelID)
 	require.NotNil(t, retriever)
@@ -357,7 +359,7 @@ func TestTransientDataFailover(t *testing.T) {
 		CCProvider:             ccProvider,
 		IdentifierProvider:     identifierProvider,
 	}
-	p := NewProvider(providers)
+	p := NewProvider(providers, &mockAppDataHandlerRegistry{})
 
 	retriever := p.RetrieverForChannel(channelID)
 	require.NotNil(t, retriever)
@@ -380,6 +382,233 @@ func TestTransientDataFailover(t *testing.T) {
 	})
 }
 
+func TestTransientDataProvider_GroupKeysByEndorser(t *testing.T) {
+	value1 := &storeapi.ExpiringValue{Value: []byte("value1")}
+	value2 := &storeapi.ExpiringValue{Value: []byte("value2")}
+	value3 := &storeapi.ExpiringValue{Value: []byte("value3")}
+
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			MaxPeerCount: 1,
+			Orgs:         []string{org1MSPID, org2MSPID},
+		})
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	identifierProvider := &mocks.IdentifierProvider{}
+	identifierProvider.GetIdentifierReturns(org1MSPID, nil)
+
+	gossip := mocks.NewMockGossipAdapter()
+	gossip.Self(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID, endorserRole)).IdentityInfo()
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(mocks.NewDataStore())
+
+	gossipProvider := &mocks.GossipProvider{}
+	gossipProvider.GetGossipServiceReturns(gossip)
+
+	p := NewProvider(&collcommon.Providers{
+		BlockPublisherProvider: mocks.NewBlockPublisherProvider(),
+		StoreProvider:          storeProvider,
+		GossipProvider:         gossipProvider,
+		CCProvider:             ccProvider,
+		IdentifierProvider:     identifierProvider,
+	}, &mockAppDataHandlerRegistry{})
+
+	retriever := p.RetrieverForChannel(channelID)
+	require.NotNil(t, retriever)
+
+	handler := newMockGossipMsgHandler(channelID).
+		Value(key1, value1).
+		Value(key2, value2).
+		Value(key3, value3)
+
+	var numMsgs, numDigests int32
+
+	gossip.MessageHandler(func(msg *gproto.GossipMessage) {
+		atomic.AddInt32(&numMsgs, 1)
+		atomic.AddInt32(&numDigests, int32(len(msg.GetCollDataReq().Digests)))
+		handler.Handle(msg)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+	defer cancel()
+
+	values, err := retriever.GetTransientDataMultipleKeys(ctx, storeapi.NewMultiKey(txID, ns1, coll1, key1, key2, key3))
+	require.NoError(t, err)
+	require.Equal(t, storeapi.ExpiringValues{value1, value2, value3}, values)
+
+	// There is only one remote endorser so each key should have been requested from it only once, and the
+	// keys should have been sent in at most one request per attempt (as opposed to one request per key)
+	require.Equal(t, int32(3), atomic.LoadInt32(&numDigests))
+	require.True(t, atomic.LoadInt32(&numMsgs) < 3)
+}
+
+func TestTransientDataProvider_TouchRemote(t *testing.T) {
+	value1 := &storeapi.ExpiringValue{Value: []byte("value1")}
+
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			MaxPeerCount: 2,
+			Orgs:         []string{org1MSPID, org2MSPID},
+		})
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	identifierProvider := &mocks.IdentifierProvider{}
+	identifierProvider.GetIdentifierReturns(org1MSPID, nil)
+
+	gossip := mocks.NewMockGossipAdapter()
+	gossip.Self(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID, endorserRole)).IdentityInfo()
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(mocks.NewDataStore().TransientData(storeapi.NewKey(txID, ns1, coll1, key1), value1))
+
+	gossipProvider := &mocks.GossipProvider{}
+	gossipProvider.GetGossipServiceReturns(gossip)
+
+	p := NewProvider(&collcommon.Providers{
+		BlockPublisherProvider: mocks.NewBlockPublisherProvider(),
+		StoreProvider:          storeProvider,
+		GossipProvider:         gossipProvider,
+		CCProvider:             ccProvider,
+		IdentifierProvider:     identifierProvider,
+	}, &mockAppDataHandlerRegistry{})
+
+	r, ok := p.RetrieverForChannel(channelID).(*retriever)
+	require.True(t, ok)
+
+	touchReqs := make(chan *gproto.AppDataRequest, 10)
+
+	gossip.MessageHandler(func(msg *gproto.GossipMessage) {
+		touchReqs <- msg.GetAppDataReq()
+	})
+
+	t.Run("Touch on read disabled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		value, err := r.GetTransientData(ctx, storeapi.NewKey(txID, ns1, coll1, key1))
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, touchReqs)
+	})
+
+	t.Run("Touch on read enabled", func(t *testing.T) {
+		r.touchOnRead = true
+		defer func() { r.touchOnRead = false }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), respTimeout)
+		defer cancel()
+
+		value, err := r.GetTransientData(ctx, storeapi.NewKey(txID, ns1, coll1, key1))
+		require.NoError(t, err)
+		require.Equal(t, value1, value)
+
+		// The value was found locally so only a key-only touch request should have been sent to the remote endorser
+		select {
+		case req := <-touchReqs:
+			require.NotNil(t, req)
+			require.Equal(t, touchDataType, req.DataType)
+
+			touchReq := &touchRequest{}
+			require.NoError(t, json.Unmarshal(req.Request, touchReq))
+			require.Equal(t, ns1, touchReq.Namespace)
+			require.Equal(t, coll1, touchReq.Collection)
+			require.Equal(t, []string{key1}, touchReq.Keys)
+		case <-time.After(respTimeout):
+			t.Fatal("timed out waiting for touch request")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, touchReqs)
+	})
+}
+
+func TestTransientDataProvider_HandleTouchRequest(t *testing.T) {
+	ccRetriever := mocks.NewCollectionConfigRetriever().
+		WithCollectionPolicy(&mocks.MockAccessPolicy{
+			MaxPeerCount: 2,
+			Orgs:         []string{org1MSPID, org2MSPID},
+		})
+	ccProvider := &mocks.CollectionConfigProvider{}
+	ccProvider.ForChannelReturns(ccRetriever)
+
+	store := mocks.NewDataStore()
+
+	storeProvider := &mocks.StoreProvider{}
+	storeProvider.StoreForChannelReturns(store)
+
+	registry := &mockAppDataHandlerRegistry{}
+
+	p := NewProvider(&collcommon.Providers{
+		StoreProvider: storeProvider,
+		CCProvider:    ccProvider,
+	}, registry).(*Provider)
+	require.NotNil(t, registry.handlers[touchDataType])
+
+	reqBytes, err := json.Marshal(&touchRequest{Namespace: ns1, Collection: coll1, Keys: []string{key1, key2}})
+	require.NoError(t, err)
+
+	req := &gproto.AppDataRequest{DataType: touchDataType, Request: reqBytes}
+
+	t.Run("Non-member -> ignored", func(t *testing.T) {
+		p.handleTouchRequest(channelID, req, &mockTouchResponder{mspID: org3MSPID})
+		require.Empty(t, store.Touched())
+	})
+
+	t.Run("Unknown requester -> ignored", func(t *testing.T) {
+		p.handleTouchRequest(channelID, req, &mockTouchResponder{})
+		require.Empty(t, store.Touched())
+	})
+
+	t.Run("Invalid request -> ignored", func(t *testing.T) {
+		p.handleTouchRequest(channelID, &gproto.AppDataRequest{DataType: touchDataType, Request: []byte("{")}, &mockTouchResponder{mspID: org2MSPID})
+		require.Empty(t, store.Touched())
+	})
+
+	t.Run("Member -> touched", func(t *testing.T) {
+		responder := &mockTouchResponder{mspID: org2MSPID}
+		p.handleTouchRequest(channelID, req, responder)
+		require.Equal(t, []storeapi.Key{
+			{Namespace: ns1, Collection: coll1, Key: key1},
+			{Namespace: ns1, Collection: coll1, Key: key2},
+		}, store.Touched())
+		require.False(t, responder.responded)
+	})
+}
+
+type mockTouchResponder struct {
+	mspID     string
+	responded bool
+}
+
+func (m *mockTouchResponder) Respond([]byte) {
+	m.responded = true
+}
+
+func (m *mockTouchResponder) RequesterMSPID() string {
+	return m.mspID
+}
+
+type mockAppDataHandlerRegistry struct {
+	handlers map[string]appdata.Handler
+}
+
+func (m *mockAppDataHandlerRegistry) Register(dataType string, handler appdata.Handler) error {
+	if m.handlers == nil {
+		m.handlers = make(map[string]appdata.Handler)
+	}
+
+	m.handlers[dataType] = handler
+
+	return nil
+}
+
 type mockGossipMsgHandler struct {
 	channelID    string
 	values       map[string]*storeapi.ExpiringValue
@@ -27,4 +27,34 @@ type Value struct {
 	Value      []byte
 	TxID       string
 	ExpiryTime time.Time
+	// TTL is the time-to-live of the collection at the time the value was stored. It is used
+	// to extend the expiry time of the value when the value is touched.
+	TTL time.Duration
+}
+
+// DB persists transient data
+type DB interface {
+	// AddKey stores the given value for the given key
+	AddKey(key Key, value *Value) error
+
+	// GetKey returns the value for the given key or nil if the key doesn't exist
+	GetKey(key Key) (*Value, error)
+
+	// DeleteKey deletes the given key
+	DeleteKey(key Key) error
+
+	// DeleteExpiredKeys deletes all of the expired keys
+	DeleteExpiredKeys() error
+
+	// Close closes the DB
+	Close()
+}
+
+// DBProvider returns the transient data DB for a given channel
+type DBProvider interface {
+	// OpenDBStore returns the DB for the given channel
+	OpenDBStore(channelID string) (DB, error)
+
+	// Close closes the DB provider
+	Close()
 }
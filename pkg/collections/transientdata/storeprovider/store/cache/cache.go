@@ -12,7 +12,9 @@ import (
 
 	"github.com/bluele/gcache"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/common/cachestats"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
@@ -26,33 +28,35 @@ type Cache struct {
 	ticker        *time.Ticker
 	dbstore       transientDB
 	alwaysPersist bool
+	stats         *cachestats.Stats
+	// keyLocks serializes puts, deletes and touches of the same key so that a touch can't resurrect a deleted key
+	keyLocks keyLocks
 }
 
 // transientDB - an interface for persisting and retrieving keys
 type transientDB interface {
 	AddKey(api.Key, *api.Value) error
+	DeleteKey(key api.Key) error
 	DeleteExpiredKeys() error
 	GetKey(key api.Key) (*api.Value, error)
 }
 
 // New return a new in-memory key-value cache
-func New(channelID string, size int, alwaysPersist bool, dbstore transientDB) *Cache {
+func New(channelID string, size int, alwaysPersist bool, dbstore transientDB, metricsProvider metrics.Provider) *Cache {
 	c := &Cache{
 		channelID:     channelID,
 		ticker:        time.NewTicker(config.GetTransientDataExpiredIntervalTime()),
 		dbstore:       dbstore,
 		alwaysPersist: alwaysPersist,
+		stats:         cachestats.New(metricsProvider, "transientdata", channelID),
 	}
 
-	cb := gcache.New(size).
+	c.cache = gcache.New(size).
 		LoaderExpireFunc(c.loadFromDB).
-		ARC()
-
-	if !alwaysPersist {
-		cb.EvictedFunc(c.evict)
-	}
-
-	c.cache = cb.Build()
+		AddedFunc(c.added).
+		EvictedFunc(c.evict).
+		ARC().
+		Build()
 
 	// cleanup expired data in db
 	go c.periodicPurge()
@@ -63,6 +67,7 @@ func New(channelID string, size int, alwaysPersist bool, dbstore transientDB) *C
 // Close closes the cache
 func (c *Cache) Close() {
 	c.cache.Purge()
+	c.stats.Clear()
 	c.ticker.Stop()
 }
 
@@ -73,6 +78,8 @@ func (c *Cache) Put(key api.Key, value []byte, txID string) {
 		TxID:  txID,
 	}
 
+	defer c.keyLocks.lock(key)()
+
 	if err := c.cache.Set(key, v); err != nil {
 		panic("Set must never return an error")
 	}
@@ -88,8 +95,11 @@ func (c *Cache) PutWithExpire(key api.Key, value []byte, txID string, expiry tim
 		Value:      value,
 		TxID:       txID,
 		ExpiryTime: time.Now().UTC().Add(expiry),
+		TTL:        expiry,
 	}
 
+	defer c.keyLocks.lock(key)()
+
 	if err := c.cache.SetWithExpire(key, v, expiry); err != nil {
 		panic("Set must never return an error")
 	}
@@ -99,8 +109,60 @@ func (c *Cache) PutWithExpire(key api.Key, value []byte, txID string, expiry tim
 	}
 }
 
+// Delete removes the given key from the cache and from the database
+func (c *Cache) Delete(key api.Key) {
+	defer c.keyLocks.lock(key)()
+
+	// Note that removing the key from the cache may cause the key to be evicted to the
+	// database so the key must be removed from the cache before it's removed from the database
+	c.cache.Remove(key)
+
+	if err := c.dbstore.DeleteKey(key); err != nil {
+		logger.Errorf("[%s] Key [%s] could not be deleted from DB: %s", c.channelID, key, err)
+	} else {
+		logger.Debugf("[%s] Key [%s] deleted", c.channelID, key)
+	}
+}
+
+// Touch extends the expiry time of the given key by the time-to-live with which the value was stored and
+// returns the updated value. Nil is returned if the key doesn't exist or if the value doesn't expire.
+// The key is locked while it's touched so that a concurrent delete isn't undone by the touch.
+func (c *Cache) Touch(key api.Key) *api.Value {
+	defer c.keyLocks.lock(key)()
+
+	current := c.Get(key)
+	if current == nil || current.TTL <= 0 {
+		return nil
+	}
+
+	v := &api.Value{
+		Value:      current.Value,
+		TxID:       current.TxID,
+		ExpiryTime: time.Now().UTC().Add(current.TTL),
+		TTL:        current.TTL,
+	}
+
+	if err := c.cache.SetWithExpire(key, v, current.TTL); err != nil {
+		panic("Set must never return an error")
+	}
+
+	if c.alwaysPersist {
+		c.persist(key, v)
+	}
+
+	logger.Debugf("[%s] Expiry time of key [%s] extended to [%s]", c.channelID, key, v.ExpiryTime)
+
+	return v
+}
+
 // Get returns the transient value for the given key
 func (c *Cache) Get(key api.Key) *api.Value {
+	if c.cache.Has(key) {
+		c.stats.Hit(key.Namespace, key.Collection)
+	} else {
+		c.stats.Miss(key.Namespace, key.Collection)
+	}
+
 	value, err := c.cache.Get(key)
 	if err != nil {
 		if err != gcache.KeyNotFoundError {
@@ -112,6 +174,29 @@ func (c *Cache) Get(key api.Key) *api.Value {
 	return value.(*api.Value)
 }
 
+// Size returns the number of keys in the cache for the given collection
+func (c *Cache) Size(ns, coll string) int {
+	return c.stats.Size(ns, coll)
+}
+
+// Flush removes all keys of the given collection from the cache and returns the number of keys removed.
+// Unless the cache is configured to always persist, the removed keys are persisted to the database
+// so that they may be reloaded into the cache on the next read.
+func (c *Cache) Flush(ns, coll string) int {
+	keys := c.stats.Remove(ns, coll)
+	for _, key := range keys {
+		c.cache.Remove(api.Key{
+			Namespace:  ns,
+			Collection: coll,
+			Key:        key,
+		})
+	}
+
+	logger.Debugf("[%s] Flushed [%d] keys of collection [%s:%s] from the cache", c.channelID, len(keys), ns, coll)
+
+	return len(keys)
+}
+
 func (c *Cache) loadFromDB(key interface{}) (interface{}, *time.Duration, error) {
 	logger.Debugf("[%s] Loading key from database: %s", c.channelID, key)
 
@@ -132,11 +217,24 @@ func (c *Cache) loadFromDB(key interface{}) (interface{}, *time.Duration, error)
 	return value, &diff, nil
 }
 
-func (c *Cache) evict(key, value interface{}) {
+func (c *Cache) added(k, _ interface{}) {
+	key := k.(api.Key)
+	c.stats.Added(key.Namespace, key.Collection, key.Key)
+}
+
+func (c *Cache) evict(k, value interface{}) {
+	key := k.(api.Key)
+	c.stats.Evicted(key.Namespace, key.Collection, key.Key)
+
+	if c.alwaysPersist {
+		// The key has already been persisted
+		return
+	}
+
 	if value != nil {
 		logger.Debugf("[%s] Evicting key to database: %s", c.channelID, key)
 
-		c.persist(key.(api.Key), value.(*api.Value))
+		c.persist(key, value.(*api.Value))
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/pkg/errors"
 	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/require"
@@ -76,7 +77,7 @@ func TestCleanupExpiredTransientDataFromDB(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, db)
 
-	cache := New(channel1, 1, false, db)
+	cache := New(channel1, 1, false, db, &disabled.Provider{})
 	require.NotNil(t, cache)
 
 	cache.PutWithExpire(k1, v1, txID1, 2*time.Second)
@@ -125,7 +126,7 @@ func TestRetrieveTransientDataFromDB(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, db)
 
-		cache := New(channel1, 1, false, db)
+		cache := New(channel1, 1, false, db, &disabled.Provider{})
 		require.NotNil(t, cache)
 		v := cache.Get(k1)
 		require.Nil(t, v)
@@ -159,7 +160,7 @@ func TestRetrieveTransientDataFromDB(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, db)
 
-		cache := New(channel1, 1, false, db)
+		cache := New(channel1, 1, false, db, &disabled.Provider{})
 		require.NotNil(t, cache)
 		v := cache.Get(k1)
 		require.Nil(t, v)
@@ -189,7 +190,7 @@ func TestRetrieveTransientDataFromDB(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, db)
 
-		cache := New(channel1, 1, false, db)
+		cache := New(channel1, 1, false, db, &disabled.Provider{})
 		require.NotNil(t, cache)
 		v := cache.Get(k1)
 		require.Nil(t, v)
@@ -227,7 +228,7 @@ func TestTransientDataCache(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, db)
 
-	cache := New(channel1, 1, false, db)
+	cache := New(channel1, 1, false, db, &disabled.Provider{})
 	require.NotNil(t, cache)
 	defer cache.Close()
 
@@ -266,6 +267,128 @@ func TestTransientDataCache(t *testing.T) {
 	})
 }
 
+func TestTransientDataCache_DeleteAndTouch(t *testing.T) {
+	defer removeDBPath(t)
+
+	p, err := dbstore.NewDBProvider()
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	defer p.Close()
+
+	db, err := p.OpenDBStore("testchannel")
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	cache := New(channel1, 1, false, db, &disabled.Provider{})
+	require.NotNil(t, cache)
+	defer cache.Close()
+
+	t.Run("Delete", func(t *testing.T) {
+		cache.PutWithExpire(k1, v1, txID1, time.Minute)
+		// Adding k2 causes k1 to be evicted to the DB
+		cache.PutWithExpire(k2, v2, txID1, time.Minute)
+
+		cache.Delete(k1)
+		cache.Delete(k2)
+
+		require.Nil(t, cache.Get(k1))
+		require.Nil(t, cache.Get(k2))
+
+		v, err := db.GetKey(k1)
+		require.NoError(t, err)
+		require.Nil(t, v)
+
+		v, err = db.GetKey(k2)
+		require.NoError(t, err)
+		require.Nil(t, v)
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		cache.PutWithExpire(k3, v1, txID1, 100*time.Millisecond)
+		cache.Put(k4, v2, txID1)
+
+		time.Sleep(60 * time.Millisecond)
+
+		v := cache.Touch(k3)
+		require.NotNil(t, v)
+		require.Equal(t, v1, v.Value)
+		require.True(t, v.ExpiryTime.After(time.Now().UTC().Add(50*time.Millisecond)))
+
+		time.Sleep(60 * time.Millisecond)
+
+		v = cache.Get(k3)
+		require.NotNilf(t, v, "expecting the expiry time to have been extended")
+		require.Equal(t, v1, v.Value)
+
+		require.Nilf(t, cache.Touch(k4), "expecting a value with no expiry to not be touched")
+		require.Nil(t, cache.Touch(api.Key{Namespace: ns1, Collection: coll1, Key: "xxx"}))
+	})
+
+	t.Run("Concurrent touch and delete", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			cache.PutWithExpire(k1, v1, txID1, time.Minute)
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+
+			go func() {
+				defer wg.Done()
+				cache.Touch(k1)
+			}()
+
+			go func() {
+				defer wg.Done()
+				cache.Delete(k1)
+			}()
+
+			wg.Wait()
+
+			require.Nilf(t, cache.Get(k1), "expecting the deleted key to not be resurrected by the touch")
+
+			v, err := db.GetKey(k1)
+			require.NoError(t, err)
+			require.Nil(t, v)
+		}
+	})
+}
+
+func TestTransientDataCache_SizeAndFlush(t *testing.T) {
+	defer removeDBPath(t)
+
+	p, err := dbstore.NewDBProvider()
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	defer p.Close()
+
+	db, err := p.OpenDBStore("testchannel")
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	cache := New(channel1, 10, false, db, &disabled.Provider{})
+	require.NotNil(t, cache)
+	defer cache.Close()
+
+	cache.Put(k3, v1, txID1)
+	cache.Put(k4, v2, txID1)
+	cache.Put(k1, v1, txID1)
+	require.Equal(t, 2, cache.Size(ns1, coll2))
+	require.Equal(t, 1, cache.Size(ns1, coll1))
+
+	require.Equal(t, 2, cache.Flush(ns1, coll2))
+	require.Zero(t, cache.Size(ns1, coll2))
+	require.Equal(t, 1, cache.Size(ns1, coll1))
+
+	// The flushed keys should have been persisted to the DB
+	v, err := db.GetKey(k3)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+
+	v = cache.Get(k4)
+	require.NotNil(t, v)
+	require.Equal(t, v2, v.Value)
+	require.Equal(t, 1, cache.Size(ns1, coll2))
+}
+
 func TestTransientDataCacheConcurrency(t *testing.T) {
 	defer removeDBPath(t)
 	p, err := dbstore.NewDBProvider()
@@ -277,7 +400,7 @@ func TestTransientDataCacheConcurrency(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, db)
 
-	cache := New(channel1, 1, false, db)
+	cache := New(channel1, 1, false, db, &disabled.Provider{})
 	require.NotNil(t, cache)
 	defer cache.Close()
 
@@ -314,7 +437,7 @@ func TestTransientDataCacheConcurrency(t *testing.T) {
 func Test_Error(t *testing.T) {
 	errExpected := errors.New("db error")
 	db := newMockDB().WithError(errExpected)
-	c := New(channel1, 100, false, db)
+	c := New(channel1, 100, false, db, &disabled.Provider{})
 
 	v := c.Get(k1)
 	require.Nil(t, v)
@@ -334,7 +457,7 @@ func TestTransientDataAlwaysPersist(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, db)
 
-	cache := New(channel1, 1, true, db)
+	cache := New(channel1, 1, true, db, &disabled.Provider{})
 	require.NotNil(t, cache)
 	defer cache.Close()
 
@@ -411,6 +534,13 @@ func (m *mockDB) AddKey(api.Key, *api.Value) error {
 	panic("not implemented")
 }
 
+func (m *mockDB) DeleteKey(api.Key) error {
+	if m.err != nil {
+		return m.err
+	}
+	panic("not implemented")
+}
+
 func (m *mockDB) DeleteExpiredKeys() error {
 	if m.err != nil {
 		return m.err
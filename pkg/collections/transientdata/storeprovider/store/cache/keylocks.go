@@ -0,0 +1,43 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+const numKeyLockStripes = 256
+
+// keyLocks serializes updates to the same key while allowing updates to different keys to proceed concurrently.
+// A key is mapped to one of a fixed number of mutexes (stripes) so unrelated keys may occasionally share a stripe.
+type keyLocks struct {
+	stripes [numKeyLockStripes]sync.Mutex
+}
+
+// lock locks the stripe of the given key and returns a function that unlocks it
+func (l *keyLocks) lock(key api.Key) func() {
+	m := &l.stripes[stripeIndex(key)]
+	m.Lock()
+
+	return m.Unlock
+}
+
+func stripeIndex(key api.Key) uint32 {
+	h := fnv.New32a()
+
+	// Writes to a hash never return an error
+	_, _ = h.Write([]byte(key.Namespace))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key.Collection))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key.Key))
+
+	return h.Sum32() % numKeyLockStripes
+}
@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package couchdbstore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
+	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+var logger = flogging.MustGetLogger("transientdata")
+
+// dbstore stores transient data in an off-ledger CouchDB database. The transient value (including its
+// time-to-live) is encoded into the document and the expiry time is also set on the document so that
+// expired documents are purged by the database.
+type dbstore struct {
+	channelID string
+	db        olapi.DB
+}
+
+func newDBStore(channelID string, db olapi.DB) *dbstore {
+	return &dbstore{
+		channelID: channelID,
+		db:        db,
+	}
+}
+
+// AddKey stores the given value for the given key
+func (s *dbstore) AddKey(key api.Key, value *api.Value) error {
+	encodedVal, err := encodeVal(value)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to encode transientdata value for key %s", key)
+	}
+
+	err = s.db.Put(olapi.NewKeyValue(encodeKey(key), encodedVal, value.TxID, value.ExpiryTime))
+	if err != nil {
+		return errors.WithMessagef(err, "failed to save transientdata key %s in db", key)
+	}
+
+	return nil
+}
+
+// GetKey returns the value for the given key or nil if the key doesn't exist
+func (s *dbstore) GetKey(key api.Key) (*api.Value, error) {
+	logger.Debugf("[%s] load transientdata key %s from db", s.channelID, key)
+
+	v, err := s.db.Get(encodeKey(key))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load transientdata key %s from db", key)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	value, err := decodeVal(v.Value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to decode transientdata value for key %s", key)
+	}
+
+	return value, nil
+}
+
+// DeleteKey deletes the given key
+func (s *dbstore) DeleteKey(key api.Key) error {
+	if err := s.db.Delete(encodeKey(key)); err != nil {
+		return errors.WithMessagef(err, "failed to delete transientdata key %s from db", key)
+	}
+
+	return nil
+}
+
+// DeleteExpiredKeys deletes all of the expired keys
+func (s *dbstore) DeleteExpiredKeys() error {
+	return s.db.DeleteExpiredKeys()
+}
+
+// Close does nothing since the database is closed by the provider
+func (s *dbstore) Close() {
+}
+
+func encodeKey(key api.Key) string {
+	return fmt.Sprintf("%s!%s!%s", key.Namespace, key.Collection, key.Key)
+}
+
+func encodeVal(v *api.Value) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeVal(b []byte) (*api.Value, error) {
+	var v *api.Value
+	if err := gob.NewDecoder(bytes.NewBuffer(b)).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package couchdbstore
+
+import (
+	"github.com/pkg/errors"
+	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+const (
+	// The transient data for a channel is stored in a single database whose name is derived from the channel ID
+	// along with the following namespace and collection. The namespace contains parentheses, which are not allowed
+	// in chaincode names, so that the database can't collide with the database of an off-ledger collection.
+	transientDataNS   = "(transientdata)"
+	transientDataColl = "data"
+)
+
+// CouchDBProvider provides transient data DBs which are backed by CouchDB. Since the data is held in CouchDB,
+// the data may be shared by multiple peers and it survives a restart of the peer.
+type CouchDBProvider struct {
+	dbProvider olapi.DBProvider
+}
+
+// NewDBProvider returns a new CouchDB provider which creates the transient data DBs using the given off-ledger
+// DB provider. The off-ledger provider's CouchDB instance and periodic purge of expired data are therefore shared.
+func NewDBProvider(dbProvider olapi.DBProvider) *CouchDBProvider {
+	logger.Debugf("constructing CouchDB DBProvider")
+
+	return &CouchDBProvider{dbProvider: dbProvider}
+}
+
+// OpenDBStore returns the DB for the given channel
+func (p *CouchDBProvider) OpenDBStore(channelID string) (api.DB, error) {
+	db, err := p.dbProvider.GetDB(channelID, transientDataColl, transientDataNS)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to open transient data DB for channel [%s]", channelID)
+	}
+
+	if db == nil {
+		return nil, errors.Errorf("transient data DB could not be created for channel [%s]", channelID)
+	}
+
+	return newDBStore(channelID, db), nil
+}
+
+// Close does nothing since the off-ledger DB provider is closed by the off-ledger store provider
+func (p *CouchDBProvider) Close() {
+}
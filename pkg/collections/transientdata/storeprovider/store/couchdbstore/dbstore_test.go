@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package couchdbstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	olapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+const channel1 = "channel1"
+
+var (
+	k1 = api.Key{Namespace: "ns1", Collection: "coll1", Key: "key1"}
+	k2 = api.Key{Namespace: "ns1", Collection: "coll1", Key: "key2"}
+)
+
+func TestDBStore(t *testing.T) {
+	olProvider := mocks.NewDBProvider()
+
+	p := NewDBProvider(olProvider)
+	defer p.Close()
+
+	db, err := p.OpenDBStore(channel1)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+	defer db.Close()
+
+	v1 := &api.Value{
+		Value:      []byte("value1"),
+		TxID:       "tx1",
+		ExpiryTime: time.Now().UTC().Add(time.Minute),
+		TTL:        time.Minute,
+	}
+
+	require.NoError(t, db.AddKey(k1, v1))
+
+	// The expiry time is also set on the stored document so that the document is purged by the database
+	olValue, err := olProvider.MockDB(transientDataNS, transientDataColl).Get("ns1!coll1!key1")
+	require.NoError(t, err)
+	require.NotNil(t, olValue)
+	require.Equal(t, v1.TxID, olValue.TxID)
+	require.Equal(t, v1.ExpiryTime, olValue.ExpiryTime)
+
+	v, err := db.GetKey(k1)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, v1.Value, v.Value)
+	require.Equal(t, v1.TxID, v.TxID)
+	require.True(t, v1.ExpiryTime.Equal(v.ExpiryTime))
+	require.Equal(t, v1.TTL, v.TTL)
+
+	v, err = db.GetKey(k2)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	require.NoError(t, db.DeleteExpiredKeys())
+	require.NoError(t, db.DeleteKey(k1))
+
+	v, err = db.GetKey(k1)
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
+
+func TestDBStoreErrors(t *testing.T) {
+	t.Run("Provider error", func(t *testing.T) {
+		errExpected := errors.New("injected provider error")
+
+		p := NewDBProvider(mocks.NewDBProvider().WithError(errExpected))
+
+		_, err := p.OpenDBStore(channel1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		errExpected := errors.New("injected DB error")
+
+		olProvider := mocks.NewDBProvider()
+		olProvider.MockDB(transientDataNS, transientDataColl).WithError(errExpected)
+
+		db, err := NewDBProvider(olProvider).OpenDBStore(channel1)
+		require.NoError(t, err)
+
+		err = db.AddKey(k1, &api.Value{Value: []byte("value1")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		_, err = db.GetKey(k1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		err = db.DeleteKey(k1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		require.EqualError(t, db.DeleteExpiredKeys(), errExpected.Error())
+	})
+
+	t.Run("Decode error", func(t *testing.T) {
+		olProvider := mocks.NewDBProvider().WithValue(transientDataNS, transientDataColl, "ns1!coll1!key1", &olapi.Value{Value: []byte("invalid")})
+
+		db, err := NewDBProvider(olProvider).OpenDBStore(channel1)
+		require.NoError(t, err)
+
+		_, err = db.GetKey(k1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode transientdata value")
+	})
+}
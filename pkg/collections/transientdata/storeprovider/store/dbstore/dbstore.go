@@ -48,6 +48,12 @@ func (s *DBStore) AddKey(key api.Key, value *api.Value) error {
 	if err != nil {
 		return errors.WithMessagef(err, "failed to encode transientdata value %s", value)
 	}
+
+	// If the key already exists with a different expiry time (i.e. the expiry time was extended) then
+	// remove the previous expiry key so that the clean up doesn't remove the key prematurely
+	if err := s.deleteExpiryKey(key, value.ExpiryTime); err != nil {
+		return err
+	}
 	// put key in db
 	err = s.db.Put(encodeCacheKey(key, time.Time{}), encodeVal, true)
 	if err != nil {
@@ -82,6 +88,52 @@ func (s *DBStore) GetKey(key api.Key) (*api.Value, error) {
 	return nil, nil
 }
 
+// DeleteKey deletes the given key from db
+func (s *DBStore) DeleteKey(key api.Key) error {
+	current, err := s.GetKey(key)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		logger.Debugf("transientdata key %s not found in db", key)
+		return nil
+	}
+
+	dbBatch := s.db.NewUpdateBatch()
+	dbBatch.Delete(encodeCacheKey(key, time.Time{}))
+	if !current.ExpiryTime.IsZero() {
+		dbBatch.Delete(encodeCacheKey(key, current.ExpiryTime))
+	}
+
+	if err := s.db.WriteBatch(dbBatch, true); err != nil {
+		return errors.Wrapf(err, "failed to delete transientdata key %s from db", key)
+	}
+
+	logger.Debugf("deleted transientdata key %s from db", key)
+
+	return nil
+}
+
+// deleteExpiryKey deletes the expiry key of the stored value for the given key if the
+// expiry time of the stored value is not the same as the given expiry time
+func (s *DBStore) deleteExpiryKey(key api.Key, expiryTime time.Time) error {
+	current, err := s.GetKey(key)
+	if err != nil {
+		return err
+	}
+
+	if current == nil || current.ExpiryTime.IsZero() || current.ExpiryTime.Equal(expiryTime) {
+		return nil
+	}
+
+	if err := s.db.Delete(encodeCacheKey(key, current.ExpiryTime), true); err != nil {
+		return errors.Wrapf(err, "failed to delete expiry key for transientdata key %s from db", key)
+	}
+
+	return nil
+}
+
 // DeleteExpiredKeys delete expired keys from db
 func (s *DBStore) DeleteExpiredKeys() error {
 	dbBatch := s.db.NewUpdateBatch()
@@ -7,15 +7,10 @@ package dbstore
 
 import (
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
-// DBProvider provides an handle to a transientdata db
-type DBProvider interface {
-	OpenDBStore(id string) (DBStore, error)
-	Close()
-}
-
 // LevelDBProvider provides an handle to a transientdata db
 type LevelDBProvider struct {
 	leveldbProvider *leveldbhelper.Provider
@@ -33,7 +28,7 @@ func NewDBProvider() (*LevelDBProvider, error) {
 }
 
 // OpenDBStore opens the db store
-func (p *LevelDBProvider) OpenDBStore(dbName string) (*DBStore, error) {
+func (p *LevelDBProvider) OpenDBStore(dbName string) (api.DB, error) {
 	indexStore := p.leveldbProvider.GetDBHandle(dbName)
 	return newDBStore(indexStore, dbName), nil
 }
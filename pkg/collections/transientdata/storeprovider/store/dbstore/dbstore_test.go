@@ -110,6 +110,63 @@ func TestAddRetrieveKeysFromDB(t *testing.T) {
 
 }
 
+func TestDeleteKeyFromDB(t *testing.T) {
+	removeDBPath(t)
+	defer removeDBPath(t)
+
+	p, err := NewDBProvider()
+	require.NoError(t, err)
+	db, err := p.OpenDBStore("testchannel")
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, db.AddKey(k2, v2))
+
+	v, err := db.GetKey(k2)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+
+	require.NoError(t, db.DeleteKey(k2))
+
+	v, err = db.GetKey(k2)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	// Deleting a key that doesn't exist is not an error
+	require.NoError(t, db.DeleteKey(k2))
+
+	// The expiry key should also have been deleted
+	itr, err := db.(*DBStore).db.GetIterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Release()
+	require.False(t, itr.Next())
+}
+
+func TestExtendExpiryInDB(t *testing.T) {
+	removeDBPath(t)
+	defer removeDBPath(t)
+
+	p, err := NewDBProvider()
+	require.NoError(t, err)
+	db, err := p.OpenDBStore("testchannel")
+	require.NoError(t, err)
+	defer p.Close()
+
+	v := &api.Value{TxID: txID1, Value: value1, ExpiryTime: time.Now().UTC()}
+	require.NoError(t, db.AddKey(k1, v))
+
+	// Extend the expiry time
+	require.NoError(t, db.AddKey(k1, &api.Value{TxID: txID1, Value: value1, ExpiryTime: time.Now().UTC().Add(time.Minute)}))
+
+	// The key should not be deleted since the previous expiry key should have been removed
+	require.NoError(t, db.DeleteExpiredKeys())
+
+	v, err = db.GetKey(k1)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	require.Equal(t, value1, v.Value)
+}
+
 func TestDBStore_Error(t *testing.T) {
 	t.Run("Encode value error", func(t *testing.T) {
 		restore := encodeCacheVal
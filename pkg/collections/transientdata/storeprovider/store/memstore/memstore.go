@@ -0,0 +1,134 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+var logger = flogging.MustGetLogger("transientdata")
+
+// MemDBProvider provides in-memory transient data DBs. The data does not survive a restart of the peer.
+type MemDBProvider struct {
+	stores map[string]*store
+	mutex  sync.Mutex
+}
+
+// NewDBProvider returns a new in-memory DB provider
+func NewDBProvider() *MemDBProvider {
+	logger.Debugf("constructing in-memory DBProvider")
+
+	return &MemDBProvider{stores: make(map[string]*store)}
+}
+
+// OpenDBStore returns the in-memory DB for the given channel
+func (p *MemDBProvider) OpenDBStore(channelID string) (api.DB, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	s, ok := p.stores[channelID]
+	if !ok {
+		s = newStore(channelID)
+		p.stores[channelID] = s
+	}
+
+	return s, nil
+}
+
+// Close closes all of the DBs
+func (p *MemDBProvider) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, s := range p.stores {
+		s.Close()
+	}
+
+	p.stores = make(map[string]*store)
+}
+
+type store struct {
+	channelID string
+	data      map[api.Key]*api.Value
+	mutex     sync.RWMutex
+}
+
+func newStore(channelID string) *store {
+	return &store{
+		channelID: channelID,
+		data:      make(map[api.Key]*api.Value),
+	}
+}
+
+// AddKey stores the given value for the given key
+func (s *store) AddKey(key api.Key, value *api.Value) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data[key] = value
+
+	return nil
+}
+
+// GetKey returns the value for the given key or nil if the key doesn't exist
+func (s *store) GetKey(key api.Key) (*api.Value, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.data[key], nil
+}
+
+// DeleteKey deletes the given key
+func (s *store) DeleteKey(key api.Key) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.data, key)
+
+	return nil
+}
+
+// DeleteExpiredKeys deletes all of the expired keys
+func (s *store) DeleteExpiredKeys() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now().UTC()
+
+	var keys []api.Key
+	for key, value := range s.data {
+		if isExpired(value, now) {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		delete(s.data, key)
+	}
+
+	if len(keys) > 0 {
+		logger.Debugf("[%s] Deleted expired keys %s", s.channelID, keys)
+	}
+
+	return nil
+}
+
+// Close clears the data in the store
+func (s *store) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data = make(map[api.Key]*api.Value)
+}
+
+func isExpired(value *api.Value, now time.Time) bool {
+	return !value.ExpiryTime.IsZero() && !value.ExpiryTime.After(now)
+}
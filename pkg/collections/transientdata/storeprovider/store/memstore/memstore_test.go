@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+)
+
+const (
+	channel1 = "channel1"
+	channel2 = "channel2"
+)
+
+var (
+	k1 = api.Key{Namespace: "ns1", Collection: "coll1", Key: "key1"}
+	k2 = api.Key{Namespace: "ns1", Collection: "coll1", Key: "key2"}
+	k3 = api.Key{Namespace: "ns1", Collection: "coll1", Key: "key3"}
+)
+
+func TestMemStore(t *testing.T) {
+	p := NewDBProvider()
+	require.NotNil(t, p)
+	defer p.Close()
+
+	db1, err := p.OpenDBStore(channel1)
+	require.NoError(t, err)
+
+	db, err := p.OpenDBStore(channel1)
+	require.NoError(t, err)
+	require.True(t, db == db1)
+
+	db2, err := p.OpenDBStore(channel2)
+	require.NoError(t, err)
+	require.False(t, db2 == db1)
+
+	v1 := &api.Value{Value: []byte("value1"), TxID: "tx1"}
+	v2 := &api.Value{Value: []byte("value2"), TxID: "tx2", ExpiryTime: time.Now().UTC().Add(-time.Second)}
+	v3 := &api.Value{Value: []byte("value3"), TxID: "tx3", ExpiryTime: time.Now().UTC().Add(time.Minute), TTL: time.Minute}
+
+	require.NoError(t, db1.AddKey(k1, v1))
+	require.NoError(t, db1.AddKey(k2, v2))
+	require.NoError(t, db1.AddKey(k3, v3))
+
+	v, err := db1.GetKey(k3)
+	require.NoError(t, err)
+	require.Equal(t, v3, v)
+
+	v, err = db2.GetKey(k3)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	require.NoError(t, db1.DeleteExpiredKeys())
+
+	v, err = db1.GetKey(k1)
+	require.NoError(t, err)
+	require.Equal(t, v1, v)
+
+	v, err = db1.GetKey(k2)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	require.NoError(t, db1.DeleteKey(k1))
+
+	v, err = db1.GetKey(k1)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	p.Close()
+
+	v, err = db1.GetKey(k3)
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
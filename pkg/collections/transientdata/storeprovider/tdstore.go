@@ -13,6 +13,7 @@ import (
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	proto "github.com/hyperledger/fabric-protos-go/transientstore"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
@@ -37,6 +38,7 @@ type gossipAdapter interface {
 
 type store struct {
 	channelID            string
+	touchOnRead          bool
 	cache                *cache.Cache
 	gossip               gossipAdapter
 	identityDeserializer msp.IdentityDeserializer
@@ -44,15 +46,17 @@ type store struct {
 
 type db interface {
 	AddKey(api.Key, *api.Value) error
+	DeleteKey(key api.Key) error
 	DeleteExpiredKeys() error
 	GetKey(key api.Key) (*api.Value, error)
 }
 
-func newStore(channelID string, cacheSize int, alwaysPersist bool, transientDB db, gossip gossipAdapter, identityDeserializer msp.IdentityDeserializer) *store {
-	logger.Debugf("[%s] Creating new store - cacheSize=%d", channelID, cacheSize)
+func newStore(channelID string, cacheSize int, alwaysPersist, touchOnRead bool, transientDB db, gossip gossipAdapter, identityDeserializer msp.IdentityDeserializer, metricsProvider metrics.Provider) *store {
+	logger.Debugf("[%s] Creating new store - cacheSize=%d, touchOnRead=%t", channelID, cacheSize, touchOnRead)
 	return &store{
 		channelID:            channelID,
-		cache:                cache.New(channelID, cacheSize, alwaysPersist, transientDB),
+		touchOnRead:          touchOnRead,
+		cache:                cache.New(channelID, cacheSize, alwaysPersist, transientDB, metricsProvider),
 		gossip:               gossip,
 		identityDeserializer: identityDeserializer,
 	}
@@ -86,6 +90,19 @@ func (s *store) GetTransientDataMultipleKeys(key *storeapi.MultiKey) (storeapi.E
 	return s.getTransientDataMultipleKeys(key), nil
 }
 
+// TouchTransientData extends the expiry time of the given keys by the time-to-live with which they were stored
+func (s *store) TouchTransientData(key *storeapi.MultiKey) {
+	if s.cache == nil {
+		return
+	}
+
+	for _, k := range key.Keys {
+		if touched := s.cache.Touch(api.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k}); touched != nil {
+			logger.Debugf("[%s] Touched key [%s:%s:%s]", s.channelID, key.Namespace, key.Collection, k)
+		}
+	}
+}
+
 // Close closes the transient data store
 func (s *store) Close() {
 	if s.cache != nil {
@@ -95,6 +112,24 @@ func (s *store) Close() {
 	}
 }
 
+// CacheSize returns the number of keys of the given collection that are held in the cache
+func (s *store) CacheSize(ns, coll string) int {
+	if s.cache == nil {
+		return 0
+	}
+
+	return s.cache.Size(ns, coll)
+}
+
+// FlushCache removes the keys of the given collection from the cache and returns the number of keys removed
+func (s *store) FlushCache(ns, coll string) int {
+	if s.cache == nil {
+		return 0
+	}
+
+	return s.cache.Flush(ns, coll)
+}
+
 func (s *store) persistColl(txID string, ns string, collConfigPkgs map[string]*pb.CollectionConfigPackage, collRWSet *rwsetutil.CollPvtRwSet) error {
 	config, exists := getCollectionConfig(collConfigPkgs, ns, collRWSet.CollectionName)
 	if !exists {
@@ -135,17 +170,18 @@ func (s *store) persistColl(txID string, ns string, collConfigPkgs map[string]*p
 }
 
 func (s *store) persistKVWrite(txID, ns, coll string, w *kvrwset.KVWrite, ttl time.Duration) {
-	if w.IsDelete {
-		logger.Debugf("[%s] Skipping key [%s:%s:%s] in private data rw-set since it was deleted", s.channelID, ns, coll, w.Key)
-		return
-	}
-
 	key := api.Key{
 		Namespace:  ns,
 		Collection: coll,
 		Key:        w.Key,
 	}
 
+	if w.IsDelete {
+		logger.Debugf("[%s] Deleting transient data key [%s]", s.channelID, key)
+		s.cache.Delete(key)
+		return
+	}
+
 	if s.cache.Get(key) != nil {
 		logger.Debugf("[%s] Transient data key [%s:%s:%s] already exists", s.channelID, ns, coll, w.Key)
 		return
@@ -171,6 +207,12 @@ func (s *store) getTransientData(txID, ns, coll, key string) *storeapi.ExpiringV
 
 	logger.Debugf("[%s] Key [%s] found in transient store", s.channelID, k)
 
+	if s.touchOnRead {
+		if touched := s.cache.Touch(k); touched != nil {
+			value = touched
+		}
+	}
+
 	return &storeapi.ExpiringValue{Value: value.Value, Expiry: value.ExpiryTime}
 }
 
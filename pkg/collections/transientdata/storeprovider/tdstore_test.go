@@ -13,6 +13,7 @@ import (
 	"time"
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/hyperledger/fabric/core/common/privdata"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 	gcommon "github.com/hyperledger/fabric/gossip/common"
@@ -61,7 +62,7 @@ var (
 )
 
 func TestStore(t *testing.T) {
-	s := newStore(channelID, 100, false, newMockDB(), mocks.NewMockGossipAdapter(), &mocks.IdentityDeserializer{})
+	s := newStore(channelID, 100, false, false, newMockDB(), mocks.NewMockGossipAdapter(), &mocks.IdentityDeserializer{}, &disabled.Provider{})
 	require.NotNil(t, s)
 	s.Close()
 
@@ -115,7 +116,7 @@ func TestStorePutAndGet(t *testing.T) {
 		Member(org2MSPID, p1Org2).
 		Member(org2MSPID, p2Org2)
 
-	s := newStore(channelID, 1, false, newMockDB(), gossip, &mocks.IdentityDeserializer{})
+	s := newStore(channelID, 1, false, false, newMockDB(), gossip, &mocks.IdentityDeserializer{}, &disabled.Provider{})
 	require.NotNil(t, s)
 	defer s.Close()
 
@@ -210,6 +211,29 @@ func TestStorePutAndGet(t *testing.T) {
 		assert.Equalf(t, value1_1, value.Value, "expecting transient data to not have been updated")
 	})
 
+	t.Run("Delete transient data", func(t *testing.T) {
+		b := mocks.NewPvtReadWriteSetBuilder()
+		b.Namespace(ns1).Collection(coll2).
+			TransientConfig(collPolicy, 1, 2, "1m").
+			Delete(key6)
+		require.NoError(t, s.Persist(txID3, b.Build()))
+
+		value, err := s.GetTransientData(storeapi.NewKey(txID4, ns1, coll2, key6))
+		require.NoError(t, err)
+		require.Nilf(t, value, "expecting transient data to have been deleted")
+
+		b2 := mocks.NewPvtReadWriteSetBuilder()
+		b2.Namespace(ns1).Collection(coll2).
+			TransientConfig(collPolicy, 1, 2, "1m").
+			Write(key6, value2_1)
+		require.NoError(t, s.Persist(txID3, b2.Build()))
+
+		value, err = s.GetTransientData(storeapi.NewKey(txID4, ns1, coll2, key6))
+		require.NoError(t, err)
+		require.NotNil(t, value)
+		require.Equalf(t, value2_1, value.Value, "expecting key to have been added after it was deleted")
+	})
+
 	t.Run("Expire transient data", func(t *testing.T) {
 		b := mocks.NewPvtReadWriteSetBuilder()
 		ns1Builder := b.Namespace(ns1)
@@ -233,8 +257,75 @@ func TestStorePutAndGet(t *testing.T) {
 	})
 }
 
+func TestStoreTouchOnRead(t *testing.T) {
+	value1 := []byte("value1")
+
+	gossip := mocks.NewMockGossipAdapter().
+		Self(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID)).
+		Member(org1MSPID, mocks.NewMember(p2Org1Endpoint, p2Org1PKIID)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID)).
+		Member(org2MSPID, mocks.NewMember(p2Org2Endpoint, p2Org2PKIID))
+
+	s := newStore(channelID, 100, false, true, newMockDB(), gossip, &mocks.IdentityDeserializer{}, &disabled.Provider{})
+	require.NotNil(t, s)
+	defer s.Close()
+
+	b := mocks.NewPvtReadWriteSetBuilder()
+	b.Namespace(ns1).Collection(coll1).
+		TransientConfig(collPolicy, 1, 2, "200ms").
+		Write(key6, value1)
+	require.NoError(t, s.Persist(txID1, b.Build()))
+
+	time.Sleep(120 * time.Millisecond)
+
+	// Reading the value extends the expiry time by the collection's time-to-live
+	value, err := s.GetTransientData(storeapi.NewKey(txID2, ns1, coll1, key6))
+	require.NoError(t, err)
+	require.NotNil(t, value)
+	require.True(t, value.Expiry.After(time.Now().Add(100*time.Millisecond)))
+
+	time.Sleep(120 * time.Millisecond)
+
+	value, err = s.GetTransientData(storeapi.NewKey(txID2, ns1, coll1, key6))
+	require.NoError(t, err)
+	require.NotNilf(t, value, "expecting the expiry time of the key to have been extended")
+	require.Equal(t, value1, value.Value)
+}
+
+func TestStoreTouchTransientData(t *testing.T) {
+	value1 := []byte("value1")
+
+	gossip := mocks.NewMockGossipAdapter().
+		Self(org1MSPID, mocks.NewMember(p1Org1Endpoint, p1Org1PKIID)).
+		Member(org1MSPID, mocks.NewMember(p2Org1Endpoint, p2Org1PKIID)).
+		Member(org2MSPID, mocks.NewMember(p1Org2Endpoint, p1Org2PKIID)).
+		Member(org2MSPID, mocks.NewMember(p2Org2Endpoint, p2Org2PKIID))
+
+	s := newStore(channelID, 100, false, false, newMockDB(), gossip, &mocks.IdentityDeserializer{}, &disabled.Provider{})
+	require.NotNil(t, s)
+	defer s.Close()
+
+	b := mocks.NewPvtReadWriteSetBuilder()
+	b.Namespace(ns1).Collection(coll1).
+		TransientConfig(collPolicy, 1, 2, "200ms").
+		Write(key6, value1)
+	require.NoError(t, s.Persist(txID1, b.Build()))
+
+	time.Sleep(120 * time.Millisecond)
+
+	// Touching the key (e.g. on request of another peer) extends the expiry time by the collection's time-to-live
+	s.TouchTransientData(storeapi.NewMultiKey(txID2, ns1, coll1, key6, key1))
+
+	time.Sleep(120 * time.Millisecond)
+
+	value, err := s.GetTransientData(storeapi.NewKey(txID2, ns1, coll1, key6))
+	require.NoError(t, err)
+	require.NotNilf(t, value, "expecting the expiry time of the key to have been extended")
+	require.Equal(t, value1, value.Value)
+}
+
 func TestStoreInvalidData(t *testing.T) {
-	s := newStore(channelID, 100, false, newMockDB(), mocks.NewMockGossipAdapter(), &mocks.IdentityDeserializer{})
+	s := newStore(channelID, 100, false, false, newMockDB(), mocks.NewMockGossipAdapter(), &mocks.IdentityDeserializer{}, &disabled.Provider{})
 	require.NotNil(t, s)
 	defer s.Close()
 
@@ -305,6 +396,12 @@ func (db *mockDB) AddKey(key api.Key, value *api.Value) error {
 	return db.err
 }
 
+func (db *mockDB) DeleteKey(key api.Key) error {
+	fmt.Printf("DB Store - Deleting key [%s]\n", key)
+	delete(db.data, key)
+	return db.err
+}
+
 func (db *mockDB) DeleteExpiredKeys() error {
 	return db.err
 }
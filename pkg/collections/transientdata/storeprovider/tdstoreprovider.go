@@ -9,34 +9,47 @@ package storeprovider
 import (
 	"sync"
 
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/pkg/errors"
 	collcommon "github.com/trustbloc/fabric-peer-ext/pkg/collections/common"
+	olstoreapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/api"
+	storeapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/api"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/couchdbstore"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/dbstore"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/memstore"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
 
+// offLedgerDBProvider provides the DB provider of the off-ledger store. Transient data that is stored in CouchDB
+// uses the same DB provider so that only one CouchDB instance and purge loop is maintained by the peer.
+type offLedgerDBProvider interface {
+	DBProvider() olstoreapi.DBProvider
+}
+
 // New returns a new transient data store provider
-func New(gossipProvider collcommon.GossipProvider, idProvider collcommon.IdentityDeserializerProvider) *StoreProvider {
+func New(gossipProvider collcommon.GossipProvider, idProvider collcommon.IdentityDeserializerProvider, metricsProvider metrics.Provider, olProvider offLedgerDBProvider) *StoreProvider {
 	logger.Infof("Creating new transient data store provider")
-	dbp, err := dbstore.NewDBProvider()
+	dbp, err := getDBProvider(olProvider)
 	if err != nil {
 		panic(err)
 	}
 	return &StoreProvider{
-		stores:         make(map[string]*store),
-		dbProvider:     dbp,
-		gossipProvider: gossipProvider,
-		idProvider:     idProvider,
+		stores:          make(map[string]*store),
+		dbProvider:      dbp,
+		gossipProvider:  gossipProvider,
+		idProvider:      idProvider,
+		metricsProvider: metricsProvider,
 	}
 }
 
 // StoreProvider is a transient data store provider
 type StoreProvider struct {
-	stores         map[string]*store
-	dbProvider     *dbstore.LevelDBProvider
-	gossipProvider collcommon.GossipProvider
-	idProvider     collcommon.IdentityDeserializerProvider
+	stores          map[string]*store
+	dbProvider      storeapi.DBProvider
+	gossipProvider  collcommon.GossipProvider
+	idProvider      collcommon.IdentityDeserializerProvider
+	metricsProvider metrics.Provider
 	sync.RWMutex
 }
 
@@ -62,7 +75,7 @@ func (sp *StoreProvider) OpenStore(channelID string) (api.Store, error) {
 		return nil, err
 	}
 
-	store := newStore(channelID, config.GetTransientDataCacheSize(), config.GetTransientDataAlwaysPersist(), db, sp.gossipProvider.GetGossipService(), sp.idProvider.GetIdentityDeserializer(channelID))
+	store := newStore(channelID, config.GetTransientDataCacheSize(), config.GetTransientDataAlwaysPersist(), config.GetTransientDataTouchOnRead(), db, sp.gossipProvider.GetGossipService(), sp.idProvider.GetIdentityDeserializer(channelID), sp.metricsProvider)
 	sp.stores[channelID] = store
 
 	return store, nil
@@ -75,3 +88,28 @@ func (sp *StoreProvider) Close() {
 	}
 	sp.dbProvider.Close()
 }
+
+// getDBProvider returns the DB provider for the configured database type. This var may be overridden by unit tests
+var getDBProvider = func(olProvider offLedgerDBProvider) (storeapi.DBProvider, error) {
+	dbType := config.GetTransientDataDBType()
+
+	logger.Infof("Using transient data database type [%s]", dbType)
+
+	switch dbType {
+	case config.MemDBType:
+		return memstore.NewDBProvider(), nil
+	case config.LevelDBType:
+		p, err := dbstore.NewDBProvider()
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case config.CouchDBType:
+		if olProvider == nil {
+			return nil, errors.Errorf("an off-ledger DB provider is required for transient data database type [%s]", dbType)
+		}
+		return couchdbstore.NewDBProvider(olProvider.DBProvider()), nil
+	default:
+		return nil, errors.Errorf("unsupported transient data database type [%s]", dbType)
+	}
+}
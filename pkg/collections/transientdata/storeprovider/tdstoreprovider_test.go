@@ -11,11 +11,16 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/pkg/errors"
 	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	olstoreapi "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/api"
+	olmocks "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/storeprovider/store/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/couchdbstore"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/dbstore"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider/store/memstore"
 	"github.com/trustbloc/fabric-peer-ext/pkg/config"
 	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
 )
@@ -28,7 +33,7 @@ func TestNewError(t *testing.T) {
 	defer restoreDBCreator()
 
 	require.PanicsWithValue(t, errExpected, func() {
-		New(nil, nil)
+		New(nil, nil, nil, nil)
 	})
 }
 
@@ -40,7 +45,7 @@ func TestStoreProvider(t *testing.T) {
 	gossipProvider := &mocks.GossipProvider{}
 	gossipProvider.GetGossipServiceReturns(mocks.NewMockGossipAdapter())
 
-	p := New(gossipProvider, &mocks.IdentityDeserializerProvider{})
+	p := New(gossipProvider, &mocks.IdentityDeserializerProvider{}, &disabled.Provider{}, &mockOffLedgerDBProvider{})
 	require.NotNil(t, p)
 
 	s1, err := p.OpenStore(channel1)
@@ -63,6 +68,70 @@ func TestStoreProvider(t *testing.T) {
 	})
 }
 
+func TestStoreProviderDBType(t *testing.T) {
+	oldVal := viper.Get(config.ConfTransientDataDBType)
+	defer viper.Set(config.ConfTransientDataDBType, oldVal)
+
+	t.Run("Memory", func(t *testing.T) {
+		viper.Set(config.ConfTransientDataDBType, config.MemDBType)
+
+		dbp, err := getDBProvider(&mockOffLedgerDBProvider{})
+		require.NoError(t, err)
+		require.IsType(t, &memstore.MemDBProvider{}, dbp)
+
+		gossipProvider := &mocks.GossipProvider{}
+		gossipProvider.GetGossipServiceReturns(mocks.NewMockGossipAdapter())
+
+		p := New(gossipProvider, &mocks.IdentityDeserializerProvider{}, &disabled.Provider{}, &mockOffLedgerDBProvider{})
+		require.NotNil(t, p)
+		defer p.Close()
+
+		s, err := p.OpenStore("channel1")
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("LevelDB", func(t *testing.T) {
+		defer removeDBPath(t)
+
+		viper.Set(config.ConfTransientDataDBType, config.LevelDBType)
+
+		dbp, err := getDBProvider(&mockOffLedgerDBProvider{})
+		require.NoError(t, err)
+		require.IsType(t, &dbstore.LevelDBProvider{}, dbp)
+		dbp.Close()
+	})
+
+	t.Run("CouchDB", func(t *testing.T) {
+		viper.Set(config.ConfTransientDataDBType, config.CouchDBType)
+
+		dbp, err := getDBProvider(&mockOffLedgerDBProvider{})
+		require.NoError(t, err)
+		require.IsType(t, &couchdbstore.CouchDBProvider{}, dbp)
+	})
+
+	t.Run("CouchDB without off-ledger DB provider -> error", func(t *testing.T) {
+		viper.Set(config.ConfTransientDataDBType, config.CouchDBType)
+
+		_, err := getDBProvider(nil)
+		require.EqualError(t, err, "an off-ledger DB provider is required for transient data database type [couchdb]")
+	})
+
+	t.Run("Unsupported -> error", func(t *testing.T) {
+		viper.Set(config.ConfTransientDataDBType, "invalid")
+
+		_, err := getDBProvider(&mockOffLedgerDBProvider{})
+		require.EqualError(t, err, "unsupported transient data database type [invalid]")
+	})
+}
+
+type mockOffLedgerDBProvider struct {
+}
+
+func (m *mockOffLedgerDBProvider) DBProvider() olstoreapi.DBProvider {
+	return olmocks.NewDBProvider()
+}
+
 func TestMain(m *testing.M) {
 	removeDBPath(nil)
 	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/transientdatadb")
@@ -0,0 +1,107 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	kitstatsd "github.com/go-kit/kit/metrics/statsd"
+	"github.com/hyperledger/fabric/common/flogging"
+	fabricmetrics "github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/common/metrics/prometheus"
+	"github.com/hyperledger/fabric/common/metrics/statsd"
+	viper "github.com/spf13/viper2015"
+)
+
+var logger = flogging.MustGetLogger("ext_metrics")
+
+const (
+	confProvider            = "metrics.provider"
+	confStatsdNetwork       = "metrics.statsd.network"
+	confStatsdAddress       = "metrics.statsd.address"
+	confStatsdWriteInterval = "metrics.statsd.writeInterval"
+	confStatsdPrefix        = "metrics.statsd.prefix"
+
+	prometheusProvider = "prometheus"
+	statsdProvider     = "statsd"
+	disabledProvider   = "disabled"
+
+	defaultStatsdWriteInterval = 10 * time.Second
+)
+
+// Provider is the metrics provider of the peer extensions. The provider is created from the peer's metrics
+// configuration so that the extension metrics are published along with the peer's metrics, i.e. Prometheus
+// metrics are registered with the default registry (which is served by the peer's operations endpoint)
+// and StatsD metrics are sent to the configured StatsD server.
+type Provider struct {
+	fabricmetrics.Provider
+	ticker *time.Ticker
+}
+
+// NewProvider returns a new metrics provider
+func NewProvider() *Provider {
+	providerType := viper.GetString(confProvider)
+
+	switch providerType {
+	case prometheusProvider:
+		logger.Info("Creating Prometheus metrics provider")
+
+		return &Provider{Provider: &prometheus.Provider{}}
+
+	case statsdProvider:
+		logger.Info("Creating StatsD metrics provider")
+
+		return newStatsdProvider()
+
+	default:
+		if providerType != "" && providerType != disabledProvider {
+			logger.Warningf("Unknown metrics provider type: %s; metrics disabled", providerType)
+		}
+
+		return &Provider{Provider: &disabled.Provider{}}
+	}
+}
+
+// Close stops sending metrics to the StatsD server
+func (p *Provider) Close() {
+	if p.ticker != nil {
+		p.ticker.Stop()
+	}
+}
+
+func newStatsdProvider() *Provider {
+	prefix := viper.GetString(confStatsdPrefix)
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	writeInterval := viper.GetDuration(confStatsdWriteInterval)
+	if writeInterval == 0 {
+		writeInterval = defaultStatsdWriteInterval
+	}
+
+	ks := kitstatsd.New(prefix, &statsdLogger{})
+	ticker := time.NewTicker(writeInterval)
+
+	go ks.SendLoop(ticker.C, viper.GetString(confStatsdNetwork), viper.GetString(confStatsdAddress))
+
+	return &Provider{
+		Provider: &statsd.Provider{Statsd: ks},
+		ticker:   ticker,
+	}
+}
+
+// statsdLogger logs the errors reported by the StatsD client
+type statsdLogger struct{}
+
+// Log logs the given key/value pairs
+func (l *statsdLogger) Log(keyvals ...interface{}) error {
+	logger.Warn(keyvals...)
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/common/metrics/prometheus"
+	"github.com/hyperledger/fabric/common/metrics/statsd"
+	viper "github.com/spf13/viper2015"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	oldVal := viper.Get(confProvider)
+	defer viper.Set(confProvider, oldVal)
+
+	t.Run("Disabled", func(t *testing.T) {
+		viper.Set(confProvider, "")
+
+		p := NewProvider()
+		require.IsType(t, &disabled.Provider{}, p.Provider)
+		p.Close()
+
+		viper.Set(confProvider, "unknown")
+		require.IsType(t, &disabled.Provider{}, NewProvider().Provider)
+	})
+
+	t.Run("Prometheus", func(t *testing.T) {
+		viper.Set(confProvider, prometheusProvider)
+
+		p := NewProvider()
+		require.IsType(t, &prometheus.Provider{}, p.Provider)
+		p.Close()
+	})
+
+	t.Run("StatsD", func(t *testing.T) {
+		viper.Set(confProvider, statsdProvider)
+		viper.Set(confStatsdNetwork, "udp")
+		viper.Set(confStatsdAddress, "127.0.0.1:8125")
+		viper.Set(confStatsdPrefix, "peer")
+
+		p := NewProvider()
+		require.IsType(t, &statsd.Provider{}, p.Provider)
+		require.NotNil(t, p.ticker)
+		p.Close()
+
+		require.NoError(t, (&statsdLogger{}).Log("err", "test"))
+	})
+}
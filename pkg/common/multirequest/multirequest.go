@@ -35,7 +35,8 @@ type Response struct {
 	Values    common.Values
 }
 
-// MultiRequest executes multiple requests and returns the first, non-error response
+// MultiRequest executes multiple requests and merges the non-error responses. The remaining requests are
+// cancelled as soon as the merged response contains all of the values.
 type MultiRequest struct {
 	requests []*req
 }
@@ -95,11 +96,11 @@ func handleResponse(response *res, resp *Response) bool {
 	resp.RequestID = response.id
 	resp.Values = resp.Values.Merge(response.values)
 
-	if response.values.AllSet() {
-		logger.Debugf("All values were received from [%s]", response.id)
+	if resp.Values.AllSet() {
+		logger.Debugf("All values were received after response from [%s]", response.id)
 		return true
 	}
 
-	logger.Debugf("One or more values are missing in response from [%s]", response.id)
+	logger.Debugf("One or more values are still missing after response from [%s]", response.id)
 	return false
 }
@@ -21,6 +21,7 @@ type requestTest struct {
 	id     string
 	err    error
 	values [][]byte
+	delay  time.Duration
 }
 
 func TestAllSet(t *testing.T) {
@@ -78,6 +79,37 @@ func TestMerge(t *testing.T) {
 	assert.Equal(t, value3, result.Values[2])
 }
 
+func TestMergedAllSet(t *testing.T) {
+	t.Parallel()
+
+	re := New()
+
+	value1 := []byte("value1")
+	value2 := []byte("value2")
+	value3 := []byte("value3")
+
+	requests := []requestTest{
+		{id: "Request1", values: [][]byte{value1, nil, nil}},
+		{id: "Request2", values: [][]byte{nil, value2, value3}},
+		{id: "Request3", values: [][]byte{value1, value2, value3}, delay: 10 * time.Second},
+	}
+
+	for _, r := range requests {
+		re.Add(r.id, getRequestFunc(r))
+	}
+
+	start := time.Now()
+
+	result := re.Execute(context.Background())
+	require.NotNil(t, result)
+	require.Equal(t, 3, len(result.Values))
+	require.Truef(t, time.Since(start) < 5*time.Second, "expecting the slow request to be cancelled once the merged response is complete")
+
+	assert.Equal(t, value1, result.Values[0])
+	assert.Equal(t, value2, result.Values[1])
+	assert.Equal(t, value3, result.Values[2])
+}
+
 func TestTimeoutOrCancel(t *testing.T) {
 	t.Parallel()
 
@@ -135,9 +167,14 @@ func TestSomeFailed(t *testing.T) {
 }
 
 func getRequestFunc(r requestTest) Request {
+	delay := r.delay
+	if delay == 0 {
+		delay = 5 * time.Millisecond
+	}
+
 	return func(ctxt context.Context) (common.Values, error) {
 		select {
-		case <-time.After(5 * time.Millisecond):
+		case <-time.After(delay):
 			return asValues(r.values), r.err
 		case <-ctxt.Done():
 			return nil, ctxt.Err()
@@ -8,6 +8,7 @@ package config
 
 import (
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
@@ -29,6 +30,7 @@ const (
 	confTransientDataCleanupIntervalTime = "coll.transientdata.cleanupExpired.Interval"
 	confTransientDataCacheSize           = "coll.transientdata.cacheSize"
 	confTransientDataAlwaysPersist       = "coll.transientdata.alwaysPersist"
+	confTransientDataTouchOnRead         = "coll.transientdata.touchOnRead"
 	confTransientDataPullTimeout         = "peer.gossip.transientData.pullTimeout"
 
 	confOLCollLeveldb              = "offLedgerLeveldb"
@@ -37,11 +39,27 @@ const (
 	confOLCollMaxRetrievalAttempts = "coll.offledger.maxRetrievalAttempts"
 	confOLCollCacheSize            = "coll.offledger.cache.size"
 	confOLCollPullTimeout          = "coll.offledger.gossip.pullTimeout"
+	confOLCollQueryTimeout         = "coll.offledger.query.timeout"
+	confOLCollQueryMaxResults      = "coll.offledger.query.maxResultsPerPeer"
+	confOLCollReconcileEnabled     = "coll.offledger.reconcile.enabled"
+	confOLCollReconcileInterval    = "coll.offledger.reconcile.interval"
+	confOLCollReconcileBatchSize   = "coll.offledger.reconcile.batchSize"
+	confOLCollTombstoneTTL         = "coll.offledger.reconcile.tombstoneTTL"
+	confOLCollEncryptionKeyFile    = "coll.offledger.encryption.keyFile"
+	confOLCollEncryptedCollTypes   = "coll.offledger.encryption.collectionTypes"
+	confOLCollPrefix               = "coll.offledger"
+	confOLCollCollections          = "coll.offledger.collections"
+	confOLCollMaxValueSize         = "limits.maxValueSize"
+	confOLCollMaxKeyCount          = "limits.maxKeyCount"
+	confOLCollMaxTotalBytes        = "limits.maxTotalBytes"
+	confOLCollQuotaReloadInterval  = "coll.offledger.limits.reloadInterval"
 
 	confDCASMaxLinksPerBlock = "coll.dcas.maxLinksPerBlock"
 	confDCASRawLeaves        = "coll.dcas.rawLeaves"
 	confDCASMaxBlockSize     = "coll.dcas.maxBlockSize"
 	confDCASBlockLayout      = "coll.dcas.blockLayout"
+	confDCASGCLeveldb        = "dcasGCLeveldb"
+	confDCASGCInterval       = "coll.dcas.gc.interval"
 
 	confConfigUpdatePublisherBufferSize = "configpublisher.buffersize"
 
@@ -55,6 +73,12 @@ const (
 	defaultOLCollMaxRetrievalAttempts = 3
 	defaultOLCollCacheSize            = 10000
 	defaultOLCollPullTimeout          = 5 * time.Second
+	defaultOLCollQueryTimeout         = 5 * time.Second
+	defaultOLCollQueryMaxResults      = 1000
+	defaultOLCollReconcileInterval    = time.Minute
+	defaultOLCollReconcileBatchSize   = 100
+	defaultOLCollTombstoneTTL         = 7 * 24 * time.Hour
+	defaultOLCollQuotaReloadInterval  = 30 * time.Second
 
 	defaultDCASrawLeaves          = true
 	defaultDCASMaxBlockSize int64 = 1024 * 256
@@ -69,11 +93,14 @@ const (
 	ConfPrivateDataStoreDBType = "ledger.storage.privateDataStore.dbtype"
 	// ConfTransientStoreDBType is the config key for the transient store database type
 	ConfTransientStoreDBType = "ledger.storage.transientStore.dbtype"
+	// ConfTransientDataDBType is the config key for the transient data database type
+	ConfTransientDataDBType = "coll.transientdata.dbtype"
 
 	defaultBlockStoreDBType       = CouchDBType
 	defaultIDStoreDBType          = CouchDBType
 	defaultPrivateDataStoreDBType = CouchDBType
 	defaultTransientStoreDBType   = MemDBType
+	defaultTransientDataDBType    = LevelDBType
 
 	confBlockStoreCacheSizeBlockByNum  = "ledger.storage.blockStore.cacheSize.blockByNum"
 	confBlockStoreCacheSizeBlockByHash = "ledger.storage.blockStore.cacheSize.blockByHash"
@@ -164,6 +191,22 @@ func GetTransientDataAlwaysPersist() bool {
 	return defaultTransientDataAlwaysPersist
 }
 
+// GetTransientDataTouchOnRead indicates whether the expiry time of transient data is to be extended (by the collection's
+// time-to-live) each time the data is read. A touch request is also sent to the other endorsers of the key in the dissemination plan.
+func GetTransientDataTouchOnRead() bool {
+	return viper.GetBool(confTransientDataTouchOnRead)
+}
+
+// GetTransientDataDBType returns the type of database that is used to persist transient data
+func GetTransientDataDBType() DBType {
+	dbType := viper.GetString(ConfTransientDataDBType)
+	if dbType == "" {
+		return defaultTransientDataDBType
+	}
+
+	return dbType
+}
+
 // GetOLCollLevelDBPath returns the filesystem path that is used to maintain the off-ledger level db
 func GetOLCollLevelDBPath() string {
 	return filepath.Join(filepath.Join(filepath.Clean(config.GetPath(confPeerFileSystemPath)), confLedgerDataPath), confOLCollLeveldb)
@@ -227,6 +270,115 @@ func GetOLCollPullTimeout() time.Duration {
 	return timeout
 }
 
+// GetOLCollQueryTimeout is the amount of time a peer waits for remote peers to respond to an off-ledger query.
+func GetOLCollQueryTimeout() time.Duration {
+	timeout := viper.GetDuration(confOLCollQueryTimeout)
+	if timeout == 0 {
+		timeout = defaultOLCollQueryTimeout
+	}
+	return timeout
+}
+
+// GetOLCollQueryMaxResultsPerPeer returns the maximum number of results that a remote peer returns for an off-ledger query.
+func GetOLCollQueryMaxResultsPerPeer() int {
+	maxResults := viper.GetInt(confOLCollQueryMaxResults)
+	if maxResults == 0 {
+		maxResults = defaultOLCollQueryMaxResults
+	}
+	return maxResults
+}
+
+// IsOLCollReconcileEnabled returns true if off-ledger collection data is periodically reconciled with other
+// collection members. Reconciliation is disabled by default.
+func IsOLCollReconcileEnabled() bool {
+	return viper.GetBool(confOLCollReconcileEnabled)
+}
+
+// GetOLCollReconcileInterval returns the interval at which off-ledger collection data is reconciled with other collection members.
+func GetOLCollReconcileInterval() time.Duration {
+	interval := viper.GetDuration(confOLCollReconcileInterval)
+	if interval == 0 {
+		interval = defaultOLCollReconcileInterval
+	}
+	return interval
+}
+
+// GetOLCollReconcileBatchSize returns the maximum number of values that are requested from a remote peer in a single
+// reconciliation request.
+func GetOLCollReconcileBatchSize() int {
+	batchSize := viper.GetInt(confOLCollReconcileBatchSize)
+	if batchSize == 0 {
+		batchSize = defaultOLCollReconcileBatchSize
+	}
+	return batchSize
+}
+
+// GetOLCollTombstoneTTL returns the time for which the deletion of an off-ledger key is remembered. The reconciler
+// won't pull a deleted key from another peer until its tombstone expires, so the TTL should be longer than the
+// time for which a peer may be disconnected from the other collection members. Tombstones are only recorded if
+// reconciliation is enabled.
+func GetOLCollTombstoneTTL() time.Duration {
+	ttl := viper.GetDuration(confOLCollTombstoneTTL)
+	if ttl == 0 {
+		ttl = defaultOLCollTombstoneTTL
+	}
+	return ttl
+}
+
+// GetOLCollEncryptionKeyFile returns the path of the file that contains the key-encryption keys which wrap the data keys
+// of encrypted off-ledger collections. A relative path is resolved relative to the peer's configuration directory.
+func GetOLCollEncryptionKeyFile() string {
+	return config.GetPath(confOLCollEncryptionKeyFile)
+}
+
+// GetOLCollEncryptedCollTypes returns the off-ledger collection types (e.g. COL_OFFLEDGER, COL_DCAS) whose values are
+// encrypted at rest. By default, encryption is disabled for all collection types.
+func GetOLCollEncryptedCollTypes() []string {
+	return viper.GetStringSlice(confOLCollEncryptedCollTypes)
+}
+
+// GetOLCollMaxValueSize returns the maximum size (in bytes) of a value in the given off-ledger collection. The
+// collection-specific setting, coll.offledger.collections.<ns>.<coll>.limits.maxValueSize, takes precedence over
+// coll.offledger.limits.maxValueSize. Sizes may be specified with a KB, MB or GB suffix. Zero means that there is no limit.
+func GetOLCollMaxValueSize(ns, coll string) int {
+	return int(viper.GetSizeInBytes(olCollSettingKey(ns, coll, confOLCollMaxValueSize)))
+}
+
+// GetOLCollMaxKeyCount returns the maximum number of keys in the given off-ledger collection. The collection-specific
+// setting, coll.offledger.collections.<ns>.<coll>.limits.maxKeyCount, takes precedence over
+// coll.offledger.limits.maxKeyCount. Zero means that there is no limit.
+func GetOLCollMaxKeyCount(ns, coll string) int {
+	return viper.GetInt(olCollSettingKey(ns, coll, confOLCollMaxKeyCount))
+}
+
+// GetOLCollMaxTotalBytes returns the maximum total size (in bytes) of all of the values in the given off-ledger
+// collection. The collection-specific setting, coll.offledger.collections.<ns>.<coll>.limits.maxTotalBytes, takes
+// precedence over coll.offledger.limits.maxTotalBytes. Sizes may be specified with a KB, MB or GB suffix. Zero means
+// that there is no limit.
+func GetOLCollMaxTotalBytes(ns, coll string) int64 {
+	return int64(viper.GetSizeInBytes(olCollSettingKey(ns, coll, confOLCollMaxTotalBytes)))
+}
+
+// GetOLCollQuotaReloadInterval returns the minimum interval between reloads of the usage of an off-ledger collection
+// that has a quota. The usage is reloaded from the database before a write that would exceed the quota is rejected.
+func GetOLCollQuotaReloadInterval() time.Duration {
+	interval := viper.GetDuration(confOLCollQuotaReloadInterval)
+	if interval == 0 {
+		interval = defaultOLCollQuotaReloadInterval
+	}
+	return interval
+}
+
+// olCollSettingKey returns the collection-specific key of the given setting if it's set, otherwise the global key
+func olCollSettingKey(ns, coll, key string) string {
+	collKey := strings.Join([]string{confOLCollCollections, ns, coll, key}, ".")
+	if ns != "" && coll != "" && viper.IsSet(collKey) {
+		return collKey
+	}
+
+	return confOLCollPrefix + "." + key
+}
+
 // GetDCASMaxLinksPerBlock specifies the maximum number of links there will be per block in a Merkle DAG.
 func GetDCASMaxLinksPerBlock() int {
 	maxLinks := viper.GetInt(confDCASMaxLinksPerBlock)
@@ -262,6 +414,17 @@ func GetDCASBlockLayout() string {
 	return viper.GetString(confDCASBlockLayout)
 }
 
+// GetDCASGCLevelDBPath returns the filesystem path that is used to maintain the DCAS garbage collection level db
+func GetDCASGCLevelDBPath() string {
+	return filepath.Join(filepath.Join(filepath.Clean(config.GetPath(confPeerFileSystemPath)), confLedgerDataPath), confDCASGCLeveldb)
+}
+
+// GetDCASGCInterval returns the interval at which unreferenced DCAS blocks are removed from
+// the off-ledger store. A value of zero (the default) disables periodic garbage collection.
+func GetDCASGCInterval() time.Duration {
+	return viper.GetDuration(confDCASGCInterval)
+}
+
 // GetConfigUpdatePublisherBufferSize returns the size of the config update publisher channel buffer for ledger config update events
 func GetConfigUpdatePublisherBufferSize() int {
 	size := viper.GetInt(confConfigUpdatePublisherBufferSize)
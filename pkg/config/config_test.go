@@ -76,6 +76,16 @@ func TestGetTransientDataAlwaysPersist(t *testing.T) {
 	require.Equal(t, false, GetTransientDataAlwaysPersist())
 }
 
+func TestGetTransientDataTouchOnRead(t *testing.T) {
+	oldVal := viper.Get(confTransientDataTouchOnRead)
+	defer viper.Set(confTransientDataTouchOnRead, oldVal)
+
+	require.False(t, GetTransientDataTouchOnRead())
+
+	viper.Set(confTransientDataTouchOnRead, true)
+	require.True(t, GetTransientDataTouchOnRead())
+}
+
 func TestGetOLLevelDBPath(t *testing.T) {
 	oldVal := viper.Get("peer.fileSystemPath")
 	defer viper.Set("peer.fileSystemPath", oldVal)
@@ -153,6 +163,135 @@ func TestGetOLCollPullTimeout(t *testing.T) {
 	assert.Equal(t, 111*time.Second, GetOLCollPullTimeout())
 }
 
+func TestGetOLCollQueryTimeout(t *testing.T) {
+	oldVal := viper.Get(confOLCollQueryTimeout)
+	defer viper.Set(confOLCollQueryTimeout, oldVal)
+
+	viper.Set(confOLCollQueryTimeout, "")
+	assert.Equal(t, defaultOLCollQueryTimeout, GetOLCollQueryTimeout())
+
+	viper.Set(confOLCollQueryTimeout, 3*time.Second)
+	assert.Equal(t, 3*time.Second, GetOLCollQueryTimeout())
+}
+
+func TestGetOLCollQueryMaxResultsPerPeer(t *testing.T) {
+	oldVal := viper.Get(confOLCollQueryMaxResults)
+	defer viper.Set(confOLCollQueryMaxResults, oldVal)
+
+	viper.Set(confOLCollQueryMaxResults, "")
+	assert.Equal(t, defaultOLCollQueryMaxResults, GetOLCollQueryMaxResultsPerPeer())
+
+	viper.Set(confOLCollQueryMaxResults, 50)
+	assert.Equal(t, 50, GetOLCollQueryMaxResultsPerPeer())
+}
+
+func TestIsOLCollReconcileEnabled(t *testing.T) {
+	oldVal := viper.Get(confOLCollReconcileEnabled)
+	defer viper.Set(confOLCollReconcileEnabled, oldVal)
+
+	viper.Set(confOLCollReconcileEnabled, "")
+	assert.False(t, IsOLCollReconcileEnabled())
+
+	viper.Set(confOLCollReconcileEnabled, true)
+	assert.True(t, IsOLCollReconcileEnabled())
+}
+
+func TestGetOLCollReconcileInterval(t *testing.T) {
+	oldVal := viper.Get(confOLCollReconcileInterval)
+	defer viper.Set(confOLCollReconcileInterval, oldVal)
+
+	viper.Set(confOLCollReconcileInterval, "")
+	assert.Equal(t, defaultOLCollReconcileInterval, GetOLCollReconcileInterval())
+
+	viper.Set(confOLCollReconcileInterval, 30*time.Second)
+	assert.Equal(t, 30*time.Second, GetOLCollReconcileInterval())
+}
+
+func TestGetOLCollReconcileBatchSize(t *testing.T) {
+	oldVal := viper.Get(confOLCollReconcileBatchSize)
+	defer viper.Set(confOLCollReconcileBatchSize, oldVal)
+
+	viper.Set(confOLCollReconcileBatchSize, "")
+	assert.Equal(t, defaultOLCollReconcileBatchSize, GetOLCollReconcileBatchSize())
+
+	viper.Set(confOLCollReconcileBatchSize, 25)
+	assert.Equal(t, 25, GetOLCollReconcileBatchSize())
+}
+
+func TestGetOLCollTombstoneTTL(t *testing.T) {
+	oldVal := viper.Get(confOLCollTombstoneTTL)
+	defer viper.Set(confOLCollTombstoneTTL, oldVal)
+
+	viper.Set(confOLCollTombstoneTTL, "")
+	assert.Equal(t, defaultOLCollTombstoneTTL, GetOLCollTombstoneTTL())
+
+	viper.Set(confOLCollTombstoneTTL, 48*time.Hour)
+	assert.Equal(t, 48*time.Hour, GetOLCollTombstoneTTL())
+}
+
+func TestGetOLCollQuotaReloadInterval(t *testing.T) {
+	oldVal := viper.Get(confOLCollQuotaReloadInterval)
+	defer viper.Set(confOLCollQuotaReloadInterval, oldVal)
+
+	viper.Set(confOLCollQuotaReloadInterval, "")
+	assert.Equal(t, defaultOLCollQuotaReloadInterval, GetOLCollQuotaReloadInterval())
+
+	viper.Set(confOLCollQuotaReloadInterval, 5*time.Second)
+	assert.Equal(t, 5*time.Second, GetOLCollQuotaReloadInterval())
+}
+
+func TestGetOLCollEncryptionSettings(t *testing.T) {
+	oldKeyFile := viper.Get(confOLCollEncryptionKeyFile)
+	defer viper.Set(confOLCollEncryptionKeyFile, oldKeyFile)
+
+	oldCollTypes := viper.Get(confOLCollEncryptedCollTypes)
+	defer viper.Set(confOLCollEncryptedCollTypes, oldCollTypes)
+
+	viper.Set(confOLCollEncryptionKeyFile, "")
+	viper.Set(confOLCollEncryptedCollTypes, nil)
+	assert.Empty(t, GetOLCollEncryptionKeyFile())
+	assert.Empty(t, GetOLCollEncryptedCollTypes())
+
+	viper.Set(confOLCollEncryptionKeyFile, "/etc/keys/kek")
+	viper.Set(confOLCollEncryptedCollTypes, []string{"COL_OFFLEDGER", "COL_DCAS"})
+	assert.Equal(t, "/etc/keys/kek", GetOLCollEncryptionKeyFile())
+	assert.Equal(t, []string{"COL_OFFLEDGER", "COL_DCAS"}, GetOLCollEncryptedCollTypes())
+}
+
+func TestGetOLCollLimits(t *testing.T) {
+	const (
+		ns1   = "ns1"
+		coll1 = "coll1"
+		coll2 = "coll2"
+	)
+
+	collKey := confOLCollCollections + "." + ns1 + "." + coll1 + "."
+
+	defer func() {
+		viper.Set(confOLCollPrefix+"."+confOLCollMaxValueSize, nil)
+		viper.Set(confOLCollPrefix+"."+confOLCollMaxTotalBytes, nil)
+		viper.Set(collKey+confOLCollMaxValueSize, nil)
+		viper.Set(collKey+confOLCollMaxKeyCount, nil)
+	}()
+
+	require.Zero(t, GetOLCollMaxValueSize(ns1, coll1))
+	require.Zero(t, GetOLCollMaxKeyCount(ns1, coll1))
+	require.Zero(t, GetOLCollMaxTotalBytes(ns1, coll1))
+
+	viper.Set(confOLCollPrefix+"."+confOLCollMaxValueSize, "1MB")
+	viper.Set(confOLCollPrefix+"."+confOLCollMaxTotalBytes, "2GB")
+	viper.Set(collKey+confOLCollMaxValueSize, 0)
+	viper.Set(collKey+confOLCollMaxKeyCount, 1000)
+
+	require.Zero(t, GetOLCollMaxValueSize(ns1, coll1))
+	require.Equal(t, 1000, GetOLCollMaxKeyCount(ns1, coll1))
+	require.Equal(t, int64(2<<30), GetOLCollMaxTotalBytes(ns1, coll1))
+
+	require.Equal(t, 1<<20, GetOLCollMaxValueSize(ns1, coll2))
+	require.Zero(t, GetOLCollMaxKeyCount(ns1, coll2))
+	require.Equal(t, 1<<20, GetOLCollMaxValueSize("", ""))
+}
+
 func TestGetConfigUpdatePublisherBufferSize(t *testing.T) {
 	oldVal := viper.Get(confConfigUpdatePublisherBufferSize)
 	defer viper.Set(confConfigUpdatePublisherBufferSize, oldVal)
@@ -208,6 +347,17 @@ func TestGetTransientStoreDBType(t *testing.T) {
 	require.Equal(t, LevelDBType, GetTransientStoreDBType())
 }
 
+func TestGetTransientDataDBType(t *testing.T) {
+	oldVal := viper.Get(ConfTransientDataDBType)
+	defer viper.Set(ConfTransientDataDBType, oldVal)
+
+	viper.Set(ConfTransientDataDBType, "")
+	require.Equal(t, defaultTransientDataDBType, GetTransientDataDBType())
+
+	viper.Set(ConfTransientDataDBType, CouchDBType)
+	require.Equal(t, CouchDBType, GetTransientDataDBType())
+}
+
 func TestGetDCASBlockLayout(t *testing.T) {
 	oldVal := viper.Get(confDCASBlockLayout)
 	defer viper.Set(confDCASBlockLayout, oldVal)
@@ -216,6 +366,26 @@ func TestGetDCASBlockLayout(t *testing.T) {
 	require.Equal(t, "trickle", GetDCASBlockLayout())
 }
 
+func TestGetDCASGCLevelDBPath(t *testing.T) {
+	oldVal := viper.Get("peer.fileSystemPath")
+	defer viper.Set("peer.fileSystemPath", oldVal)
+
+	viper.Set("peer.fileSystemPath", "/tmp123")
+
+	require.Equal(t, "/tmp123/ledgersData/dcasGCLeveldb", GetDCASGCLevelDBPath())
+}
+
+func TestGetDCASGCInterval(t *testing.T) {
+	oldVal := viper.Get(confDCASGCInterval)
+	defer viper.Set(confDCASGCInterval, oldVal)
+
+	viper.Set(confDCASGCInterval, "")
+	require.Equal(t, time.Duration(0), GetDCASGCInterval())
+
+	viper.Set(confDCASGCInterval, "1m")
+	require.Equal(t, time.Minute, GetDCASGCInterval())
+}
+
 func TestGetDCASMaxBlockSize(t *testing.T) {
 	oldVal := viper.Get(confDCASMaxBlockSize)
 	defer viper.Set(confDCASMaxBlockSize, oldVal)
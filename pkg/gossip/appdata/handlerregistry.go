@@ -21,6 +21,14 @@ type Responder interface {
 	Respond(data []byte)
 }
 
+// Requester provides information about the peer that sent an application data request. The Responder
+// that is passed to a Handler may be cast to a Requester.
+type Requester interface {
+	// RequesterMSPID returns the MSP ID of the peer that sent the request. An empty string is returned
+	// if the MSP ID could not be determined.
+	RequesterMSPID() string
+}
+
 // Handler handles an application data request
 type Handler func(channelID string, request *gproto.AppDataRequest, responder Responder)
 
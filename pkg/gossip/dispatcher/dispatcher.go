@@ -45,15 +45,22 @@ type appDataHandlerProvider interface {
 type responder struct {
 	channelID string
 	request   protoext.ReceivedMessage
+	mspID     string
 }
 
-func newResponder(channelID string, req protoext.ReceivedMessage) *responder {
+func newResponder(channelID string, req protoext.ReceivedMessage, mspID string) *responder {
 	return &responder{
 		channelID: channelID,
 		request:   req,
+		mspID:     mspID,
 	}
 }
 
+// RequesterMSPID returns the MSP ID of the peer that sent the request
+func (r *responder) RequesterMSPID() string {
+	return r.mspID
+}
+
 func (r *responder) Respond(resp []byte) {
 	msg := r.request.GetGossipMessage()
 	reqID := msg.GetAppDataReq().Nonce
@@ -283,7 +290,7 @@ func (s *Dispatcher) isAuthorized(mspID string, ns, coll string) (bool, error) {
 func (s *Dispatcher) handleAppDataRequest(msg protoext.ReceivedMessage) {
 	req := msg.GetGossipMessage().GetAppDataReq()
 
-	resp := newResponder(s.channelID, msg)
+	resp := newResponder(s.channelID, msg, s.mspIDFromPKIID(msg.GetConnectionInfo()))
 
 	handleRequest, ok := s.HandlerForType(req.DataType)
 	if ok {
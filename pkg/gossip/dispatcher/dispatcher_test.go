@@ -467,7 +467,12 @@ func TestDispatchAppDataRequest(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		appDataHandlerProvider := &gmocks.AppDataHandlerProvider{}
 		handlerResponse := []byte("handlerResponse")
+		var requesterMSPID string
 		appDataHandlerProvider.HandlerForTypeReturns(func(channelID string, request *gproto.AppDataRequest, responder appdata.Responder) {
+			requester, ok := responder.(appdata.Requester)
+			if ok {
+				requesterMSPID = requester.RequesterMSPID()
+			}
 			responder.Respond(handlerResponse)
 		}, true)
 
@@ -493,6 +498,7 @@ func TestDispatchAppDataRequest(t *testing.T) {
 		require.NotNil(t, res)
 		require.Equal(t, reqID1, res.Nonce)
 		require.Equal(t, handlerResponse, res.Response)
+		require.Equal(t, org2MSPID, requesterMSPID)
 	})
 
 	t.Run("No handler", func(t *testing.T) {
@@ -45,3 +45,12 @@ func (p *ChaincodeInfoProvider) ChaincodeInfo(channelName, chaincodeName string,
 
 	return p.ccInfoMap[chaincodeName], nil
 }
+
+// AllChaincodesInfo returns mock chaincode info for all chaincodes
+func (p *ChaincodeInfoProvider) AllChaincodesInfo(channelName string, qe ledger.SimpleQueryExecutor) (map[string]*ledger.DeployedChaincodeInfo, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return p.ccInfoMap, nil
+}
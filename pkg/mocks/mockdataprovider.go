@@ -8,6 +8,8 @@ package mocks
 
 import (
 	"context"
+	"sort"
+	"strconv"
 
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
 )
@@ -108,6 +110,72 @@ func (m *dataRetriever) Query(ctxt context.Context, key *storeapi.QueryKey) (sto
 	return newResultsIterator(m.queryResults[*key]), nil
 }
 
+// QueryWithPagination returns a page of the results of the given rich query
+func (m *dataRetriever) QueryWithPagination(ctxt context.Context, key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+
+	results, metadata, err := pageResults(m.queryResults[*key], bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newResultsIterator(results), metadata, nil
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (m *dataRetriever) GetDataByRange(ctxt context.Context, key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return newResultsIterator(rangeResults(m.data, key)), nil
+}
+
+// pageResults returns the page of results starting at the offset given by the bookmark along with the
+// bookmark of the next page
+func pageResults(results []*storeapi.QueryResult, bookmark string, pageSize int32) ([]*storeapi.QueryResult, *storeapi.QueryResponseMetadata, error) {
+	start := 0
+	if bookmark != "" {
+		offset, err := strconv.Atoi(bookmark)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = offset
+	}
+
+	if start >= len(results) {
+		return nil, &storeapi.QueryResponseMetadata{}, nil
+	}
+
+	end := start + int(pageSize)
+	if end >= len(results) {
+		return results[start:], &storeapi.QueryResponseMetadata{FetchedRecordsCount: int32(len(results) - start)}, nil
+	}
+
+	return results[start:end], &storeapi.QueryResponseMetadata{FetchedRecordsCount: pageSize, Bookmark: strconv.Itoa(end)}, nil
+}
+
+func rangeResults(data map[storeapi.Key]*storeapi.ExpiringValue, key *storeapi.RangeKey) []*storeapi.QueryResult {
+	var results []*storeapi.QueryResult
+	for k, v := range data {
+		if k.Namespace != key.Namespace || k.Collection != key.Collection {
+			continue
+		}
+		if k.Key < key.StartKey || (key.EndKey != "" && k.Key >= key.EndKey) {
+			continue
+		}
+		results = append(results, &storeapi.QueryResult{
+			Key:           storeapi.NewKey(k.EndorsedAtTxID, k.Namespace, k.Collection, k.Key),
+			ExpiringValue: v,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Key.Key < results[j].Key.Key })
+
+	return results
+}
+
 type resultsIterator struct {
 	results []*storeapi.QueryResult
 	nextIdx int
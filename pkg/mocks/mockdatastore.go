@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
+	"time"
+
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	proto "github.com/hyperledger/fabric-protos-go/transientstore"
 	storeapi "github.com/hyperledger/fabric/extensions/collections/api/store"
@@ -16,6 +18,9 @@ import (
 type DataStore struct {
 	transientData map[storeapi.Key]*storeapi.ExpiringValue
 	olData        map[storeapi.Key]*storeapi.ExpiringValue
+	revisions     map[storeapi.Key]string
+	tombstones    map[storeapi.Key]*storeapi.Tombstone
+	touched       []storeapi.Key
 	err           error
 	queryResults  map[storeapi.QueryKey][]*storeapi.QueryResult
 	itErr         error
@@ -26,6 +31,8 @@ func NewDataStore() *DataStore {
 	return &DataStore{
 		transientData: make(map[storeapi.Key]*storeapi.ExpiringValue),
 		olData:        make(map[storeapi.Key]*storeapi.ExpiringValue),
+		revisions:     make(map[storeapi.Key]string),
+		tombstones:    make(map[storeapi.Key]*storeapi.Tombstone),
 		queryResults:  make(map[storeapi.QueryKey][]*storeapi.QueryResult),
 	}
 }
@@ -84,20 +91,70 @@ func (m *DataStore) GetTransientDataMultipleKeys(key *storeapi.MultiKey) (storea
 	return values, m.err
 }
 
+// TouchTransientData records the given keys as touched
+func (m *DataStore) TouchTransientData(key *storeapi.MultiKey) {
+	for _, k := range key.Keys {
+		m.touched = append(m.touched, storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k})
+	}
+}
+
+// Touched returns the transient data keys that were touched
+func (m *DataStore) Touched() []storeapi.Key {
+	return m.touched
+}
+
 // PutData stores the key/value
 func (m *DataStore) PutData(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.olData[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}] = value
+	k := storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}
+	m.olData[k] = value
+	m.revisions[k] = key.EndorsedAtTxID
+	return nil
+}
+
+// PutDataIfLocalRevision stores the key/value if the given revision matches the revision of the stored value
+func (m *DataStore) PutDataIfLocalRevision(config *pb.StaticCollectionConfig, key *storeapi.Key, value *storeapi.ExpiringValue, revision string) error {
+	if m.err != nil {
+		return m.err
+	}
+	current := m.revisions[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}]
+	if current != revision {
+		return &storeapi.RevisionConflictError{Key: key, Expected: revision, Actual: current}
+	}
+	return m.PutData(config, key, value)
+}
+
+// DeleteData deletes the given keys
+func (m *DataStore) DeleteData(config *pb.StaticCollectionConfig, key *storeapi.MultiKey) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, k := range key.Keys {
+		delete(m.olData, storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k})
+		delete(m.revisions, storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k})
+		m.tombstones[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: k}] = &storeapi.Tombstone{TxID: key.EndorsedAtTxID, WriteTime: time.Now().UTC()}
+	}
 	return nil
 }
 
+// GetTombstone returns the tombstone of the given key or nil if the key wasn't deleted
+func (m *DataStore) GetTombstone(key *storeapi.Key) (*storeapi.Tombstone, error) {
+	return m.tombstones[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}], m.err
+}
+
 // GetData gets the value for the given DCAS item
 func (m *DataStore) GetData(key *storeapi.Key) (*storeapi.ExpiringValue, error) {
 	return m.olData[storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}], m.err
 }
 
+// GetDataWithRevision gets the value for the given item along with the revision of the stored value
+func (m *DataStore) GetDataWithRevision(key *storeapi.Key) (*storeapi.ExpiringValue, string, error) {
+	k := storeapi.Key{Namespace: key.Namespace, Collection: key.Collection, Key: key.Key}
+	return m.olData[k], m.revisions[k], m.err
+}
+
 // GetDataMultipleKeys gets the values for the multiple DCAS items in a single call
 func (m *DataStore) GetDataMultipleKeys(key *storeapi.MultiKey) (storeapi.ExpiringValues, error) {
 	var values storeapi.ExpiringValues
@@ -119,6 +176,33 @@ func (m *DataStore) Query(key *storeapi.QueryKey) (storeapi.ResultsIterator, err
 	return newStoreResultsIterator(m.queryResults[*key], m.itErr), nil
 }
 
+// QueryWithPagination returns a page of the results of the given rich query
+func (m *DataStore) QueryWithPagination(key *storeapi.QueryKey, bookmark string, pageSize int32) (storeapi.ResultsIterator, *storeapi.QueryResponseMetadata, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+
+	results, metadata, err := pageResults(m.queryResults[*key], bookmark, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newStoreResultsIterator(results, m.itErr), metadata, nil
+}
+
+// GetDataByRange returns the data for the given range of keys, ordered by key
+func (m *DataStore) GetDataByRange(key *storeapi.RangeKey) (storeapi.ResultsIterator, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	results := rangeResults(m.olData, key)
+	for _, r := range results {
+		r.Key.EndorsedAtTxID = m.revisions[storeapi.Key{Namespace: r.Key.Namespace, Collection: r.Key.Collection, Key: r.Key.Key}]
+	}
+
+	return newStoreResultsIterator(results, m.itErr), nil
+}
+
 // Close closes the store
 func (m *DataStore) Close() {
 }
@@ -8,6 +8,7 @@ package peer
 
 import (
 	"fmt"
+	"time"
 
 	cfg "github.com/trustbloc/fabric-peer-ext/pkg/config"
 )
@@ -17,6 +18,7 @@ type dcasConfig struct {
 	rawLeaves        bool
 	blockSize        int64
 	layout           string
+	gcInterval       time.Duration
 }
 
 func newDCASConfig() *dcasConfig {
@@ -25,6 +27,7 @@ func newDCASConfig() *dcasConfig {
 		rawLeaves:        cfg.IsDCASRawLeaves(),
 		blockSize:        cfg.GetDCASMaxBlockSize(),
 		layout:           cfg.GetDCASBlockLayout(),
+		gcInterval:       cfg.GetDCASGCInterval(),
 	}
 
 	logger.Infof("Created DCAS config: %s", cfg)
@@ -33,7 +36,8 @@ func newDCASConfig() *dcasConfig {
 }
 
 func (c *dcasConfig) String() string {
-	return fmt.Sprintf("blockSize: %d, maxLinksPerBlock: %d, rawLeaves: %t, blockLayout: %s", c.blockSize, c.maxLinksPerBlock, c.rawLeaves, c.layout)
+	return fmt.Sprintf("blockSize: %d, maxLinksPerBlock: %d, rawLeaves: %t, blockLayout: %s, gcInterval: %s",
+		c.blockSize, c.maxLinksPerBlock, c.rawLeaves, c.layout, c.gcInterval)
 }
 
 // GetDCASMaxLinksPerBlock specifies the maximum number of links there will be per block in a Merkle DAG.
@@ -56,3 +60,9 @@ func (c *dcasConfig) GetDCASMaxBlockSize() int64 {
 func (c *dcasConfig) GetDCASBlockLayout() string {
 	return c.layout
 }
+
+// GetDCASGCInterval returns the interval at which unreferenced blocks are removed.
+// A value of zero indicates that garbage collection is disabled.
+func (c *dcasConfig) GetDCASGCInterval() time.Duration {
+	return c.gcInterval
+}
@@ -8,6 +8,7 @@ package peer
 
 import (
 	"testing"
+	"time"
 
 	viper "github.com/spf13/viper2015"
 	"github.com/stretchr/testify/require"
@@ -19,16 +20,20 @@ func TestDCASConfigConfig(t *testing.T) {
 		rawLeaves        = false
 		maxBlockSize     = int64(44)
 		blockLayout      = "trickle"
+		gcInterval       = 10 * time.Minute
 	)
 
 	viper.Set("coll.dcas.maxLinksPerBlock", maxLinksPerBlock)
 	viper.Set("coll.dcas.rawLeaves", rawLeaves)
 	viper.Set("coll.dcas.maxBlockSize", maxBlockSize)
 	viper.Set("coll.dcas.blockLayout", blockLayout)
+	viper.Set("coll.dcas.gc.interval", gcInterval)
+	defer viper.Set("coll.dcas.gc.interval", nil)
 
 	c := newDCASConfig()
 	require.Equal(t, maxLinksPerBlock, c.GetDCASMaxLinksPerBlock())
 	require.Equal(t, rawLeaves, c.IsDCASRawLeaves())
 	require.Equal(t, maxBlockSize, c.GetDCASMaxBlockSize())
 	require.Equal(t, blockLayout, c.GetDCASBlockLayout())
+	require.Equal(t, gcInterval, c.GetDCASGCInterval())
 }
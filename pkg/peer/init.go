@@ -11,18 +11,23 @@ import (
 	"github.com/hyperledger/fabric/extensions/gossip/state"
 	storagecouchdb "github.com/hyperledger/fabric/extensions/storage/couchdb"
 
+	"github.com/trustbloc/fabric-peer-ext/cmd/chaincode/collcachescc"
 	"github.com/trustbloc/fabric-peer-ext/cmd/chaincode/configcc"
 	ccnotifier "github.com/trustbloc/fabric-peer-ext/pkg/chaincode/notifier"
+	"github.com/trustbloc/fabric-peer-ext/pkg/chaincode/scc"
 	"github.com/trustbloc/fabric-peer-ext/pkg/chaincode/ucc"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/client"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas"
 	dcasclient "github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dcas/client"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/dissemination"
+	"github.com/trustbloc/fabric-peer-ext/pkg/collections/offledger/reconciler"
 	extretriever "github.com/trustbloc/fabric-peer-ext/pkg/collections/retriever"
 	"github.com/trustbloc/fabric-peer-ext/pkg/collections/storeprovider"
 	tretriever "github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/retriever"
 	tdatastore "github.com/trustbloc/fabric-peer-ext/pkg/collections/transientdata/storeprovider"
 	extcouchdb "github.com/trustbloc/fabric-peer-ext/pkg/common/couchdb"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/dbname"
+	extmetrics "github.com/trustbloc/fabric-peer-ext/pkg/common/metrics"
 	"github.com/trustbloc/fabric-peer-ext/pkg/common/support"
 	cfgservice "github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/service"
 	configvalidator "github.com/trustbloc/fabric-peer-ext/pkg/config/ledgerconfig/validator"
@@ -52,11 +57,13 @@ func Initialize() {
 }
 
 func registerResources() {
+	resource.Register(extmetrics.NewProvider)
 	resource.Register(support.NewCollectionConfigRetrieverProvider)
 	resource.Register(tdatastore.New)
 	resource.Register(storeprovider.NewOffLedgerProvider)
 	resource.Register(tretriever.NewProvider)
 	resource.Register(extretriever.NewOffLedgerProvider)
+	resource.Register(reconciler.NewProvider)
 	resource.Register(client.NewProvider)
 	resource.Register(dcasclient.NewProvider)
 	resource.Register(gossipstate.NewUpdateHandler)
@@ -65,6 +72,7 @@ func registerResources() {
 	resource.Register(newConfig)
 	resource.Register(txn.NewProvider)
 	resource.Register(dissemination.LocalMSPProvider.Initialize)
+	resource.Register(dcas.CIDSettingsProvider.Initialize)
 	resource.Register(appdata.NewHandlerRegistry)
 	resource.Register(proprespvalidator.New)
 	resource.Register(extcouchdb.NewReadOnlyProvider)
@@ -80,4 +88,5 @@ func registerResources() {
 
 func registerChaincodes() {
 	ucc.Register(configcc.New)
+	scc.Register(collcachescc.New)
 }
@@ -8,6 +8,7 @@ package peer
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hyperledger/fabric/extensions/collections/storeprovider"
@@ -49,6 +50,7 @@ func TestInitialize(t *testing.T) {
 
 func removeDBPath(t testing.TB) {
 	removePath(t, extconfig.GetTransientDataLevelDBPath())
+	removePath(t, filepath.Dir(extconfig.GetDCASGCLevelDBPath()))
 }
 
 func removePath(t testing.TB, path string) {
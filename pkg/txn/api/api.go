@@ -9,6 +9,7 @@ package api
 import (
 	"errors"
 
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
@@ -57,8 +58,41 @@ type Request struct {
 	// before responding. If true, the commit request returns only after reciving a block with the transaction.
 	AsyncCommit bool
 
+	// ConflictPolicy specifies what to do if the committed transaction is invalidated due to a read conflict,
+	// i.e. with MVCC_READ_CONFLICT or PHANTOM_READ_CONFLICT (default FailOnConflict). The policy only applies
+	// to synchronous commits of requests that don't specify a TransactionID.
+	ConflictPolicy ConflictPolicy
+
+	// CommitStatus (optional) is only used when AsyncCommit is true. If set, the validation status of the transaction
+	// is sent to the channel once the block containing the transaction is committed by the local peer. This allows
+	// the caller to do other work and still find out whether the transaction was VALID or was invalidated (e.g. with
+	// MVCC_READ_CONFLICT). The channel must be buffered since the status is sent without blocking. No status is sent
+	// if the transaction isn't sent to the Orderer, i.e. if an error is returned or if the transaction didn't need to be
+	// committed. A timeout event is sent if the transaction isn't committed within the execute timeout. Requests with a
+	// CommitStatus channel are never batched.
+	CommitStatus chan<- *TxStatusEvent
+
 	// Handler is a custom invocation handler. If nil then the default handler is used
 	Handler invoke.Handler
+
+	// BatchHandler (optional), if set, allows the request to be batched with other compatible requests to
+	// the same chaincode when batching is enabled in the transaction service config. The batched requests
+	// are endorsed as a single invocation and committed together. Requests that specify a custom Handler,
+	// Targets, PeerFilter or TransactionID are never batched. The handler must be comparable (e.g. a pointer)
+	// since requests are only batched together if they have the same handler.
+	BatchHandler BatchHandler
+}
+
+// BatchHandler combines a batch of requests into a single (multi-op) chaincode invocation and splits the
+// response of the invocation into a response for each request
+type BatchHandler interface {
+	// Combine returns the args and transient data of a single chaincode invocation that
+	// performs the operations of all of the given requests
+	Combine(reqs []*Request) (args [][]byte, transientData map[string][]byte, err error)
+
+	// Split splits the payload returned by the combined invocation into one payload per request.
+	// The payloads must be returned in the same order as the requests that were passed to Combine.
+	Split(payload []byte, numRequests int) ([][]byte, error)
 }
 
 // CommitRequest contains the endorsements to be committed along with options
@@ -76,10 +110,24 @@ type CommitRequest struct {
 	// before responding. If true, the commit request returns only after reciving a block with the transaction.
 	AsyncCommit bool
 
+	// CommitStatus (optional) is only used when AsyncCommit is true. If set, the validation status of the transaction
+	// is sent to the (buffered) channel once the block containing the transaction is committed by the local peer, or a
+	// timeout event is sent if the transaction isn't committed within the execute timeout.
+	CommitStatus chan<- *TxStatusEvent
+
 	// Handler is a custom invocation handler. If nil then the default handler is used
 	Handler invoke.Handler
 }
 
+// TxStatusEvent contains the validation status of a committed transaction. If the transaction wasn't committed
+// within the timeout then Timeout is true and the validation code is NOT_VALIDATED.
+type TxStatusEvent struct {
+	TxID             string
+	BlockNumber      uint64
+	TxValidationCode pb.TxValidationCode
+	Timeout          bool
+}
+
 // ChaincodeCall ...
 type ChaincodeCall struct {
 	ChaincodeName string
@@ -124,6 +172,32 @@ func (t CommitType) String() string {
 	}
 }
 
+// ConflictPolicy specifies how a read conflict that's detected when the transaction is validated should be handled
+type ConflictPolicy int
+
+const (
+	// FailOnConflict indicates that the read conflict is handled according to the general retry options of the
+	// transaction service, i.e. an error is returned once the retry attempts for the request are exhausted
+	FailOnConflict ConflictPolicy = iota
+
+	// RetryOnConflict indicates that the request should be endorsed (i.e. simulated) and committed again if the
+	// transaction is invalidated due to a read conflict. The number of attempts and the backoff are specified in the
+	// general config of the transaction service.
+	RetryOnConflict
+)
+
+// String returns the string value of ConflictPolicy
+func (p ConflictPolicy) String() string {
+	switch p {
+	case FailOnConflict:
+		return "failOnConflict"
+	case RetryOnConflict:
+		return "retryOnConflict"
+	default:
+		return "unknown"
+	}
+}
+
 // Namespace contains a chaincode name and an optional set of private data collections to ignore
 type Namespace struct {
 	Name        string
@@ -0,0 +1,235 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// errorThreshold is the status code at (or above) which a chaincode response is considered to be an error
+const errorThreshold = 400
+
+// EndorsementSet contains a transaction proposal along with the proposal responses (endorsements) that were
+// gathered for it. An EndorsementSet is used to collect endorsements offline, for example, when the endorsements
+// of partner organizations are obtained over an out-of-band channel. The typical flow is as follows:
+//
+// - The proposal is created with Service.CreateProposal and signed with Service.SignProposal.
+// - The (marshalled) set is passed to the other parties which endorse the signed proposal and return their proposal responses.
+// - The proposal responses are added to the set with AddResponses.
+// - The endorsements are validated with Service.ValidateProposalResponses.
+// - The set is converted to a response with Response and committed with Service.CommitEndorsements.
+//
+// The set may be marshalled to bytes (see Marshal and UnmarshalEndorsementSet) so that it may be passed between processes.
+type EndorsementSet struct {
+	// TxID is the ID of the transaction
+	TxID string
+
+	// Proposal is the transaction proposal
+	Proposal *pb.Proposal
+
+	// Signature is the signature of the proposal creator over the proposal bytes. It is nil until the proposal is signed.
+	Signature []byte
+
+	// Responses contains the proposal responses that were added to the set
+	Responses []*pb.ProposalResponse
+}
+
+type endorsementSetJSON struct {
+	TxID      string   `json:"txID"`
+	Proposal  []byte   `json:"proposal"`
+	Signature []byte   `json:"signature,omitempty"`
+	Responses [][]byte `json:"responses,omitempty"`
+}
+
+// SignedProposal returns the signed proposal. An error is returned if the proposal hasn't been signed.
+func (s *EndorsementSet) SignedProposal() (*pb.SignedProposal, error) {
+	if len(s.Signature) == 0 {
+		return nil, errors.Errorf("proposal for tx [%s] has not been signed", s.TxID)
+	}
+
+	proposalBytes, err := proto.Marshal(s.Proposal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error marshalling proposal for tx [%s]", s.TxID)
+	}
+
+	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: s.Signature}, nil
+}
+
+// AddResponses adds the given proposal responses to the set. An error is returned if a response is unsuccessful,
+// if it isn't a response to the proposal in the set, or if its payload (i.e. the simulation results) differs from
+// the payloads of the responses that were already added. A response from an endorser that has already endorsed
+// the proposal replaces the previous response.
+func (s *EndorsementSet) AddResponses(responses ...*pb.ProposalResponse) error {
+	proposalHash, err := s.proposalHash()
+	if err != nil {
+		return err
+	}
+
+	// All of the responses must have the same payload as the first response
+	var expectedPayload []byte
+	if len(s.Responses) > 0 {
+		expectedPayload = s.Responses[0].Payload
+	}
+
+	for _, r := range responses {
+		if err := s.validateResponse(r, proposalHash, expectedPayload); err != nil {
+			return err
+		}
+
+		expectedPayload = r.Payload
+	}
+
+	for _, r := range responses {
+		s.addResponse(r)
+	}
+
+	return nil
+}
+
+// Response returns the set as a channel response which may be committed with Service.CommitEndorsements
+func (s *EndorsementSet) Response() (*channel.Response, error) {
+	if len(s.Responses) == 0 {
+		return nil, errors.Errorf("no proposal responses were added for tx [%s]", s.TxID)
+	}
+
+	responses := make([]*fab.TransactionProposalResponse, len(s.Responses))
+	for i, r := range s.Responses {
+		responses[i] = &fab.TransactionProposalResponse{
+			Status:           r.Response.Status,
+			ChaincodeStatus:  r.Response.Status,
+			ProposalResponse: r,
+		}
+	}
+
+	return &channel.Response{
+		TransactionID: fab.TransactionID(s.TxID),
+		Proposal: &fab.TransactionProposal{
+			TxnID:    fab.TransactionID(s.TxID),
+			Proposal: s.Proposal,
+		},
+		Responses:       responses,
+		ChaincodeStatus: s.Responses[0].Response.Status,
+		Payload:         s.Responses[0].Response.Payload,
+	}, nil
+}
+
+// Marshal marshals the set to bytes
+func (s *EndorsementSet) Marshal() ([]byte, error) {
+	proposalBytes, err := proto.Marshal(s.Proposal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error marshalling proposal for tx [%s]", s.TxID)
+	}
+
+	setJSON := &endorsementSetJSON{
+		TxID:      s.TxID,
+		Proposal:  proposalBytes,
+		Signature: s.Signature,
+	}
+
+	for _, r := range s.Responses {
+		responseBytes, err := proto.Marshal(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error marshalling proposal response for tx [%s]", s.TxID)
+		}
+
+		setJSON.Responses = append(setJSON.Responses, responseBytes)
+	}
+
+	return json.Marshal(setJSON)
+}
+
+// UnmarshalEndorsementSet unmarshals an endorsement set from the given bytes
+func UnmarshalEndorsementSet(setBytes []byte) (*EndorsementSet, error) {
+	setJSON := &endorsementSetJSON{}
+	if err := json.Unmarshal(setBytes, setJSON); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling endorsement set")
+	}
+
+	proposal, err := protoutil.UnmarshalProposal(setJSON.Proposal)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error unmarshalling proposal for tx [%s]", setJSON.TxID)
+	}
+
+	s := &EndorsementSet{
+		TxID:      setJSON.TxID,
+		Proposal:  proposal,
+		Signature: setJSON.Signature,
+	}
+
+	for _, responseBytes := range setJSON.Responses {
+		r, err := protoutil.UnmarshalProposalResponse(responseBytes)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error unmarshalling proposal response for tx [%s]", setJSON.TxID)
+		}
+
+		s.Responses = append(s.Responses, r)
+	}
+
+	return s, nil
+}
+
+func (s *EndorsementSet) proposalHash() ([]byte, error) {
+	if s.Proposal == nil {
+		return nil, errors.Errorf("proposal for tx [%s] is nil", s.TxID)
+	}
+
+	hdr, err := protoutil.UnmarshalHeader(s.Proposal.Header)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error unmarshalling proposal header for tx [%s]", s.TxID)
+	}
+
+	proposalHash, err := protoutil.GetProposalHash1(hdr, s.Proposal.Payload)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error computing proposal hash for tx [%s]", s.TxID)
+	}
+
+	return proposalHash, nil
+}
+
+func (s *EndorsementSet) validateResponse(r *pb.ProposalResponse, proposalHash, expectedPayload []byte) error {
+	if r == nil || r.Response == nil || r.Endorsement == nil {
+		return errors.Errorf("invalid proposal response for tx [%s]: response and endorsement are required", s.TxID)
+	}
+
+	if r.Response.Status >= errorThreshold {
+		return errors.Errorf("proposal response for tx [%s] was not successful - status: %d, message: %s", s.TxID, r.Response.Status, r.Response.Message)
+	}
+
+	payload, err := protoutil.UnmarshalProposalResponsePayload(r.Payload)
+	if err != nil {
+		return errors.WithMessagef(err, "error unmarshalling proposal response payload for tx [%s]", s.TxID)
+	}
+
+	if !bytes.Equal(payload.ProposalHash, proposalHash) {
+		return errors.Errorf("proposal response is not a response to the proposal for tx [%s]", s.TxID)
+	}
+
+	if expectedPayload != nil && !bytes.Equal(expectedPayload, r.Payload) {
+		return errors.Errorf("payload of proposal response for tx [%s] doesn't match the payloads of the other responses", s.TxID)
+	}
+
+	return nil
+}
+
+func (s *EndorsementSet) addResponse(r *pb.ProposalResponse) {
+	for i, existing := range s.Responses {
+		if bytes.Equal(existing.Endorsement.Endorser, r.Endorsement.Endorser) {
+			s.Responses[i] = r
+			return
+		}
+	}
+
+	s.Responses = append(s.Responses, r)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	txID1     = "tx1"
+	endorser1 = "endorser1"
+	endorser2 = "endorser2"
+)
+
+func TestEndorsementSet(t *testing.T) {
+	proposal := newProposal(t, txID1)
+
+	proposalHash, err := getProposalHash(proposal)
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		s := &EndorsementSet{TxID: txID1, Proposal: proposal}
+
+		_, err := s.SignedProposal()
+		require.EqualError(t, err, "proposal for tx [tx1] has not been signed")
+
+		_, err = s.Response()
+		require.EqualError(t, err, "no proposal responses were added for tx [tx1]")
+
+		s.Signature = []byte("signature")
+
+		signedProposal, err := s.SignedProposal()
+		require.NoError(t, err)
+		require.Equal(t, s.Signature, signedProposal.Signature)
+
+		require.NoError(t, s.AddResponses(newProposalResponse(t, endorser1, proposalHash, "result")))
+		require.NoError(t, s.AddResponses(
+			newProposalResponse(t, endorser1, proposalHash, "result"),
+			newProposalResponse(t, endorser2, proposalHash, "result"),
+		))
+		require.Len(t, s.Responses, 2, "expecting the response from endorser1 to be replaced")
+
+		resp, err := s.Response()
+		require.NoError(t, err)
+		require.Equal(t, fab.TransactionID(txID1), resp.TransactionID)
+		require.Equal(t, fab.TransactionID(txID1), resp.Proposal.TxnID)
+		require.True(t, proto.Equal(proposal, resp.Proposal.Proposal))
+		require.Len(t, resp.Responses, 2)
+		require.Equal(t, int32(200), resp.ChaincodeStatus)
+		require.Equal(t, []byte("result"), resp.Payload)
+
+		setBytes, err := s.Marshal()
+		require.NoError(t, err)
+
+		s2, err := UnmarshalEndorsementSet(setBytes)
+		require.NoError(t, err)
+		require.Equal(t, s.TxID, s2.TxID)
+		require.Equal(t, s.Signature, s2.Signature)
+		require.True(t, proto.Equal(s.Proposal, s2.Proposal))
+		require.Len(t, s2.Responses, 2)
+
+		for i, r := range s.Responses {
+			require.True(t, proto.Equal(r, s2.Responses[i]))
+		}
+	})
+
+	t.Run("Invalid response", func(t *testing.T) {
+		s := &EndorsementSet{TxID: txID1, Proposal: proposal}
+
+		err := s.AddResponses(&pb.ProposalResponse{})
+		require.EqualError(t, err, "invalid proposal response for tx [tx1]: response and endorsement are required")
+
+		r := newProposalResponse(t, endorser1, proposalHash, "result")
+		r.Response.Status = 500
+		r.Response.Message = "chaincode error"
+
+		err = s.AddResponses(r)
+		require.EqualError(t, err, "proposal response for tx [tx1] was not successful - status: 500, message: chaincode error")
+
+		r = newProposalResponse(t, endorser1, proposalHash, "result")
+		r.Payload = []byte("invalid payload")
+
+		err = s.AddResponses(r)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling proposal response payload")
+
+		err = s.AddResponses(newProposalResponse(t, endorser1, []byte("other hash"), "result"))
+		require.EqualError(t, err, "proposal response is not a response to the proposal for tx [tx1]")
+
+		err = s.AddResponses(
+			newProposalResponse(t, endorser1, proposalHash, "result"),
+			newProposalResponse(t, endorser2, proposalHash, "other result"),
+		)
+		require.EqualError(t, err, "payload of proposal response for tx [tx1] doesn't match the payloads of the other responses")
+		require.Empty(t, s.Responses, "expecting no responses to be added if any of the responses is invalid")
+	})
+
+	t.Run("Invalid proposal", func(t *testing.T) {
+		s := &EndorsementSet{TxID: txID1}
+
+		err := s.AddResponses(newProposalResponse(t, endorser1, proposalHash, "result"))
+		require.EqualError(t, err, "proposal for tx [tx1] is nil")
+
+		s.Proposal = &pb.Proposal{Header: []byte{0xff}}
+
+		err = s.AddResponses(newProposalResponse(t, endorser1, proposalHash, "result"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling proposal header")
+
+		s.Proposal = &pb.Proposal{Header: protoutil.MarshalOrPanic(&cb.Header{})}
+
+		err = s.AddResponses(newProposalResponse(t, endorser1, proposalHash, "result"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error computing proposal hash")
+	})
+
+	t.Run("Unmarshal error", func(t *testing.T) {
+		_, err := UnmarshalEndorsementSet([]byte("{"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling endorsement set")
+
+		_, err = UnmarshalEndorsementSet([]byte(`{"txID":"tx1","proposal":"aW52YWxpZA=="}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling proposal for tx [tx1]")
+
+		_, err = UnmarshalEndorsementSet([]byte(`{"txID":"tx1","responses":["aW52YWxpZA=="]}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling proposal response for tx [tx1]")
+	})
+}
+
+func newProposal(t *testing.T, txID string) *pb.Proposal {
+	cis := &pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			ChaincodeId: &pb.ChaincodeID{Name: "cc1"},
+			Input:       &pb.ChaincodeInput{Args: [][]byte{[]byte("put"), []byte("key1"), []byte("value1")}},
+		},
+	}
+
+	proposal, _, err := protoutil.CreateChaincodeProposalWithTxIDNonceAndTransient(txID, cb.HeaderType_ENDORSER_TRANSACTION,
+		"channel1", cis, []byte("nonce"), []byte("creator"), map[string][]byte{"transient": []byte("data")})
+	require.NoError(t, err)
+
+	return proposal
+}
+
+func getProposalHash(proposal *pb.Proposal) ([]byte, error) {
+	hdr, err := protoutil.UnmarshalHeader(proposal.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return protoutil.GetProposalHash1(hdr, proposal.Payload)
+}
+
+func newProposalResponse(t *testing.T, endorser string, proposalHash []byte, result string) *pb.ProposalResponse {
+	payload, err := proto.Marshal(&pb.ProposalResponsePayload{
+		ProposalHash: proposalHash,
+		Extension:    []byte(result),
+	})
+	require.NoError(t, err)
+
+	return &pb.ProposalResponse{
+		Version: 1,
+		Response: &pb.Response{
+			Status:  200,
+			Payload: []byte(result),
+		},
+		Payload: payload,
+		Endorsement: &pb.Endorsement{
+			Endorser:  []byte(endorser),
+			Signature: []byte("signature"),
+		},
+	}
+}
@@ -36,4 +36,11 @@ type Service interface {
 
 	// ValidateProposalResponses validates the given proposal responses
 	ValidateProposalResponses(signedProposal *pb.SignedProposal, proposalResponses []*pb.ProposalResponse) (pb.TxValidationCode, error)
+
+	// CreateProposal creates an unsigned transaction proposal for the given request without sending it to any endorsers.
+	// The returned endorsement set is used to collect endorsements offline (see EndorsementSet).
+	CreateProposal(req *Request) (*EndorsementSet, error)
+
+	// SignProposal signs the proposal in the given endorsement set with the signing identity of the service
+	SignProposal(set *EndorsementSet) error
 }
@@ -0,0 +1,295 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
+)
+
+const defaultBatchWindow = 50 * time.Millisecond
+
+type endorseAndCommitFunc func(req *api.Request) (*channel.Response, bool, error)
+
+// batchKey identifies the requests that are compatible with each other, i.e. the requests that may be
+// endorsed and committed in the same transaction
+type batchKey struct {
+	chaincodeID      string
+	commitType       api.CommitType
+	asyncCommit      bool
+	conflictPolicy   api.ConflictPolicy
+	ignoreNamespaces string
+	handler          api.BatchHandler
+}
+
+func newBatchKey(req *api.Request) batchKey {
+	return batchKey{
+		chaincodeID:      req.ChaincodeID,
+		commitType:       req.CommitType,
+		asyncCommit:      req.AsyncCommit,
+		conflictPolicy:   req.ConflictPolicy,
+		ignoreNamespaces: fmt.Sprintf("%v", req.IgnoreNameSpaces),
+		handler:          req.BatchHandler,
+	}
+}
+
+type batchResult struct {
+	resp      *channel.Response
+	committed bool
+	err       error
+}
+
+type pendingRequest struct {
+	req    *api.Request
+	result chan *batchResult
+}
+
+type batch struct {
+	key      batchKey
+	requests []*pendingRequest
+	timer    *time.Timer
+}
+
+// batcher buffers compatible requests and endorses and commits them in a single transaction once either the
+// maximum batch size is reached or the batch window expires. Each caller is blocked until the transaction
+// for its batch has completed and then receives its own response.
+type batcher struct {
+	channelID        string
+	endorseAndCommit endorseAndCommitFunc
+	mutex            sync.Mutex
+	maxSize          int
+	window           time.Duration
+	batches          map[batchKey]*batch
+}
+
+func newBatcher(channelID string, endorseAndCommit endorseAndCommitFunc) *batcher {
+	return &batcher{
+		channelID:        channelID,
+		endorseAndCommit: endorseAndCommit,
+		window:           defaultBatchWindow,
+		batches:          make(map[batchKey]*batch),
+	}
+}
+
+// configure sets the maximum batch size and the batch window. Batching is disabled if maxSize is less than 2.
+func (b *batcher) configure(maxSize int, window time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if window <= 0 {
+		window = defaultBatchWindow
+	}
+
+	logger.Debugf("[%s] Batching config - MaxSize: %d, Window: %s", b.channelID, maxSize, window)
+
+	b.maxSize = maxSize
+	b.window = window
+}
+
+// canBatch returns true if batching is enabled and the given request may be batched
+func (b *batcher) canBatch(req *api.Request) bool {
+	if req.BatchHandler == nil || req.Handler != nil || req.TransactionID != "" || len(req.Nonce) > 0 ||
+		len(req.Targets) > 0 || req.PeerFilter != nil || req.CommitStatus != nil {
+		return false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.maxSize > 1
+}
+
+// submit adds the given request to a batch and blocks until the batch has been processed
+func (b *batcher) submit(req *api.Request) (*channel.Response, bool, error) {
+	p := &pendingRequest{
+		req:    req,
+		result: make(chan *batchResult, 1),
+	}
+
+	if full := b.add(p); full != nil {
+		b.process(full)
+	}
+
+	r := <-p.result
+
+	return r.resp, r.committed, r.err
+}
+
+// add adds the given request to the batch for its key and returns the batch if it's full
+func (b *batcher) add(p *pendingRequest) *batch {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := newBatchKey(p.req)
+
+	bt, ok := b.batches[key]
+	if !ok {
+		bt = &batch{key: key}
+		b.batches[key] = bt
+		bt.timer = time.AfterFunc(b.window, func() { b.flush(bt) })
+	}
+
+	bt.requests = append(bt.requests, p)
+
+	if len(bt.requests) < b.maxSize {
+		return nil
+	}
+
+	bt.timer.Stop()
+	delete(b.batches, key)
+
+	return bt
+}
+
+// flush processes the given batch unless it has already been processed
+func (b *batcher) flush(bt *batch) {
+	b.mutex.Lock()
+
+	if b.batches[bt.key] != bt {
+		b.mutex.Unlock()
+		return
+	}
+
+	delete(b.batches, bt.key)
+	b.mutex.Unlock()
+
+	b.process(bt)
+}
+
+// flushAll processes all of the pending batches
+func (b *batcher) flushAll() {
+	b.mutex.Lock()
+
+	var batches []*batch
+	for _, bt := range b.batches {
+		bt.timer.Stop()
+		batches = append(batches, bt)
+	}
+
+	b.batches = make(map[batchKey]*batch)
+	b.mutex.Unlock()
+
+	for _, bt := range batches {
+		b.process(bt)
+	}
+}
+
+func (b *batcher) process(bt *batch) {
+	if len(bt.requests) == 1 {
+		logger.Debugf("[%s] Only one request in batch for chaincode [%s]. Processing request individually.", b.channelID, bt.key.chaincodeID)
+
+		p := bt.requests[0]
+		resp, committed, err := b.endorseAndCommit(p.req)
+		p.result <- &batchResult{resp: resp, committed: committed, err: err}
+
+		return
+	}
+
+	logger.Debugf("[%s] Processing batch of %d requests for chaincode [%s]", b.channelID, len(bt.requests), bt.key.chaincodeID)
+
+	responses, committed, err := b.endorseAndCommitBatch(bt)
+	if err != nil {
+		logger.Debugf("[%s] Error processing batch of %d requests for chaincode [%s]: %s", b.channelID, len(bt.requests), bt.key.chaincodeID, err)
+	}
+
+	for i, p := range bt.requests {
+		if err != nil {
+			p.result <- &batchResult{err: err}
+		} else {
+			p.result <- &batchResult{resp: responses[i], committed: committed}
+		}
+	}
+}
+
+func (b *batcher) endorseAndCommitBatch(bt *batch) ([]*channel.Response, bool, error) {
+	reqs := make([]*api.Request, len(bt.requests))
+	for i, p := range bt.requests {
+		reqs[i] = p.req
+	}
+
+	args, transientData, err := bt.key.handler.Combine(reqs)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "error combining batched requests")
+	}
+
+	if len(args) == 0 {
+		return nil, false, errors.New("no args returned for the combined request")
+	}
+
+	resp, committed, err := b.endorseAndCommit(&api.Request{
+		ChaincodeID:      bt.key.chaincodeID,
+		Args:             args,
+		TransientData:    transientData,
+		InvocationChain:  mergeInvocationChains(reqs),
+		CommitType:       bt.key.commitType,
+		IgnoreNameSpaces: reqs[0].IgnoreNameSpaces,
+		AsyncCommit:      bt.key.asyncCommit,
+		ConflictPolicy:   bt.key.conflictPolicy,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	payloads, err := bt.key.handler.Split(resp.Payload, len(reqs))
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "error splitting response of batched requests")
+	}
+
+	if len(payloads) != len(reqs) {
+		return nil, false, errors.Errorf("expecting %d payloads in the response of the batched requests but got %d", len(reqs), len(payloads))
+	}
+
+	responses := make([]*channel.Response, len(reqs))
+	for i, payload := range payloads {
+		r := *resp
+		r.Payload = payload
+		responses[i] = &r
+	}
+
+	return responses, committed, nil
+}
+
+// mergeInvocationChains returns the union of the invocation chains of the given requests
+func mergeInvocationChains(reqs []*api.Request) []*api.ChaincodeCall {
+	var chain []*api.ChaincodeCall
+	calls := make(map[string]*api.ChaincodeCall)
+
+	for _, req := range reqs {
+		for _, call := range req.InvocationChain {
+			merged, ok := calls[call.ChaincodeName]
+			if !ok {
+				merged = &api.ChaincodeCall{ChaincodeName: call.ChaincodeName}
+				calls[call.ChaincodeName] = merged
+				chain = append(chain, merged)
+			}
+
+			for _, coll := range call.Collections {
+				if !contains(merged.Collections, coll) {
+					merged.Collections = append(merged.Collections, coll)
+				}
+			}
+		}
+	}
+
+	return chain
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
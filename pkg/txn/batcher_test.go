@@ -0,0 +1,290 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
+)
+
+const (
+	cc1 = "cc1"
+	cc2 = "cc2"
+)
+
+func TestBatcher_CanBatch(t *testing.T) {
+	b := newBatcher("channel1", nil)
+
+	bh := &mockBatchHandler{}
+
+	req := &api.Request{ChaincodeID: cc1, Args: [][]byte{[]byte("put")}, BatchHandler: bh}
+	require.False(t, b.canBatch(req), "batching should be disabled by default")
+
+	b.configure(10, 0)
+	require.Equal(t, defaultBatchWindow, b.window)
+	require.True(t, b.canBatch(req))
+
+	require.False(t, b.canBatch(&api.Request{ChaincodeID: cc1}))
+	require.False(t, b.canBatch(&api.Request{ChaincodeID: cc1, BatchHandler: bh, TransactionID: "tx1", Nonce: []byte("nonce")}))
+	require.False(t, b.canBatch(&api.Request{ChaincodeID: cc1, BatchHandler: bh, Targets: []fab.Peer{nil}}))
+	require.False(t, b.canBatch(&api.Request{ChaincodeID: cc1, BatchHandler: bh, PeerFilter: &mockPeerFilter{}}))
+	require.False(t, b.canBatch(&api.Request{ChaincodeID: cc1, BatchHandler: bh, AsyncCommit: true, CommitStatus: make(chan *api.TxStatusEvent, 1)}))
+}
+
+func TestBatcher_Submit(t *testing.T) {
+	bh := &mockBatchHandler{}
+
+	var mutex sync.Mutex
+	var invoked []*api.Request
+
+	var errEndorse error
+
+	b := newBatcher("channel1", func(req *api.Request) (*channel.Response, bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		invoked = append(invoked, req)
+
+		if errEndorse != nil {
+			return nil, false, errEndorse
+		}
+
+		return &channel.Response{TransactionID: "tx1", Payload: bytes.Join(req.Args[1:], []byte(","))}, true, nil
+	})
+
+	reset := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		invoked = nil
+	}
+
+	newRequest := func(ccID, arg string) *api.Request {
+		return &api.Request{
+			ChaincodeID:     ccID,
+			Args:            [][]byte{[]byte("put"), []byte(arg)},
+			BatchHandler:    bh,
+			InvocationChain: []*api.ChaincodeCall{{ChaincodeName: ccID, Collections: []string{arg}}},
+		}
+	}
+
+	t.Run("Maximum batch size reached", func(t *testing.T) {
+		defer reset()
+
+		b.configure(3, time.Hour)
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"), newRequest(cc1, "c"))
+
+		require.Len(t, invoked, 1)
+		require.Equal(t, cc1, invoked[0].ChaincodeID)
+		require.Len(t, invoked[0].Args, 4)
+		require.Len(t, invoked[0].InvocationChain, 1)
+		require.Len(t, invoked[0].InvocationChain[0].Collections, 3)
+
+		for i, arg := range []string{"a", "b", "c"} {
+			require.NoError(t, results[i].err)
+			require.True(t, results[i].committed)
+			require.Equal(t, fab.TransactionID("tx1"), results[i].resp.TransactionID)
+			require.Equal(t, arg, string(results[i].resp.Payload))
+		}
+	})
+
+	t.Run("Batch window expired", func(t *testing.T) {
+		defer reset()
+
+		b.configure(10, 20*time.Millisecond)
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"), newRequest(cc2, "c"))
+
+		require.Len(t, invoked, 2)
+
+		for i, arg := range []string{"a", "b", "c"} {
+			require.NoError(t, results[i].err)
+			require.Equal(t, arg, string(results[i].resp.Payload))
+		}
+	})
+
+	t.Run("Flush all", func(t *testing.T) {
+		defer reset()
+
+		b.configure(10, time.Hour)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+
+		var result *batchResult
+		go func() {
+			defer wg.Done()
+
+			resp, committed, err := b.submit(newRequest(cc1, "a"))
+			result = &batchResult{resp: resp, committed: committed, err: err}
+		}()
+
+		// Wait for the request to be added to a batch
+		for {
+			b.mutex.Lock()
+			n := len(b.batches)
+			b.mutex.Unlock()
+
+			if n > 0 {
+				break
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		b.flushAll()
+		wg.Wait()
+
+		require.NoError(t, result.err)
+		require.Equal(t, "a", string(result.resp.Payload))
+	})
+
+	t.Run("Endorsement error", func(t *testing.T) {
+		defer reset()
+
+		b.configure(2, time.Hour)
+
+		errEndorse = errors.New("injected endorsement error")
+		defer func() { errEndorse = nil }()
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"))
+		for _, r := range results {
+			require.EqualError(t, r.err, errEndorse.Error())
+		}
+	})
+
+	t.Run("Combine error", func(t *testing.T) {
+		defer reset()
+
+		b.configure(2, time.Hour)
+
+		bh.combineErr = errors.New("injected combine error")
+		defer func() { bh.combineErr = nil }()
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"))
+		for _, r := range results {
+			require.Error(t, r.err)
+			require.Contains(t, r.err.Error(), bh.combineErr.Error())
+		}
+
+		require.Empty(t, invoked)
+	})
+
+	t.Run("No args error", func(t *testing.T) {
+		defer reset()
+
+		b.configure(2, time.Hour)
+
+		bh.noArgs = true
+		defer func() { bh.noArgs = false }()
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"))
+		for _, r := range results {
+			require.EqualError(t, r.err, "no args returned for the combined request")
+		}
+	})
+
+	t.Run("Split error", func(t *testing.T) {
+		defer reset()
+
+		b.configure(2, time.Hour)
+
+		bh.splitErr = errors.New("injected split error")
+		defer func() { bh.splitErr = nil }()
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"))
+		for _, r := range results {
+			require.Error(t, r.err)
+			require.Contains(t, r.err.Error(), bh.splitErr.Error())
+		}
+	})
+
+	t.Run("Payload count mismatch", func(t *testing.T) {
+		defer reset()
+
+		b.configure(2, time.Hour)
+
+		bh.extraPayload = true
+		defer func() { bh.extraPayload = false }()
+
+		results := submitAll(b, newRequest(cc1, "a"), newRequest(cc1, "b"))
+		for _, r := range results {
+			require.EqualError(t, r.err, "expecting 2 payloads in the response of the batched requests but got 3")
+		}
+	})
+}
+
+// submitAll submits the given requests concurrently and returns the results in the same order as the requests
+func submitAll(b *batcher, reqs ...*api.Request) []*batchResult {
+	results := make([]*batchResult, len(reqs))
+
+	var wg sync.WaitGroup
+	wg.Add(len(reqs))
+
+	for i, req := range reqs {
+		go func(i int, req *api.Request) {
+			defer wg.Done()
+
+			resp, committed, err := b.submit(req)
+			results[i] = &batchResult{resp: resp, committed: committed, err: err}
+		}(i, req)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// mockBatchHandler combines requests by appending the args (excluding the function name) of each
+// request and splits the response payload on commas
+type mockBatchHandler struct {
+	combineErr   error
+	splitErr     error
+	noArgs       bool
+	extraPayload bool
+}
+
+func (h *mockBatchHandler) Combine(reqs []*api.Request) ([][]byte, map[string][]byte, error) {
+	if h.combineErr != nil {
+		return nil, nil, h.combineErr
+	}
+
+	if h.noArgs {
+		return nil, nil, nil
+	}
+
+	args := [][]byte{[]byte("batch")}
+	for _, req := range reqs {
+		args = append(args, req.Args[1:]...)
+	}
+
+	return args, nil, nil
+}
+
+func (h *mockBatchHandler) Split(payload []byte, numRequests int) ([][]byte, error) {
+	if h.splitErr != nil {
+		return nil, h.splitErr
+	}
+
+	payloads := bytes.Split(payload, []byte(","))
+
+	if h.extraPayload {
+		payloads = append(payloads, []byte("extra"))
+	}
+
+	return payloads, nil
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"strings"
 
+	"github.com/golang/protobuf/proto"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
@@ -44,10 +45,15 @@ type cryptoSuiteProvider interface {
 	CryptoSuite() core.CryptoSuite
 }
 
+type signer interface {
+	Sign(msg []byte) ([]byte, error)
+}
+
 type channelProviders struct {
 	ChannelClient
 	identitySerializer
 	cryptoSuiteProvider
+	signer
 	fabapi.DiscoveryService
 	fabapi.ChannelMembership
 }
@@ -149,6 +155,21 @@ func (c *Client) SigningIdentity() ([]byte, error) {
 	return identity, nil
 }
 
+// SignProposal signs the given proposal with the identity in the channel context
+func (c *Client) SignProposal(proposal *pb.Proposal) (*pb.SignedProposal, error) {
+	proposalBytes, err := proto.Marshal(proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling proposal")
+	}
+
+	signature, err := c.Sign(proposalBytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error signing proposal")
+	}
+
+	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: signature}, nil
+}
+
 // GetPeer returns the peer matching the given endpoint
 func (c *Client) GetPeer(endpoint string) (fabapi.Peer, error) {
 	logger.Debugf("[%s] Finding peer through discovery for URL [%s]", c.channelID, endpoint)
@@ -328,7 +349,17 @@ var newChannelClient = func(channelID, userName, org string, sdk *fabsdk.FabricS
 		ChannelClient:       client,
 		identitySerializer:  ctx,
 		cryptoSuiteProvider: ctx,
+		signer:              &contextSigner{ctx: ctx},
 		DiscoveryService:    discovery,
 		ChannelMembership:   membership,
 	}, nil
 }
+
+// contextSigner signs messages using the signing manager and private key of the client context
+type contextSigner struct {
+	ctx context.Client
+}
+
+func (s *contextSigner) Sign(msg []byte) ([]byte, error) {
+	return s.ctx.SigningManager().Sign(msg, s.ctx.PrivateKey())
+}
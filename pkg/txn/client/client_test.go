@@ -20,6 +20,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
 	"github.com/hyperledger/fabric/bccsp"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -244,6 +245,64 @@ func TestClient_SigningIdentity(t *testing.T) {
 	})
 }
 
+func TestClient_SignProposal(t *testing.T) {
+	peerCfg := &mocks.PeerConfig{}
+	peerCfg.TLSCertPathReturns("./testdata/tls.crt")
+	peerCfg.MSPIDReturns("Org1MSP")
+	peerCfg.PeerAddressReturns("peer0.org1.com:7051")
+
+	sdkCfgBytes, err := ioutil.ReadFile("./testdata/sdk-config.yaml")
+	require.NoError(t, err)
+
+	proposal := &pb.Proposal{Header: []byte("header"), Payload: []byte("payload")}
+
+	t.Run("SignProposal -> success", func(t *testing.T) {
+		signature := []byte("signature")
+
+		newChannelClient = func(channelID, userName, org string, sdk *fabsdk.FabricSDK) (*channelProviders, error) {
+			return &channelProviders{ChannelClient: &clientmocks.ChannelClient{}, signer: &mockSigner{signature: signature}}, nil
+		}
+
+		c, err := New("channel1", "User1", peerCfg, sdkCfgBytes, "YAML")
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		signedProposal, err := c.SignProposal(proposal)
+		require.NoError(t, err)
+		require.NotNil(t, signedProposal)
+		require.Equal(t, signature, signedProposal.Signature)
+
+		p := &pb.Proposal{}
+		require.NoError(t, proto.Unmarshal(signedProposal.ProposalBytes, p))
+		require.True(t, proto.Equal(proposal, p))
+	})
+
+	t.Run("SignProposal -> error", func(t *testing.T) {
+		errExpected := errors.New("injected signer error")
+
+		newChannelClient = func(channelID, userName, org string, sdk *fabsdk.FabricSDK) (*channelProviders, error) {
+			return &channelProviders{ChannelClient: &clientmocks.ChannelClient{}, signer: &mockSigner{err: errExpected}}, nil
+		}
+
+		c, err := New("channel1", "User1", peerCfg, sdkCfgBytes, "YAML")
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		signedProposal, err := c.SignProposal(proposal)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+		require.Nil(t, signedProposal)
+	})
+
+	t.Run("Context signer", func(t *testing.T) {
+		s := &contextSigner{ctx: sdkmocks.NewMockContext(mockmsp.NewMockSigningIdentity("user1", "Org1MSP"))}
+
+		signature, err := s.Sign([]byte("message"))
+		require.NoError(t, err)
+		require.NotEmpty(t, signature)
+	})
+}
+
 func TestClient_ComputeTxnID(t *testing.T) {
 	peerCfg := &mocks.PeerConfig{}
 	peerCfg.TLSCertPathReturns("./testdata/tls.crt")
@@ -582,3 +641,12 @@ type mockHandler struct {
 
 func (c *mockHandler) Handle(*invoke.RequestContext, *invoke.ClientContext) {
 }
+
+type mockSigner struct {
+	signature []byte
+	err       error
+}
+
+func (m *mockSigner) Sign([]byte) ([]byte, error) {
+	return m.signature, m.err
+}
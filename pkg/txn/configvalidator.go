@@ -75,6 +75,36 @@ func (v *configValidator) validateTXNConfig(kv *config.KeyValue) error {
 		}
 	}
 
+	if txnConfig.ConflictRetryAttempts < 0 {
+		return errors.Errorf("invalid value for 'ConflictRetryAttempts' [%d]", txnConfig.ConflictRetryAttempts)
+	}
+
+	if txnConfig.ConflictInitialBackoff != "" {
+		if _, err := time.ParseDuration(txnConfig.ConflictInitialBackoff); err != nil {
+			return errors.Errorf("invalid value for 'ConflictInitialBackoff' [%s]", txnConfig.ConflictInitialBackoff)
+		}
+	}
+
+	if txnConfig.ConflictMaxBackoff != "" {
+		if _, err := time.ParseDuration(txnConfig.ConflictMaxBackoff); err != nil {
+			return errors.Errorf("invalid value for 'ConflictMaxBackoff' [%s]", txnConfig.ConflictMaxBackoff)
+		}
+	}
+
+	if txnConfig.BatchMaxSize < 0 {
+		return errors.Errorf("invalid value for 'BatchMaxSize' [%d]", txnConfig.BatchMaxSize)
+	}
+
+	if txnConfig.BatchWindow != "" {
+		if _, err := time.ParseDuration(txnConfig.BatchWindow); err != nil {
+			return errors.Errorf("invalid value for 'BatchWindow' [%s]", txnConfig.BatchWindow)
+		}
+	}
+
+	if _, err := newEndorserSorter(txnConfig.EndorserSelection, nil, nil, nil, ""); err != nil {
+		return errors.Errorf("invalid value for 'EndorserSelection' [%s]", txnConfig.EndorserSelection)
+	}
+
 	return nil
 }
 
@@ -81,6 +81,46 @@ func TestConfigValidator_Validate(t *testing.T) {
 			require.Error(t, err)
 			require.Contains(t, err.Error(), "invalid value for 'MaxBackoff'")
 		})
+
+		t.Run("Batch settings", func(t *testing.T) {
+			require.NoError(t, v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","BatchMaxSize":10,"BatchWindow":"20ms"}`})))
+
+			err := v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","BatchMaxSize":-1}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'BatchMaxSize'")
+
+			err = v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","BatchMaxSize":10,"BatchWindow":"xxx"}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'BatchWindow'")
+		})
+
+		t.Run("Conflict retry settings", func(t *testing.T) {
+			require.NoError(t, v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json",
+				Config: `{"User":"User1","ConflictRetryAttempts":5,"ConflictInitialBackoff":"100ms","ConflictMaxBackoff":"2s","ConflictBackoffFactor":2}`})))
+
+			err := v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","ConflictRetryAttempts":-1}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'ConflictRetryAttempts'")
+
+			err = v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","ConflictInitialBackoff":"xxx"}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'ConflictInitialBackoff'")
+
+			err = v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","ConflictMaxBackoff":"xxx"}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'ConflictMaxBackoff'")
+		})
+
+		t.Run("Endorser selection", func(t *testing.T) {
+			for _, strategy := range []string{latencySelection, ledgerHeightSelection, localMSPSelection, roundRobinSelection} {
+				require.NoError(t, v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json",
+					Config: `{"User":"User1","EndorserSelection":"` + strategy + `"}`})))
+			}
+
+			err := v.Validate(config.NewKeyValue(txnKeyV1, &config.Value{Format: "json", Config: `{"User":"User1","EndorserSelection":"xxx"}`}))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for 'EndorserSelection'")
+		})
 	})
 
 	t.Run("SDK config", func(t *testing.T) {
@@ -0,0 +1,203 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	gossipapi "github.com/hyperledger/fabric/extensions/gossip/api"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
+)
+
+// Endorser selection strategies that may be specified in the 'EndorserSelection' field of the TXN config
+const (
+	// defaultSelection uses the selection strategy of the SDK
+	defaultSelection = ""
+
+	// latencySelection prefers the endorsers with the lowest observed response time
+	latencySelection = "Latency"
+
+	// ledgerHeightSelection prefers the endorsers with the highest ledger height
+	ledgerHeightSelection = "LedgerHeight"
+
+	// localMSPSelection prefers the endorsers in the local peer's MSP
+	localMSPSelection = "LocalMSP"
+
+	// roundRobinSelection rotates the preferred endorser on each request
+	roundRobinSelection = "RoundRobin"
+)
+
+// latencyWeight is the weight given to a new response time when computing the average response time of an endorser
+const latencyWeight = 0.3
+
+// newEndorserSorter returns the target sorter for the given selection strategy. Nil is returned for the default
+// strategy, in which case the endorsers are sorted by the SDK.
+func newEndorserSorter(strategy string, latencies *peerLatencies, d *discovery.Discovery, publisher gossipapi.BlockPublisher, localMSPID string) (fab.TargetSorter, error) {
+	switch strategy {
+	case defaultSelection:
+		return nil, nil
+	case latencySelection:
+		return &latencySorter{latencies: latencies}, nil
+	case ledgerHeightSelection:
+		return &ledgerHeightSorter{Discovery: d, publisher: publisher}, nil
+	case localMSPSelection:
+		return &localMSPSorter{mspID: localMSPID}, nil
+	case roundRobinSelection:
+		return &roundRobinSorter{}, nil
+	default:
+		return nil, errors.Errorf("unsupported endorser selection strategy [%s]", strategy)
+	}
+}
+
+// peerLatencies maintains the average response time of endorsers, keyed by endpoint
+type peerLatencies struct {
+	mutex     sync.RWMutex
+	latencies map[string]time.Duration
+}
+
+func newPeerLatencies() *peerLatencies {
+	return &peerLatencies{latencies: make(map[string]time.Duration)}
+}
+
+// Record records the response time of the given endorser. The average is weighted towards recent response times.
+func (l *peerLatencies) Record(endpoint string, latency time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	avg, ok := l.latencies[endpoint]
+	if !ok {
+		l.latencies[endpoint] = latency
+		return
+	}
+
+	l.latencies[endpoint] = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(avg))
+}
+
+// Get returns the average response time of the given endorser. False is returned if no response time was recorded.
+func (l *peerLatencies) Get(endpoint string) (time.Duration, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	latency, ok := l.latencies[endpoint]
+
+	return latency, ok
+}
+
+// latencySorter sorts endorsers by average response time, lowest first. Endorsers for which no response time
+// has been recorded are sorted first so that their response time is measured. Endorsers that failed to respond
+// have the request timeout recorded as a penalty and are therefore sorted after the responsive endorsers.
+type latencySorter struct {
+	latencies *peerLatencies
+}
+
+func (s *latencySorter) Sort(peers []fab.Peer) []fab.Peer {
+	latencies := make(map[string]time.Duration, len(peers))
+	for _, p := range peers {
+		latency, ok := s.latencies.Get(p.URL())
+		if ok {
+			latencies[p.URL()] = latency
+		}
+	}
+
+	sorted := copyPeers(peers)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return latencies[sorted[i].URL()] < latencies[sorted[j].URL()]
+	})
+
+	return sorted
+}
+
+// ledgerHeightSorter sorts endorsers by ledger height, highest first. The ledger height of a remote peer is obtained
+// from gossip discovery and the ledger height of the local peer is obtained from the local block publisher. If the
+// endorser isn't known to discovery then the height is obtained from the peer itself (if it provides its state).
+type ledgerHeightSorter struct {
+	*discovery.Discovery
+	publisher gossipapi.BlockPublisher
+}
+
+func (s *ledgerHeightSorter) Sort(peers []fab.Peer) []fab.Peer {
+	heights := make(map[string]uint64)
+	for _, m := range s.GetMembers(func(*discovery.Member) bool { return true }) {
+		switch {
+		case m.Local:
+			heights[m.Endpoint] = s.publisher.LedgerHeight()
+		case m.Properties != nil:
+			heights[m.Endpoint] = m.Properties.LedgerHeight
+		}
+	}
+
+	for _, p := range peers {
+		if _, ok := heights[p.URL()]; ok {
+			continue
+		}
+
+		if ps, ok := p.(fab.PeerState); ok {
+			heights[p.URL()] = ps.BlockHeight()
+		}
+	}
+
+	sorted := copyPeers(peers)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return heights[sorted[i].URL()] > heights[sorted[j].URL()]
+	})
+
+	return sorted
+}
+
+// localMSPSorter sorts the endorsers in the given MSP ahead of the other endorsers
+type localMSPSorter struct {
+	mspID string
+}
+
+func (s *localMSPSorter) Sort(peers []fab.Peer) []fab.Peer {
+	sorted := copyPeers(peers)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MSPID() == s.mspID && sorted[j].MSPID() != s.mspID
+	})
+
+	return sorted
+}
+
+// roundRobinSorter sorts the endorsers by endpoint and then rotates the list by one position on each request
+type roundRobinSorter struct {
+	counter uint32
+}
+
+func (s *roundRobinSorter) Sort(peers []fab.Peer) []fab.Peer {
+	if len(peers) == 0 {
+		return peers
+	}
+
+	sorted := copyPeers(peers)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].URL() < sorted[j].URL()
+	})
+
+	offset := int((atomic.AddUint32(&s.counter, 1) - 1) % uint32(len(sorted)))
+
+	rotated := make([]fab.Peer, 0, len(sorted))
+	rotated = append(rotated, sorted[offset:]...)
+
+	return append(rotated, sorted[:offset]...)
+}
+
+func copyPeers(peers []fab.Peer) []fab.Peer {
+	c := make([]fab.Peer, len(peers))
+	copy(c, peers)
+
+	return c
+}
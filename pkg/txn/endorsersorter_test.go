@@ -0,0 +1,147 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/discovery"
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+)
+
+const org2MSPID = "Org2MSP"
+
+func TestNewEndorserSorter(t *testing.T) {
+	s, err := newEndorserSorter(defaultSelection, nil, nil, nil, "")
+	require.NoError(t, err)
+	require.Nil(t, s)
+
+	for _, strategy := range []string{latencySelection, ledgerHeightSelection, localMSPSelection, roundRobinSelection} {
+		s, err := newEndorserSorter(strategy, newPeerLatencies(), nil, nil, org1MSPID)
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	}
+
+	s, err = newEndorserSorter("xxx", nil, nil, nil, "")
+	require.EqualError(t, err, "unsupported endorser selection strategy [xxx]")
+	require.Nil(t, s)
+}
+
+func TestPeerLatencies(t *testing.T) {
+	l := newPeerLatencies()
+
+	_, ok := l.Get(p1Endpoint)
+	require.False(t, ok)
+
+	l.Record(p1Endpoint, 100*time.Millisecond)
+
+	latency, ok := l.Get(p1Endpoint)
+	require.True(t, ok)
+	require.Equal(t, 100*time.Millisecond, latency)
+
+	l.Record(p1Endpoint, 200*time.Millisecond)
+
+	latency, ok = l.Get(p1Endpoint)
+	require.True(t, ok)
+	require.Equal(t, 130*time.Millisecond, latency)
+}
+
+func TestLatencySorter(t *testing.T) {
+	p1 := sdkmocks.NewMockPeer("p1", p1Endpoint)
+	p2 := sdkmocks.NewMockPeer("p2", p2Endpoint)
+	p3 := sdkmocks.NewMockPeer("p3", p3Endpoint)
+
+	l := newPeerLatencies()
+	l.Record(p1Endpoint, 300*time.Millisecond)
+	l.Record(p2Endpoint, 100*time.Millisecond)
+
+	s, err := newEndorserSorter(latencySelection, l, nil, nil, "")
+	require.NoError(t, err)
+
+	require.Equal(t, []fab.Peer{p3, p2, p1}, s.Sort([]fab.Peer{p1, p2, p3}),
+		"expecting the peer with no recorded latency to be first followed by the peer with the lowest latency")
+}
+
+func TestLedgerHeightSorter(t *testing.T) {
+	self := mocks.NewMember(p1Endpoint, p1PKIID)
+	m2 := mocks.NewMember(p2Endpoint, p2PKIID)
+	m2.Properties.LedgerHeight = 1000
+
+	gossip := mocks.NewMockGossipAdapter().
+		Self(org1MSPID, self).
+		Member(org1MSPID, m2)
+
+	publisher := mocks.NewBlockPublisher()
+	publisher.Height = 1001
+
+	s, err := newEndorserSorter(ledgerHeightSelection, nil, discovery.New(channel1, gossip), publisher, "")
+	require.NoError(t, err)
+
+	p1 := sdkmocks.NewMockPeer("p1", p1Endpoint)
+	p2 := sdkmocks.NewMockPeer("p2", p2Endpoint)
+	p3 := sdkmocks.NewMockPeer("p3", p3Endpoint)
+
+	t.Run("Local peer highest", func(t *testing.T) {
+		require.Equal(t, []fab.Peer{p1, p2, p3}, s.Sort([]fab.Peer{p3, p2, p1}))
+	})
+
+	t.Run("Remote peer highest", func(t *testing.T) {
+		publisher.Height = 999
+
+		require.Equal(t, []fab.Peer{p2, p1, p3}, s.Sort([]fab.Peer{p3, p1, p2}))
+	})
+
+	t.Run("Height from peer state", func(t *testing.T) {
+		p4 := &mockPeerWithState{MockPeer: sdkmocks.NewMockPeer("p4", "p4.org1.com:7051"), height: 2000}
+
+		require.Equal(t, []fab.Peer{p4, p2, p1, p3}, s.Sort([]fab.Peer{p3, p1, p2, p4}))
+	})
+}
+
+func TestLocalMSPSorter(t *testing.T) {
+	p1 := sdkmocks.NewMockPeer("p1", p1Endpoint)
+	p1.SetMSPID(org2MSPID)
+	p2 := sdkmocks.NewMockPeer("p2", p2Endpoint)
+	p3 := sdkmocks.NewMockPeer("p3", p3Endpoint)
+	p3.SetMSPID(org2MSPID)
+
+	s, err := newEndorserSorter(localMSPSelection, nil, nil, nil, org2MSPID)
+	require.NoError(t, err)
+
+	require.Equal(t, []fab.Peer{p1, p3, p2}, s.Sort([]fab.Peer{p1, p2, p3}))
+	require.Equal(t, []fab.Peer{p3, p1, p2}, s.Sort([]fab.Peer{p2, p3, p1}))
+}
+
+func TestRoundRobinSorter(t *testing.T) {
+	p1 := sdkmocks.NewMockPeer("p1", p1Endpoint)
+	p2 := sdkmocks.NewMockPeer("p2", p2Endpoint)
+	p3 := sdkmocks.NewMockPeer("p3", p3Endpoint)
+
+	s, err := newEndorserSorter(roundRobinSelection, nil, nil, nil, "")
+	require.NoError(t, err)
+
+	require.Empty(t, s.Sort(nil))
+
+	require.Equal(t, []fab.Peer{p1, p2, p3}, s.Sort([]fab.Peer{p3, p1, p2}))
+	require.Equal(t, []fab.Peer{p2, p3, p1}, s.Sort([]fab.Peer{p2, p3, p1}))
+	require.Equal(t, []fab.Peer{p3, p1, p2}, s.Sort([]fab.Peer{p1, p2, p3}))
+	require.Equal(t, []fab.Peer{p1, p2, p3}, s.Sort([]fab.Peer{p1, p2, p3}))
+}
+
+type mockPeerWithState struct {
+	*sdkmocks.MockPeer
+	height uint64
+}
+
+func (p *mockPeerWithState) BlockHeight() uint64 {
+	return p.height
+}
@@ -7,18 +7,32 @@ SPDX-License-Identifier: Apache-2.0
 package handler
 
 import (
+	"time"
+
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
 )
 
+// TxStatusRegistrar registers for the validation status of a transaction. The status is sent to the given channel
+// when the block containing the transaction is committed. The registration expires after the given timeout, in
+// which case a timeout event is sent.
+type TxStatusRegistrar interface {
+	Register(txID string, statusCh chan<- *api.TxStatusEvent, timeout time.Duration)
+	Unregister(txID string)
+}
+
 // Commit is a handler that commits the endorsement responses to the Orderer and aoptionally waits for a block
 // event that indicates the status of the transaction.
 type Commit struct {
 	next        invoke.Handler
 	asyncCommit bool
+	registrar   TxStatusRegistrar
+	statusCh    chan<- *api.TxStatusEvent
 }
 
 // NewCommitHandler returns a new commit handler
@@ -29,6 +43,17 @@ func NewCommitHandler(asyncCommit bool, next ...invoke.Handler) *Commit {
 	}
 }
 
+// NewAsyncCommitHandler returns a commit handler that doesn't wait for the transaction to be committed. Instead,
+// the validation status of the transaction is sent to the given channel once the transaction is committed.
+func NewAsyncCommitHandler(registrar TxStatusRegistrar, statusCh chan<- *api.TxStatusEvent, next ...invoke.Handler) *Commit {
+	return &Commit{
+		asyncCommit: true,
+		registrar:   registrar,
+		statusCh:    statusCh,
+		next:        getNext(next),
+	}
+}
+
 // Handle handles the commit
 func (c *Commit) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
 	txnID := requestContext.Response.TransactionID
@@ -47,11 +72,20 @@ func (c *Commit) Handle(requestContext *invoke.RequestContext, clientContext *in
 		}
 
 		defer clientContext.EventService.Unregister(reg)
+	} else if c.statusCh != nil {
+		// Register before sending the transaction so that the status isn't missed
+		logger.Debugf("Registering for the status of tx [%s] with the local block publisher", txnID)
+
+		c.registrar.Register(string(txnID), c.statusCh, requestContext.Opts.Timeouts[fab.Execute])
 	} else {
 		logger.Debugf("Not registering for tx [%s] event since the call is async", txnID)
 	}
 
 	if _, err := createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses); err != nil {
+		if c.statusCh != nil {
+			c.registrar.Unregister(string(txnID))
+		}
+
 		requestContext.Error = errors.Wrap(err, "createAndSendTransaction failed")
 		return
 	}
@@ -15,8 +15,11 @@ import (
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
 	"github.com/trustbloc/fabric-peer-ext/pkg/txn/handler/mocks"
 )
 
@@ -63,6 +66,62 @@ func TestCommitHandler(t *testing.T) {
 		require.NoError(t, reqCtx.Error)
 	})
 
+	t.Run("Async - with commit status", func(t *testing.T) {
+		reqCtx := &invoke.RequestContext{
+			Request:  invoke.Request{},
+			Opts:     invoke.Opts{},
+			Response: invoke.Response{TransactionID: "tx1"},
+			Ctx:      context.Background(),
+		}
+
+		clientCtx := &invoke.ClientContext{
+			Transactor:   &mocks.Transactor{},
+			EventService: sdkmocks.NewMockEventService(),
+		}
+
+		reqCtx.Opts.Timeouts = map[fab.TimeoutType]time.Duration{fab.Execute: time.Minute}
+
+		registrar := newMockTxStatusRegistrar()
+		statusCh := make(chan *api.TxStatusEvent, 1)
+
+		h := NewAsyncCommitHandler(registrar, statusCh, &mocks.InvokeHandler{})
+		require.NotNil(t, h)
+
+		h.Handle(reqCtx, clientCtx)
+		require.NoError(t, reqCtx.Error)
+		require.Contains(t, registrar.registered, "tx1")
+		require.Equal(t, time.Minute, registrar.timeouts["tx1"])
+	})
+
+	t.Run("Async - with commit status and SendTransaction error", func(t *testing.T) {
+		reqCtx := &invoke.RequestContext{
+			Request:  invoke.Request{},
+			Opts:     invoke.Opts{},
+			Response: invoke.Response{TransactionID: "tx1"},
+			Ctx:      context.Background(),
+		}
+
+		errExpected := errors.New("injected transactor error")
+
+		transactor := &mocks.Transactor{}
+		transactor.SendTransactionReturns(nil, errExpected)
+
+		clientCtx := &invoke.ClientContext{
+			Transactor:   transactor,
+			EventService: sdkmocks.NewMockEventService(),
+		}
+
+		registrar := newMockTxStatusRegistrar()
+
+		h := NewAsyncCommitHandler(registrar, make(chan *api.TxStatusEvent, 1))
+		require.NotNil(t, h)
+
+		h.Handle(reqCtx, clientCtx)
+		require.Error(t, reqCtx.Error)
+		require.Contains(t, reqCtx.Error.Error(), errExpected.Error())
+		require.NotContains(t, registrar.registered, "tx1")
+	})
+
 	t.Run("Sync - no next handler", func(t *testing.T) {
 		reqCtx := &invoke.RequestContext{
 			Request:  invoke.Request{},
@@ -206,3 +265,24 @@ func TestCommitHandler(t *testing.T) {
 		require.Contains(t, reqCtx.Error.Error(), "TIMEOUT")
 	})
 }
+
+type mockTxStatusRegistrar struct {
+	registered map[string]chan<- *api.TxStatusEvent
+	timeouts   map[string]time.Duration
+}
+
+func newMockTxStatusRegistrar() *mockTxStatusRegistrar {
+	return &mockTxStatusRegistrar{
+		registered: make(map[string]chan<- *api.TxStatusEvent),
+		timeouts:   make(map[string]time.Duration),
+	}
+}
+
+func (m *mockTxStatusRegistrar) Register(txID string, statusCh chan<- *api.TxStatusEvent, timeout time.Duration) {
+	m.registered[txID] = statusCh
+	m.timeouts[txID] = timeout
+}
+
+func (m *mockTxStatusRegistrar) Unregister(txID string) {
+	delete(m.registered, txID)
+}
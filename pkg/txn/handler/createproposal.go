@@ -0,0 +1,61 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package handler
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/pkg/errors"
+)
+
+// NewCreateProposalHandler returns a handler that creates a transaction proposal for the request but doesn't send
+// the proposal to any endorsers. The proposal is set in the response of the request context.
+func NewCreateProposalHandler(txnOptsProvider invoke.TxnHeaderOptsProvider, next ...invoke.Handler) *CreateProposalHandler {
+	return &CreateProposalHandler{txnOptsProvider: txnOptsProvider, next: getNext(next)}
+}
+
+// CreateProposalHandler creates a transaction proposal
+type CreateProposalHandler struct {
+	next            invoke.Handler
+	txnOptsProvider invoke.TxnHeaderOptsProvider
+}
+
+// Handle creates the transaction proposal
+func (h *CreateProposalHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	var opts []fab.TxnHeaderOpt
+	if h.txnOptsProvider != nil {
+		opts = h.txnOptsProvider()
+	}
+
+	txh, err := clientContext.Transactor.CreateTransactionHeader(opts...)
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "creating transaction header failed")
+		return
+	}
+
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID:  requestContext.Request.ChaincodeID,
+		Fcn:          requestContext.Request.Fcn,
+		Args:         requestContext.Request.Args,
+		TransientMap: requestContext.Request.TransientMap,
+		IsInit:       requestContext.Request.IsInit,
+	})
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "creating transaction proposal failed")
+		return
+	}
+
+	logger.Debugf("Created proposal for tx [%s]", proposal.TxnID)
+
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/handler/mocks"
+)
+
+func TestCreateProposalHandler(t *testing.T) {
+	txh, err := sdkmocks.NewMockTransactionHeader("channel1")
+	require.NoError(t, err)
+
+	newRequestContext := func() *invoke.RequestContext {
+		return &invoke.RequestContext{
+			Request: invoke.Request{
+				ChaincodeID: "cc1",
+				Fcn:         "put",
+				Args:        [][]byte{[]byte("key1"), []byte("value1")},
+			},
+			Opts:     invoke.Opts{},
+			Response: invoke.Response{},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		transactor := &mocks.Transactor{}
+		transactor.CreateTransactionHeaderReturns(txh, nil)
+
+		optsProviderInvoked := false
+		optsProvider := func() []fab.TxnHeaderOpt {
+			optsProviderInvoked = true
+			return nil
+		}
+
+		reqCtx := newRequestContext()
+
+		h := NewCreateProposalHandler(optsProvider, &mocks.InvokeHandler{})
+		require.NotNil(t, h)
+
+		h.Handle(reqCtx, &invoke.ClientContext{Transactor: transactor})
+		require.NoError(t, reqCtx.Error)
+		require.True(t, optsProviderInvoked)
+		require.NotNil(t, reqCtx.Response.Proposal)
+		require.NotNil(t, reqCtx.Response.Proposal.Proposal)
+		require.Equal(t, txh.TransactionID(), reqCtx.Response.TransactionID)
+		require.Empty(t, reqCtx.Response.Responses)
+	})
+
+	t.Run("Transaction header error", func(t *testing.T) {
+		errExpected := errors.New("injected header error")
+
+		transactor := &mocks.Transactor{}
+		transactor.CreateTransactionHeaderReturns(nil, errExpected)
+
+		reqCtx := newRequestContext()
+
+		NewCreateProposalHandler(nil).Handle(reqCtx, &invoke.ClientContext{Transactor: transactor})
+		require.Error(t, reqCtx.Error)
+		require.Contains(t, reqCtx.Error.Error(), errExpected.Error())
+	})
+
+	t.Run("Create proposal error", func(t *testing.T) {
+		transactor := &mocks.Transactor{}
+		transactor.CreateTransactionHeaderReturns(txh, nil)
+
+		reqCtx := newRequestContext()
+		reqCtx.Request.Fcn = ""
+
+		NewCreateProposalHandler(nil).Handle(reqCtx, &invoke.ClientContext{Transactor: transactor})
+		require.Error(t, reqCtx.Error)
+		require.Contains(t, reqCtx.Error.Error(), "creating transaction proposal failed")
+	})
+}
@@ -0,0 +1,123 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// defaultFailurePenalty is the latency recorded for an endorser that fails to respond to a proposal when
+// the request has no deadline
+const defaultFailurePenalty = 30 * time.Second
+
+// LatencyRecorder records the time taken by an endorser to respond to a transaction proposal
+type LatencyRecorder interface {
+	Record(endpoint string, latency time.Duration)
+}
+
+// EndorserTiming is a handler that records the time taken by each of the selected endorsers to
+// respond to the transaction proposal. If an endorser fails to respond then the request timeout is recorded
+// as a penalty so that the endorser is sorted after the responsive endorsers. It must be invoked after the endorsers are selected and before
+// the proposal is sent, i.e. between the proposal processor handler and the endorsement handler.
+type EndorserTiming struct {
+	next     invoke.Handler
+	recorder LatencyRecorder
+}
+
+// NewEndorserTimingHandler returns a new endorser timing handler
+func NewEndorserTimingHandler(recorder LatencyRecorder, next ...invoke.Handler) *EndorserTiming {
+	return &EndorserTiming{
+		recorder: recorder,
+		next:     getNext(next),
+	}
+}
+
+// Handle wraps the selected endorsers so that the response time of each endorser is recorded
+func (h *EndorserTiming) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	targets := make([]fab.Peer, len(requestContext.Opts.Targets))
+	for i, p := range requestContext.Opts.Targets {
+		targets[i] = newTimedPeer(p, h.recorder)
+	}
+
+	requestContext.Opts.Targets = targets
+
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+type timedPeer struct {
+	fab.Peer
+	recorder LatencyRecorder
+}
+
+// timedPeerWithState is a timed peer that also exposes the state (ledger height) of the wrapped peer
+type timedPeerWithState struct {
+	*timedPeer
+	fab.PeerState
+}
+
+// newTimedPeer wraps the given peer. If the peer provides its state then the returned peer also
+// provides the state so that the state isn't hidden from the endorser sorters.
+func newTimedPeer(p fab.Peer, recorder LatencyRecorder) fab.Peer {
+	tp := &timedPeer{Peer: p, recorder: recorder}
+
+	if ps, ok := p.(fab.PeerState); ok {
+		return &timedPeerWithState{timedPeer: tp, PeerState: ps}
+	}
+
+	return tp
+}
+
+// ProcessTransactionProposal sends the proposal to the peer and records the response time. If the peer
+// fails to respond then the request timeout is recorded as a penalty.
+func (p *timedPeer) ProcessTransactionProposal(ctx context.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	start := time.Now()
+
+	resp, err := p.Peer.ProcessTransactionProposal(ctx, request)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			// The request was cancelled by the client so the peer isn't at fault
+			return nil, err
+		}
+
+		penalty := failurePenalty(ctx, start)
+
+		logger.Debugf("Peer [%s] failed to respond to the proposal - recording a penalty of %s: %s", p.URL(), penalty, err)
+
+		p.recorder.Record(p.URL(), penalty)
+
+		return nil, err
+	}
+
+	latency := time.Since(start)
+
+	logger.Debugf("Peer [%s] responded to the proposal in %s", p.URL(), latency)
+
+	p.recorder.Record(p.URL(), latency)
+
+	return resp, nil
+}
+
+// failurePenalty returns the request timeout (or the default penalty if the request has no deadline)
+// or the time elapsed since the request was sent, whichever is greater
+func failurePenalty(ctx context.Context, start time.Time) time.Duration {
+	penalty := defaultFailurePenalty
+	if deadline, ok := ctx.Deadline(); ok {
+		penalty = deadline.Sub(start)
+	}
+
+	if elapsed := time.Since(start); elapsed > penalty {
+		return elapsed
+	}
+
+	return penalty
+}
@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/handler/mocks"
+)
+
+func TestEndorserTimingHandler(t *testing.T) {
+	const (
+		url1 = "peer1:7051"
+		url2 = "peer2:7051"
+	)
+
+	recorder := &mockLatencyRecorder{latencies: make(map[string]time.Duration)}
+
+	peer1 := sdkmocks.NewMockPeer("peer1", url1)
+	peer2 := sdkmocks.NewMockPeer("peer2", url2)
+	peer2.Error = errors.New("injected endorsement error")
+
+	reqCtx := &invoke.RequestContext{
+		Opts: invoke.Opts{Targets: []fab.Peer{peer1, peer2}},
+	}
+
+	next := &mocks.InvokeHandler{}
+
+	h := NewEndorserTimingHandler(recorder, next)
+	require.NotNil(t, h)
+
+	h.Handle(reqCtx, &invoke.ClientContext{})
+	require.Equal(t, 1, next.HandleCallCount())
+	require.Len(t, reqCtx.Opts.Targets, 2)
+
+	resp, err := reqCtx.Opts.Targets[0].ProcessTransactionProposal(context.Background(), fab.ProcessProposalRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, url1, reqCtx.Opts.Targets[0].URL())
+
+	resp, err = reqCtx.Opts.Targets[1].ProcessTransactionProposal(context.Background(), fab.ProcessProposalRequest{})
+	require.EqualError(t, err, peer2.Error.Error())
+	require.Nil(t, resp)
+
+	require.Equal(t, 1, peer1.ProcessProposalCalls)
+	require.Equal(t, 1, peer2.ProcessProposalCalls)
+
+	_, ok := recorder.get(url1)
+	require.True(t, ok)
+
+	latency, ok := recorder.get(url2)
+	require.True(t, ok)
+	require.Equal(t, defaultFailurePenalty, latency, "expecting a penalty to be recorded for a failed request")
+}
+
+func TestEndorserTimingHandler_FailurePenalty(t *testing.T) {
+	const url = "peer1:7051"
+
+	peer := sdkmocks.NewMockPeer("peer1", url)
+	peer.Error = errors.New("injected endorsement error")
+
+	t.Run("Request timeout", func(t *testing.T) {
+		recorder := &mockLatencyRecorder{latencies: make(map[string]time.Duration)}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := newTimedPeer(peer, recorder).ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+		require.Error(t, err)
+
+		latency, ok := recorder.get(url)
+		require.True(t, ok)
+		require.True(t, latency > 4*time.Second && latency <= 5*time.Second,
+			"expecting the request timeout to be recorded as the penalty but got %s", latency)
+	})
+
+	t.Run("Request cancelled -> not recorded", func(t *testing.T) {
+		recorder := &mockLatencyRecorder{latencies: make(map[string]time.Duration)}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := newTimedPeer(peer, recorder).ProcessTransactionProposal(ctx, fab.ProcessProposalRequest{})
+		require.Error(t, err)
+
+		_, ok := recorder.get(url)
+		require.False(t, ok, "expecting the time of a cancelled request to not be recorded")
+	})
+}
+
+func TestEndorserTimingHandler_PeerState(t *testing.T) {
+	peer1 := sdkmocks.NewMockPeer("peer1", "peer1:7051")
+	peer2 := &mockPeerWithState{MockPeer: sdkmocks.NewMockPeer("peer2", "peer2:7051"), blockHeight: 1000}
+
+	reqCtx := &invoke.RequestContext{
+		Opts: invoke.Opts{Targets: []fab.Peer{peer1, peer2}},
+	}
+
+	h := NewEndorserTimingHandler(&mockLatencyRecorder{latencies: make(map[string]time.Duration)})
+	h.Handle(reqCtx, &invoke.ClientContext{})
+
+	_, ok := reqCtx.Opts.Targets[0].(fab.PeerState)
+	require.False(t, ok)
+
+	ps, ok := reqCtx.Opts.Targets[1].(fab.PeerState)
+	require.True(t, ok, "expecting the state of the wrapped peer to be exposed")
+	require.Equal(t, uint64(1000), ps.BlockHeight())
+
+	_, err := reqCtx.Opts.Targets[1].ProcessTransactionProposal(context.Background(), fab.ProcessProposalRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, peer2.ProcessProposalCalls)
+}
+
+type mockPeerWithState struct {
+	*sdkmocks.MockPeer
+	blockHeight uint64
+}
+
+func (p *mockPeerWithState) BlockHeight() uint64 {
+	return p.blockHeight
+}
+
+type mockLatencyRecorder struct {
+	mutex     sync.Mutex
+	latencies map[string]time.Duration
+}
+
+func (m *mockLatencyRecorder) Record(endpoint string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.latencies[endpoint] = latency
+}
+
+func (m *mockLatencyRecorder) get(endpoint string) (time.Duration, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	latency, ok := m.latencies[endpoint]
+
+	return latency, ok
+}
@@ -74,6 +74,19 @@ type TxnClient struct {
 	verifyProposalSignatureReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SignProposalStub        func(proposal *pb.Proposal) (*pb.SignedProposal, error)
+	signProposalMutex       sync.RWMutex
+	signProposalArgsForCall []struct {
+		proposal *pb.Proposal
+	}
+	signProposalReturns struct {
+		result1 *pb.SignedProposal
+		result2 error
+	}
+	signProposalReturnsOnCall map[int]struct {
+		result1 *pb.SignedProposal
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -329,6 +342,57 @@ func (fake *TxnClient) VerifyProposalSignatureReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
+func (fake *TxnClient) SignProposal(proposal *pb.Proposal) (*pb.SignedProposal, error) {
+	fake.signProposalMutex.Lock()
+	ret, specificReturn := fake.signProposalReturnsOnCall[len(fake.signProposalArgsForCall)]
+	fake.signProposalArgsForCall = append(fake.signProposalArgsForCall, struct {
+		proposal *pb.Proposal
+	}{proposal})
+	fake.recordInvocation("SignProposal", []interface{}{proposal})
+	fake.signProposalMutex.Unlock()
+	if fake.SignProposalStub != nil {
+		return fake.SignProposalStub(proposal)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.signProposalReturns.result1, fake.signProposalReturns.result2
+}
+
+func (fake *TxnClient) SignProposalCallCount() int {
+	fake.signProposalMutex.RLock()
+	defer fake.signProposalMutex.RUnlock()
+	return len(fake.signProposalArgsForCall)
+}
+
+func (fake *TxnClient) SignProposalArgsForCall(i int) *pb.Proposal {
+	fake.signProposalMutex.RLock()
+	defer fake.signProposalMutex.RUnlock()
+	return fake.signProposalArgsForCall[i].proposal
+}
+
+func (fake *TxnClient) SignProposalReturns(result1 *pb.SignedProposal, result2 error) {
+	fake.SignProposalStub = nil
+	fake.signProposalReturns = struct {
+		result1 *pb.SignedProposal
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *TxnClient) SignProposalReturnsOnCall(i int, result1 *pb.SignedProposal, result2 error) {
+	fake.SignProposalStub = nil
+	if fake.signProposalReturnsOnCall == nil {
+		fake.signProposalReturnsOnCall = make(map[int]struct {
+			result1 *pb.SignedProposal
+			result2 error
+		})
+	}
+	fake.signProposalReturnsOnCall[i] = struct {
+		result1 *pb.SignedProposal
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *TxnClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -342,6 +406,8 @@ func (fake *TxnClient) Invocations() map[string][][]interface{} {
 	defer fake.getPeerMutex.RUnlock()
 	fake.verifyProposalSignatureMutex.RLock()
 	defer fake.verifyProposalSignatureMutex.RUnlock()
+	fake.signProposalMutex.RLock()
+	defer fake.signProposalMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -33,18 +33,22 @@ type gossipProvider interface {
 	GetGossipService() gossipapi.GossipService
 }
 
+type blockPublisherProvider interface {
+	ForChannel(channelID string) gossipapi.BlockPublisher
+}
+
 type proposalResponseValidatorProvider interface {
 	ValidatorForChannel(channelID string) api.ProposalResponseValidator
 }
 
 // NewProvider returns a new transaction service provider
-func NewProvider(configProvider configServiceProvider, peerConfig api.PeerConfig, validatorRegistry configValidatorRegistry, gossipProvider gossipProvider, validatorProvider proposalResponseValidatorProvider) *Provider {
+func NewProvider(configProvider configServiceProvider, peerConfig api.PeerConfig, validatorRegistry configValidatorRegistry, gossipProvider gossipProvider, validatorProvider proposalResponseValidatorProvider, bpProvider blockPublisherProvider) *Provider {
 	validatorRegistry.Register(newConfigValidator())
 
-	return newProvider(configProvider, peerConfig, gossipProvider, validatorProvider, &defaultClientProvider{})
+	return newProvider(configProvider, peerConfig, gossipProvider, validatorProvider, bpProvider, &defaultClientProvider{})
 }
 
-func newProvider(configProvider configServiceProvider, peerConfig api.PeerConfig, gossipProvider gossipProvider, validatorProvider proposalResponseValidatorProvider, clientProvider clientProvider) *Provider {
+func newProvider(configProvider configServiceProvider, peerConfig api.PeerConfig, gossipProvider gossipProvider, validatorProvider proposalResponseValidatorProvider, bpProvider blockPublisherProvider, clientProvider clientProvider) *Provider {
 	logger.Info("Creating transaction service provider")
 
 	return &Provider{
@@ -58,6 +62,7 @@ func newProvider(configProvider configServiceProvider, peerConfig api.PeerConfig
 					clientProvider:            clientProvider,
 					gossip:                    gossipProvider.GetGossipService(),
 					proposalResponseValidator: validatorProvider.ValidatorForChannel(channelID),
+					blockPublisher:            bpProvider.ForChannel(channelID),
 				})
 		}).Build(),
 	}
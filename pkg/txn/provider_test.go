@@ -24,7 +24,7 @@ import (
 //go:generate counterfeiter -o ./mocks/proprespvalidatorprovider.gen.go --fake-name ProposalResponseValidatorProvider . proposalResponseValidatorProvider
 
 func TestNewProvider(t *testing.T) {
-	require.NotNil(t, NewProvider(&txnmocks.ConfigServiceProvider{}, &mocks.PeerConfig{}, &txnmocks.ConfigValidatorRegistry{}, &mocks.GossipProvider{}, &txnmocks.ProposalResponseValidatorProvider{}))
+	require.NotNil(t, NewProvider(&txnmocks.ConfigServiceProvider{}, &mocks.PeerConfig{}, &txnmocks.ConfigValidatorRegistry{}, &mocks.GossipProvider{}, &txnmocks.ProposalResponseValidatorProvider{}, mocks.NewBlockPublisherProvider()))
 }
 
 func TestProvider(t *testing.T) {
@@ -47,7 +47,7 @@ func TestProvider(t *testing.T) {
 	}, nil)
 	csp.ForChannelReturns(cs)
 
-	p := newProvider(csp, &mocks.PeerConfig{}, &mocks.GossipProvider{}, &txnmocks.ProposalResponseValidatorProvider{}, &mockClientProvider{cl: &txnmocks.TxnClient{}})
+	p := newProvider(csp, &mocks.PeerConfig{}, &mocks.GossipProvider{}, &txnmocks.ProposalResponseValidatorProvider{}, mocks.NewBlockPublisherProvider(), &mockClientProvider{cl: &txnmocks.TxnClient{}})
 	require.NotNil(t, p)
 
 	s, err := p.ForChannel("channel1")
@@ -47,6 +47,7 @@ type providers struct {
 	clientProvider            clientProvider
 	gossip                    gossipapi.GossipService
 	proposalResponseValidator api.ProposalResponseValidator
+	blockPublisher            gossipapi.BlockPublisher
 }
 
 // Service implements a Transaction service that gathers multiple endorsements (according to chaincode policy) and
@@ -54,14 +55,20 @@ type providers struct {
 type Service struct {
 	*providers
 	*discovery.Discovery
-	channelID       string
-	txnCfgKey       *config.Key
-	sdkCfgKey       *config.Key
-	cfgTxID         string
-	c               channelClient
-	mutex           sync.RWMutex
-	retryOpts       retry.Opts
-	commitRetryOpts retry.Opts
+	channelID           string
+	txnCfgKey           *config.Key
+	sdkCfgKey           *config.Key
+	cfgTxID             string
+	c                   channelClient
+	mutex               sync.RWMutex
+	retryOpts           retry.Opts
+	commitRetryOpts     retry.Opts
+	conflictRetryOpts   retry.Opts
+	retryOnConflictOpts retry.Opts
+	batcher             *batcher
+	txStatus            *txStatusNotifier
+	latencies           *peerLatencies
+	endorserSorter      fab.TargetSorter
 }
 
 // New returns a new transaction service
@@ -74,8 +81,12 @@ func newService(channelID string, p *providers) (*Service, error) {
 		txnCfgKey: config.NewPeerComponentKey(p.peerConfig.MSPID(), p.peerConfig.PeerID(), configApp, configVersion, generalConfigComponent, generalConfigVersion),
 		sdkCfgKey: config.NewPeerComponentKey(p.peerConfig.MSPID(), p.peerConfig.PeerID(), configApp, configVersion, sdkConfigComponent, sdkConfigVersion),
 		Discovery: discovery.New(channelID, p.gossip),
+		latencies: newPeerLatencies(),
 	}
 
+	s.batcher = newBatcher(channelID, s.endorseAndCommit)
+	s.txStatus = newTxStatusNotifier(channelID, p.blockPublisher)
+
 	if err := s.load(); err != nil {
 		return nil, err
 	}
@@ -143,6 +154,10 @@ func (s *Service) load() error {
 	s.c = c
 	s.retryOpts = newRetryOpts(txnCfg)
 	s.commitRetryOpts = newCommitRetryOpts(s.retryOpts)
+	s.conflictRetryOpts = newConflictRetryOpts(txnCfg)
+	s.retryOnConflictOpts = newRetryOnConflictOpts(s.retryOpts)
+	s.batcher.configure(txnCfg.BatchMaxSize, newBatchWindow(txnCfg))
+	s.endorserSorter = s.newEndorserSorter(txnCfg)
 
 	return nil
 }
@@ -156,11 +171,13 @@ func (s *Service) Endorse(req *api.Request) (*channel.Response, error) {
 	h := req.Handler
 	if h == nil {
 		h = invoke.NewProposalProcessorHandler(
-			invoke.NewEndorsementHandlerWithOpts(
-				invoke.NewEndorsementValidationHandler(
-					invoke.NewSignatureValidationHandler(),
+			handler.NewEndorserTimingHandler(s.latencies,
+				invoke.NewEndorsementHandlerWithOpts(
+					invoke.NewEndorsementValidationHandler(
+						invoke.NewSignatureValidationHandler(),
+					),
+					getTxnOptsProvider(req),
 				),
-				getTxnOptsProvider(req),
 			),
 		)
 	}
@@ -172,6 +189,7 @@ func (s *Service) Endorse(req *api.Request) (*channel.Response, error) {
 		h, asChannelRequest(req),
 		channel.WithTargets(req.Targets...),
 		channel.WithTargetFilter(newTargetFilter(newEndorserFilter(s.Discovery, req.PeerFilter))),
+		channel.WithTargetSorter(s.getEndorserSorter()),
 		channel.WithRetry(s.retryOpts),
 		channel.WithBeforeRetry(s.beforeRetryHandler(&numRetries, &lastErr)))
 	if err != nil {
@@ -189,26 +207,97 @@ func (s *Service) Endorse(req *api.Request) (*channel.Response, error) {
 	return &resp, nil
 }
 
-// EndorseAndCommit collects endorsements (according to chaincode policy) and sends the endorsements to the Orderer
+// EndorseAndCommit collects endorsements (according to chaincode policy) and sends the endorsements to the Orderer.
+// If batching is enabled and the request has a batch handler then the request is endorsed and committed
+// along with other compatible requests.
 func (s *Service) EndorseAndCommit(req *api.Request) (*channel.Response, bool, error) {
+	if s.batcher.canBatch(req) {
+		return s.batcher.submit(req)
+	}
+
+	return s.endorseAndCommit(req)
+}
+
+func (s *Service) endorseAndCommit(req *api.Request) (*channel.Response, bool, error) {
 	if err := s.validateTxnIDFromRequest(req); err != nil {
 		return nil, false, err
 	}
 
+	if err := validateCommitStatus(req.CommitStatus); err != nil {
+		return nil, false, err
+	}
+
+	if !s.retryOnConflict(req) {
+		return s.invokeEndorseAndCommit(req, s.retryOpts)
+	}
+
+	retryOpts, conflictRetryOpts := s.getRetryOnConflictOpts()
+	retryHandler := retry.New(conflictRetryOpts)
+
+	for attempt := 1; ; attempt++ {
+		resp, committed, err := s.invokeEndorseAndCommit(req, retryOpts)
+		if err == nil {
+			if attempt > 1 {
+				logger.Infof("[%s] Transaction was committed after %d attempts", s.channelID, attempt)
+			}
+
+			return resp, committed, nil
+		}
+
+		// Required blocks for the backoff period if another attempt is to be made
+		if !retryHandler.Required(err) {
+			if attempt > 1 {
+				logger.Infof("[%s] Transaction failed after %d attempts. Last error: %s", s.channelID, attempt, err)
+			}
+
+			return nil, false, err
+		}
+
+		logger.Infof("[%s] Transaction was invalidated due to a read conflict. Endorsing again - attempt #%d. Error: %s", s.channelID, attempt+1, err)
+	}
+}
+
+// retryOnConflict returns true if the request should be endorsed and committed again when the transaction
+// is invalidated due to a read conflict
+func (s *Service) retryOnConflict(req *api.Request) bool {
+	if req.ConflictPolicy != api.RetryOnConflict {
+		return false
+	}
+
+	if req.AsyncCommit {
+		logger.Debugf("[%s] Conflict policy [%s] is ignored for an async commit", s.channelID, req.ConflictPolicy)
+
+		return false
+	}
+
+	if req.TransactionID != "" {
+		// The ID of an invalidated transaction is recorded in the ledger, so the transaction
+		// can't be committed again with the same ID
+		logger.Debugf("[%s] Conflict policy [%s] is ignored since TransactionID [%s] was provided", s.channelID, req.ConflictPolicy, req.TransactionID)
+
+		return false
+	}
+
+	return true
+}
+
+func (s *Service) invokeEndorseAndCommit(req *api.Request, retryOpts retry.Opts) (*channel.Response, bool, error) {
 	checkForCommit := handler.NewCheckForCommitHandler(req.IgnoreNameSpaces, req.CommitType,
-		handler.NewCommitHandler(req.AsyncCommit),
+		s.newCommitHandler(req.AsyncCommit, req.CommitStatus),
 	)
 
 	h := req.Handler
 	if h == nil {
 		h = invoke.NewProposalProcessorHandler(
-			invoke.NewEndorsementHandlerWithOpts(
-				invoke.NewEndorsementValidationHandler(
-					invoke.NewSignatureValidationHandler(
-						checkForCommit,
+			handler.NewEndorserTimingHandler(s.latencies,
+				invoke.NewEndorsementHandlerWithOpts(
+					invoke.NewEndorsementValidationHandler(
+						invoke.NewSignatureValidationHandler(
+							checkForCommit,
+						),
 					),
+					getTxnOptsProvider(req),
 				),
-				getTxnOptsProvider(req),
 			),
 		)
 	}
@@ -220,7 +309,8 @@ func (s *Service) EndorseAndCommit(req *api.Request) (*channel.Response, bool, e
 		h, asChannelRequest(req),
 		channel.WithTargets(req.Targets...),
 		channel.WithTargetFilter(newTargetFilter(newEndorserFilter(s.Discovery, req.PeerFilter))),
-		channel.WithRetry(s.retryOpts),
+		channel.WithTargetSorter(s.getEndorserSorter()),
+		channel.WithRetry(retryOpts),
 		channel.WithBeforeRetry(s.beforeRetryHandler(&numRetries, &lastErr)))
 	if err != nil {
 		if numRetries > 0 {
@@ -239,8 +329,12 @@ func (s *Service) EndorseAndCommit(req *api.Request) (*channel.Response, bool, e
 
 // CommitEndorsements commits the provided endorsements.
 func (s *Service) CommitEndorsements(req *api.CommitRequest) (*channel.Response, bool, error) {
+	if err := validateCommitStatus(req.CommitStatus); err != nil {
+		return nil, false, err
+	}
+
 	checkForCommit := handler.NewCheckForCommitHandler(req.IgnoreNameSpaces, req.CommitType,
-		handler.NewCommitHandler(req.AsyncCommit),
+		s.newCommitHandler(req.AsyncCommit, req.CommitStatus),
 	)
 
 	h := req.Handler
@@ -272,6 +366,52 @@ func (s *Service) CommitEndorsements(req *api.CommitRequest) (*channel.Response,
 	return &resp, checkForCommit.ShouldCommit, nil
 }
 
+// CreateProposal creates an unsigned transaction proposal for the given request. The proposal isn't sent to any
+// endorsers. Instead, the returned endorsement set may be passed to other parties which endorse the proposal offline.
+func (s *Service) CreateProposal(req *api.Request) (*api.EndorsementSet, error) {
+	if err := s.validateTxnIDFromRequest(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client().InvokeHandler(handler.NewCreateProposalHandler(getTxnOptsProvider(req)), asChannelRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Proposal == nil {
+		return nil, errors.New("no proposal was created")
+	}
+
+	logger.Debugf("[%s] Created proposal for tx [%s]", s.channelID, resp.TransactionID)
+
+	return &api.EndorsementSet{
+		TxID:     string(resp.TransactionID),
+		Proposal: resp.Proposal.Proposal,
+	}, nil
+}
+
+// SignProposal signs the proposal in the given endorsement set with the signing identity of the transaction service
+func (s *Service) SignProposal(set *api.EndorsementSet) error {
+	signedProposal, err := s.client().SignProposal(set.Proposal)
+	if err != nil {
+		return err
+	}
+
+	set.Signature = signedProposal.Signature
+
+	return nil
+}
+
+// newCommitHandler returns a commit handler that, for an async commit with a status channel,
+// sends the validation status of the transaction to the channel once the transaction is committed
+func (s *Service) newCommitHandler(asyncCommit bool, statusCh chan<- *api.TxStatusEvent) *handler.Commit {
+	if asyncCommit && statusCh != nil {
+		return handler.NewAsyncCommitHandler(s.txStatus, statusCh)
+	}
+
+	return handler.NewCommitHandler(asyncCommit)
+}
+
 // SigningIdentity returns the serialized identity of the proposal signer
 func (s *Service) SigningIdentity() ([]byte, error) {
 	return s.client().SigningIdentity()
@@ -296,8 +436,10 @@ type closable interface {
 	Close()
 }
 
-// Close releases the resources for this service
+// Close releases the resources for this service. Any pending batches are processed before the client is closed.
 func (s *Service) Close() {
+	s.batcher.flushAll()
+
 	closableClient, ok := s.client().(closable)
 	if ok {
 		logger.Debugf("[%s] Closing client", s.channelID)
@@ -312,6 +454,17 @@ type txnConfig struct {
 	MaxBackoff     string
 	BackoffFactor  float64
 	RetryableCodes []int
+	BatchMaxSize   int
+	BatchWindow    string
+
+	ConflictRetryAttempts  int
+	ConflictInitialBackoff string
+	ConflictMaxBackoff     string
+	ConflictBackoffFactor  float64
+
+	// EndorserSelection is the strategy used to select endorsers. Valid values are Latency, LedgerHeight,
+	// LocalMSP and RoundRobin. If not set then the SDK's default selection is used.
+	EndorserSelection string
 }
 
 func (s *Service) client() channelClient {
@@ -321,6 +474,37 @@ func (s *Service) client() channelClient {
 	return s.c
 }
 
+func (s *Service) getEndorserSorter() fab.TargetSorter {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.endorserSorter
+}
+
+// newEndorserSorter returns the target sorter for the endorser selection strategy in the given config. If the
+// strategy is invalid then the SDK's default selection is used.
+func (s *Service) newEndorserSorter(cfg *txnConfig) fab.TargetSorter {
+	sorter, err := newEndorserSorter(cfg.EndorserSelection, s.latencies, s.Discovery, s.blockPublisher, s.peerConfig.MSPID())
+	if err != nil {
+		logger.Warnf("[%s] Invalid value for EndorserSelection [%s]. Will use default selection: %s", s.channelID, cfg.EndorserSelection, err)
+
+		return nil
+	}
+
+	logger.Debugf("[%s] Using endorser selection strategy [%s]", s.channelID, cfg.EndorserSelection)
+
+	return sorter
+}
+
+// getRetryOnConflictOpts returns the options used to invoke a request whose conflict policy is RetryOnConflict
+// along with the options used to endorse and commit the request again on a read conflict
+func (s *Service) getRetryOnConflictOpts() (retry.Opts, retry.Opts) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.retryOnConflictOpts, s.conflictRetryOpts
+}
+
 func (s *Service) getTxnConfig() (*txnConfig, error) {
 	txnCfg, err := s.configService.Get(s.txnCfgKey)
 	if err != nil {
@@ -397,6 +581,16 @@ func (s *Service) validateTxnIDFromRequest(req *api.Request) error {
 	return nil
 }
 
+// validateCommitStatus ensures that the commit status channel (if provided) is buffered since
+// the status is sent without blocking
+func validateCommitStatus(statusCh chan<- *api.TxStatusEvent) error {
+	if statusCh != nil && cap(statusCh) == 0 {
+		return errors.New("commit status channel must be buffered")
+	}
+
+	return nil
+}
+
 func getTxnOptsProvider(req *api.Request) invoke.TxnHeaderOptsProvider {
 	if len(req.Nonce) == 0 {
 		return nil
@@ -408,18 +602,57 @@ func getTxnOptsProvider(req *api.Request) invoke.TxnHeaderOptsProvider {
 }
 
 func newRetryOpts(cfg *txnConfig) retry.Opts {
-	attempts := cfg.RetryAttempts
-	initialBackoff, err := time.ParseDuration(cfg.InitialBackoff)
-	if err != nil {
-		logger.Warnf("Invalid value for InitialBackoff [%s]. Will use default InitialBackoff", cfg.InitialBackoff)
+	opts := newBackoffOpts("", cfg.RetryAttempts, cfg.InitialBackoff, cfg.MaxBackoff, cfg.BackoffFactor)
+
+	retryableCodes := make(map[status.Group][]status.Code)
+	for key, value := range retry.ChannelClientRetryableCodes {
+		retryableCodes[key] = value
+	}
+
+	for _, code := range cfg.RetryableCodes {
+		retryableCodes[status.ChaincodeStatus] = append(retryableCodes[status.ChaincodeStatus], status.Code(code))
+	}
+
+	opts.RetryableCodes = retryableCodes
+
+	return opts
+}
+
+// newRetryOnConflictOpts returns the given retry options without the read conflict codes. These options are used
+// for requests whose conflict policy is RetryOnConflict since read conflicts are retried according to the
+// conflict retry options (see newConflictRetryOpts) rather than the general retry options.
+func newRetryOnConflictOpts(opts retry.Opts) retry.Opts {
+	retryableCodes := make(map[status.Group][]status.Code)
+	for key, value := range opts.RetryableCodes {
+		retryableCodes[key] = excludeCodes(value, conflictRetryableCodes[key])
 	}
 
-	maxBackoff, err := time.ParseDuration(cfg.MaxBackoff)
+	opts.RetryableCodes = retryableCodes
+
+	return opts
+}
+
+// newConflictRetryOpts returns the options used to endorse and commit a request again when the transaction
+// is invalidated due to a read conflict
+func newConflictRetryOpts(cfg *txnConfig) retry.Opts {
+	opts := newBackoffOpts("Conflict", cfg.ConflictRetryAttempts, cfg.ConflictInitialBackoff, cfg.ConflictMaxBackoff, cfg.ConflictBackoffFactor)
+	opts.RetryableCodes = conflictRetryableCodes
+
+	return opts
+}
+
+// newBackoffOpts returns retry options with the given attempts and backoff. Default values are used for
+// the values that aren't set. The prefix is the prefix of the config field names and is used for logging.
+func newBackoffOpts(prefix string, attempts int, initialBackoffStr, maxBackoffStr string, factor float64) retry.Opts {
+	initialBackoff, err := time.ParseDuration(initialBackoffStr)
 	if err != nil {
-		logger.Warnf("Invalid value for MaxBackoff [%s]. Will use default MaxBackoff", cfg.MaxBackoff)
+		logger.Warnf("Invalid value for %sInitialBackoff [%s]. Will use default %sInitialBackoff", prefix, initialBackoffStr, prefix)
 	}
 
-	factor := cfg.BackoffFactor
+	maxBackoff, err := time.ParseDuration(maxBackoffStr)
+	if err != nil {
+		logger.Warnf("Invalid value for %sMaxBackoff [%s]. Will use default %sMaxBackoff", prefix, maxBackoffStr, prefix)
+	}
 
 	if attempts == 0 {
 		attempts = retry.DefaultAttempts
@@ -437,24 +670,51 @@ func newRetryOpts(cfg *txnConfig) retry.Opts {
 		factor = retry.DefaultBackoffFactor
 	}
 
-	retryableCodes := make(map[status.Group][]status.Code)
-	for key, value := range retry.ChannelClientRetryableCodes {
-		retryableCodes[key] = value
-	}
-
-	for _, code := range cfg.RetryableCodes {
-		retryableCodes[status.ChaincodeStatus] = append(retryableCodes[status.ChaincodeStatus], status.Code(code))
-	}
-
 	return retry.Opts{
 		Attempts:       attempts,
 		InitialBackoff: initialBackoff,
 		MaxBackoff:     maxBackoff,
 		BackoffFactor:  factor,
-		RetryableCodes: retryableCodes,
 	}
 }
 
+func excludeCodes(codes, excluded []status.Code) []status.Code {
+	var result []status.Code
+
+	for _, code := range codes {
+		if !containsCode(excluded, code) {
+			result = append(result, code)
+		}
+	}
+
+	return result
+}
+
+func containsCode(codes []status.Code, code status.Code) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newBatchWindow(cfg *txnConfig) time.Duration {
+	if cfg.BatchWindow == "" {
+		return defaultBatchWindow
+	}
+
+	window, err := time.ParseDuration(cfg.BatchWindow)
+	if err != nil {
+		logger.Warnf("Invalid value for BatchWindow [%s]. Will use default BatchWindow", cfg.BatchWindow)
+
+		return defaultBatchWindow
+	}
+
+	return window
+}
+
 func newCommitRetryOpts(opts retry.Opts) retry.Opts {
 	opts.RetryableCodes = make(map[status.Group][]status.Code)
 
@@ -497,6 +757,7 @@ type channelClient interface {
 	SigningIdentity() ([]byte, error)
 	GetPeer(endpoint string) (fab.Peer, error)
 	VerifyProposalSignature(signedProposal *pb.SignedProposal) error
+	SignProposal(proposal *pb.Proposal) (*pb.SignedProposal, error)
 }
 
 type clientProvider interface {
@@ -546,6 +807,15 @@ func newTargetFilter(filter api.PeerFilter) fab.TargetFilter {
 	return &targetFilter{filter: filter}
 }
 
+// conflictRetryableCodes are the validation codes of the transactions that are endorsed and
+// committed again if the request's conflict policy is RetryOnConflict
+var conflictRetryableCodes = map[status.Group][]status.Code{
+	status.EventServerStatus: {
+		status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT),
+		status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT),
+	},
+}
+
 // commitOnlyRetryableCodes are the suggested codes for commit only
 var commitOnlyRetryableCodes = map[status.Group][]status.Code{
 	status.OrdererClientStatus: {
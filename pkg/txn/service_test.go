@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txn
 
 import (
+	"bytes"
 	"errors"
 	"io/ioutil"
 	"sync"
@@ -15,8 +16,10 @@ import (
 
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	sdkmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/stretchr/testify/require"
 
@@ -66,7 +69,7 @@ func TestClient(t *testing.T) {
 	cliReturned := &mockClosableClient{}
 	clientProvider := &mockClientProvider{cl: cliReturned}
 
-	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: clientProvider, proposalResponseValidator: &txnmocks.ProposalResponseValidator{}}
+	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: clientProvider, proposalResponseValidator: &txnmocks.ProposalResponseValidator{}, blockPublisher: mocks.NewBlockPublisher()}
 	s, err := newService("channel1", p)
 	require.NoError(t, err)
 	require.NotNil(t, s)
@@ -197,6 +200,32 @@ func TestClient(t *testing.T) {
 		require.Nil(t, resp)
 	})
 
+	t.Run("EndorseAndCommit with commit status -> success", func(t *testing.T) {
+		req := &api.Request{
+			Args:         [][]byte{[]byte("arg1")},
+			AsyncCommit:  true,
+			CommitStatus: make(chan *api.TxStatusEvent, 1),
+		}
+
+		cliReturned.InvokeHandlerReturns(channel.Response{}, nil)
+		resp, _, err := s.EndorseAndCommit(req)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+	})
+
+	t.Run("EndorseAndCommit with unbuffered commit status -> error", func(t *testing.T) {
+		req := &api.Request{
+			Args:         [][]byte{[]byte("arg1")},
+			AsyncCommit:  true,
+			CommitStatus: make(chan *api.TxStatusEvent),
+		}
+
+		resp, committed, err := s.EndorseAndCommit(req)
+		require.EqualError(t, err, "commit status channel must be buffered")
+		require.False(t, committed)
+		require.Nil(t, resp)
+	})
+
 	t.Run("CommitEndorsements -> success", func(t *testing.T) {
 		req := &api.CommitRequest{}
 
@@ -206,6 +235,73 @@ func TestClient(t *testing.T) {
 		require.NotNil(t, resp)
 	})
 
+	t.Run("CommitEndorsements with unbuffered commit status -> error", func(t *testing.T) {
+		req := &api.CommitRequest{
+			AsyncCommit:  true,
+			CommitStatus: make(chan *api.TxStatusEvent),
+		}
+
+		resp, committed, err := s.CommitEndorsements(req)
+		require.EqualError(t, err, "commit status channel must be buffered")
+		require.False(t, committed)
+		require.Nil(t, resp)
+	})
+
+	t.Run("CreateProposal -> success", func(t *testing.T) {
+		proposal := &pb.Proposal{Header: []byte("header"), Payload: []byte("payload")}
+		cliReturned.InvokeHandlerReturns(channel.Response{
+			TransactionID: "tx1",
+			Proposal:      &fab.TransactionProposal{TxnID: "tx1", Proposal: proposal},
+		}, nil)
+
+		set, err := s.CreateProposal(req)
+		require.NoError(t, err)
+		require.NotNil(t, set)
+		require.Equal(t, "tx1", set.TxID)
+		require.Equal(t, proposal, set.Proposal)
+		require.Empty(t, set.Signature)
+		require.Empty(t, set.Responses)
+	})
+
+	t.Run("CreateProposal -> error", func(t *testing.T) {
+		errExpected := errors.New("injected invoke error")
+		cliReturned.InvokeHandlerReturns(channel.Response{}, errExpected)
+
+		set, err := s.CreateProposal(req)
+		require.EqualError(t, err, errExpected.Error())
+		require.Nil(t, set)
+
+		cliReturned.InvokeHandlerReturns(channel.Response{}, nil)
+
+		set, err = s.CreateProposal(req)
+		require.EqualError(t, err, "no proposal was created")
+		require.Nil(t, set)
+
+		set, err = s.CreateProposal(&api.Request{ChaincodeID: "cc1", Args: req.Args, TransactionID: "tx1"})
+		require.Error(t, err)
+		require.Nil(t, set)
+	})
+
+	t.Run("SignProposal", func(t *testing.T) {
+		set := &api.EndorsementSet{TxID: "tx1", Proposal: &pb.Proposal{}}
+
+		errExpected := errors.New("injected sign error")
+		cliReturned.SignProposalReturns(nil, errExpected)
+		require.EqualError(t, s.SignProposal(set), errExpected.Error())
+		require.Empty(t, set.Signature)
+
+		cliReturned.SignProposalReturns(&pb.SignedProposal{Signature: []byte("signature")}, nil)
+		require.NoError(t, s.SignProposal(set))
+		require.Equal(t, []byte("signature"), set.Signature)
+	})
+
+	t.Run("Endorser selection", func(t *testing.T) {
+		require.Nil(t, s.getEndorserSorter(), "expecting the SDK's default selection since no strategy was configured")
+
+		require.IsType(t, &localMSPSorter{}, s.newEndorserSorter(&txnConfig{EndorserSelection: localMSPSelection}))
+		require.Nil(t, s.newEndorserSorter(&txnConfig{EndorserSelection: "xxx"}))
+	})
+
 	t.Run("SigningIdentity -> success", func(t *testing.T) {
 		identity := []byte("identity")
 		cliReturned.InvokeHandlerReturns(channel.Response{}, nil)
@@ -297,6 +393,74 @@ func TestClient(t *testing.T) {
 	})
 }
 
+func TestService_EndorseAndCommitBatch(t *testing.T) {
+	cs := &txnmocks.ConfigService{}
+
+	sdkCfgBytes, err := ioutil.ReadFile("./client/testdata/sdk-config.yaml")
+	require.NoError(t, err)
+
+	cs.GetReturnsOnCall(0, &config.Value{
+		TxID:   "txid1",
+		Format: "json",
+		Config: `{"User":"User1","BatchMaxSize":2,"BatchWindow":"1h"}`,
+	}, nil)
+	cs.GetReturnsOnCall(1, &config.Value{
+		TxID:   "txid2",
+		Format: "yaml",
+		Config: string(sdkCfgBytes),
+	}, nil)
+
+	peerCfg := &mocks.PeerConfig{}
+	peerCfg.MSPIDReturns(msp1)
+	peerCfg.PeerIDReturns(peer1)
+
+	cl := &mockClosableClient{}
+	cl.InvokeHandlerStub = func(_ invoke.Handler, req channel.Request, _ ...channel.RequestOption) (channel.Response, error) {
+		// Echo the args of the combined request
+		return channel.Response{TransactionID: "tx1", Payload: bytes.Join(req.Args, []byte(","))}, nil
+	}
+
+	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: &mockClientProvider{cl: cl}, proposalResponseValidator: &txnmocks.ProposalResponseValidator{}, blockPublisher: mocks.NewBlockPublisher()}
+	s, err := newService("channel1", p)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	bh := &mockBatchHandler{}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	responses := make([]*channel.Response, 2)
+	for i, arg := range []string{"a", "b"} {
+		go func(i int, arg string) {
+			defer wg.Done()
+
+			resp, _, err := s.EndorseAndCommit(&api.Request{
+				ChaincodeID:  "cc1",
+				Args:         [][]byte{[]byte("put"), []byte(arg)},
+				BatchHandler: bh,
+			})
+			require.NoError(t, err)
+
+			responses[i] = resp
+		}(i, arg)
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 1, cl.InvokeHandlerCallCount())
+	require.Equal(t, "a", string(responses[0].Payload))
+	require.Equal(t, "b", string(responses[1].Payload))
+	require.Equal(t, fab.TransactionID("tx1"), responses[0].TransactionID)
+	require.Equal(t, fab.TransactionID("tx1"), responses[1].TransactionID)
+
+	_, req, _ := cl.InvokeHandlerArgsForCall(0)
+	require.Equal(t, "batch", req.Fcn)
+
+	s.Close()
+	require.True(t, cl.isClosed())
+}
+
 func TestNew_Error(t *testing.T) {
 	cs := &txnmocks.ConfigService{}
 
@@ -319,7 +483,7 @@ func TestNew_Error(t *testing.T) {
 
 	errExpected := errors.New("injected new client error")
 
-	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: &mockClientProvider{err: errExpected}}
+	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: &mockClientProvider{err: errExpected}, blockPublisher: mocks.NewBlockPublisher()}
 	s, err := newService("channel1", p)
 	require.EqualError(t, err, errExpected.Error())
 	require.Nil(t, s)
@@ -346,6 +510,22 @@ func TestNewRetryOpts(t *testing.T) {
 		require.Len(t, codes, 2)
 		require.Equal(t, status.Code(500), codes[0])
 		require.Equal(t, status.Code(501), codes[1])
+
+		codes = opts.RetryableCodes[status.EventServerStatus]
+		require.Equal(t, retry.ChannelClientRetryableCodes[status.EventServerStatus], codes)
+
+		// Read conflicts are excluded for requests that retry on conflict
+		conflictOpts := newRetryOnConflictOpts(opts)
+		require.Equal(t, opts.Attempts, conflictOpts.Attempts)
+		require.Equal(t, opts.RetryableCodes[status.ChaincodeStatus], conflictOpts.RetryableCodes[status.ChaincodeStatus])
+
+		codes = conflictOpts.RetryableCodes[status.EventServerStatus]
+		require.Contains(t, codes, status.Code(pb.TxValidationCode_DUPLICATE_TXID))
+		require.NotContains(t, codes, status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT))
+		require.NotContains(t, codes, status.Code(pb.TxValidationCode_PHANTOM_READ_CONFLICT))
+
+		// The default options aren't modified
+		require.Contains(t, opts.RetryableCodes[status.EventServerStatus], status.Code(pb.TxValidationCode_MVCC_READ_CONFLICT))
 	})
 
 	t.Run("Default values", func(t *testing.T) {
@@ -357,6 +537,136 @@ func TestNewRetryOpts(t *testing.T) {
 	})
 }
 
+func TestNewConflictRetryOpts(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		cfg := &txnConfig{
+			ConflictRetryAttempts:  5,
+			ConflictInitialBackoff: "100ms",
+			ConflictMaxBackoff:     "2s",
+			ConflictBackoffFactor:  1.5,
+		}
+
+		opts := newConflictRetryOpts(cfg)
+		require.Equal(t, cfg.ConflictRetryAttempts, opts.Attempts)
+		require.Equal(t, 100*time.Millisecond, opts.InitialBackoff)
+		require.Equal(t, 2*time.Second, opts.MaxBackoff)
+		require.Equal(t, cfg.ConflictBackoffFactor, opts.BackoffFactor)
+		require.Equal(t, conflictRetryableCodes, opts.RetryableCodes)
+	})
+
+	t.Run("Default values", func(t *testing.T) {
+		opts := newConflictRetryOpts(&txnConfig{})
+		require.Equal(t, retry.DefaultAttempts, opts.Attempts)
+		require.Equal(t, retry.DefaultInitialBackoff, opts.InitialBackoff)
+		require.Equal(t, retry.DefaultMaxBackoff, opts.MaxBackoff)
+		require.Equal(t, retry.DefaultBackoffFactor, opts.BackoffFactor)
+	})
+}
+
+func TestService_RetryOnConflict(t *testing.T) {
+	cs := &txnmocks.ConfigService{}
+
+	sdkCfgBytes, err := ioutil.ReadFile("./client/testdata/sdk-config.yaml")
+	require.NoError(t, err)
+
+	cs.GetReturnsOnCall(0, &config.Value{
+		TxID:   "txid1",
+		Format: "json",
+		Config: `{"User":"User1","ConflictRetryAttempts":2,"ConflictInitialBackoff":"1ms","ConflictMaxBackoff":"5ms"}`,
+	}, nil)
+	cs.GetReturnsOnCall(1, &config.Value{
+		TxID:   "txid2",
+		Format: "yaml",
+		Config: string(sdkCfgBytes),
+	}, nil)
+
+	peerCfg := &mocks.PeerConfig{}
+	peerCfg.MSPIDReturns(msp1)
+	peerCfg.PeerIDReturns(peer1)
+
+	errConflict := status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "received invalid transaction", nil)
+
+	cl := &mockClosableClient{}
+
+	p := &providers{peerConfig: peerCfg, configService: cs, clientProvider: &mockClientProvider{cl: cl}, proposalResponseValidator: &txnmocks.ProposalResponseValidator{}, blockPublisher: mocks.NewBlockPublisher()}
+	s, err := newService("channel1", p)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	defer s.Close()
+
+	newRequest := func() *api.Request {
+		return &api.Request{
+			ChaincodeID:    "cc1",
+			Args:           [][]byte{[]byte("put")},
+			ConflictPolicy: api.RetryOnConflict,
+		}
+	}
+
+	t.Run("Success after conflict", func(t *testing.T) {
+		cl.InvokeHandlerReturnsOnCall(0, channel.Response{}, errConflict)
+		cl.InvokeHandlerReturnsOnCall(1, channel.Response{TransactionID: "tx2"}, nil)
+		defer func() { cl.InvokeHandlerReturnsOnCall(1, channel.Response{}, nil) }()
+
+		resp, _, err := s.EndorseAndCommit(newRequest())
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, fab.TransactionID("tx2"), resp.TransactionID)
+		require.Equal(t, 2, cl.InvokeHandlerCallCount())
+	})
+
+	t.Run("Attempts exhausted", func(t *testing.T) {
+		cl.InvokeHandlerReturns(channel.Response{}, errConflict)
+
+		start := cl.InvokeHandlerCallCount()
+
+		resp, committed, err := s.EndorseAndCommit(newRequest())
+		require.EqualError(t, err, errConflict.Error())
+		require.False(t, committed)
+		require.Nil(t, resp)
+		require.Equal(t, 3, cl.InvokeHandlerCallCount()-start)
+	})
+
+	t.Run("Non-retryable error", func(t *testing.T) {
+		errExpected := status.New(status.EventServerStatus, int32(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE), "received invalid transaction", nil)
+		cl.InvokeHandlerReturns(channel.Response{}, errExpected)
+
+		start := cl.InvokeHandlerCallCount()
+
+		_, _, err := s.EndorseAndCommit(newRequest())
+		require.EqualError(t, err, errExpected.Error())
+		require.Equal(t, 1, cl.InvokeHandlerCallCount()-start)
+	})
+
+	t.Run("Policy not applicable", func(t *testing.T) {
+		cl.InvokeHandlerReturns(channel.Response{}, errConflict)
+
+		for _, req := range []*api.Request{
+			{ChaincodeID: "cc1", Args: [][]byte{[]byte("put")}},
+			{ChaincodeID: "cc1", Args: [][]byte{[]byte("put")}, ConflictPolicy: api.RetryOnConflict, AsyncCommit: true},
+		} {
+			start := cl.InvokeHandlerCallCount()
+
+			_, _, err := s.EndorseAndCommit(req)
+			require.EqualError(t, err, errConflict.Error())
+			require.Equal(t, 1, cl.InvokeHandlerCallCount()-start)
+		}
+
+		const txnID = "txn1234"
+		cl.ComputeTxnIDReturns(txnID, nil)
+
+		req := newRequest()
+		req.TransactionID = txnID
+		req.Nonce = []byte("nonce1")
+
+		start := cl.InvokeHandlerCallCount()
+
+		_, _, err := s.EndorseAndCommit(req)
+		require.EqualError(t, err, errConflict.Error())
+		require.Equal(t, 1, cl.InvokeHandlerCallCount()-start)
+	})
+}
+
 func TestPeerFilter(t *testing.T) {
 	f := newTargetFilter(&mockPeerFilter{})
 	require.False(t, f.Accept(&sdkmocks.MockPeer{MockMSP: msp1, MockURL: peer1}))
@@ -0,0 +1,175 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"sync"
+	"time"
+
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	gossipapi "github.com/hyperledger/fabric/extensions/gossip/api"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/common/txflags"
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
+)
+
+// txStatusNotifier sends the validation status of registered transactions to subscribers. The status is obtained
+// from the blocks that are published by the local peer's block publisher (rather than from the SDK event service)
+// so that the status reflects what was committed to the local ledger. A registration expires if the transaction
+// isn't committed within the given timeout (for example, if the Orderer dropped the transaction), in which case
+// a timeout event is sent.
+type txStatusNotifier struct {
+	channelID   string
+	mutex       sync.Mutex
+	subscribers map[string]*txStatusSubscriber
+}
+
+type txStatusSubscriber struct {
+	statusCh chan<- *api.TxStatusEvent
+	timer    *time.Timer
+}
+
+// defaultTxStatusTimeout is the time after which a registration expires if no timeout was provided
+const defaultTxStatusTimeout = 3 * time.Minute
+
+func newTxStatusNotifier(channelID string, publisher gossipapi.BlockPublisher) *txStatusNotifier {
+	n := &txStatusNotifier{
+		channelID:   channelID,
+		subscribers: make(map[string]*txStatusSubscriber),
+	}
+
+	publisher.AddBlockHandler(n.handleBlock)
+
+	return n
+}
+
+// Register registers for the validation status of the given transaction. The status is sent to the
+// given channel only once, after which the registration is removed. If the transaction isn't committed
+// within the given timeout then the registration expires and a timeout event is sent.
+func (n *txStatusNotifier) Register(txID string, statusCh chan<- *api.TxStatusEvent, timeout time.Duration) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	logger.Debugf("[%s] Registering for the status of TxID [%s] with timeout %s", n.channelID, txID, timeout)
+
+	if timeout <= 0 {
+		timeout = defaultTxStatusTimeout
+	}
+
+	if existing, ok := n.subscribers[txID]; ok {
+		existing.timer.Stop()
+	}
+
+	s := &txStatusSubscriber{statusCh: statusCh}
+	s.timer = time.AfterFunc(timeout, func() { n.expire(txID, s) })
+
+	n.subscribers[txID] = s
+}
+
+// Unregister removes the registration for the given transaction
+func (n *txStatusNotifier) Unregister(txID string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	logger.Debugf("[%s] Unregistering for the status of TxID [%s]", n.channelID, txID)
+
+	if s, ok := n.subscribers[txID]; ok {
+		s.timer.Stop()
+		delete(n.subscribers, txID)
+	}
+}
+
+func (n *txStatusNotifier) handleBlock(block *cb.Block) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if len(n.subscribers) == 0 {
+		return nil
+	}
+
+	txFilter := txflags.ValidationFlags(block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	if len(txFilter) != len(block.Data.Data) {
+		return errors.Errorf("the number of transaction validation flags [%d] in block %d doesn't match the number of transactions [%d]",
+			len(txFilter), block.Header.Number, len(block.Data.Data))
+	}
+
+	for txNum := range block.Data.Data {
+		txID, err := getTxID(block, txNum)
+		if err != nil {
+			logger.Warnf("[%s] Unable to extract the transaction ID at index %d in block %d: %s", n.channelID, txNum, block.Header.Number, err)
+			continue
+		}
+
+		s, ok := n.subscribers[txID]
+		if !ok {
+			continue
+		}
+
+		s.timer.Stop()
+		delete(n.subscribers, txID)
+
+		event := &api.TxStatusEvent{
+			TxID:             txID,
+			BlockNumber:      block.Header.Number,
+			TxValidationCode: txFilter.Flag(txNum),
+		}
+
+		logger.Debugf("[%s] Sending status of TxID [%s] in block %d: %s", n.channelID, txID, block.Header.Number, event.TxValidationCode)
+
+		n.send(s, event)
+	}
+
+	return nil
+}
+
+// expire removes the given registration, if it hasn't already been removed, and sends a timeout event since the
+// transaction wasn't committed in time
+func (n *txStatusNotifier) expire(txID string, s *txStatusSubscriber) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.subscribers[txID] != s {
+		// The status was already sent or the registration was removed or replaced
+		return
+	}
+
+	delete(n.subscribers, txID)
+
+	logger.Warnf("[%s] Registration for the status of TxID [%s] expired", n.channelID, txID)
+
+	n.send(s, &api.TxStatusEvent{
+		TxID:             txID,
+		TxValidationCode: pb.TxValidationCode_NOT_VALIDATED,
+		Timeout:          true,
+	})
+}
+
+// send sends the given event to the subscriber without blocking. The caller must hold the lock.
+func (n *txStatusNotifier) send(s *txStatusSubscriber, event *api.TxStatusEvent) {
+	select {
+	case s.statusCh <- event:
+	default:
+		logger.Warnf("[%s] Unable to send status of TxID [%s] since the subscriber's channel is full", n.channelID, event.TxID)
+	}
+}
+
+func getTxID(block *cb.Block, txNum int) (string, error) {
+	env, err := protoutil.ExtractEnvelope(block, txNum)
+	if err != nil {
+		return "", err
+	}
+
+	chdr, err := protoutil.ChannelHeader(env)
+	if err != nil {
+		return "", err
+	}
+
+	return chdr.TxId, nil
+}
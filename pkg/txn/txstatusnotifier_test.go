@@ -0,0 +1,176 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package txn
+
+import (
+	"testing"
+	"time"
+
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-peer-ext/pkg/mocks"
+	"github.com/trustbloc/fabric-peer-ext/pkg/txn/api"
+)
+
+func TestTxStatusNotifier(t *testing.T) {
+	const (
+		channelID = "channel1"
+		txID1     = "tx1"
+		txID2     = "tx2"
+		txID3     = "tx3"
+	)
+
+	publisher := mocks.NewBlockPublisher()
+
+	n := newTxStatusNotifier(channelID, publisher)
+	require.NotNil(t, publisher.HandleBlock)
+
+	t.Run("No subscribers", func(t *testing.T) {
+		b := mocks.NewBlockBuilder(channelID, 1000)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+
+		require.NoError(t, publisher.HandleBlock(b.Build()))
+	})
+
+	t.Run("Status sent", func(t *testing.T) {
+		statusCh1 := make(chan *api.TxStatusEvent, 1)
+		statusCh2 := make(chan *api.TxStatusEvent, 1)
+		statusCh3 := make(chan *api.TxStatusEvent, 1)
+
+		n.Register(txID1, statusCh1, time.Minute)
+		n.Register(txID2, statusCh2, time.Minute)
+		n.Register(txID3, statusCh3, time.Minute)
+		n.Unregister(txID3)
+
+		b := mocks.NewBlockBuilder(channelID, 1001)
+		b.Transaction("tx0", pb.TxValidationCode_VALID)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+		b.Transaction(txID2, pb.TxValidationCode_MVCC_READ_CONFLICT)
+		b.Transaction(txID3, pb.TxValidationCode_VALID)
+
+		require.NoError(t, publisher.HandleBlock(b.Build()))
+
+		require.Len(t, statusCh1, 1)
+		event := <-statusCh1
+		require.Equal(t, txID1, event.TxID)
+		require.Equal(t, uint64(1001), event.BlockNumber)
+		require.Equal(t, pb.TxValidationCode_VALID, event.TxValidationCode)
+
+		require.Len(t, statusCh2, 1)
+		event = <-statusCh2
+		require.Equal(t, txID2, event.TxID)
+		require.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, event.TxValidationCode)
+
+		require.Empty(t, statusCh3)
+		require.Empty(t, n.subscribers)
+	})
+
+	t.Run("Subscriber channel full", func(t *testing.T) {
+		statusCh := make(chan *api.TxStatusEvent, 1)
+		statusCh <- &api.TxStatusEvent{}
+
+		n.Register(txID1, statusCh, time.Minute)
+
+		b := mocks.NewBlockBuilder(channelID, 1002)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+
+		require.NoError(t, publisher.HandleBlock(b.Build()))
+		require.Empty(t, n.subscribers)
+	})
+
+	t.Run("Registration expired -> timeout event", func(t *testing.T) {
+		statusCh := make(chan *api.TxStatusEvent, 1)
+		n.Register(txID1, statusCh, 10*time.Millisecond)
+		n.Register(txID2, make(chan *api.TxStatusEvent, 1), time.Minute)
+		defer n.Unregister(txID2)
+
+		// The registration expires without a new block or registration
+		select {
+		case event := <-statusCh:
+			require.Equal(t, txID1, event.TxID)
+			require.True(t, event.Timeout)
+			require.Equal(t, pb.TxValidationCode_NOT_VALIDATED, event.TxValidationCode)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the timeout event")
+		}
+
+		n.mutex.Lock()
+		require.NotContains(t, n.subscribers, txID1)
+		require.Contains(t, n.subscribers, txID2)
+		n.mutex.Unlock()
+	})
+
+	t.Run("Status sent -> no timeout event", func(t *testing.T) {
+		statusCh := make(chan *api.TxStatusEvent, 2)
+		n.Register(txID1, statusCh, 10*time.Millisecond)
+
+		b := mocks.NewBlockBuilder(channelID, 1003)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+
+		require.NoError(t, publisher.HandleBlock(b.Build()))
+
+		time.Sleep(20 * time.Millisecond)
+
+		require.Len(t, statusCh, 1)
+		require.False(t, (<-statusCh).Timeout)
+	})
+
+	t.Run("Unregistered -> no timeout event", func(t *testing.T) {
+		statusCh := make(chan *api.TxStatusEvent, 1)
+		n.Register(txID1, statusCh, 10*time.Millisecond)
+		n.Unregister(txID1)
+
+		time.Sleep(20 * time.Millisecond)
+
+		require.Empty(t, statusCh)
+	})
+
+	t.Run("Registration replaced", func(t *testing.T) {
+		statusCh1 := make(chan *api.TxStatusEvent, 1)
+		statusCh2 := make(chan *api.TxStatusEvent, 1)
+		n.Register(txID1, statusCh1, 10*time.Millisecond)
+		n.Register(txID1, statusCh2, time.Minute)
+		defer n.Unregister(txID1)
+
+		time.Sleep(20 * time.Millisecond)
+
+		require.Empty(t, statusCh1)
+		require.Empty(t, statusCh2)
+	})
+
+	t.Run("Invalid transaction", func(t *testing.T) {
+		statusCh := make(chan *api.TxStatusEvent, 1)
+		n.Register(txID1, statusCh, time.Minute)
+		defer n.Unregister(txID1)
+
+		b := mocks.NewBlockBuilder(channelID, 1004)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+
+		block := b.Build()
+		block.Data.Data[0] = []byte("invalid envelope")
+
+		require.NoError(t, publisher.HandleBlock(block))
+		require.Empty(t, statusCh)
+	})
+
+	t.Run("Validation flags mismatch", func(t *testing.T) {
+		n.Register(txID1, make(chan *api.TxStatusEvent, 1), 0)
+		defer n.Unregister(txID1)
+
+		b := mocks.NewBlockBuilder(channelID, 1005)
+		b.Transaction(txID1, pb.TxValidationCode_VALID)
+
+		block := b.Build()
+		block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = nil
+
+		err := publisher.HandleBlock(block)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't match the number of transactions")
+	})
+}